@@ -8,6 +8,7 @@ import (
 	"my-bulker/internal/pkg/appmeta"
 	"my-bulker/internal/pkg/database"
 	"my-bulker/internal/pkg/scheduler"
+	"my-bulker/internal/pkg/secret"
 	"my-bulker/internal/router"
 	"my-bulker/internal/service"
 	"net/http"
@@ -20,17 +21,40 @@ import (
 
 // NewApp 创建应用实例
 func NewApp(frontendFS embed.FS) *fiber.App {
+	// 加载主密钥，需在访问实例数据之前完成
+	if err := secret.Init("./data"); err != nil {
+		log.Fatalf("Failed to load master key: %v", err)
+	}
+
 	// 初始化数据库
 	if err := database.Init(); err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 
+	// 加密历史明文密码并完成主密钥轮换
+	if err := service.MigrateInstanceSecrets(); err != nil {
+		log.Fatalf("Failed to migrate instance secrets: %v", err)
+	}
+
+	// 没有任何用户时创建初始管理员
+	if err := service.EnsureAdmin(); err != nil {
+		log.Fatalf("Failed to create initial admin user: %v", err)
+	}
+
 	// 启动调度服务
 	simpleSchedulerSvc := service.NewSimpleSchedulerService()
 	go simpleSchedulerSvc.Start()
 
 	// 启动通用调度器
 	go scheduler.Start()
+
+	// 恢复查询任务执行队列
+	if err := service.GetQueryTaskQueue().Restore(); err != nil {
+		log.Printf("ERROR: failed to restore query task queue: %v", err)
+	}
+	if err := service.FailInterruptedSchemaCompares(); err != nil {
+		log.Printf("ERROR: failed to mark interrupted schema compare jobs: %v", err)
+	}
 	// defer simpleSchedulerSvc.Stop() // Graceful shutdown should be handled.
 
 	// 创建 Fiber 应用实例
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/response"
+	"my-bulker/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuditHandler 审计日志处理器
+type AuditHandler struct {
+	service *service.AuditService
+}
+
+// NewAuditHandler 创建审计日志处理器
+func NewAuditHandler() *AuditHandler {
+	return &AuditHandler{service: service.NewAuditService()}
+}
+
+// List 分页查询审计事件
+func (h *AuditHandler) List(c *fiber.Ctx) error {
+	var req model.AuditListRequest
+	if err := c.QueryParser(&req); err != nil {
+		return response.Invalid(c, "无效的查询参数")
+	}
+	req.Pagination.ValidateAndSetDefaults()
+	list, err := h.service.List(&req)
+	if err != nil {
+		return h.handleError(c, "获取审计日志失败", err)
+	}
+	return response.Success(c, list)
+}
+
+// Export 按查询条件导出审计事件为 JSONL 文件
+func (h *AuditHandler) Export(c *fiber.Ctx) error {
+	var req model.AuditListRequest
+	if err := c.QueryParser(&req); err != nil {
+		return response.Invalid(c, "无效的查询参数")
+	}
+	var buf bytes.Buffer
+	count, err := h.service.Export(&req, &buf)
+	if err != nil {
+		return h.handleError(c, "导出审计日志失败", err)
+	}
+	if err := h.service.Record(c.UserContext(), model.AuditAuditExport, "", nil, fiber.Map{"filter": req, "count": count}); err != nil {
+		return response.Internal(c, "记录审计日志失败: "+err.Error())
+	}
+
+	fileName := fmt.Sprintf("audit_%s.jsonl", time.Now().Format("20060102150405"))
+	c.Set(fiber.HeaderContentDisposition, "attachment; filename="+fileName)
+	c.Set(fiber.HeaderContentType, "application/x-ndjson")
+	return c.Send(buf.Bytes())
+}
+
+// Verify 校验审计哈希链是否完整
+func (h *AuditHandler) Verify(c *fiber.Ctx) error {
+	result, err := h.service.Verify()
+	if err != nil {
+		return response.Internal(c, "校验审计日志失败: "+err.Error())
+	}
+	return response.Success(c, result)
+}
+
+func (h *AuditHandler) handleError(c *fiber.Ctx, prefix string, err error) error {
+	if errors.Is(err, service.ErrInvalidAuditFilter) {
+		return response.Invalid(c, err.Error())
+	}
+	return response.Internal(c, prefix+": "+err.Error())
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"my-bulker/internal/middleware"
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/authctx"
+	"my-bulker/internal/pkg/credential"
+	"my-bulker/internal/pkg/response"
+	"my-bulker/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// AuthHandler 登录与 API 令牌处理器
+type AuthHandler struct {
+	service *service.AuthService
+	audit   *service.AuditService
+}
+
+// NewAuthHandler 创建登录处理器
+func NewAuthHandler() *AuthHandler {
+	return &AuthHandler{service: service.NewAuthService(), audit: service.NewAuditService()}
+}
+
+// Login 登录，会话令牌写入 HttpOnly Cookie
+func (h *AuthHandler) Login(c *fiber.Ctx) error {
+	var req model.LoginRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Invalid(c, "无效的请求参数")
+	}
+	if strings.TrimSpace(req.Username) == "" || req.Password == "" {
+		return response.Invalid(c, "用户名和密码不能为空")
+	}
+
+	ctx := authctx.WithClientIP(c.UserContext(), c.IP())
+	token, session, user, err := h.service.Login(&req, c.IP(), c.Get(fiber.HeaderUserAgent))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCredentials) || errors.Is(err, service.ErrUserDisabled) {
+			// 登录失败时记录尝试的用户名，操作人ID为 0
+			attempt := &model.User{Username: strings.TrimSpace(req.Username)}
+			h.audit.Log(authctx.WithUser(ctx, attempt), model.AuditLoginFailed, "", nil, fiber.Map{"reason": err.Error()})
+			return response.Auth(c, err.Error())
+		}
+		return response.Internal(c, "登录失败: "+err.Error())
+	}
+	h.audit.Log(authctx.WithUser(ctx, user), model.AuditLogin, model.AuditTargetUser, []uint{user.ID}, fiber.Map{"user_agent": c.Get(fiber.HeaderUserAgent)})
+	c.Cookie(&fiber.Cookie{
+		Name:     middleware.SessionCookie,
+		Value:    token,
+		Path:     "/",
+		Expires:  session.ExpiresAt,
+		HTTPOnly: true,
+		Secure:   c.Protocol() == "https",
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+	return response.Success(c, model.LoginResponse{User: user, ExpiresAt: session.ExpiresAt})
+}
+
+// Logout 注销当前会话
+func (h *AuthHandler) Logout(c *fiber.Ctx) error {
+	if err := h.service.Logout(middleware.Token(c)); err != nil {
+		return response.Internal(c, "注销失败: "+err.Error())
+	}
+	c.Cookie(&fiber.Cookie{
+		Name:     middleware.SessionCookie,
+		Value:    "",
+		Path:     "/",
+		Expires:  time.Unix(0, 0),
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+	return response.Ok(c, "已退出登录")
+}
+
+// Me 获取当前用户
+func (h *AuthHandler) Me(c *fiber.Ctx) error {
+	return response.Success(c, authctx.User(c.UserContext()))
+}
+
+// ChangePassword 修改当前用户密码
+func (h *AuthHandler) ChangePassword(c *fiber.Ctx) error {
+	var req model.ChangePasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Invalid(c, "无效的请求参数")
+	}
+	if err := credential.ValidatePassword(req.NewPassword); err != nil {
+		return response.Invalid(c, err.Error())
+	}
+	if err := h.service.ChangePassword(authctx.User(c.UserContext()), &req, middleware.Token(c)); err != nil {
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			return response.Invalid(c, "原密码错误")
+		}
+		return response.Internal(c, "修改密码失败: "+err.Error())
+	}
+	return response.Ok(c, "密码已修改，其他会话已退出登录")
+}
+
+// ListTokens 获取当前用户的 API 令牌
+func (h *AuthHandler) ListTokens(c *fiber.Ctx) error {
+	tokens, err := h.service.ListAPITokens(authctx.UserID(c.UserContext()))
+	if err != nil {
+		return response.Internal(c, "获取API令牌失败: "+err.Error())
+	}
+	return response.Success(c, tokens)
+}
+
+// CreateToken 创建 API 令牌
+func (h *AuthHandler) CreateToken(c *fiber.Ctx) error {
+	var req model.CreateAPITokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Invalid(c, "无效的请求参数")
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		return response.Invalid(c, "令牌名称不能为空")
+	}
+	if req.ExpiresInDays < 0 {
+		return response.Invalid(c, "有效天数不能为负数")
+	}
+	result, err := h.service.CreateAPIToken(authctx.UserID(c.UserContext()), &req)
+	if err != nil {
+		return response.Internal(c, "创建API令牌失败: "+err.Error())
+	}
+	h.audit.Log(c.UserContext(), model.AuditTokenCreate, model.AuditTargetToken, []uint{result.Item.ID}, fiber.Map{"name": req.Name, "expires_in_days": req.ExpiresInDays})
+	return response.Success(c, result)
+}
+
+// DeleteToken 删除 API 令牌
+func (h *AuthHandler) DeleteToken(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return response.Invalid(c, "无效的令牌ID")
+	}
+	if err := h.service.DeleteAPIToken(authctx.UserID(c.UserContext()), uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "令牌不存在")
+		}
+		return response.Internal(c, "删除API令牌失败: "+err.Error())
+	}
+	h.audit.Log(c.UserContext(), model.AuditTokenDelete, model.AuditTargetToken, []uint{uint(id)}, nil)
+	return response.Ok(c, "令牌已删除")
+}
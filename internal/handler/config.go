@@ -10,11 +10,13 @@ import (
 
 type ConfigHandler struct {
 	service *service.ConfigService
+	audit   *service.AuditService
 }
 
 func NewConfigHandler() *ConfigHandler {
 	return &ConfigHandler{
 		service: service.NewConfigService(),
+		audit:   service.NewAuditService(),
 	}
 }
 
@@ -46,6 +48,7 @@ func (h *ConfigHandler) SetConfig(c *fiber.Ctx) error {
 	if err := h.service.SetConfig(req.CKey, req.CValue); err != nil {
 		return response.Internal(c, "保存配置失败")
 	}
+	h.audit.Log(c.UserContext(), model.AuditConfigSet, model.AuditTargetConfig, nil, fiber.Map{"configs": []model.Config{{CKey: req.CKey, CValue: req.CValue}}})
 	return response.Ok(c, "保存成功")
 }
 
@@ -71,6 +74,7 @@ func (h *ConfigHandler) SaveConfigs(c *fiber.Ctx) error {
 	if err := h.service.BatchSetConfig(configs); err != nil {
 		return response.Internal(c, "批量保存配置失败")
 	}
+	h.audit.Log(c.UserContext(), model.AuditConfigSet, model.AuditTargetConfig, nil, fiber.Map{"configs": configs})
 	return response.Ok(c, "批量保存成功")
 }
 
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"my-bulker/internal/model"
 	"my-bulker/internal/pkg/database"
 	"my-bulker/internal/pkg/response"
@@ -42,7 +43,7 @@ func (h *DatabaseHandler) List(c *fiber.Ctx) error {
 		}
 	}
 
-	list, err := h.service.List(c.Context(), &req)
+	list, err := h.service.List(c.UserContext(), &req)
 	if err != nil {
 		return response.Internal(c, "获取数据库列表失败")
 	}
@@ -57,8 +58,11 @@ func (h *DatabaseHandler) Get(c *fiber.Ctx) error {
 		return response.Invalid(c, "无效的数据库ID")
 	}
 
-	db, err := h.service.Get(c.Context(), uint(id))
+	db, err := h.service.Get(c.UserContext(), uint(id))
 	if err != nil {
+		if errors.Is(err, service.ErrForbidden) {
+			return response.Forbid(c, err.Error())
+		}
 		return response.Internal(c, "获取数据库详情失败")
 	}
 
@@ -73,6 +77,12 @@ func (h *DatabaseHandler) BatchList(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil || len(req.InstanceIDs) == 0 {
 		return response.Invalid(c, "参数错误")
 	}
+	// 只返回有查看权限的实例下的数据库
+	instanceIDs, err := h.service.VisibleInstanceIDs(c.UserContext(), req.InstanceIDs)
+	if err != nil {
+		return response.Internal(c, "查询数据库失败")
+	}
+	req.InstanceIDs = instanceIDs
 	db := database.GetDB()
 	var dbs []model.Database
 	if err := db.Where("instance_id IN ?", req.InstanceIDs).Find(&dbs).Error; err != nil {
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"my-bulker/internal/model"
 	"my-bulker/internal/pkg/database"
 	"my-bulker/internal/pkg/response"
@@ -13,13 +14,15 @@ import (
 
 // DbDocHandler 数据库文档处理
 type DbDocHandler struct {
-	svc *service.DbDocService
+	svc   *service.DbDocService
+	audit *service.AuditService
 }
 
 // NewDbDocHandler 创建数据库文档处理
 func NewDbDocHandler() *DbDocHandler {
 	return &DbDocHandler{
-		svc: service.NewDbDocService(database.GetDB()),
+		svc:   service.NewDbDocService(database.GetDB()),
+		audit: service.NewAuditService(),
 	}
 }
 
@@ -30,11 +33,15 @@ func (h *DbDocHandler) Create(c *fiber.Ctx) error {
 		return response.Invalid(c, err.Error())
 	}
 
-	task, err := h.svc.CreateTask(req)
+	task, err := h.svc.CreateTask(c.UserContext(), req)
 	if err != nil {
+		if errors.Is(err, service.ErrForbidden) {
+			return response.Forbid(c, err.Error())
+		}
 		return response.Internal(c, err.Error())
 	}
 
+	h.audit.Log(c.UserContext(), model.AuditDocTaskCreate, model.AuditTargetDocTask, []uint{task.ID}, req)
 	return response.Success(c, task)
 }
 
@@ -46,20 +53,28 @@ func (h *DbDocHandler) Update(c *fiber.Ctx) error {
 		return response.Invalid(c, err.Error())
 	}
 
-	task, err := h.svc.UpdateTask(uint(id), req)
+	task, err := h.svc.UpdateTask(c.UserContext(), uint(id), req)
 	if err != nil {
+		if errors.Is(err, service.ErrForbidden) {
+			return response.Forbid(c, err.Error())
+		}
 		return response.Internal(c, err.Error())
 	}
 
+	h.audit.Log(c.UserContext(), model.AuditDocTaskUpdate, model.AuditTargetDocTask, []uint{task.ID}, req)
 	return response.Success(c, task)
 }
 
 // Delete 删除任务
 func (h *DbDocHandler) Delete(c *fiber.Ctx) error {
 	id, _ := strconv.Atoi(c.Params("id"))
-	if err := h.svc.DeleteTask(uint(id)); err != nil {
+	if err := h.svc.DeleteTask(c.UserContext(), uint(id)); err != nil {
+		if errors.Is(err, service.ErrForbidden) {
+			return response.Forbid(c, err.Error())
+		}
 		return response.Internal(c, err.Error())
 	}
+	h.audit.Log(c.UserContext(), model.AuditDocTaskDelete, model.AuditTargetDocTask, []uint{uint(id)}, nil)
 
 	return response.Ok(c, "success")
 }
@@ -67,8 +82,11 @@ func (h *DbDocHandler) Delete(c *fiber.Ctx) error {
 // Get 获取详情
 func (h *DbDocHandler) Get(c *fiber.Ctx) error {
 	id, _ := strconv.Atoi(c.Params("id"))
-	task, err := h.svc.GetTask(uint(id))
+	task, err := h.svc.GetTask(c.UserContext(), uint(id))
 	if err != nil {
+		if errors.Is(err, service.ErrForbidden) {
+			return response.Forbid(c, err.Error())
+		}
 		return response.Internal(c, err.Error())
 	}
 
@@ -84,7 +102,7 @@ func (h *DbDocHandler) List(c *fiber.Ctx) error {
 		return response.Invalid(c, err.Error())
 	}
 	req.Pagination.ValidateAndSetDefaults()
-	tasks, total, err := h.svc.ListTasks(req)
+	tasks, total, err := h.svc.ListTasks(c.UserContext(), req)
 	if err != nil {
 		return response.Internal(c, err.Error())
 	}
@@ -103,9 +121,13 @@ func (h *DbDocHandler) List(c *fiber.Ctx) error {
 // Run 运行任务
 func (h *DbDocHandler) Run(c *fiber.Ctx) error {
 	id, _ := strconv.Atoi(c.Params("id"))
-	if err := h.svc.RunTask(uint(id)); err != nil {
+	if err := h.svc.RunTask(c.UserContext(), uint(id)); err != nil {
+		if errors.Is(err, service.ErrForbidden) {
+			return response.Forbid(c, err.Error())
+		}
 		return response.Internal(c, err.Error())
 	}
+	h.audit.Log(c.UserContext(), model.AuditDocTaskRun, model.AuditTargetDocTask, []uint{uint(id)}, nil)
 
 	return response.Ok(c, "success")
 }
@@ -132,5 +154,8 @@ func (h *DbDocHandler) convertToResponse(task *model.DbDocTask) model.DbDocTaskR
 		LastStatus:   task.LastStatus,
 		LastError:    task.LastError,
 		Instance:     &task.Instance,
+
+		CreatedBy:     task.CreatedBy,
+		CreatedByName: task.CreatedByName,
 	}
 }
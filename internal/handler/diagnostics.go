@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"my-bulker/internal/pkg/response"
+	"my-bulker/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DiagnosticsHandler 运行诊断处理器
+type DiagnosticsHandler struct {
+	service *service.DiagnosticsService
+}
+
+// NewDiagnosticsHandler 创建运行诊断处理器
+func NewDiagnosticsHandler() *DiagnosticsHandler {
+	return &DiagnosticsHandler{
+		service: service.NewDiagnosticsService(),
+	}
+}
+
+// Pools 获取实例连接池统计信息
+func (h *DiagnosticsHandler) Pools(c *fiber.Ctx) error {
+	return response.Success(c, h.service.GetPoolDiagnostics())
+}
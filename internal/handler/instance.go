@@ -15,12 +15,14 @@ import (
 // InstanceHandler 实例处理器
 type InstanceHandler struct {
 	service *service.InstanceService
+	audit   *service.AuditService
 }
 
 // NewInstanceHandler 创建实例处理器
 func NewInstanceHandler() *InstanceHandler {
 	return &InstanceHandler{
 		service: service.NewInstanceService(),
+		audit:   service.NewAuditService(),
 	}
 }
 
@@ -31,14 +33,24 @@ func (h *InstanceHandler) Create(c *fiber.Ctx) error {
 		return response.Invalid(c, "无效的请求数据")
 	}
 
-	instance, err := h.service.Create(&req)
+	instance, err := h.service.Create(c.UserContext(), &req)
 	if err != nil {
+		if errors.Is(err, service.ErrForbidden) {
+			return response.Forbid(c, err.Error())
+		}
 		if err == service.ErrInstanceNameExists {
 			return response.Invalid(c, "实例名称已存在")
 		}
+		if err == service.ErrInvalidLimits || err == service.ErrInvalidReplicas ||
+			err == service.ErrInvalidSSH || err == service.ErrInvalidTLS ||
+			err == service.ErrInvalidEngine || err == service.ErrInvalidFileGlob ||
+			err == service.ErrInvalidTags || err == service.ErrInvalidProtection {
+			return response.Invalid(c, err.Error())
+		}
 		return response.Internal(c, "创建实例失败")
 	}
 
+	h.audit.Log(c.UserContext(), model.AuditInstanceCreate, model.AuditTargetInstance, []uint{instance.ID}, instanceAuditDetail(instance, true))
 	return response.Custom(c, response.CodeSuccess, "创建实例成功", instance)
 }
 
@@ -54,14 +66,24 @@ func (h *InstanceHandler) Update(c *fiber.Ctx) error {
 		return response.Invalid(c, "无效的请求数据")
 	}
 
-	instance, err := h.service.Update(uint(id), &req)
+	instance, err := h.service.Update(c.UserContext(), uint(id), &req)
 	if err != nil {
+		if errors.Is(err, service.ErrForbidden) {
+			return response.Forbid(c, err.Error())
+		}
 		if err == service.ErrInstanceNameExists {
 			return response.Invalid(c, "实例名称已存在")
 		}
+		if err == service.ErrInvalidLimits || err == service.ErrInvalidReplicas ||
+			err == service.ErrInvalidSSH || err == service.ErrInvalidTLS ||
+			err == service.ErrInvalidEngine || err == service.ErrInvalidFileGlob ||
+			err == service.ErrInvalidTags || err == service.ErrInvalidProtection {
+			return response.Invalid(c, err.Error())
+		}
 		return response.Internal(c, "更新实例失败")
 	}
 
+	h.audit.Log(c.UserContext(), model.AuditInstanceUpdate, model.AuditTargetInstance, []uint{instance.ID}, instanceAuditDetail(instance, req.Password != ""))
 	return response.Custom(c, response.CodeSuccess, "更新实例成功", instance)
 }
 
@@ -72,9 +94,13 @@ func (h *InstanceHandler) Delete(c *fiber.Ctx) error {
 		return response.Invalid(c, "无效的实例ID")
 	}
 
-	if err := h.service.Delete(uint(id)); err != nil {
+	if err := h.service.Delete(c.UserContext(), uint(id)); err != nil {
+		if errors.Is(err, service.ErrForbidden) {
+			return response.Forbid(c, err.Error())
+		}
 		return response.Internal(c, "删除实例失败")
 	}
+	h.audit.Log(c.UserContext(), model.AuditInstanceDelete, model.AuditTargetInstance, []uint{uint(id)}, nil)
 
 	return response.Success(c, nil)
 }
@@ -90,9 +116,13 @@ func (h *InstanceHandler) BatchDelete(c *fiber.Ctx) error {
 		return response.Invalid(c, "实例ID列表不能为空")
 	}
 
-	if err := h.service.BatchDelete(req.InstanceIDs); err != nil {
+	if err := h.service.BatchDelete(c.UserContext(), req.InstanceIDs); err != nil {
+		if errors.Is(err, service.ErrForbidden) {
+			return response.Forbid(c, err.Error())
+		}
 		return response.Internal(c, "批量删除实例失败")
 	}
+	h.audit.Log(c.UserContext(), model.AuditInstanceDelete, model.AuditTargetInstance, req.InstanceIDs, nil)
 
 	return response.Ok(c, "批量删除成功")
 }
@@ -104,8 +134,11 @@ func (h *InstanceHandler) Get(c *fiber.Ctx) error {
 		return response.Invalid(c, "无效的实例ID")
 	}
 
-	instance, err := h.service.Get(uint(id))
+	instance, err := h.service.Get(c.UserContext(), uint(id))
 	if err != nil {
+		if errors.Is(err, service.ErrForbidden) {
+			return response.Forbid(c, err.Error())
+		}
 		return response.Internal(c, "获取实例失败")
 	}
 
@@ -119,14 +152,21 @@ func (h *InstanceHandler) GetPassword(c *fiber.Ctx) error {
 		return response.Invalid(c, "无效的实例ID")
 	}
 
-	password, err := h.service.GetPassword(uint(id))
+	password, err := h.service.GetPassword(c.UserContext(), uint(id))
 	if err != nil {
+		if errors.Is(err, service.ErrForbidden) {
+			return response.Forbid(c, err.Error())
+		}
 		// 实例不存在时返回明确提示，避免前端误判为系统错误。
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return response.NotFound(c, "实例不存在")
 		}
 		return response.Internal(c, "获取实例密码失败")
 	}
+	// 密码查看必须留痕，审计记录失败时不返回密码
+	if err := h.audit.Record(c.UserContext(), model.AuditInstancePassword, model.AuditTargetInstance, []uint{uint(id)}, nil); err != nil {
+		return response.Internal(c, "记录审计日志失败")
+	}
 
 	return response.Custom(c, response.CodeSuccess, "获取实例密码成功", password)
 }
@@ -140,8 +180,11 @@ func (h *InstanceHandler) List(c *fiber.Ctx) error {
 		return response.Invalid(c, "无效的查询参数")
 	}
 
-	list, err := h.service.List(&req)
+	list, err := h.service.List(c.UserContext(), &req)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidTagExpr) {
+			return response.Invalid(c, err.Error())
+		}
 		return response.Internal(c, "获取实例列表失败")
 	}
 
@@ -151,27 +194,52 @@ func (h *InstanceHandler) List(c *fiber.Ctx) error {
 // TestConnection 测试数据库连接
 func (h *InstanceHandler) TestConnection(c *fiber.Ctx) error {
 	var req struct {
+		ID       uint                 `json:"id"` // 编辑已有实例时传入，未填写的密码沿用原值
+		Engine   string               `json:"engine"`
 		Host     string               `json:"host"`
 		Port     int                  `json:"port"`
 		Username string               `json:"username"`
 		Password string               `json:"password"`
 		Params   model.InstanceParams `json:"params"`
+		SSH      model.SSHTunnel      `json:"ssh"`
+		TLS      model.InstanceTLS    `json:"tls"`
+
+		ConnectDatabase string `json:"connect_database"`
+		FileGlob        string `json:"file_glob"`
+
+		ClearSecrets []string `json:"clear_secrets"` // 不沿用的已保存敏感字段
 	}
 
 	if err := c.BodyParser(&req); err != nil {
 		return response.Invalid(c, "无效的请求数据")
 	}
 
-	if err := h.service.TestConnection(req.Host, req.Port, req.Username, req.Password, req.Params); err != nil {
+	instance := &model.Instance{
+		Engine:          req.Engine,
+		Host:            req.Host,
+		Port:            req.Port,
+		Username:        req.Username,
+		Password:        req.Password,
+		Params:          req.Params,
+		ConnectDatabase: req.ConnectDatabase,
+		FileGlob:        req.FileGlob,
+		SSH:             req.SSH,
+		TLS:             req.TLS,
+	}
+	result, err := h.service.TestConnection(c.UserContext(), req.ID, instance, req.ClearSecrets)
+	if err != nil {
+		if errors.Is(err, service.ErrForbidden) {
+			return response.Forbid(c, err.Error())
+		}
 		return response.Invalid(c, err.Error())
 	}
 
-	return response.Success(c, nil)
+	return response.Success(c, result)
 }
 
 // Options 获取实例选项
 func (h *InstanceHandler) Options(c *fiber.Ctx) error {
-	options, err := h.service.GetOptions()
+	options, err := h.service.GetOptions(c.UserContext())
 	if err != nil {
 		return response.Internal(c, "获取实例选项失败")
 	}
@@ -179,18 +247,58 @@ func (h *InstanceHandler) Options(c *fiber.Ctx) error {
 	return response.Success(c, options)
 }
 
-// SyncDatabases 同步数据库信息
+// TagOptions 获取已使用的标签
+func (h *InstanceHandler) TagOptions(c *fiber.Ctx) error {
+	options, err := h.service.TagOptions(c.UserContext())
+	if err != nil {
+		return response.Internal(c, "获取标签失败")
+	}
+
+	return response.Success(c, options)
+}
+
+// BatchTag 批量修改实例标签
+func (h *InstanceHandler) BatchTag(c *fiber.Ctx) error {
+	var req model.BatchTagInstancesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Invalid(c, "无效的请求数据")
+	}
+
+	if len(req.InstanceIDs) == 0 {
+		return response.Invalid(c, "实例ID列表不能为空")
+	}
+
+	if err := h.service.BatchTag(c.UserContext(), &req); err != nil {
+		if errors.Is(err, service.ErrForbidden) {
+			return response.Forbid(c, err.Error())
+		}
+		if err == service.ErrInvalidTags {
+			return response.Invalid(c, err.Error())
+		}
+		return response.Internal(c, "修改实例标签失败")
+	}
+	h.audit.Log(c.UserContext(), model.AuditInstanceTag, model.AuditTargetInstance, req.InstanceIDs, fiber.Map{"set": req.Set, "remove": req.Remove})
+
+	return response.Ok(c, "修改标签成功")
+}
+
+// SyncDatabases 同步数据库信息，返回各实例的同步结果
 func (h *InstanceHandler) SyncDatabases(c *fiber.Ctx) error {
 	var req model.SyncDatabasesRequest
 	if err := c.BodyParser(&req); err != nil {
 		return response.Invalid(c, "无效的请求数据")
 	}
 
-	if err := h.service.SyncDatabases(req.InstanceIDs); err != nil {
+	// 部分实例失败时仍返回成功，各实例的结果见摘要
+	summary, err := h.service.SyncDatabases(c.UserContext(), req.InstanceIDs)
+	if err != nil {
+		if errors.Is(err, service.ErrForbidden) {
+			return response.Forbid(c, err.Error())
+		}
 		return response.Internal(c, fmt.Sprintf("同步数据库失败: %v", err))
 	}
 
-	return response.Ok(c, "同步数据库成功")
+	return response.Success(c, summary)
 }
 
 // ExportInstances 导出实例配置
@@ -200,9 +308,19 @@ func (h *InstanceHandler) ExportInstances(c *fiber.Ctx) error {
 		return response.Invalid(c, "无效的请求数据")
 	}
 
-	instances, err := h.service.ExportInstances(req.InstanceIDs)
+	instances, err := h.service.ExportInstances(c.UserContext(), req.InstanceIDs, req.Passphrase)
 	if err != nil {
-		return response.Internal(c, "导出实例配置失败")
+		if errors.Is(err, service.ErrForbidden) {
+			return response.Forbid(c, err.Error())
+		}
+		return response.Internal(c, fmt.Sprintf("导出实例配置失败: %v", err))
+	}
+	ids := make([]uint, len(instances))
+	for i := range instances {
+		ids[i] = instances[i].ID
+	}
+	if err := h.audit.Record(c.UserContext(), model.AuditInstanceExport, model.AuditTargetInstance, ids, fiber.Map{"with_passwords": req.Passphrase != ""}); err != nil {
+		return response.Internal(c, "记录审计日志失败")
 	}
 
 	return response.Success(c, instances)
@@ -221,10 +339,30 @@ func (h *InstanceHandler) ImportInstances(c *fiber.Ctx) error {
 	}
 	defer fileContent.Close()
 
-	summary, err := h.service.ImportInstances(fileContent)
+	summary, err := h.service.ImportInstances(c.UserContext(), fileContent, c.FormValue("passphrase"))
 	if err != nil {
 		return response.Internal(c, fmt.Sprintf("导入失败: %v", err))
 	}
+	h.audit.Log(c.UserContext(), model.AuditInstanceImport, model.AuditTargetInstance, nil, fiber.Map{
+		"file":      file.Filename,
+		"succeeded": summary.Succeeded,
+		"failed":    summary.Failed,
+		"skipped":   summary.Skipped,
+	})
 
 	return response.Success(c, summary)
 }
+
+// instanceAuditDetail 实例审计详情，不包含任何密码
+func instanceAuditDetail(instance *model.Instance, passwordChanged bool) fiber.Map {
+	return fiber.Map{
+		"name":             instance.Name,
+		"engine":           instance.Engine,
+		"host":             instance.Host,
+		"port":             instance.Port,
+		"username":         instance.Username,
+		"tags":             instance.Tags,
+		"protection_mode":  instance.ProtectionMode,
+		"password_changed": passwordChanged,
+	}
+}
@@ -2,31 +2,39 @@ package handler
 
 import (
 	"bytes"
-	"context"
 	"encoding/base64"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/authctx"
 	"my-bulker/internal/pkg/database"
+	"my-bulker/internal/pkg/rbac"
 	"my-bulker/internal/pkg/response"
 	"my-bulker/internal/service"
 	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 )
 
 // QueryTaskHandler 查询任务处理器
 type QueryTaskHandler struct {
-	service *service.QueryTaskService
-	creator *service.QueryTaskCreatorService
+	service  *service.QueryTaskService
+	creator  *service.QueryTaskCreatorService
+	reviewer *service.QueryTaskReviewService
+	audit    *service.AuditService
 }
 
 // NewQueryTaskHandler 创建查询任务处理器
 func NewQueryTaskHandler() *QueryTaskHandler {
 	return &QueryTaskHandler{
-		service: service.NewQueryTaskService(database.GetDB()),
-		creator: service.NewQueryTaskCreatorService(database.GetDB()),
+		service:  service.NewQueryTaskService(database.GetDB()),
+		creator:  service.NewQueryTaskCreatorService(database.GetDB()),
+		reviewer: service.NewQueryTaskReviewService(database.GetDB()),
+		audit:    service.NewAuditService(),
 	}
 }
 
@@ -43,28 +51,83 @@ func (h *QueryTaskHandler) Create(c *fiber.Ctx) error {
 	if req.TaskName == "" {
 		return response.Invalid(c, "任务名称不能为空")
 	}
-	if len(req.InstanceIDs) == 0 {
-		return response.Invalid(c, "请选择至少一个实例")
-	}
-	if req.DatabaseMode != "include" && req.DatabaseMode != "exclude" {
-		return response.Invalid(c, "数据库选择模式必须是 include 或 exclude")
-	}
-	if len(req.SelectedDBs) == 0 {
-		return response.Invalid(c, "选中的数据库列表不能为空")
+	if msg := validateTargetSelection(&req.TargetSelection); msg != "" {
+		return response.Invalid(c, msg)
 	}
 	if req.SQLContent == "" {
 		return response.Invalid(c, "SQL语句内容不能为空")
 	}
 
 	// 创建任务
-	task, err := h.creator.Create(c.Context(), &req)
+	task, err := h.creator.Create(c.UserContext(), &req)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidTagExpr) || errors.Is(err, service.ErrInvalidTargetRule) {
+			return response.Invalid(c, err.Error())
+		}
+		if errors.Is(err, service.ErrReadOnlyInstance) || errors.Is(err, service.ErrForbidden) {
+			return response.Forbid(c, err.Error())
+		}
 		return response.Internal(c, "创建查询任务失败: "+err.Error())
 	}
 
+	h.audit.Log(c.UserContext(), model.AuditTaskCreate, model.AuditTargetQueryTask, []uint{task.ID}, h.audit.TaskDetail(task.ID))
 	return response.Success(c, task)
 }
 
+// PreviewTargets 预览目标数据库
+func (h *QueryTaskHandler) PreviewTargets(c *fiber.Ctx) error {
+	var req model.TargetSelection
+	if err := c.BodyParser(&req); err != nil {
+		return response.Invalid(c, "无效的请求参数")
+	}
+	if msg := validateTargetSelection(&req); msg != "" {
+		return response.Invalid(c, msg)
+	}
+
+	result, err := h.creator.PreviewTargets(c.UserContext(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTagExpr) || errors.Is(err, service.ErrInvalidTargetRule) {
+			return response.Invalid(c, err.Error())
+		}
+		if errors.Is(err, service.ErrForbidden) {
+			return response.Forbid(c, err.Error())
+		}
+		return response.Internal(c, "预览目标数据库失败: "+err.Error())
+	}
+	return response.Success(c, result)
+}
+
+// validateTargetSelection 校验目标数据库选择参数，返回错误提示，为空表示通过
+func validateTargetSelection(sel *model.TargetSelection) string {
+	switch sel.DatabaseMode {
+	case model.TargetModeInclude, model.TargetModeExclude:
+		if len(sel.InstanceIDs) == 0 {
+			return "请选择至少一个实例"
+		}
+		if len(sel.SelectedDBs) == 0 {
+			return "选中的数据库列表不能为空"
+		}
+	case model.TargetModeTags:
+		if strings.TrimSpace(sel.TagExpr) == "" {
+			return "标签表达式不能为空"
+		}
+	case model.TargetModePattern:
+		if len(sel.InstanceIDs) == 0 && strings.TrimSpace(sel.TagExpr) == "" {
+			return "请选择实例或填写标签表达式"
+		}
+	case model.TargetModeSchema:
+		if len(sel.InstanceIDs) == 0 && strings.TrimSpace(sel.TagExpr) == "" {
+			return "请选择实例或填写标签表达式"
+		}
+		if len(sel.Require.Tables) == 0 && len(sel.Require.Columns) == 0 && strings.TrimSpace(sel.Require.ProbeSQL) == "" {
+			return "请填写需要的表、字段或探测语句"
+		}
+	default:
+		return "数据库选择模式必须是 include、exclude、tags、pattern 或 schema"
+	}
+	return ""
+}
+
 // Get 获取查询任务详情
 func (h *QueryTaskHandler) Get(c *fiber.Ctx) error {
 	idStr := c.Params("id")
@@ -73,7 +136,10 @@ func (h *QueryTaskHandler) Get(c *fiber.Ctx) error {
 		return response.Invalid(c, "无效的任务ID")
 	}
 
-	task, err := h.service.Get(c.Context(), uint(id))
+	if err := h.service.Authorize(c.UserContext(), uint(id), rbac.ActionView); err != nil {
+		return accessError(c, err, "查询任务不存在")
+	}
+	task, err := h.service.Get(c.UserContext(), uint(id))
 	if err != nil {
 		return response.Internal(c, "获取查询任务详情失败")
 	}
@@ -98,7 +164,7 @@ func (h *QueryTaskHandler) List(c *fiber.Ctx) error {
 	req.Pagination.ValidateAndSetDefaults()
 	req.Sorting.ValidateAndSetDefaults()
 
-	list, err := h.service.List(c.Context(), &req)
+	list, err := h.service.List(c.UserContext(), &req)
 	if err != nil {
 		return response.Internal(c, "获取查询任务列表失败")
 	}
@@ -114,7 +180,10 @@ func (h *QueryTaskHandler) GetSQLs(c *fiber.Ctx) error {
 		return response.Invalid(c, "无效的任务ID")
 	}
 
-	sqls, err := h.service.GetSQLs(c.Context(), uint(taskID))
+	if err := h.service.Authorize(c.UserContext(), uint(taskID), rbac.ActionView); err != nil {
+		return accessError(c, err, "查询任务不存在")
+	}
+	sqls, err := h.service.GetSQLs(c.UserContext(), uint(taskID))
 	if err != nil {
 		return response.Internal(c, "获取SQL语句列表失败")
 	}
@@ -130,8 +199,11 @@ func (h *QueryTaskHandler) GetSQLExecutions(c *fiber.Ctx) error {
 		return response.Invalid(c, "无效的任务ID")
 	}
 
+	if err := h.service.Authorize(c.UserContext(), uint(taskID), rbac.ActionView); err != nil {
+		return accessError(c, err, "查询任务不存在")
+	}
 	// 查询所有SQL
-	sqls, err := h.service.GetSQLsWithExecutions(c.Context(), uint(taskID))
+	sqls, err := h.service.GetSQLsWithExecutions(c.UserContext(), uint(taskID))
 	if err != nil {
 		return response.Internal(c, "获取SQL执行明细失败")
 	}
@@ -139,35 +211,173 @@ func (h *QueryTaskHandler) GetSQLExecutions(c *fiber.Ctx) error {
 	return response.Success(c, sqls)
 }
 
-// Run 运行查询任务
+// Run 运行查询任务，re_resolve=true 时先按任务保存的选择规则和最新的同步结果重新确定目标
 func (h *QueryTaskHandler) Run(c *fiber.Ctx) error {
 	idStr := c.Params("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
 		return response.Invalid(c, "无效的任务ID")
 	}
+	// 先检查运行权限，无权限的用户不会得到任务状态相关的提示
+	if err := h.service.AuthorizeRun(c.UserContext(), uint(id)); err != nil {
+		return accessError(c, err, "查询任务不存在")
+	}
 	db := database.GetDB()
-	// 先查任务状态
+	// 查询任务状态
 	var task model.QueryTask
 	if err := db.First(&task, id).Error; err != nil {
 		return response.Internal(c, "查询任务失败: "+err.Error())
 	}
-	if task.Status == 2 || task.Status == 3 {
-		runService := service.NewQueryTaskRunService(db)
+	queue := service.GetQueryTaskQueue()
+	if queue.IsActive(task.ID) {
+		return response.Conflict(c, "任务已在排队或执行中")
+	}
+	if task.Status == model.QueryTaskStatusPendingApproval {
+		return response.Forbid(c, service.ErrApprovalRequired.Error())
+	}
+	if task.Status == model.QueryTaskStatusRejected {
+		return response.Forbid(c, service.ErrTaskRejected.Error())
+	}
+	runService := service.NewQueryTaskRunService(db)
+	// 按保存的选择规则和最新的同步结果重新确定目标
+	var refreshed *model.RefreshTargetsResult
+	if c.QueryBool("re_resolve") {
+		refreshed, err = runService.ReResolveTargets(c.UserContext(), uint(id))
+		if err != nil {
+			if errors.Is(err, service.ErrTargetRuleMissing) || errors.Is(err, service.ErrInvalidTagExpr) || errors.Is(err, service.ErrInvalidTargetRule) {
+				return response.Invalid(c, err.Error())
+			}
+			if errors.Is(err, service.ErrForbidden) {
+				return response.Forbid(c, err.Error())
+			}
+			return response.Internal(c, "重新解析目标失败: "+err.Error())
+		}
+	}
+	// 写入语句命中只读实例时拒绝执行；需要审批而未审批或审批后 SQL、目标有变化时转为待审批
+	if err := runService.CheckRunProtection(uint(id)); err != nil {
+		if errors.Is(err, service.ErrReadOnlyInstance) || errors.Is(err, service.ErrApprovalRequired) || errors.Is(err, service.ErrTaskRejected) {
+			return response.Forbid(c, err.Error())
+		}
+		return response.Internal(c, "检查实例保护模式失败: "+err.Error())
+	}
+	// 检查全部通过后才清空上次的执行结果，被拒绝的重跑不会丢失已有结果
+	if task.Status == model.QueryTaskStatusCompleted || task.Status == model.QueryTaskStatusFailed {
 		if err := runService.ResetQueryTask(c.Context(), uint(id)); err != nil {
 			return response.Internal(c, "重置任务失败: "+err.Error())
 		}
 	}
-	// 立即将任务状态设为执行中
-	if err := db.Model(&model.QueryTask{}).Where("id = ?", id).Update("status", 1).Error; err != nil {
-		return response.Internal(c, "更新任务状态失败: "+err.Error())
+	// 加入全局执行队列，由队列按顺序调度执行
+	position, err := queue.Enqueue(&task, authctx.UserID(c.UserContext()))
+	if err != nil {
+		if errors.Is(err, service.ErrTaskAlreadyQueued) {
+			return response.Conflict(c, "任务已在排队或执行中")
+		}
+		return response.Internal(c, "任务入队失败: "+err.Error())
+	}
+	detail := h.audit.TaskDetail(task.ID)
+	detail["re_resolve"] = refreshed != nil
+	h.audit.Log(c.UserContext(), model.AuditTaskRun, model.AuditTargetQueryTask, []uint{task.ID}, detail)
+	message := "任务已开始执行"
+	if position > 0 {
+		message = fmt.Sprintf("任务已加入队列，当前排在第 %d 位", position)
+	}
+	if refreshed != nil {
+		message += fmt.Sprintf("；目标数据库共 %d 个，新增 %d 个，移除 %d 个", refreshed.Total, refreshed.Added, refreshed.Retired)
+	}
+	return response.Custom(c, response.CodeSuccess, message, fiber.Map{"queue_position": position, "targets": refreshed})
+}
+
+// Approve 审批通过任务
+func (h *QueryTaskHandler) Approve(c *fiber.Ctx) error {
+	return h.review(c, true)
+}
+
+// Reject 驳回任务
+func (h *QueryTaskHandler) Reject(c *fiber.Ctx) error {
+	return h.review(c, false)
+}
+
+// review 处理审批请求，驳回时必须填写审批意见
+func (h *QueryTaskHandler) review(c *fiber.Ctx, approve bool) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return response.Invalid(c, "无效的任务ID")
+	}
+	var req model.ReviewQueryTaskRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Invalid(c, "无效的请求参数")
+	}
+	req.Comment = strings.TrimSpace(req.Comment)
+	if !approve && req.Comment == "" {
+		return response.Invalid(c, "驳回时必须填写审批意见")
+	}
+
+	var review *model.QueryTaskReview
+	if approve {
+		review, err = h.reviewer.Approve(c.UserContext(), uint(id), &req)
+	} else {
+		review, err = h.reviewer.Reject(c.UserContext(), uint(id), &req)
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "任务不存在")
+		}
+		if errors.Is(err, service.ErrTaskNotPendingApproval) {
+			return response.Conflict(c, err.Error())
+		}
+		if errors.Is(err, service.ErrReadOnlyInstance) || errors.Is(err, service.ErrForbidden) {
+			return response.Forbid(c, err.Error())
+		}
+		return response.Internal(c, "审批任务失败: "+err.Error())
+	}
+	message, action := "任务已驳回", model.AuditTaskReject
+	if approve {
+		message, action = "任务已审批通过", model.AuditTaskApprove
+	}
+	detail := h.audit.TaskDetail(uint(id))
+	detail["comment"] = req.Comment
+	h.audit.Log(c.UserContext(), action, model.AuditTargetQueryTask, []uint{uint(id)}, detail)
+	return response.Custom(c, response.CodeSuccess, message, review)
+}
+
+// Reviews 获取任务的审批记录
+func (h *QueryTaskHandler) Reviews(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return response.Invalid(c, "无效的任务ID")
+	}
+	if err := h.service.Authorize(c.UserContext(), uint(id), rbac.ActionView); err != nil {
+		return accessError(c, err, "任务不存在")
+	}
+	reviews, err := h.reviewer.List(uint(id))
+	if err != nil {
+		return response.Internal(c, "获取审批记录失败: "+err.Error())
+	}
+	return response.Success(c, reviews)
+}
+
+// Cancel 取消排队中或执行中的任务
+func (h *QueryTaskHandler) Cancel(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return response.Invalid(c, "无效的任务ID")
 	}
-	// 异步执行任务
-	go func(taskID uint) {
-		runService := service.NewQueryTaskRunService(db)
-		_ = runService.Run(context.Background(), uint(taskID))
-	}(uint(id))
-	return response.Ok(c, "任务已开始执行")
+	if err := h.service.Authorize(c.UserContext(), uint(id), rbac.ActionRunRead); err != nil {
+		return accessError(c, err, "任务不存在")
+	}
+	if err := service.GetQueryTaskQueue().Cancel(uint(id)); err != nil {
+		if errors.Is(err, service.ErrTaskNotQueued) {
+			return response.Invalid(c, "任务未在排队或执行中")
+		}
+		return response.Internal(c, "取消任务失败: "+err.Error())
+	}
+	h.audit.Log(c.UserContext(), model.AuditTaskCancel, model.AuditTargetQueryTask, []uint{uint(id)}, nil)
+	return response.Ok(c, "任务已取消")
+}
+
+// Queue 获取执行队列状态
+func (h *QueryTaskHandler) Queue(c *fiber.Ctx) error {
+	return response.Success(c, service.GetQueryTaskQueue().Status())
 }
 
 // GetSQLResult 查询SQL结果表
@@ -188,10 +398,10 @@ func (h *QueryTaskHandler) GetSQLResult(c *fiber.Ctx) error {
 	}
 
 	db := database.GetDB()
-	// 查找SQL记录，获取表名和schema
-	var sqlRec model.QueryTaskSQL
-	if err := db.First(&sqlRec, sqlID).Error; err != nil {
-		return response.NotFound(c, "SQL记录不存在")
+	// 查找SQL记录，获取表名和schema，需要任务全部目标实例的查看权限
+	sqlRec, err := h.service.AuthorizeSQL(c.UserContext(), uint(sqlID))
+	if err != nil {
+		return accessError(c, err, "SQL记录不存在")
 	}
 	tableName := sqlRec.ResultTableName
 	schema := sqlRec.ResultTableSchema
@@ -255,10 +465,10 @@ func (h *QueryTaskHandler) ExportSQLResult(c *fiber.Ctx) error {
 	}
 
 	db := database.GetDB()
-	// 查找SQL记录，获取表名和schema
-	var sqlRec model.QueryTaskSQL
-	if err := db.First(&sqlRec, sqlID).Error; err != nil {
-		return response.NotFound(c, "SQL记录不存在")
+	// 查找SQL记录，获取表名和schema，需要任务全部目标实例的查看权限
+	sqlRec, err := h.service.AuthorizeSQL(c.UserContext(), uint(sqlID))
+	if err != nil {
+		return accessError(c, err, "SQL记录不存在")
 	}
 	tableName := sqlRec.ResultTableName
 	schemaStr := sqlRec.ResultTableSchema
@@ -350,6 +560,14 @@ func (h *QueryTaskHandler) ExportSQLResult(c *fiber.Ctx) error {
 		return response.Internal(c, "刷新CSV写入器失败: "+err.Error())
 	}
 
+	if err := h.audit.Record(c.UserContext(), model.AuditTaskResultExport, model.AuditTargetQueryTaskSQL, []uint{sqlRec.ID}, fiber.Map{
+		"task_id": sqlRec.TaskID,
+		"sql":     sqlRec.SQLContent,
+		"rows":    len(rows),
+	}); err != nil {
+		return response.Internal(c, "记录审计日志失败: "+err.Error())
+	}
+
 	// 设置响应头并发送文件
 	fileName := fmt.Sprintf("task_%d_sql_%d_results.csv", sqlRec.TaskID, sqlRec.ID)
 	c.Set(fiber.HeaderContentDisposition, "attachment; filename="+fileName)
@@ -358,6 +576,17 @@ func (h *QueryTaskHandler) ExportSQLResult(c *fiber.Ctx) error {
 	return c.Send(buf.Bytes())
 }
 
+// accessError 处理任务权限检查的错误，notFound 为记录不存在时的提示
+func accessError(c *fiber.Ctx, err error, notFound string) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return response.NotFound(c, notFound)
+	}
+	if errors.Is(err, service.ErrForbidden) {
+		return response.Forbid(c, err.Error())
+	}
+	return response.Internal(c, "检查权限失败: "+err.Error())
+}
+
 // encodeB64 base64编码字段名
 func encodeB64(s string) string {
 	return base64.RawURLEncoding.EncodeToString([]byte(s))
@@ -370,7 +599,10 @@ func (h *QueryTaskHandler) GetExecutionStats(c *fiber.Ctx) error {
 	if err != nil {
 		return response.Invalid(c, "无效的任务ID")
 	}
-	stats, err := h.service.GetExecutionStats(c.Context(), uint(taskID))
+	if err := h.service.Authorize(c.UserContext(), uint(taskID), rbac.ActionView); err != nil {
+		return accessError(c, err, "查询任务不存在")
+	}
+	stats, err := h.service.GetExecutionStats(c.UserContext(), uint(taskID))
 	if err != nil {
 		return response.Internal(c, "获取执行统计失败")
 	}
@@ -385,7 +617,10 @@ func (h *QueryTaskHandler) ToggleFavoriteStatus(c *fiber.Ctx) error {
 		return response.Invalid(c, "无效的任务ID")
 	}
 
-	if err := h.service.ToggleFavoriteStatus(c.Context(), uint(id)); err != nil {
+	if err := h.service.Authorize(c.UserContext(), uint(id), rbac.ActionView); err != nil {
+		return accessError(c, err, "任务不存在")
+	}
+	if err := h.service.ToggleFavoriteStatus(c.UserContext(), uint(id)); err != nil {
 		return response.Internal(c, "切换常用状态失败: "+err.Error())
 	}
 
@@ -408,9 +643,18 @@ func (h *QueryTaskHandler) BatchDeleteTasks(c *fiber.Ctx) error {
 		return response.Invalid(c, "任务ID列表不能为空")
 	}
 
-	if err := h.service.BatchDeleteTasks(c.Context(), req.TaskIDs); err != nil {
+	// 删除前记录任务内容，删除后无法再取得
+	tasks := make([]map[string]interface{}, len(req.TaskIDs))
+	for i, id := range req.TaskIDs {
+		tasks[i] = h.audit.TaskDetail(id)
+	}
+	if err := h.service.BatchDeleteTasks(c.UserContext(), req.TaskIDs); err != nil {
+		if errors.Is(err, service.ErrForbidden) {
+			return response.Forbid(c, err.Error())
+		}
 		return response.Internal(c, "批量删除任务失败: "+err.Error())
 	}
+	h.audit.Log(c.UserContext(), model.AuditTaskDelete, model.AuditTargetQueryTask, req.TaskIDs, fiber.Map{"tasks": tasks})
 
 	return response.Ok(c, "批量删除成功")
 }
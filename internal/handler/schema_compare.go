@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/database"
+	"my-bulker/internal/pkg/response"
+	"my-bulker/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// SchemaCompareHandler 库结构一致性检查处理器
+type SchemaCompareHandler struct {
+	service *service.SchemaCompareService
+	audit   *service.AuditService
+}
+
+// NewSchemaCompareHandler 创建库结构一致性检查处理器
+func NewSchemaCompareHandler() *SchemaCompareHandler {
+	return &SchemaCompareHandler{
+		service: service.NewSchemaCompareService(database.GetDB()),
+		audit:   service.NewAuditService(),
+	}
+}
+
+// Create 创建检查任务并开始运行
+func (h *SchemaCompareHandler) Create(c *fiber.Ctx) error {
+	var req model.CreateSchemaCompareRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Invalid(c, "无效的请求参数")
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		return response.Invalid(c, "任务名称不能为空")
+	}
+	if req.ReferenceInstanceID == 0 || req.ReferenceDatabase == "" {
+		return response.Invalid(c, "请选择参照库")
+	}
+	if msg := validateTargetSelection(&req.TargetSelection); msg != "" {
+		return response.Invalid(c, msg)
+	}
+
+	job, err := h.service.Create(c.UserContext(), &req)
+	if err != nil {
+		return schemaCompareError(c, err, "创建检查任务失败")
+	}
+	h.audit.Log(c.UserContext(), model.AuditSchemaCompareCreate, model.AuditTargetSchemaCompare, []uint{job.ID}, map[string]interface{}{
+		"name":                  job.Name,
+		"reference_instance_id": job.ReferenceInstanceID,
+		"reference_database":    job.ReferenceDatabase,
+		"total":                 job.Total,
+	})
+	return response.Success(c, job)
+}
+
+// List 获取检查任务列表
+func (h *SchemaCompareHandler) List(c *fiber.Ctx) error {
+	var req model.SchemaCompareListRequest
+	if err := c.QueryParser(&req); err != nil {
+		return response.Invalid(c, "无效的查询参数")
+	}
+	req.Pagination.ValidateAndSetDefaults()
+
+	list, err := h.service.List(c.UserContext(), &req)
+	if err != nil {
+		return response.Internal(c, "获取检查任务失败: "+err.Error())
+	}
+	return response.Success(c, list)
+}
+
+// Get 获取检查任务详情
+func (h *SchemaCompareHandler) Get(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return response.Invalid(c, "无效的任务ID")
+	}
+	job, err := h.service.Get(c.UserContext(), uint(id))
+	if err != nil {
+		return schemaCompareError(c, err, "获取检查任务失败")
+	}
+	return response.Success(c, job)
+}
+
+// Results 获取检查任务的比较结果
+func (h *SchemaCompareHandler) Results(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return response.Invalid(c, "无效的任务ID")
+	}
+	var req model.SchemaCompareResultListRequest
+	if err := c.QueryParser(&req); err != nil {
+		return response.Invalid(c, "无效的查询参数")
+	}
+	req.Pagination.ValidateAndSetDefaults()
+
+	list, err := h.service.Results(c.UserContext(), uint(id), &req)
+	if err != nil {
+		return schemaCompareError(c, err, "获取比较结果失败")
+	}
+	return response.Success(c, list)
+}
+
+// Run 重新运行检查任务
+func (h *SchemaCompareHandler) Run(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return response.Invalid(c, "无效的任务ID")
+	}
+	job, err := h.service.Run(c.UserContext(), uint(id))
+	if err != nil {
+		return schemaCompareError(c, err, "运行检查任务失败")
+	}
+	h.audit.Log(c.UserContext(), model.AuditSchemaCompareRun, model.AuditTargetSchemaCompare, []uint{job.ID}, map[string]interface{}{"total": job.Total})
+	return response.Success(c, job)
+}
+
+// Delete 删除检查任务
+func (h *SchemaCompareHandler) Delete(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return response.Invalid(c, "无效的任务ID")
+	}
+	if err := h.service.Delete(c.UserContext(), uint(id)); err != nil {
+		return schemaCompareError(c, err, "删除检查任务失败")
+	}
+	h.audit.Log(c.UserContext(), model.AuditSchemaCompareDelete, model.AuditTargetSchemaCompare, []uint{uint(id)}, nil)
+	return response.Ok(c, "删除成功")
+}
+
+// schemaCompareError 按错误类型返回检查任务相关的错误响应
+func schemaCompareError(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, service.ErrForbidden):
+		return response.Forbid(c, err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return response.NotFound(c, "检查任务不存在")
+	case errors.Is(err, service.ErrSchemaCompareRunning):
+		return response.Conflict(c, err.Error())
+	case errors.Is(err, service.ErrReferenceNotFound), errors.Is(err, service.ErrNoCompareTargets),
+		errors.Is(err, service.ErrInvalidTagExpr), errors.Is(err, service.ErrInvalidTargetRule):
+		return response.Invalid(c, err.Error())
+	}
+	return response.Internal(c, message+": "+err.Error())
+}
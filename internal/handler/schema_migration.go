@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"errors"
+	"strings"
+
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/database"
+	"my-bulker/internal/pkg/response"
+	"my-bulker/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// SchemaMigrationHandler 迁移语句处理器
+type SchemaMigrationHandler struct {
+	service *service.SchemaMigrationService
+	audit   *service.AuditService
+}
+
+// NewSchemaMigrationHandler 创建迁移语句处理器
+func NewSchemaMigrationHandler() *SchemaMigrationHandler {
+	return &SchemaMigrationHandler{
+		service: service.NewSchemaMigrationService(database.GetDB()),
+		audit:   service.NewAuditService(),
+	}
+}
+
+// Generate 比较两个库并生成使目标库与源库一致的语句
+func (h *SchemaMigrationHandler) Generate(c *fiber.Ctx) error {
+	var req model.SchemaMigrationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Invalid(c, "无效的请求参数")
+	}
+	if msg := validateMigration(&req); msg != "" {
+		return response.Invalid(c, msg)
+	}
+
+	result, err := h.service.Generate(c.UserContext(), &req)
+	if err != nil {
+		return schemaMigrationError(c, err, "生成迁移语句失败")
+	}
+	return response.Success(c, result)
+}
+
+// CreateTask 生成迁移语句并创建为查询任务
+func (h *SchemaMigrationHandler) CreateTask(c *fiber.Ctx) error {
+	var req model.CreateSchemaMigrationTaskRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Invalid(c, "无效的请求参数")
+	}
+	if msg := validateMigration(&req.SchemaMigrationRequest); msg != "" {
+		return response.Invalid(c, msg)
+	}
+	if strings.TrimSpace(req.TaskName) == "" {
+		return response.Invalid(c, "任务名称不能为空")
+	}
+	if req.DatabaseMode != "" {
+		if msg := validateTargetSelection(&req.TargetSelection); msg != "" {
+			return response.Invalid(c, msg)
+		}
+	}
+
+	task, migration, err := h.service.CreateTask(c.UserContext(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrNothingToMigrate) {
+			return response.Custom(c, response.CodeInvalid, err.Error(), migration)
+		}
+		return schemaMigrationError(c, err, "创建查询任务失败")
+	}
+
+	detail := h.audit.TaskDetail(task.ID)
+	detail["source_instance_id"] = req.SourceInstanceID
+	detail["source_database"] = req.SourceDatabase
+	detail["target_instance_id"] = req.TargetInstanceID
+	detail["target_database"] = req.TargetDatabase
+	h.audit.Log(c.UserContext(), model.AuditTaskCreate, model.AuditTargetQueryTask, []uint{task.ID}, detail)
+	return response.Success(c, task)
+}
+
+// validateMigration 校验源库和目标库，返回错误提示，为空表示通过
+func validateMigration(req *model.SchemaMigrationRequest) string {
+	if req.SourceInstanceID == 0 || req.SourceDatabase == "" {
+		return "请选择源库"
+	}
+	if req.TargetInstanceID == 0 || req.TargetDatabase == "" {
+		return "请选择目标库"
+	}
+	if req.SourceInstanceID == req.TargetInstanceID && req.SourceDatabase == req.TargetDatabase {
+		return "源库与目标库不能相同"
+	}
+	return ""
+}
+
+// schemaMigrationError 按错误类型返回迁移语句相关的错误响应
+func schemaMigrationError(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, service.ErrForbidden), errors.Is(err, service.ErrReadOnlyInstance):
+		return response.Forbid(c, err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return response.NotFound(c, "实例不存在")
+	case errors.Is(err, service.ErrEngineMismatch), errors.Is(err, service.ErrInvalidTagExpr), errors.Is(err, service.ErrInvalidTargetRule):
+		return response.Invalid(c, err.Error())
+	}
+	return response.Internal(c, message+": "+err.Error())
+}
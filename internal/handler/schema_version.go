@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/database"
+	"my-bulker/internal/pkg/response"
+	"my-bulker/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// SchemaVersionHandler 库结构版本处理器
+type SchemaVersionHandler struct {
+	service *service.SchemaVersionService
+}
+
+// NewSchemaVersionHandler 创建库结构版本处理器
+func NewSchemaVersionHandler() *SchemaVersionHandler {
+	return &SchemaVersionHandler{
+		service: service.NewSchemaVersionService(database.GetDB()),
+	}
+}
+
+// ListVersions 查询库的结构版本
+func (h *SchemaVersionHandler) ListVersions(c *fiber.Ctx) error {
+	var req model.SchemaVersionListRequest
+	if err := c.QueryParser(&req); err != nil {
+		return response.Invalid(c, "无效的查询参数")
+	}
+	req.Pagination.ValidateAndSetDefaults()
+
+	list, err := h.service.ListVersions(c.UserContext(), &req)
+	if err != nil {
+		return response.Internal(c, "获取结构版本失败: "+err.Error())
+	}
+	return response.Success(c, list)
+}
+
+// GetVersion 获取结构版本详情
+func (h *SchemaVersionHandler) GetVersion(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return response.Invalid(c, "无效的版本ID")
+	}
+	version, err := h.service.GetVersion(c.UserContext(), uint(id))
+	if err != nil {
+		return schemaVersionError(c, err, "获取结构版本失败")
+	}
+	return response.Success(c, version)
+}
+
+// ListChanges 查询结构变更记录
+func (h *SchemaVersionHandler) ListChanges(c *fiber.Ctx) error {
+	var req model.SchemaChangeListRequest
+	if err := c.QueryParser(&req); err != nil {
+		return response.Invalid(c, "无效的查询参数")
+	}
+	req.Pagination.ValidateAndSetDefaults()
+
+	list, err := h.service.ListChanges(c.UserContext(), &req)
+	if err != nil {
+		return response.Internal(c, "获取结构变更失败: "+err.Error())
+	}
+	return response.Success(c, list)
+}
+
+// Diff 比较两个结构版本
+func (h *SchemaVersionHandler) Diff(c *fiber.Ctx) error {
+	from, to := c.QueryInt("from"), c.QueryInt("to")
+	if from <= 0 || to <= 0 {
+		return response.Invalid(c, "请指定要比较的两个版本")
+	}
+	result, err := h.service.Diff(c.UserContext(), uint(from), uint(to))
+	if err != nil {
+		return schemaVersionError(c, err, "比较结构版本失败")
+	}
+	return response.Success(c, result)
+}
+
+// schemaVersionError 按错误类型返回结构版本相关的错误响应
+func schemaVersionError(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, service.ErrForbidden):
+		return response.Forbid(c, err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return response.NotFound(c, "结构版本不存在")
+	}
+	return response.Internal(c, message+": "+err.Error())
+}
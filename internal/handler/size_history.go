@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"errors"
+
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/database"
+	"my-bulker/internal/pkg/response"
+	"my-bulker/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SizeHistoryHandler 大小历史处理器
+type SizeHistoryHandler struct {
+	service *service.SizeHistoryService
+}
+
+// NewSizeHistoryHandler 创建大小历史处理器
+func NewSizeHistoryHandler() *SizeHistoryHandler {
+	return &SizeHistoryHandler{
+		service: service.NewSizeHistoryService(database.GetDB()),
+	}
+}
+
+// Growth 获取数据库或表在指定天数内的大小趋势，不指定库时按天汇总
+func (h *SizeHistoryHandler) Growth(c *fiber.Ctx) error {
+	var req model.SizeGrowthRequest
+	if err := c.QueryParser(&req); err != nil {
+		return response.Invalid(c, "无效的查询参数")
+	}
+	result, err := h.service.Growth(c.UserContext(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidGrowthFilter) {
+			return response.Invalid(c, err.Error())
+		}
+		return response.Internal(c, "获取大小趋势失败: "+err.Error())
+	}
+	return response.Success(c, result)
+}
+
+// TopGrowing 获取指定天数内增长最多的数据库或表
+func (h *SizeHistoryHandler) TopGrowing(c *fiber.Ctx) error {
+	var req model.TopGrowingRequest
+	if err := c.QueryParser(&req); err != nil {
+		return response.Invalid(c, "无效的查询参数")
+	}
+	items, err := h.service.TopGrowing(c.UserContext(), &req)
+	if err != nil {
+		return response.Internal(c, "获取增长排行失败: "+err.Error())
+	}
+	return response.List(c, items)
+}
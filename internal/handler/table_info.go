@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/database"
+	"my-bulker/internal/pkg/response"
+	"my-bulker/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// TableInfoHandler 表元数据处理器
+type TableInfoHandler struct {
+	service *service.TableInfoService
+}
+
+// NewTableInfoHandler 创建表元数据处理器
+func NewTableInfoHandler() *TableInfoHandler {
+	return &TableInfoHandler{
+		service: service.NewTableInfoService(database.GetDB()),
+	}
+}
+
+// List 按实例、库、表名、引擎、大小等条件查询表元数据
+func (h *TableInfoHandler) List(c *fiber.Ctx) error {
+	var req model.TableInfoListRequest
+	if err := c.QueryParser(&req); err != nil {
+		return response.Invalid(c, "无效的查询参数")
+	}
+	req.Pagination.ValidateAndSetDefaults()
+
+	list, err := h.service.List(c.UserContext(), &req)
+	if err != nil {
+		return response.Internal(c, "获取表列表失败: "+err.Error())
+	}
+	return response.Success(c, list)
+}
+
+// Largest 获取全部实例中最大的表
+func (h *TableInfoHandler) Largest(c *fiber.Ctx) error {
+	items, err := h.service.Largest(c.UserContext(), c.QueryInt("limit", 20), c.Query("by"))
+	if err != nil {
+		return response.Internal(c, "获取最大表失败: "+err.Error())
+	}
+	return response.List(c, items)
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/authctx"
+	"my-bulker/internal/pkg/credential"
+	"my-bulker/internal/pkg/response"
+	"my-bulker/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// UserHandler 用户管理处理器
+type UserHandler struct {
+	service *service.UserService
+	audit   *service.AuditService
+}
+
+// NewUserHandler 创建用户管理处理器
+func NewUserHandler() *UserHandler {
+	return &UserHandler{service: service.NewUserService(), audit: service.NewAuditService()}
+}
+
+// List 获取用户列表
+func (h *UserHandler) List(c *fiber.Ctx) error {
+	users, err := h.service.List()
+	if err != nil {
+		return response.Internal(c, "获取用户列表失败: "+err.Error())
+	}
+	return response.Success(c, users)
+}
+
+// Create 创建用户
+func (h *UserHandler) Create(c *fiber.Ctx) error {
+	var req model.CreateUserRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Invalid(c, "无效的请求参数")
+	}
+	if strings.TrimSpace(req.Username) == "" {
+		return response.Invalid(c, "用户名不能为空")
+	}
+	if err := credential.ValidatePassword(req.Password); err != nil {
+		return response.Invalid(c, err.Error())
+	}
+	user, err := h.service.Create(&req)
+	if err != nil {
+		if errors.Is(err, service.ErrUsernameTaken) {
+			return response.Conflict(c, err.Error())
+		}
+		return response.Internal(c, "创建用户失败: "+err.Error())
+	}
+	h.audit.Log(c.UserContext(), model.AuditUserCreate, model.AuditTargetUser, []uint{user.ID}, fiber.Map{"username": user.Username, "is_admin": user.IsAdmin})
+	return response.Success(c, user)
+}
+
+// Update 更新用户
+func (h *UserHandler) Update(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return response.Invalid(c, "无效的用户ID")
+	}
+	var req model.UpdateUserRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Invalid(c, "无效的请求参数")
+	}
+	if req.Password != "" {
+		if err := credential.ValidatePassword(req.Password); err != nil {
+			return response.Invalid(c, err.Error())
+		}
+	}
+	if uint(id) == authctx.UserID(c.UserContext()) && (req.Disabled || !req.IsAdmin) {
+		return response.Invalid(c, "不能禁用自己或取消自己的管理员权限")
+	}
+	user, err := h.service.Update(uint(id), &req)
+	if err != nil {
+		return h.handleError(c, "更新用户失败", err)
+	}
+	h.audit.Log(c.UserContext(), model.AuditUserUpdate, model.AuditTargetUser, []uint{user.ID}, fiber.Map{
+		"username":       user.Username,
+		"is_admin":       req.IsAdmin,
+		"disabled":       req.Disabled,
+		"password_reset": req.Password != "",
+	})
+	return response.Success(c, user)
+}
+
+// Delete 删除用户
+func (h *UserHandler) Delete(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return response.Invalid(c, "无效的用户ID")
+	}
+	if uint(id) == authctx.UserID(c.UserContext()) {
+		return response.Invalid(c, "不能删除自己")
+	}
+	if err := h.service.Delete(uint(id)); err != nil {
+		return h.handleError(c, "删除用户失败", err)
+	}
+	h.audit.Log(c.UserContext(), model.AuditUserDelete, model.AuditTargetUser, []uint{uint(id)}, nil)
+	return response.Ok(c, "用户已删除")
+}
+
+// Roles 获取用户的角色授权
+func (h *UserHandler) Roles(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return response.Invalid(c, "无效的用户ID")
+	}
+	bindings, err := h.service.Roles(uint(id))
+	if err != nil {
+		return h.handleError(c, "获取角色授权失败", err)
+	}
+	return response.Success(c, bindings)
+}
+
+// SaveRoles 保存用户的角色授权，整体替换原有授权
+func (h *UserHandler) SaveRoles(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return response.Invalid(c, "无效的用户ID")
+	}
+	var req model.SaveRoleBindingsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Invalid(c, "无效的请求参数")
+	}
+	bindings, err := h.service.SaveRoles(uint(id), &req)
+	if err != nil {
+		return h.handleError(c, "保存角色授权失败", err)
+	}
+	h.audit.Log(c.UserContext(), model.AuditUserRoles, model.AuditTargetUser, []uint{uint(id)}, fiber.Map{"bindings": req.Bindings})
+	return response.Custom(c, response.CodeSuccess, "角色授权已保存", bindings)
+}
+
+func (h *UserHandler) handleError(c *fiber.Ctx, prefix string, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return response.NotFound(c, "用户不存在")
+	}
+	if errors.Is(err, service.ErrLastAdmin) || errors.Is(err, service.ErrInvalidRoleBinding) {
+		return response.Invalid(c, err.Error())
+	}
+	return response.Internal(c, prefix+": "+err.Error())
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"errors"
+	"strings"
+
+	"my-bulker/internal/pkg/authctx"
+	"my-bulker/internal/pkg/response"
+	"my-bulker/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SessionCookie 界面登录会话的 Cookie 名称
+const SessionCookie = "my_bulker_session"
+
+// Auth 认证中间件，校验 Authorization: Bearer 令牌或会话 Cookie，通过后将当前用户写入请求上下文
+func Auth() fiber.Handler {
+	authService := service.NewAuthService()
+	return func(c *fiber.Ctx) error {
+		user, err := authService.Authenticate(Token(c))
+		if err != nil {
+			if errors.Is(err, service.ErrUnauthenticated) || errors.Is(err, service.ErrUserDisabled) {
+				return response.Auth(c, err.Error())
+			}
+			return response.Internal(c, "认证失败: "+err.Error())
+		}
+		ctx := authctx.WithClientIP(c.UserContext(), c.IP())
+		c.SetUserContext(authctx.WithUser(ctx, user))
+		return c.Next()
+	}
+}
+
+// RequireAdmin 仅允许管理员访问，需在 Auth 之后使用
+func RequireAdmin() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user := authctx.User(c.UserContext())
+		if user == nil || !user.IsAdmin {
+			return response.Forbid(c, "需要管理员权限")
+		}
+		return c.Next()
+	}
+}
+
+// Token 获取请求携带的令牌，优先使用 Authorization 头
+func Token(c *fiber.Ctx) string {
+	if header := c.Get(fiber.HeaderAuthorization); header != "" {
+		if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+	}
+	return c.Cookies(SessionCookie)
+}
@@ -1,13 +1,33 @@
 package middleware
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"os"
+	"strings"
 
-// CORS 中间件配置
+	"github.com/gofiber/fiber/v2"
+)
+
+// EnvCORSOrigins 允许跨域访问的来源，多个以逗号分隔，如 https://ops.example.com；未设置时只允许同源访问
+const EnvCORSOrigins = "MY_BULKER_CORS_ORIGINS"
+
+// CORS 中间件配置，只对允许的来源返回跨域响应头
 func CORS() fiber.Handler {
+	allowed := make(map[string]bool)
+	for _, origin := range strings.Split(os.Getenv(EnvCORSOrigins), ",") {
+		if origin = strings.TrimRight(strings.TrimSpace(origin), "/"); origin != "" {
+			allowed[origin] = true
+		}
+	}
+
 	return func(c *fiber.Ctx) error {
-		c.Set("Access-Control-Allow-Origin", "*")
-		c.Set("Access-Control-Allow-Methods", "GET,POST,PUT,DELETE,OPTIONS")
-		c.Set("Access-Control-Allow-Headers", "Content-Type,Authorization")
+		origin := c.Get(fiber.HeaderOrigin)
+		if origin != "" && allowed[origin] {
+			c.Vary(fiber.HeaderOrigin)
+			c.Set("Access-Control-Allow-Origin", origin)
+			c.Set("Access-Control-Allow-Credentials", "true")
+			c.Set("Access-Control-Allow-Methods", "GET,POST,PUT,DELETE,OPTIONS")
+			c.Set("Access-Control-Allow-Headers", "Content-Type,Authorization")
+		}
 
 		if c.Method() == "OPTIONS" {
 			return c.SendStatus(fiber.StatusNoContent)
@@ -0,0 +1,71 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 审计动作
+const (
+	AuditLogin               = "auth.login"               // 登录成功
+	AuditLoginFailed         = "auth.login_failed"        // 登录失败
+	AuditTokenCreate         = "auth.token_create"        // 创建 API 令牌
+	AuditTokenDelete         = "auth.token_delete"        // 删除 API 令牌
+	AuditUserCreate          = "user.create"              // 创建用户
+	AuditUserUpdate          = "user.update"              // 更新用户
+	AuditUserDelete          = "user.delete"              // 删除用户
+	AuditUserRoles           = "user.roles"               // 修改角色授权
+	AuditInstanceCreate      = "instance.create"          // 创建实例
+	AuditInstanceUpdate      = "instance.update"          // 更新实例
+	AuditInstanceDelete      = "instance.delete"          // 删除实例
+	AuditInstanceTag         = "instance.tag"             // 批量修改标签
+	AuditInstancePassword    = "instance.password_reveal" // 查看实例密码
+	AuditInstanceExport      = "instance.export"          // 导出实例配置
+	AuditInstanceImport      = "instance.import"          // 导入实例配置
+	AuditTaskCreate          = "query_task.create"        // 创建查询任务
+	AuditTaskRun             = "query_task.run"           // 运行查询任务
+	AuditTaskCancel          = "query_task.cancel"        // 取消查询任务
+	AuditTaskDelete          = "query_task.delete"        // 删除查询任务
+	AuditTaskApprove         = "query_task.approve"       // 审批通过
+	AuditTaskReject          = "query_task.reject"        // 审批驳回
+	AuditTaskResultExport    = "query_task.result_export" // 导出 SQL 结果
+	AuditDocTaskCreate       = "db_doc.create"            // 创建数据库文档任务
+	AuditDocTaskUpdate       = "db_doc.update"            // 更新数据库文档任务
+	AuditDocTaskDelete       = "db_doc.delete"            // 删除数据库文档任务
+	AuditDocTaskRun          = "db_doc.run"               // 运行数据库文档任务
+	AuditSchemaCompareCreate = "schema_compare.create"    // 创建库结构一致性检查
+	AuditSchemaCompareRun    = "schema_compare.run"       // 运行库结构一致性检查
+	AuditSchemaCompareDelete = "schema_compare.delete"    // 删除库结构一致性检查
+	AuditConfigSet           = "config.set"               // 修改配置
+	AuditAuditExport         = "audit.export"             // 导出审计日志
+	AuditTargetInstance      = "instance"
+	AuditTargetQueryTask     = "query_task"
+	AuditTargetQueryTaskSQL  = "query_task_sql"
+	AuditTargetDocTask       = "db_doc_task"
+	AuditTargetSchemaCompare = "schema_compare"
+	AuditTargetUser          = "user"
+	AuditTargetToken         = "api_token"
+	AuditTargetConfig        = "config"
+)
+
+// AuditEvent 审计事件，只追加不修改
+// 每条事件保存前一条事件的哈希，并对自身内容计算哈希，形成哈希链用于发现篡改。
+type AuditEvent struct {
+	ID        uint      `gorm:"primarykey;column:id" json:"id"`
+	CreatedAt time.Time `gorm:"index;column:created_at" json:"created_at"`
+
+	ActorID    uint            `gorm:"not null;default:0;index;column:actor_id;comment:操作人ID，0 表示系统" json:"actor_id"`
+	Actor      string          `gorm:"size:50;column:actor;comment:操作人用户名" json:"actor"`
+	IP         string          `gorm:"size:64;column:ip;comment:客户端IP" json:"ip"`
+	Action     string          `gorm:"size:50;not null;index;column:action;comment:动作" json:"action"`
+	TargetType string          `gorm:"size:30;column:target_type;comment:对象类型" json:"target_type"`
+	TargetIDs  string          `gorm:"size:1000;column:target_ids;comment:对象ID，逗号分隔" json:"target_ids"`
+	Detail     json.RawMessage `gorm:"type:text;column:detail;comment:详情(JSON)" json:"detail"`
+	PrevHash   string          `gorm:"size:64;column:prev_hash;comment:前一条事件的哈希" json:"prev_hash"`
+	Hash       string          `gorm:"size:64;not null;uniqueIndex;column:hash;comment:事件哈希" json:"hash"`
+}
+
+// TableName 指定表名
+func (AuditEvent) TableName() string {
+	return "audit_events"
+}
@@ -0,0 +1,27 @@
+package model
+
+// AuditListRequest 审计事件查询条件
+type AuditListRequest struct {
+	Pagination `query:""`
+	Actor      string `query:"actor" json:"actor"`             // 操作人用户名
+	Action     string `query:"action" json:"action"`           // 动作，支持前缀如 instance.
+	TargetType string `query:"target_type" json:"target_type"` // 对象类型
+	TargetID   uint   `query:"target_id" json:"target_id"`     // 对象ID
+	IP         string `query:"ip" json:"ip"`                   // 客户端IP
+	Start      string `query:"start" json:"start"`             // 开始时间，RFC3339 或 2006-01-02 15:04:05
+	End        string `query:"end" json:"end"`                 // 结束时间
+}
+
+// AuditListResponse 审计事件列表
+type AuditListResponse struct {
+	Total int64        `json:"total"`
+	Items []AuditEvent `json:"items"`
+}
+
+// AuditVerifyResponse 哈希链校验结果
+type AuditVerifyResponse struct {
+	Total    int64  `json:"total"`     // 已校验的事件数
+	Valid    bool   `json:"valid"`     // 链是否完整
+	BrokenID uint   `json:"broken_id"` // 第一条不一致事件的ID
+	Message  string `json:"message"`   // 说明
+}
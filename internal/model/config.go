@@ -20,23 +20,47 @@ func (Config) TableName() string {
 // 字段名与配置项一一对应
 
 type DefaultConfig struct {
-	MaxConn         int
-	Concurrency     int
-	QueryTimeoutSec int
+	MaxConn              int
+	Concurrency          int
+	QueryTimeoutSec      int
+	MaxRunningTasks      int // 同时运行的最大任务数
+	InstanceMaxConn      int // 单个实例在所有任务间共享的最大并发执行数
+	ApproveAllWrites     int // 是否所有包含 DML/DDL 的任务都需要审批：1-是，0-仅要求审批的实例
+	SizeHistoryRawDays   int // 大小快照保留全部采样点的天数，更早的每天只保留一个
+	SizeHistoryDays      int // 数据库大小快照的保留天数
+	TableSizeHistoryDays int // 表大小快照的保留天数
+	SyncConcurrency      int // 同步数据库信息时同时处理的实例数
+	SyncTimeoutSec       int // 单个实例同步数据库信息的超时时间(秒)
 }
 
 // DefaultConfigValues 默认配置实例
 var DefaultConfigValues = DefaultConfig{
-	MaxConn:         100,
-	Concurrency:     50,
-	QueryTimeoutSec: 300,
+	MaxConn:              100,
+	Concurrency:          50,
+	QueryTimeoutSec:      300,
+	MaxRunningTasks:      3,
+	InstanceMaxConn:      50,
+	ApproveAllWrites:     1,
+	SizeHistoryRawDays:   7,
+	SizeHistoryDays:      365,
+	TableSizeHistoryDays: 90,
+	SyncConcurrency:      5,
+	SyncTimeoutSec:       300,
 }
 
 // ToMap 转为 map[string]string
 func (c DefaultConfig) ToMap() map[string]string {
 	return map[string]string{
-		"max_conn":          fmt.Sprintf("%d", c.MaxConn),
-		"concurrency":       fmt.Sprintf("%d", c.Concurrency),
-		"query_timeout_sec": fmt.Sprintf("%d", c.QueryTimeoutSec),
+		"max_conn":                fmt.Sprintf("%d", c.MaxConn),
+		"concurrency":             fmt.Sprintf("%d", c.Concurrency),
+		"query_timeout_sec":       fmt.Sprintf("%d", c.QueryTimeoutSec),
+		"max_running_tasks":       fmt.Sprintf("%d", c.MaxRunningTasks),
+		"instance_max_conn":       fmt.Sprintf("%d", c.InstanceMaxConn),
+		"approve_all_writes":      fmt.Sprintf("%d", c.ApproveAllWrites),
+		"size_history_raw_days":   fmt.Sprintf("%d", c.SizeHistoryRawDays),
+		"size_history_days":       fmt.Sprintf("%d", c.SizeHistoryDays),
+		"table_size_history_days": fmt.Sprintf("%d", c.TableSizeHistoryDays),
+		"sync_concurrency":        fmt.Sprintf("%d", c.SyncConcurrency),
+		"sync_timeout_sec":        fmt.Sprintf("%d", c.SyncTimeoutSec),
 	}
 }
@@ -1,6 +1,9 @@
 package model
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -30,3 +33,55 @@ type Database struct {
 func (Database) TableName() string {
 	return "databases"
 }
+
+// DatabaseTable 数据库中的表及其字段名，同步数据库信息时整体刷新，用于按表结构选择目标数据库
+type DatabaseTable struct {
+	ID           uint       `gorm:"primarykey;column:id" json:"id"`
+	InstanceID   uint       `gorm:"not null;index:idx_database_tables_db;column:instance_id;comment:实例ID" json:"instance_id"`
+	DatabaseName string     `gorm:"size:100;not null;index:idx_database_tables_db;column:database_name;comment:数据库名称" json:"database_name"`
+	Name         string     `gorm:"size:100;not null;index;column:name;comment:表名" json:"name"`
+	Columns      ColumnList `gorm:"type:text;column:columns;comment:字段名列表" json:"columns"`
+}
+
+// TableName 指定表名
+func (DatabaseTable) TableName() string {
+	return "database_tables"
+}
+
+// ColumnList 字段名列表
+type ColumnList []string
+
+// Value 实现 driver.Valuer 接口
+func (l ColumnList) Value() (driver.Value, error) {
+	return json.Marshal(l)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (l *ColumnList) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		if str, isStr := value.(string); isStr {
+			bytes = []byte(str)
+		} else {
+			return nil
+		}
+	}
+	return json.Unmarshal(bytes, l)
+}
+
+// Has 判断是否包含指定字段，忽略大小写
+func (l ColumnList) Has(column string) bool {
+	for _, c := range l {
+		if strings.EqualFold(c, column) {
+			return true
+		}
+	}
+	return false
+}
+
+// ColumnRef 字段所在的库和表，由引擎驱动在同步时返回
+type ColumnRef struct {
+	DatabaseName string
+	TableName    string
+	ColumnName   string
+}
@@ -35,3 +35,27 @@ type DatabaseListResponse struct {
 	Total int64              `json:"total"` // 总数
 	Items []DatabaseResponse `json:"items"` // 列表项
 }
+
+// TableInfoListRequest 表元数据列表请求，默认按总大小倒序
+type TableInfoListRequest struct {
+	Pagination   `query:""`
+	Sorting      `query:""`
+	InstanceID   uint   `query:"instance_id" json:"instance_id"`     // 实例ID
+	DatabaseName string `query:"database_name" json:"database_name"` // 数据库名称（精确匹配）
+	Name         string `query:"name" json:"name"`                   // 表名（模糊查询）
+	Engine       string `query:"engine" json:"engine"`               // 存储引擎
+	MinSizeMB    int64  `query:"min_size_mb" json:"min_size_mb"`     // 最小总大小(MB)
+	MinRows      int64  `query:"min_rows" json:"min_rows"`           // 最小行数
+}
+
+// TableInfoResponse 表元数据响应
+type TableInfoResponse struct {
+	TableInfo
+	Instance InstanceBasicInfo `json:"instance"`
+}
+
+// TableInfoListResponse 表元数据列表响应
+type TableInfoListResponse struct {
+	Total int64               `json:"total"`
+	Items []TableInfoResponse `json:"items"`
+}
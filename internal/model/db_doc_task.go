@@ -25,6 +25,9 @@ type DbDocTask struct {
 	LastStatus   int        `gorm:"not null;default:0;column:last_status;comment:最后运行状态(0:未运行, 1:成功, 2:失败)" json:"last_status"`
 	LastError    string     `gorm:"type:text;column:last_error;comment:最后错误信息" json:"last_error"`
 
+	CreatedBy     uint   `gorm:"not null;default:0;column:created_by;comment:创建人ID, 0表示启用登录前创建" json:"created_by"`
+	CreatedByName string `gorm:"size:50;column:created_by_name;comment:创建人用户名" json:"created_by_name"`
+
 	// 关联
 	Instance Instance `gorm:"foreignKey:InstanceID" json:"instance,omitempty"`
 }
@@ -38,4 +38,7 @@ type DbDocTaskResponse struct {
 	LastStatus   int       `json:"last_status"`
 	LastError    string    `json:"last_error"`
 	Instance     *Instance `json:"instance,omitempty"`
+
+	CreatedBy     uint   `json:"created_by"`
+	CreatedByName string `json:"created_by_name"`
 }
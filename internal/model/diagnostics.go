@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+
+	"my-bulker/internal/pkg/limiter"
+)
+
+// PoolStat 实例连接池统计信息
+type PoolStat struct {
+	InstanceID     uint      `json:"instance_id"`
+	InstanceName   string    `json:"instance_name"`
+	Refs           int       `json:"refs"`             // 当前引用数（使用中的任务/操作）
+	MaxOpen        int       `json:"max_open"`         // 最大连接数
+	Open           int       `json:"open"`             // 已建立连接数
+	InUse          int       `json:"in_use"`           // 使用中连接数
+	Idle           int       `json:"idle"`             // 空闲连接数
+	WaitCount      int64     `json:"wait_count"`       // 累计等待连接次数
+	WaitDurationMs int64     `json:"wait_duration_ms"` // 累计等待连接时长（毫秒）
+	MaxIdleClosed  int64     `json:"max_idle_closed"`  // 因超出空闲数关闭的连接数
+	IdleTimeClosed int64     `json:"idle_time_closed"` // 因空闲超时关闭的连接数
+	LifetimeClosed int64     `json:"lifetime_closed"`  // 因超出生命周期关闭的连接数
+	Retired        bool      `json:"retired"`          // 实例配置已变更，等待引用释放后关闭
+	CreatedAt      time.Time `json:"created_at"`
+	LastUsedAt     time.Time `json:"last_used_at"`
+}
+
+// PoolDiagnosticsResponse 连接池诊断信息响应
+type PoolDiagnosticsResponse struct {
+	Pools         []PoolStat              `json:"pools"`          // 实例连接池
+	InstanceSlots []limiter.SlotStats     `json:"instance_slots"` // 实例级并发名额占用
+	Queue         *QueryTaskQueueResponse `json:"queue"`          // 任务执行队列
+}
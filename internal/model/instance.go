@@ -3,8 +3,12 @@ package model
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
+	"my-bulker/internal/pkg/secret"
+
 	"gorm.io/gorm"
 )
 
@@ -16,16 +20,226 @@ type Instance struct {
 	DeletedAt gorm.DeletedAt `gorm:"index;column:deleted_at" json:"-"`
 
 	Name     string         `gorm:"size:100;not null;column:name;comment:实例名称" json:"name"`
+	Engine   string         `gorm:"size:20;not null;default:mysql;column:engine;comment:数据库引擎: mysql/postgres/sqlite" json:"engine"`
 	Host     string         `gorm:"size:255;not null;column:host;comment:主机地址" json:"host"`
 	Port     int            `gorm:"not null;column:port;comment:端口" json:"port"`
 	Username string         `gorm:"size:100;not null;column:username;comment:用户名" json:"username"`
-	Password string         `gorm:"size:255;not null;column:password;comment:密码" json:"password"`
+	Password string         `gorm:"size:255;not null;column:password;comment:密码(主密钥加密存储)" json:"password"`
 	Version  string         `gorm:"size:50;column:version;comment:数据库版本" json:"version"`
 	Params   InstanceParams `gorm:"type:text;column:params;comment:额外参数" json:"params"`
 	Remark   string         `gorm:"size:500;column:remark;comment:备注" json:"remark"`
+	Tags     InstanceTags   `gorm:"type:text;column:tags;comment:标签(JSON), 如 env=prod, 用于按标签选择实例" json:"tags"`
+
+	ProtectionMode string `gorm:"size:20;not null;default:unrestricted;column:protection_mode;comment:保护模式: unrestricted/approval/readonly" json:"protection_mode"`
+
+	ConnectDatabase string `gorm:"size:100;column:connect_database;comment:连接的数据库(PostgreSQL使用, 为空时为postgres)" json:"connect_database"`
+	FileGlob        string `gorm:"size:1000;column:file_glob;comment:SQLite文件路径通配符(SQLite使用), 每个匹配的文件作为一个数据库" json:"file_glob"`
 
 	SyncInterval int        `gorm:"column:sync_interval;comment:同步间隔(分钟), 0表示禁用" json:"sync_interval"`
 	LastSyncAt   *time.Time `gorm:"column:last_sync_at;comment:上次同步时间" json:"last_sync_at"`
+
+	LastSyncStatus     int8   `gorm:"not null;default:0;column:last_sync_status;comment:上次同步结果(0:未同步, 1:成功, 2:失败)" json:"last_sync_status"`
+	LastSyncError      string `gorm:"type:text;column:last_sync_error;comment:上次同步失败原因" json:"last_sync_error"`
+	LastSyncDurationMs int64  `gorm:"not null;default:0;column:last_sync_duration_ms;comment:上次同步耗时(毫秒)" json:"last_sync_duration_ms"`
+
+	MaxConnections int `gorm:"not null;default:0;column:max_connections;comment:最大连接数, 0表示使用全局配置" json:"max_connections"`
+	MaxConcurrency int `gorm:"not null;default:0;column:max_concurrency;comment:最大并发语句数, 0表示使用全局配置" json:"max_concurrency"`
+	RateLimit      int `gorm:"not null;default:0;column:rate_limit;comment:每秒最多执行语句数, 0表示不限制" json:"rate_limit"`
+
+	ReplicaIDs       InstanceIDList `gorm:"type:text;column:replica_ids;comment:从库实例ID列表" json:"replica_ids"`
+	LagCheckQuery    string         `gorm:"size:1000;column:lag_check_query;comment:自定义复制延迟查询(在本实例执行，返回秒数)" json:"lag_check_query"`
+	MaxReplicaLagSec int            `gorm:"not null;default:0;column:max_replica_lag_sec;comment:允许的最大复制延迟(秒), 0表示不检查" json:"max_replica_lag_sec"`
+	LagCheckFailOpen bool           `gorm:"not null;default:false;column:lag_check_fail_open;comment:无法获取复制延迟时是否放行写入" json:"lag_check_fail_open"`
+
+	SSH SSHTunnel   `gorm:"embedded;embeddedPrefix:ssh_" json:"ssh"`
+	TLS InstanceTLS `gorm:"embedded;embeddedPrefix:tls_" json:"tls"`
+
+	CreatedBy     uint   `gorm:"not null;default:0;column:created_by;comment:创建人ID, 0表示启用登录前创建" json:"created_by"`
+	CreatedByName string `gorm:"size:50;column:created_by_name;comment:创建人用户名" json:"created_by_name"`
+}
+
+// 数据库引擎
+const (
+	EngineMySQL    = "mysql"
+	EnginePostgres = "postgres"
+	EngineSQLite   = "sqlite"
+)
+
+// ValidEngine 判断数据库引擎是否有效，空字符串视为 MySQL
+func ValidEngine(engine string) bool {
+	switch engine {
+	case "", EngineMySQL, EnginePostgres, EngineSQLite:
+		return true
+	}
+	return false
+}
+
+// 实例保护模式，限制写入语句（DML、DDL 等非只读语句）在实例上的执行
+const (
+	ProtectionUnrestricted = "unrestricted" // 不限制
+	ProtectionApproval     = "approval"     // 写入语句需审批后执行
+	ProtectionReadOnly     = "readonly"     // 只读，拒绝写入语句，连接会话设为只读
+)
+
+// ValidProtectionMode 判断保护模式是否有效，空字符串视为不限制
+func ValidProtectionMode(mode string) bool {
+	switch mode {
+	case "", ProtectionUnrestricted, ProtectionApproval, ProtectionReadOnly:
+		return true
+	}
+	return false
+}
+
+// Protection 获取实例的保护模式，历史数据未设置时为不限制
+func (i *Instance) Protection() string {
+	if i.ProtectionMode == "" {
+		return ProtectionUnrestricted
+	}
+	return i.ProtectionMode
+}
+
+// EngineName 获取实例的数据库引擎，历史数据未设置时为 MySQL
+func (i *Instance) EngineName() string {
+	if i.Engine == "" {
+		return EngineMySQL
+	}
+	return i.Engine
+}
+
+// SSHTunnel 跳板机（SSH 隧道）配置，启用后实例连接经由跳板机转发
+type SSHTunnel struct {
+	Enabled          bool   `gorm:"not null;default:false;column:enabled;comment:是否通过SSH隧道连接" json:"enabled"`
+	Host             string `gorm:"size:255;column:host;comment:跳板机地址" json:"host"`
+	Port             int    `gorm:"not null;default:0;column:port;comment:跳板机端口, 0表示22" json:"port"`
+	User             string `gorm:"size:100;column:user;comment:跳板机用户名" json:"user"`
+	Password         string `gorm:"size:255;column:password;comment:跳板机密码(主密钥加密存储)" json:"password"`
+	PrivateKey       string `gorm:"type:text;column:private_key;comment:跳板机私钥(主密钥加密存储)" json:"private_key"`
+	KeyPassphrase    string `gorm:"size:255;column:key_passphrase;comment:私钥口令(主密钥加密存储)" json:"key_passphrase"`
+	KnownHosts       string `gorm:"type:text;column:known_hosts;comment:跳板机主机公钥(known_hosts格式), 为空时使用~/.ssh/known_hosts" json:"known_hosts"`
+	SkipHostKeyCheck bool   `gorm:"not null;default:false;column:skip_host_key_check;comment:跳过主机公钥校验" json:"skip_host_key_check"`
+}
+
+// Redacted 返回去除密码、私钥等敏感信息的副本，用于接口响应
+func (t SSHTunnel) Redacted() SSHTunnel {
+	t.Password = ""
+	t.PrivateKey = ""
+	t.KeyPassphrase = ""
+	return t
+}
+
+// TLS 模式，与 MySQL 客户端的 --ssl-mode 含义一致
+const (
+	TLSModeDisabled       = "disabled"        // 不使用 TLS
+	TLSModePreferred      = "preferred"       // 服务端支持时使用 TLS，不校验证书
+	TLSModeRequired       = "required"        // 必须使用 TLS，不校验证书
+	TLSModeVerifyCA       = "verify-ca"       // 必须使用 TLS，并校验服务端证书由受信任的 CA 签发
+	TLSModeVerifyIdentity = "verify-identity" // 在 verify-ca 基础上校验证书与主机名匹配
+)
+
+// InstanceTLS TLS 连接配置
+type InstanceTLS struct {
+	Mode string `gorm:"size:20;column:mode;comment:TLS模式, 为空表示不设置(沿用额外参数)" json:"mode"`
+	CA   string `gorm:"type:text;column:ca;comment:CA证书(PEM)" json:"ca"`
+	Cert string `gorm:"type:text;column:cert;comment:客户端证书(PEM)" json:"cert"`
+	Key  string `gorm:"type:text;column:key;comment:客户端私钥(PEM, 主密钥加密存储)" json:"key"`
+}
+
+// Redacted 返回去除客户端私钥的副本，用于接口响应
+func (t InstanceTLS) Redacted() InstanceTLS {
+	t.Key = ""
+	return t
+}
+
+// ValidTLSMode 判断 TLS 模式是否有效，空字符串表示不设置
+func ValidTLSMode(mode string) bool {
+	switch mode {
+	case "", TLSModeDisabled, TLSModePreferred, TLSModeRequired, TLSModeVerifyCA, TLSModeVerifyIdentity:
+		return true
+	}
+	return false
+}
+
+// InstanceSecretColumns 实例表中加密存储的列
+var InstanceSecretColumns = []string{"password", "ssh_password", "ssh_private_key", "ssh_key_passphrase", "tls_key"}
+
+// SecretFields 返回需加密存储的字段，顺序与 InstanceSecretColumns 一致
+func (i *Instance) SecretFields() []*string {
+	return []*string{&i.Password, &i.SSH.Password, &i.SSH.PrivateKey, &i.SSH.KeyPassphrase, &i.TLS.Key}
+}
+
+// BeforeSave 保存前加密密码等敏感字段
+func (i *Instance) BeforeSave(tx *gorm.DB) error {
+	for _, field := range i.SecretFields() {
+		encrypted, err := secret.Encrypt(*field)
+		if err != nil {
+			return fmt.Errorf("加密实例密码失败: %w", err)
+		}
+		*field = encrypted
+	}
+	return nil
+}
+
+// AfterSave 保存后还原明文密码，保证调用方持有的对象可继续用于连接
+func (i *Instance) AfterSave(tx *gorm.DB) error {
+	return i.decryptPassword()
+}
+
+// AfterFind 查询后解密密码等敏感字段
+func (i *Instance) AfterFind(tx *gorm.DB) error {
+	return i.decryptPassword()
+}
+
+func (i *Instance) decryptPassword() error {
+	for _, field := range i.SecretFields() {
+		plaintext, err := secret.Decrypt(*field)
+		if err != nil {
+			return fmt.Errorf("解密实例 [%s] 密码失败: %w", i.Name, err)
+		}
+		*field = plaintext
+	}
+	return nil
+}
+
+// EffectiveMaxConnections 获取实际生效的最大连接数，未单独设置时使用全局配置
+func (i *Instance) EffectiveMaxConnections(globalValue int) int {
+	if i.MaxConnections > 0 {
+		return i.MaxConnections
+	}
+	return globalValue
+}
+
+// EffectiveMaxConcurrency 获取实际生效的最大并发语句数，未单独设置时使用全局配置
+func (i *Instance) EffectiveMaxConcurrency(globalValue int) int {
+	if i.MaxConcurrency > 0 {
+		return i.MaxConcurrency
+	}
+	return globalValue
+}
+
+// LagCheckEnabled 是否启用复制延迟检查
+func (i *Instance) LagCheckEnabled() bool {
+	return i.MaxReplicaLagSec > 0 && (len(i.ReplicaIDs) > 0 || i.LagCheckQuery != "")
+}
+
+// InstanceIDList 实例ID列表
+type InstanceIDList []uint
+
+// Value 实现 driver.Valuer 接口
+func (l InstanceIDList) Value() (driver.Value, error) {
+	return json.Marshal(l)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (l *InstanceIDList) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		if str, isStr := value.(string); isStr {
+			bytes = []byte(str)
+		} else {
+			return nil
+		}
+	}
+	return json.Unmarshal(bytes, l)
 }
 
 // InstanceParams 实例额外参数
@@ -45,9 +259,60 @@ func (p *InstanceParams) Scan(value interface{}) error {
 	return json.Unmarshal(bytes, p)
 }
 
+// InstanceTags 实例标签，键值对形式，如 env=prod、region=eu
+type InstanceTags map[string]string
+
+// Value 实现 driver.Valuer 接口
+func (t InstanceTags) Value() (driver.Value, error) {
+	return json.Marshal(t)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (t *InstanceTags) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		if str, isStr := value.(string); isStr {
+			bytes = []byte(str)
+		} else {
+			return nil
+		}
+	}
+	return json.Unmarshal(bytes, t)
+}
+
 // ExportInstancesRequest 导出实例请求
 type ExportInstancesRequest struct {
 	InstanceIDs []uint `json:"instance_ids"`
+	Passphrase  string `json:"passphrase"` // 导出口令，为空时导出文件不包含密码
+}
+
+// SyncResult 单个实例的同步结果
+type SyncResult struct {
+	InstanceID   uint   `json:"instance_id"`
+	InstanceName string `json:"instance_name"`
+	Success      bool   `json:"success"`
+	DurationMs   int64  `json:"duration_ms"`
+	Error        string `json:"error,omitempty"`
+}
+
+// SyncSummary 同步结果摘要，单个实例失败不影响其他实例
+type SyncSummary struct {
+	Total      int          `json:"total"`
+	Succeeded  int          `json:"succeeded"`
+	Failed     int          `json:"failed"`
+	DurationMs int64        `json:"duration_ms"`
+	Results    []SyncResult `json:"results"`
+}
+
+// Err 汇总失败实例的原因，全部成功时返回 nil
+func (s *SyncSummary) Err() error {
+	var errs []error
+	for _, r := range s.Results {
+		if !r.Success {
+			errs = append(errs, fmt.Errorf("%s: %s", r.InstanceName, r.Error))
+		}
+	}
+	return errors.Join(errs...)
 }
 
 // ImportSummary 导入结果摘要
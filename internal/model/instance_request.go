@@ -2,42 +2,112 @@ package model
 
 // CreateInstanceRequest 创建实例请求
 type CreateInstanceRequest struct {
-	Name         string         `json:"name" validate:"required"`     // 实例名称
-	Host         string         `json:"host" validate:"required"`     // 主机地址
-	Port         int            `json:"port" validate:"required"`     // 端口
-	Username     string         `json:"username" validate:"required"` // 用户名
-	Password     string         `json:"password" validate:"required"` // 密码
-	Params       InstanceParams `json:"params"`                       // 额外参数
-	Remark       string         `json:"remark"`                       // 备注
-	SyncInterval int            `json:"sync_interval"`                // 同步间隔(分钟)
+	Name           string         `json:"name" validate:"required"`     // 实例名称
+	Engine         string         `json:"engine"`                       // 数据库引擎: mysql/postgres/sqlite，为空时为 mysql
+	Host           string         `json:"host" validate:"required"`     // 主机地址
+	Port           int            `json:"port" validate:"required"`     // 端口
+	Username       string         `json:"username" validate:"required"` // 用户名
+	Password       string         `json:"password" validate:"required"` // 密码
+	Params         InstanceParams `json:"params"`                       // 额外参数
+	Remark         string         `json:"remark"`                       // 备注
+	Tags           InstanceTags   `json:"tags"`                         // 标签
+	ProtectionMode string         `json:"protection_mode"`              // 保护模式: unrestricted/approval/readonly，为空时为 unrestricted
+	SyncInterval   int            `json:"sync_interval"`                // 同步间隔(分钟)
+
+	ConnectDatabase string `json:"connect_database"` // 连接的数据库(PostgreSQL使用)
+	FileGlob        string `json:"file_glob"`        // SQLite文件路径通配符(SQLite使用)
+
+	MaxConnections int `json:"max_connections"` // 最大连接数，0表示使用全局配置
+	MaxConcurrency int `json:"max_concurrency"` // 最大并发语句数，0表示使用全局配置
+	RateLimit      int `json:"rate_limit"`      // 每秒最多执行语句数，0表示不限制
+
+	ReplicaIDs       InstanceIDList `json:"replica_ids"`         // 从库实例ID列表
+	LagCheckQuery    string         `json:"lag_check_query"`     // 自定义复制延迟查询
+	MaxReplicaLagSec int            `json:"max_replica_lag_sec"` // 允许的最大复制延迟(秒)，0表示不检查
+	LagCheckFailOpen bool           `json:"lag_check_fail_open"` // 无法获取复制延迟时是否放行写入，默认暂停
+
+	SSH SSHTunnel   `json:"ssh"` // SSH 隧道配置
+	TLS InstanceTLS `json:"tls"` // TLS 配置
 }
 
 // UpdateInstanceRequest 更新实例请求
 type UpdateInstanceRequest struct {
-	Name         string         `json:"name" validate:"required"`     // 实例名称
-	Host         string         `json:"host" validate:"required"`     // 主机地址
-	Port         int            `json:"port" validate:"required"`     // 端口
-	Username     string         `json:"username" validate:"required"` // 用户名
-	Password     string         `json:"password"`                     // 密码（可选）
-	Params       InstanceParams `json:"params"`                       // 额外参数
-	Remark       string         `json:"remark"`                       // 备注
-	SyncInterval int            `json:"sync_interval"`                // 同步间隔(分钟)
+	Name           string         `json:"name" validate:"required"`     // 实例名称
+	Engine         string         `json:"engine"`                       // 数据库引擎: mysql/postgres/sqlite，为空时为 mysql
+	Host           string         `json:"host" validate:"required"`     // 主机地址
+	Port           int            `json:"port" validate:"required"`     // 端口
+	Username       string         `json:"username" validate:"required"` // 用户名
+	Password       string         `json:"password"`                     // 密码（可选）
+	Params         InstanceParams `json:"params"`                       // 额外参数
+	Remark         string         `json:"remark"`                       // 备注
+	Tags           InstanceTags   `json:"tags"`                         // 标签
+	ProtectionMode string         `json:"protection_mode"`              // 保护模式: unrestricted/approval/readonly，为空时为 unrestricted
+	SyncInterval   int            `json:"sync_interval"`                // 同步间隔(分钟)
+
+	ConnectDatabase string `json:"connect_database"` // 连接的数据库(PostgreSQL使用)
+	FileGlob        string `json:"file_glob"`        // SQLite文件路径通配符(SQLite使用)
+
+	MaxConnections int `json:"max_connections"` // 最大连接数，0表示使用全局配置
+	MaxConcurrency int `json:"max_concurrency"` // 最大并发语句数，0表示使用全局配置
+	RateLimit      int `json:"rate_limit"`      // 每秒最多执行语句数，0表示不限制
+
+	ReplicaIDs       InstanceIDList `json:"replica_ids"`         // 从库实例ID列表
+	LagCheckQuery    string         `json:"lag_check_query"`     // 自定义复制延迟查询
+	MaxReplicaLagSec int            `json:"max_replica_lag_sec"` // 允许的最大复制延迟(秒)，0表示不检查
+	LagCheckFailOpen bool           `json:"lag_check_fail_open"` // 无法获取复制延迟时是否放行写入，默认暂停
+
+	SSH SSHTunnel   `json:"ssh"` // SSH 隧道配置，密码、私钥为空时沿用原值
+	TLS InstanceTLS `json:"tls"` // TLS 配置，客户端私钥为空时沿用原值
+
+	ClearSecrets []string `json:"clear_secrets"` // 需清除的已保存敏感字段，可选 password、ssh_password、ssh_private_key、ssh_key_passphrase、tls_key
 }
 
 // InstanceResponse 实例响应
 type InstanceResponse struct {
-	ID           uint           `json:"id"`            // 实例ID
-	CreatedAt    string         `json:"created_at"`    // 创建时间
-	UpdatedAt    string         `json:"updated_at"`    // 更新时间
-	Name         string         `json:"name"`          // 实例名称
-	Host         string         `json:"host"`          // 主机地址
-	Port         int            `json:"port"`          // 端口
-	Username     string         `json:"username"`      // 用户名
-	Version      string         `json:"version"`       // 数据库版本
-	Params       InstanceParams `json:"params"`        // 额外参数
-	Remark       string         `json:"remark"`        // 备注
-	SyncInterval int            `json:"sync_interval"` // 同步间隔(分钟)
-	LastSyncAt   *string        `json:"last_sync_at"`  // 上次同步时间
+	ID             uint           `json:"id"`              // 实例ID
+	CreatedAt      string         `json:"created_at"`      // 创建时间
+	UpdatedAt      string         `json:"updated_at"`      // 更新时间
+	Name           string         `json:"name"`            // 实例名称
+	Engine         string         `json:"engine"`          // 数据库引擎
+	Host           string         `json:"host"`            // 主机地址
+	Port           int            `json:"port"`            // 端口
+	Username       string         `json:"username"`        // 用户名
+	Version        string         `json:"version"`         // 数据库版本
+	Params         InstanceParams `json:"params"`          // 额外参数
+	Remark         string         `json:"remark"`          // 备注
+	Tags           InstanceTags   `json:"tags"`            // 标签
+	ProtectionMode string         `json:"protection_mode"` // 保护模式
+	SyncInterval   int            `json:"sync_interval"`   // 同步间隔(分钟)
+	LastSyncAt     *string        `json:"last_sync_at"`    // 上次同步时间
+
+	LastSyncStatus     int8   `json:"last_sync_status"`      // 上次同步结果(0:未同步, 1:成功, 2:失败)
+	LastSyncError      string `json:"last_sync_error"`       // 上次同步失败原因
+	LastSyncDurationMs int64  `json:"last_sync_duration_ms"` // 上次同步耗时(毫秒)
+
+	ConnectDatabase string `json:"connect_database"` // 连接的数据库(PostgreSQL使用)
+	FileGlob        string `json:"file_glob"`        // SQLite文件路径通配符(SQLite使用)
+
+	MaxConnections int `json:"max_connections"` // 最大连接数，0表示使用全局配置
+	MaxConcurrency int `json:"max_concurrency"` // 最大并发语句数，0表示使用全局配置
+	RateLimit      int `json:"rate_limit"`      // 每秒最多执行语句数，0表示不限制
+
+	ReplicaIDs       InstanceIDList `json:"replica_ids"`         // 从库实例ID列表
+	LagCheckQuery    string         `json:"lag_check_query"`     // 自定义复制延迟查询
+	MaxReplicaLagSec int            `json:"max_replica_lag_sec"` // 允许的最大复制延迟(秒)，0表示不检查
+	LagCheckFailOpen bool           `json:"lag_check_fail_open"` // 无法获取复制延迟时是否放行写入，默认暂停
+
+	SSH SSHTunnel   `json:"ssh"` // SSH 隧道配置（不含密码、私钥）
+	TLS InstanceTLS `json:"tls"` // TLS 配置（不含客户端私钥）
+
+	CreatedBy     uint   `json:"created_by"`      // 创建人ID
+	CreatedByName string `json:"created_by_name"` // 创建人用户名
+}
+
+// TestConnectionResult 测试连接结果
+type TestConnectionResult struct {
+	Version    string `json:"version"`     // 数据库版本
+	TLSVersion string `json:"tls_version"` // 协商的 TLS 版本，未使用 TLS 时为空
+	TLSCipher  string `json:"tls_cipher"`  // 协商的加密套件，未使用 TLS 时为空
 }
 
 // InstancePasswordResponse 实例密码响应
@@ -52,6 +122,7 @@ type InstanceListRequest struct {
 	Host       string     `query:"host" json:"host"`         // 主机地址（模糊查询）
 	Username   string     `query:"username" json:"username"` // 用户名（模糊查询）
 	Remark     string     `query:"remark" json:"remark"`     // 备注（模糊查询）
+	Tags       string     `query:"tags" json:"tags"`         // 标签表达式，如 env=prod AND region!=cn
 }
 
 // InstanceListResponse 实例列表响应
@@ -65,6 +136,19 @@ type SyncDatabasesRequest struct {
 	InstanceIDs []uint `json:"instance_ids" validate:"required,min=1"` // 实例ID列表
 }
 
+// BatchTagInstancesRequest 批量修改实例标签请求
+type BatchTagInstancesRequest struct {
+	InstanceIDs []uint       `json:"instance_ids" validate:"required,min=1"` // 实例ID列表
+	Set         InstanceTags `json:"set"`                                    // 新增或覆盖的标签
+	Remove      []string     `json:"remove"`                                 // 删除的标签键
+}
+
+// InstanceTagOption 已使用的标签键及其取值
+type InstanceTagOption struct {
+	Key    string   `json:"key"`
+	Values []string `json:"values"`
+}
+
 // BatchDeleteInstancesRequest 批量删除实例请求
 type BatchDeleteInstancesRequest struct {
 	InstanceIDs []uint `json:"instance_ids" validate:"required,min=1"` // 实例ID列表
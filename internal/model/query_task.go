@@ -8,6 +8,17 @@ import (
 	"gorm.io/gorm"
 )
 
+// 查询任务状态
+const (
+	QueryTaskStatusPending         int8 = 0 // 待执行
+	QueryTaskStatusRunning         int8 = 1 // 执行中
+	QueryTaskStatusCompleted       int8 = 2 // 已完成
+	QueryTaskStatusFailed          int8 = 3 // 失败
+	QueryTaskStatusQueued          int8 = 4 // 排队中
+	QueryTaskStatusPendingApproval int8 = 5 // 待审批
+	QueryTaskStatusRejected        int8 = 6 // 已驳回
+)
+
 // QueryTask 查询任务
 type QueryTask struct {
 	ID        uint           `gorm:"primarykey;column:id" json:"id"`
@@ -17,7 +28,7 @@ type QueryTask struct {
 
 	TaskName      string     `gorm:"size:100;not null;column:task_name;comment:任务名称" json:"task_name"`
 	Databases     string     `gorm:"type:text;not null;column:databases;comment:目标数据库列表(JSON格式，包含instance_id和database_name)" json:"databases"`
-	Status        int8       `gorm:"not null;default:0;column:status;comment:任务状态：0-待执行，1-执行中，2-已完成，3-失败" json:"status"`
+	Status        int8       `gorm:"not null;default:0;column:status;comment:任务状态：0-待执行，1-执行中，2-已完成，3-失败，4-排队中，5-待审批，6-已驳回" json:"status"`
 	TotalDBs      int        `gorm:"not null;default:0;column:total_dbs;comment:数据库总数" json:"total_dbs"`
 	CompletedDBs  int        `gorm:"not null;default:0;column:completed_dbs;comment:已完成数据库数" json:"completed_dbs"`
 	FailedDBs     int        `gorm:"not null;default:0;column:failed_dbs;comment:失败数据库数" json:"failed_dbs"`
@@ -28,6 +39,12 @@ type QueryTask struct {
 	CompletedAt   *time.Time `gorm:"column:completed_at;comment:完成时间" json:"completed_at"`
 	Description   string     `gorm:"type:text;column:description;comment:任务描述" json:"description"`
 	IsFavorite    bool       `gorm:"default:false;column:is_favorite;comment:是否为常用任务" json:"is_favorite"`
+	ChunkSize     int        `gorm:"not null;default:0;column:chunk_size;comment:UPDATE/DELETE 分批行数, 0表示不分批" json:"chunk_size"`
+	ChunkSleepMs  int        `gorm:"not null;default:0;column:chunk_sleep_ms;comment:分批间隔(毫秒)" json:"chunk_sleep_ms"`
+	TargetRule    TargetRule `gorm:"type:text;column:target_rule;comment:目标选择规则(JSON), 动态模式在每次运行前据此重新解析目标" json:"target_rule"`
+	CreatedBy     uint       `gorm:"not null;default:0;column:created_by;comment:创建人ID, 0表示启用登录前创建" json:"created_by"`
+	CreatedByName string     `gorm:"size:50;column:created_by_name;comment:创建人用户名" json:"created_by_name"`
+	RunBy         uint       `gorm:"not null;default:0;column:run_by;comment:最近一次发起运行的用户ID, 排队的任务以该用户的权限执行" json:"run_by"`
 
 	// 关联
 	SQLs []QueryTaskSQL `gorm:"foreignKey:TaskID" json:"sqls,omitempty"`
@@ -38,6 +55,68 @@ func (QueryTask) TableName() string {
 	return "query_task_tasks"
 }
 
+// 目标数据库选择模式
+const (
+	TargetModeInclude = "include" // 仅选中的数据库
+	TargetModeExclude = "exclude" // 所选实例的全部数据库，排除选中的数据库
+	TargetModeTags    = "tags"    // 标签表达式匹配的实例的全部数据库
+	TargetModePattern = "pattern" // 所选实例中名称和属性满足筛选条件的数据库
+	TargetModeSchema  = "schema"  // 所选实例中包含指定表、字段或探测语句返回真值的数据库
+)
+
+// TargetRule 任务目标选择规则
+type TargetRule struct {
+	Mode        string             `json:"mode"`                   // 选择模式
+	TagExpr     string             `json:"tag_expr,omitempty"`     // 实例标签表达式(tags 模式；pattern、schema 模式未指定实例时使用)
+	InstanceIDs []uint             `json:"instance_ids,omitempty"` // 实例ID列表(include、exclude、pattern、schema 模式)
+	SelectedDBs TaskDatabases      `json:"selected_dbs,omitempty"` // 选中的数据库(include、exclude 模式)
+	Filter      *DatabaseFilter    `json:"filter,omitempty"`       // 数据库筛选条件(pattern 模式)
+	Require     *SchemaRequirement `json:"require,omitempty"`      // 表结构条件(schema 模式)
+}
+
+// Dynamic 是否为运行时重新解析目标的动态模式
+func (r TargetRule) Dynamic() bool {
+	return r.Mode == TargetModeTags || r.Mode == TargetModePattern || r.Mode == TargetModeSchema
+}
+
+// DatabaseFilter 按名称模式及属性筛选数据库
+// 名称模式支持通配符(% 或 * 匹配任意字符，? 匹配单个字符)和以斜杠包裹的正则，如 /^tenant_\d+$/。
+type DatabaseFilter struct {
+	IncludePatterns []string `json:"include_patterns,omitempty"` // 包含的名称模式，为空表示全部
+	ExcludePatterns []string `json:"exclude_patterns,omitempty"` // 排除的名称模式
+	MinSizeMB       *int64   `json:"min_size_mb,omitempty"`      // 最小大小(MB)
+	MaxSizeMB       *int64   `json:"max_size_mb,omitempty"`      // 最大大小(MB)
+	MinTables       *int     `json:"min_tables,omitempty"`       // 最少表数量
+	MaxTables       *int     `json:"max_tables,omitempty"`       // 最多表数量
+	Charset         string   `json:"charset,omitempty"`          // 字符集，不区分大小写
+}
+
+// SchemaRequirement 按表结构选择数据库的条件，多个条件需同时满足
+// 表和字段按最近一次同步的结果判断，探测语句在满足其余条件的库上逐个执行。
+type SchemaRequirement struct {
+	Tables   []string `json:"tables,omitempty"`    // 必须存在的表
+	Columns  []string `json:"columns,omitempty"`   // 必须存在的字段，格式为 表名.字段名
+	ProbeSQL string   `json:"probe_sql,omitempty"` // 只读探测语句，首行首列为真值时选中该库
+}
+
+// Value 实现 driver.Valuer 接口
+func (r TargetRule) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (r *TargetRule) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		if str, isStr := value.(string); isStr {
+			bytes = []byte(str)
+		} else {
+			return nil
+		}
+	}
+	return json.Unmarshal(bytes, r)
+}
+
 // TaskDatabase 任务目标数据库结构
 type TaskDatabase struct {
 	InstanceID   uint   `json:"instance_id"`   // 实例ID
@@ -53,6 +132,19 @@ func (d TaskDatabases) Value() (driver.Value, error) {
 	return json.Marshal(d)
 }
 
+// InstanceIDs 获取目标涉及的实例ID，按首次出现的顺序去重
+func (d TaskDatabases) InstanceIDs() []uint {
+	ids := make([]uint, 0)
+	seen := make(map[uint]bool)
+	for _, db := range d {
+		if !seen[db.InstanceID] {
+			seen[db.InstanceID] = true
+			ids = append(ids, db.InstanceID)
+		}
+	}
+	return ids
+}
+
 // Scan 实现 sql.Scanner 接口
 func (d *TaskDatabases) Scan(value interface{}) error {
 	bytes, ok := value.([]byte)
@@ -21,6 +21,10 @@ type QueryTaskExecution struct {
 	ErrorMessage  string     `gorm:"type:text;column:error_message;comment:错误信息" json:"error_message"`
 	ResultCount   *int       `gorm:"column:result_count;comment:结果集行数" json:"result_count"`
 	ExecutionTime *int       `gorm:"column:execution_time;comment:执行时间(毫秒)" json:"execution_time"`
+	PausedTime    int64      `gorm:"not null;default:0;column:paused_time;comment:因复制延迟暂停时长(毫秒)" json:"paused_time"`
+	PauseReason   string     `gorm:"size:500;column:pause_reason;comment:最近一次暂停原因" json:"pause_reason"`
+	AffectedRows  *int64     `gorm:"column:affected_rows;comment:分批执行累计影响行数" json:"affected_rows"`
+	ChunkCount    int        `gorm:"not null;default:0;column:chunk_count;comment:分批执行批次数" json:"chunk_count"`
 	StartedAt     *time.Time `gorm:"column:started_at;comment:开始执行时间" json:"started_at"`
 	CompletedAt   *time.Time `gorm:"column:completed_at;comment:完成时间" json:"completed_at"`
 
@@ -11,14 +11,42 @@ type QueryTaskListRequest struct {
 	IsFavorite *bool      `query:"is_favorite" json:"is_favorite"`
 }
 
+// TargetSelection 目标数据库选择参数
+type TargetSelection struct {
+	InstanceIDs  []uint            `json:"instance_ids"`                                                                // 实例ID列表(include/exclude/pattern/schema 模式)
+	DatabaseMode string            `json:"database_mode" validate:"required,oneof=include exclude tags pattern schema"` // 数据库选择模式：include-包含，exclude-排除，tags-按实例标签，pattern-按名称模式，schema-按表结构
+	SelectedDBs  TaskDatabases     `json:"selected_dbs"`                                                                // 选中的数据库列表(include/exclude 模式)
+	TagExpr      string            `json:"tag_expr"`                                                                    // 实例标签表达式(tags 模式；pattern、schema 模式未选择实例时使用)，如 env=prod AND region!=cn
+	Filter       DatabaseFilter    `json:"filter"`                                                                      // 数据库筛选条件(pattern 模式)
+	Require      SchemaRequirement `json:"require"`                                                                     // 表结构条件(schema 模式)
+}
+
 // CreateQueryTaskRequest 创建查询任务请求
 type CreateQueryTaskRequest struct {
-	TaskName     string        `json:"task_name" validate:"required"`                           // 任务名称
-	Description  string        `json:"description"`                                             // 任务描述
-	InstanceIDs  []uint        `json:"instance_ids" validate:"required,min=1"`                  // 实例ID列表
-	DatabaseMode string        `json:"database_mode" validate:"required,oneof=include exclude"` // 数据库选择模式：include-包含，exclude-排除
-	SelectedDBs  TaskDatabases `json:"selected_dbs" validate:"required"`                        // 选中的数据库列表
-	SQLContent   string        `json:"sql_content" validate:"required"`                         // SQL语句内容（字符串，系统自动拆分）
+	TaskName    string `json:"task_name" validate:"required"` // 任务名称
+	Description string `json:"description"`                   // 任务描述
+	TargetSelection
+	SQLContent   string `json:"sql_content" validate:"required"` // SQL语句内容（字符串，系统自动拆分）
+	ChunkSize    int    `json:"chunk_size"`                      // UPDATE/DELETE 分批行数，0表示不分批
+	ChunkSleepMs int    `json:"chunk_sleep_ms"`                  // 分批间隔(毫秒)
+}
+
+// ReviewQueryTaskRequest 审批查询任务请求，审批人为当前登录用户
+type ReviewQueryTaskRequest struct {
+	Comment string `json:"comment"` // 审批意见，驳回时必填
+}
+
+// PreviewTargetsResponse 目标数据库预览
+type PreviewTargetsResponse struct {
+	Total int           `json:"total"` // 数据库总数
+	Items TaskDatabases `json:"items"` // 目标数据库列表
+}
+
+// RefreshTargetsResult 重新解析目标的结果
+type RefreshTargetsResult struct {
+	Total   int `json:"total"`   // 目标数据库总数
+	Added   int `json:"added"`   // 新增的数据库数
+	Retired int `json:"retired"` // 移除的数据库数
 }
 
 // QueryTaskResponse 查询任务响应
@@ -39,6 +67,12 @@ type QueryTaskResponse struct {
 	CompletedAt   *time.Time `json:"completed_at"`
 	Description   string     `json:"description"`
 	IsFavorite    bool       `json:"is_favorite"`
+	QueuePosition int        `json:"queue_position"`  // 排队位置，从1开始，0表示未排队
+	ChunkSize     int        `json:"chunk_size"`      // UPDATE/DELETE 分批行数，0表示不分批
+	ChunkSleepMs  int        `json:"chunk_sleep_ms"`  // 分批间隔(毫秒)
+	TargetRule    TargetRule `json:"target_rule"`     // 目标选择规则
+	CreatedBy     uint       `json:"created_by"`      // 创建人ID
+	CreatedByName string     `json:"created_by_name"` // 创建人用户名
 }
 
 // QueryTaskListResponse 查询任务列表响应
@@ -69,3 +103,19 @@ type QueryTaskSQLListResponse struct {
 	Total int64                  `json:"total"` // 总数
 	Items []QueryTaskSQLResponse `json:"items"` // 列表项
 }
+
+// QueryTaskQueueItem 执行队列中的任务
+type QueryTaskQueueItem struct {
+	TaskID     uint       `json:"task_id"`
+	TaskName   string     `json:"task_name"`
+	Position   int        `json:"position"`    // 排队位置，运行中的任务为0
+	EnqueuedAt time.Time  `json:"enqueued_at"` // 进入队列时间
+	StartedAt  *time.Time `json:"started_at"`  // 开始运行时间
+}
+
+// QueryTaskQueueResponse 执行队列状态
+type QueryTaskQueueResponse struct {
+	MaxRunning int                  `json:"max_running"` // 最大同时运行任务数
+	Running    []QueryTaskQueueItem `json:"running"`     // 运行中的任务
+	Queued     []QueryTaskQueueItem `json:"queued"`      // 排队中的任务
+}
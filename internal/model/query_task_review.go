@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// 审批结论
+const (
+	ReviewDecisionApproved int8 = 1 // 通过
+	ReviewDecisionRejected int8 = 2 // 驳回
+)
+
+// QueryTaskReview 查询任务审批记录
+// 审批通过时记录任务 SQL 和目标库列表的摘要，运行前据此判断审批后任务是否被修改。
+type QueryTaskReview struct {
+	ID        uint      `gorm:"primarykey;column:id" json:"id"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+
+	TaskID      uint   `gorm:"not null;index;column:task_id;comment:任务ID" json:"task_id"`
+	Decision    int8   `gorm:"not null;column:decision;comment:审批结论：1-通过，2-驳回" json:"decision"`
+	ReviewerID  uint   `gorm:"not null;default:0;column:reviewer_id;comment:审批人ID" json:"reviewer_id"`
+	Reviewer    string `gorm:"size:100;not null;column:reviewer;comment:审批人" json:"reviewer"`
+	Comment     string `gorm:"type:text;column:comment;comment:审批意见" json:"comment"`
+	SQLHash     string `gorm:"size:64;not null;column:sql_hash;comment:审批时SQL语句的SHA-256摘要" json:"sql_hash"`
+	TargetHash  string `gorm:"size:64;not null;column:target_hash;comment:审批时目标数据库列表的SHA-256摘要" json:"target_hash"`
+	TargetCount int    `gorm:"not null;default:0;column:target_count;comment:审批时目标数据库数" json:"target_count"`
+}
+
+// TableName 指定表名
+func (QueryTaskReview) TableName() string {
+	return "query_task_reviews"
+}
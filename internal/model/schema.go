@@ -0,0 +1,57 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+)
+
+// SchemaTable 表结构
+type SchemaTable struct {
+	Name    string         `json:"name"`    // 表名
+	Comment string         `json:"comment"` // 表注释
+	Columns []SchemaColumn `json:"columns"` // 字段，按定义顺序排列
+	Indexes []SchemaIndex  `json:"indexes"` // 索引，按名称排列
+}
+
+// SchemaColumn 字段结构
+type SchemaColumn struct {
+	Name     string  `json:"name"`     // 字段名
+	Type     string  `json:"type"`     // 完整类型，如 varchar(64)、int unsigned
+	Nullable bool    `json:"nullable"` // 是否允许为空
+	Default  *string `json:"default"`  // 默认值，nil 表示无默认值
+	Comment  string  `json:"comment"`  // 字段注释
+
+	// 以下仅 MySQL 采集，其他引擎为空
+	Collation string `json:"collation,omitempty"` // 排序规则，与表的默认排序规则相同时为空
+	Extra     string `json:"extra,omitempty"`     // 附加属性，如 auto_increment、on update CURRENT_TIMESTAMP、STORED GENERATED
+	Generated string `json:"generated,omitempty"` // 生成列的表达式
+}
+
+// SchemaIndex 索引结构
+type SchemaIndex struct {
+	Name    string   `json:"name"`    // 索引名
+	Columns []string `json:"columns"` // 索引字段，按索引内顺序排列
+	Unique  bool     `json:"unique"`  // 是否唯一索引
+	Primary bool     `json:"primary"` // 是否主键
+}
+
+// SchemaTables 库结构，以 JSON 保存
+type SchemaTables []SchemaTable
+
+// Value 实现 driver.Valuer 接口
+func (t SchemaTables) Value() (driver.Value, error) {
+	return json.Marshal(t)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (t *SchemaTables) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		if str, isStr := value.(string); isStr {
+			bytes = []byte(str)
+		} else {
+			return nil
+		}
+	}
+	return json.Unmarshal(bytes, t)
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// SchemaCompareJob 库结构一致性检查任务：以参照库为准，逐个比较选中的库的表、字段、类型、默认值和索引
+type SchemaCompareJob struct {
+	ID        uint      `gorm:"primarykey;column:id" json:"id"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+
+	Name                 string     `gorm:"size:100;not null;column:name;comment:任务名称" json:"name"`
+	ReferenceInstanceID  uint       `gorm:"not null;index;column:reference_instance_id;comment:参照库所在实例ID" json:"reference_instance_id"`
+	ReferenceDatabase    string     `gorm:"size:100;not null;column:reference_database;comment:参照库名称" json:"reference_database"`
+	ReferenceFingerprint string     `gorm:"size:64;column:reference_fingerprint;comment:参照库结构指纹" json:"reference_fingerprint"`
+	TargetRule           TargetRule `gorm:"type:text;column:target_rule;comment:比较范围选择规则(JSON)，每次运行前重新解析" json:"target_rule"`
+	GenerateDDL          bool       `gorm:"not null;default:false;column:generate_ddl;comment:是否生成使目标库与参照库一致的变更语句" json:"generate_ddl"`
+	Status               int8       `gorm:"not null;default:0;column:status;comment:状态(0:待运行, 1:运行中, 2:已完成, 3:失败)" json:"status"`
+	Total                int        `gorm:"not null;default:0;column:total;comment:比较的库数量" json:"total"`
+	Consistent           int        `gorm:"not null;default:0;column:consistent;comment:与参照库一致的库数量" json:"consistent"`
+	Deviated             int        `gorm:"not null;default:0;column:deviated;comment:存在差异的库数量" json:"deviated"`
+	Failed               int        `gorm:"not null;default:0;column:failed;comment:获取结构失败的库数量" json:"failed"`
+	Error                string     `gorm:"type:text;column:error;comment:任务失败原因" json:"error"`
+	StartedAt            *time.Time `gorm:"column:started_at;comment:最近一次开始运行时间" json:"started_at"`
+	FinishedAt           *time.Time `gorm:"column:finished_at;comment:最近一次运行结束时间" json:"finished_at"`
+
+	CreatedBy     uint   `gorm:"not null;default:0;column:created_by;comment:创建人ID, 0表示启用登录前创建" json:"created_by"`
+	CreatedByName string `gorm:"size:50;column:created_by_name;comment:创建人用户名" json:"created_by_name"`
+}
+
+// TableName 指定表名
+func (SchemaCompareJob) TableName() string {
+	return "schema_compare_jobs"
+}
+
+// SchemaCompareResult 单个库与参照库的比较结果，每次运行整体替换
+type SchemaCompareResult struct {
+	ID           uint            `gorm:"primarykey;column:id" json:"id"`
+	JobID        uint            `gorm:"not null;index;column:job_id;comment:检查任务ID" json:"job_id"`
+	InstanceID   uint            `gorm:"not null;column:instance_id;comment:实例ID" json:"instance_id"`
+	InstanceName string          `gorm:"size:100;column:instance_name;comment:实例名称" json:"instance_name"`
+	DatabaseName string          `gorm:"size:100;not null;column:database_name;comment:数据库名称" json:"database_name"`
+	Status       int8            `gorm:"not null;default:0;column:status;comment:结果(0:一致, 1:存在差异, 2:失败)" json:"status"`
+	Fingerprint  string          `gorm:"size:64;column:fingerprint;comment:结构指纹" json:"fingerprint"`
+	DiffCount    int             `gorm:"not null;default:0;column:diff_count;comment:差异数量" json:"diff_count"`
+	Changes      json.RawMessage `gorm:"type:text;column:changes;comment:使该库与参照库一致所需的变更(JSON)" json:"changes"`
+	DDL          string          `gorm:"type:text;column:ddl;comment:变更语句" json:"ddl"`
+	Error        string          `gorm:"type:text;column:error;comment:失败原因" json:"error"`
+}
+
+// TableName 指定表名
+func (SchemaCompareResult) TableName() string {
+	return "schema_compare_results"
+}
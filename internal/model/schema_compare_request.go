@@ -0,0 +1,35 @@
+package model
+
+// CreateSchemaCompareRequest 创建库结构一致性检查请求，参照库本身不参与比较
+type CreateSchemaCompareRequest struct {
+	Name                string `json:"name" validate:"required"`                  // 任务名称
+	ReferenceInstanceID uint   `json:"reference_instance_id" validate:"required"` // 参照库所在实例ID
+	ReferenceDatabase   string `json:"reference_database" validate:"required"`    // 参照库名称
+	TargetSelection
+	GenerateDDL bool `json:"generate_ddl"` // 是否生成变更语句
+}
+
+// SchemaCompareListRequest 库结构一致性检查任务列表请求
+type SchemaCompareListRequest struct {
+	Pagination `query:""`
+	Name       string `query:"name" json:"name"` // 任务名称（模糊查询）
+}
+
+// SchemaCompareListResponse 库结构一致性检查任务列表响应
+type SchemaCompareListResponse struct {
+	Total int64              `json:"total"`
+	Items []SchemaCompareJob `json:"items"`
+}
+
+// SchemaCompareResultListRequest 比较结果列表请求
+type SchemaCompareResultListRequest struct {
+	Pagination   `query:""`
+	Status       *int8  `query:"status" json:"status"`               // 结果
+	DatabaseName string `query:"database_name" json:"database_name"` // 数据库名称（模糊查询）
+}
+
+// SchemaCompareResultListResponse 比较结果列表响应
+type SchemaCompareResultListResponse struct {
+	Total int64                 `json:"total"`
+	Items []SchemaCompareResult `json:"items"`
+}
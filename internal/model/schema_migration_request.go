@@ -0,0 +1,18 @@
+package model
+
+// SchemaMigrationRequest 生成迁移语句请求，两个库可以属于不同实例，语句使目标库与源库结构一致
+type SchemaMigrationRequest struct {
+	SourceInstanceID uint   `json:"source_instance_id" validate:"required"` // 源库所在实例ID
+	SourceDatabase   string `json:"source_database" validate:"required"`    // 源库名称
+	TargetInstanceID uint   `json:"target_instance_id" validate:"required"` // 目标库所在实例ID
+	TargetDatabase   string `json:"target_database" validate:"required"`    // 目标库名称
+}
+
+// CreateSchemaMigrationTaskRequest 将迁移语句创建为查询任务的请求
+// 未指定执行范围时只在目标库上执行；指定时范围内的库应与目标库结构相同
+type CreateSchemaMigrationTaskRequest struct {
+	SchemaMigrationRequest
+	TaskName    string `json:"task_name" validate:"required"` // 任务名称
+	Description string `json:"description"`                   // 任务描述
+	TargetSelection
+}
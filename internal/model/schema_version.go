@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+// SchemaVersion 库结构版本，同步时结构指纹与上一版本不同才生成新版本
+type SchemaVersion struct {
+	ID           uint         `gorm:"primarykey;column:id" json:"id"`
+	InstanceID   uint         `gorm:"not null;index:idx_schema_versions_db;column:instance_id;comment:实例ID" json:"instance_id"`
+	DatabaseName string       `gorm:"size:100;not null;index:idx_schema_versions_db;column:database_name;comment:数据库名称" json:"database_name"`
+	Version      int          `gorm:"not null;column:version;comment:版本号，按库从1递增" json:"version"`
+	Fingerprint  string       `gorm:"size:64;not null;index;column:fingerprint;comment:结构指纹" json:"fingerprint"`
+	TableCount   int          `gorm:"not null;default:0;column:table_count;comment:表数量" json:"table_count"`
+	Tables       SchemaTables `gorm:"type:text;column:tables;comment:规范化后的库结构" json:"tables,omitempty"`
+	CapturedAt   time.Time    `gorm:"not null;column:captured_at;comment:首次采集时间" json:"captured_at"`
+	LastSeenAt   time.Time    `gorm:"not null;column:last_seen_at;comment:最后一次采集到该结构的时间" json:"last_seen_at"`
+}
+
+// TableName 指定表名
+func (SchemaVersion) TableName() string {
+	return "schema_versions"
+}
+
+// SchemaChange 结构变更记录，由相邻两个结构版本比较得出
+type SchemaChange struct {
+	ID            uint      `gorm:"primarykey;column:id" json:"id"`
+	InstanceID    uint      `gorm:"not null;index:idx_schema_changes_db;column:instance_id;comment:实例ID" json:"instance_id"`
+	DatabaseName  string    `gorm:"size:100;not null;index:idx_schema_changes_db;column:database_name;comment:数据库名称" json:"database_name"`
+	FromVersionID uint      `gorm:"not null;column:from_version_id;comment:变更前版本ID" json:"from_version_id"`
+	ToVersionID   uint      `gorm:"not null;index;column:to_version_id;comment:变更后版本ID" json:"to_version_id"`
+	Kind          string    `gorm:"size:30;not null;column:kind;comment:变更类型" json:"kind"`
+	Table         string    `gorm:"size:100;not null;column:table_name;comment:表名" json:"table_name"`
+	Object        string    `gorm:"size:100;column:object_name;comment:字段名或索引名" json:"object_name"`
+	Before        string    `gorm:"type:text;column:before_value;comment:变更前描述" json:"before"`
+	After         string    `gorm:"type:text;column:after_value;comment:变更后描述" json:"after"`
+	DetectedAt    time.Time `gorm:"not null;index;column:detected_at;comment:发现时间" json:"detected_at"`
+}
+
+// TableName 指定表名
+func (SchemaChange) TableName() string {
+	return "schema_changes"
+}
@@ -0,0 +1,35 @@
+package model
+
+// SchemaVersionListRequest 结构版本列表请求，按版本倒序
+type SchemaVersionListRequest struct {
+	Pagination   `query:""`
+	InstanceID   uint   `query:"instance_id" json:"instance_id"`     // 实例ID
+	DatabaseName string `query:"database_name" json:"database_name"` // 数据库名称
+}
+
+// SchemaVersionListResponse 结构版本列表响应，不含结构内容
+type SchemaVersionListResponse struct {
+	Total int64           `json:"total"`
+	Items []SchemaVersion `json:"items"`
+}
+
+// SchemaChangeListRequest 结构变更记录列表请求，按发现时间倒序
+type SchemaChangeListRequest struct {
+	Pagination   `query:""`
+	InstanceID   uint   `query:"instance_id" json:"instance_id"`     // 实例ID
+	DatabaseName string `query:"database_name" json:"database_name"` // 数据库名称
+	Kind         string `query:"kind" json:"kind"`                   // 变更类型
+	TableName    string `query:"table_name" json:"table_name"`       // 表名
+}
+
+// SchemaChangeResponse 结构变更记录响应
+type SchemaChangeResponse struct {
+	SchemaChange
+	Instance InstanceBasicInfo `json:"instance"`
+}
+
+// SchemaChangeListResponse 结构变更记录列表响应
+type SchemaChangeListResponse struct {
+	Total int64                  `json:"total"`
+	Items []SchemaChangeResponse `json:"items"`
+}
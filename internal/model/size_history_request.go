@@ -0,0 +1,48 @@
+package model
+
+import "time"
+
+// SizeGrowthRequest 大小增长趋势请求
+// 指定表名时返回该表的趋势（需同时指定实例和库）；指定实例和库时返回该库的趋势；
+// 否则按天汇总当前用户可查看的全部数据库（可按实例过滤）。
+type SizeGrowthRequest struct {
+	InstanceID   uint   `query:"instance_id" json:"instance_id"`     // 实例ID
+	DatabaseName string `query:"database_name" json:"database_name"` // 数据库名称
+	TableName    string `query:"table_name" json:"table_name"`       // 表名
+	Days         int    `query:"days" json:"days"`                   // 统计天数，默认 30
+}
+
+// SizePoint 大小趋势中的一个采样点
+type SizePoint struct {
+	At   time.Time `json:"at"`   // 采样时间，按天汇总时为当天零点
+	Size int64     `json:"size"` // 大小(字节)
+	Rows int64     `json:"rows"` // 行数，仅表趋势有值
+}
+
+// SizeGrowthResponse 大小增长趋势响应
+type SizeGrowthResponse struct {
+	Points    []SizePoint `json:"points"`
+	StartSize int64       `json:"start_size"` // 区间内第一个采样点的大小
+	EndSize   int64       `json:"end_size"`   // 区间内最后一个采样点的大小
+	Growth    int64       `json:"growth"`     // 区间内增长的字节数，可能为负
+}
+
+// TopGrowingRequest 增长最快排行请求
+type TopGrowingRequest struct {
+	Days  int    `query:"days" json:"days"`   // 统计天数，默认 7
+	Limit int    `query:"limit" json:"limit"` // 返回数量，默认 10
+	Level string `query:"level" json:"level"` // database（默认）或 table
+}
+
+// SizeGrowthItem 增长排行中的一项，按库统计时表名为空
+type SizeGrowthItem struct {
+	InstanceID   uint      `json:"instance_id"`
+	InstanceName string    `json:"instance_name" gorm:"-"`
+	DatabaseName string    `json:"database_name"`
+	TableName    string    `json:"table_name"`
+	StartSize    int64     `json:"start_size"`
+	EndSize      int64     `json:"end_size"`
+	Growth       int64     `json:"growth"`
+	StartAt      time.Time `json:"start_at"`
+	EndAt        time.Time `json:"end_at"`
+}
@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// DatabaseSizeSnapshot 数据库大小快照，每次同步实例时记录一次，用于统计增长趋势
+// 超过原始保留期的快照每库每天只保留最后一个，超过总保留期后删除。
+type DatabaseSizeSnapshot struct {
+	ID           uint      `gorm:"primarykey;column:id" json:"id"`
+	InstanceID   uint      `gorm:"not null;index:idx_db_size_snapshots_db;column:instance_id;comment:实例ID" json:"instance_id"`
+	DatabaseName string    `gorm:"size:100;not null;index:idx_db_size_snapshots_db;column:database_name;comment:数据库名称" json:"database_name"`
+	Size         int64     `gorm:"not null;default:0;column:size;comment:数据库大小(字节)" json:"size"`
+	TableCount   int       `gorm:"not null;default:0;column:table_count;comment:表数量" json:"table_count"`
+	CapturedAt   time.Time `gorm:"not null;index;column:captured_at;comment:采集时间" json:"captured_at"`
+}
+
+// TableName 指定表名
+func (DatabaseSizeSnapshot) TableName() string {
+	return "database_size_snapshots"
+}
+
+// TableSizeSnapshot 表大小快照，与数据库大小快照同时记录
+type TableSizeSnapshot struct {
+	ID           uint      `gorm:"primarykey;column:id" json:"id"`
+	InstanceID   uint      `gorm:"not null;index:idx_table_size_snapshots_table;column:instance_id;comment:实例ID" json:"instance_id"`
+	DatabaseName string    `gorm:"size:100;not null;index:idx_table_size_snapshots_table;column:database_name;comment:数据库名称" json:"database_name"`
+	Table        string    `gorm:"size:100;not null;index:idx_table_size_snapshots_table;column:table_name;comment:表名" json:"table_name"`
+	Rows         int64     `gorm:"not null;default:0;column:rows;comment:估算行数" json:"rows"`
+	TotalSize    int64     `gorm:"not null;default:0;column:total_size;comment:数据与索引总大小(字节)" json:"total_size"`
+	CapturedAt   time.Time `gorm:"not null;index;column:captured_at;comment:采集时间" json:"captured_at"`
+}
+
+// TableName 指定表名
+func (TableSizeSnapshot) TableName() string {
+	return "table_size_snapshots"
+}
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// TableInfo 表级元数据，同步数据库信息时按实例整体刷新
+// 行数为引擎统计信息中的估算值（SQLite 取自 sqlite_stat1，未执行过 ANALYZE 时为 0），各引擎不支持的字段为空。
+type TableInfo struct {
+	ID            uint       `gorm:"primarykey;column:id" json:"id"`
+	InstanceID    uint       `gorm:"not null;index:idx_table_infos_db;column:instance_id;comment:实例ID" json:"instance_id"`
+	DatabaseName  string     `gorm:"size:100;not null;index:idx_table_infos_db;column:database_name;comment:数据库名称" json:"database_name"`
+	Name          string     `gorm:"size:100;not null;index;column:name;comment:表名" json:"name"`
+	Engine        string     `gorm:"size:50;column:engine;comment:存储引擎" json:"engine"`
+	Collation     string     `gorm:"size:100;column:collation;comment:排序规则" json:"collation"`
+	Rows          int64      `gorm:"not null;default:0;column:rows;comment:估算行数" json:"rows"`
+	DataSize      int64      `gorm:"not null;default:0;column:data_size;comment:数据大小(字节)" json:"data_size"`
+	IndexSize     int64      `gorm:"not null;default:0;column:index_size;comment:索引大小(字节)" json:"index_size"`
+	TotalSize     int64      `gorm:"not null;default:0;index;column:total_size;comment:数据与索引总大小(字节)" json:"total_size"`
+	AutoIncrement *int64     `gorm:"column:auto_increment;comment:下一个自增值" json:"auto_increment"`
+	CreateTime    *time.Time `gorm:"column:create_time;comment:建表时间" json:"create_time"`
+	UpdateTime    *time.Time `gorm:"column:update_time;comment:最后更新时间" json:"update_time"`
+	SyncedAt      time.Time  `gorm:"column:synced_at;comment:同步时间" json:"synced_at"`
+}
+
+// TableName 指定表名
+func (TableInfo) TableName() string {
+	return "table_infos"
+}
@@ -0,0 +1,79 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// User 本地用户
+type User struct {
+	ID        uint           `gorm:"primarykey;column:id" json:"id"`
+	CreatedAt time.Time      `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"column:updated_at" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index;column:deleted_at" json:"-"`
+
+	Username     string     `gorm:"size:50;not null;uniqueIndex;column:username;comment:用户名" json:"username"`
+	PasswordHash string     `gorm:"size:100;not null;column:password_hash;comment:密码(bcrypt)" json:"-"`
+	DisplayName  string     `gorm:"size:100;column:display_name;comment:显示名称" json:"display_name"`
+	IsAdmin      bool       `gorm:"not null;default:false;column:is_admin;comment:是否管理员" json:"is_admin"`
+	Disabled     bool       `gorm:"not null;default:false;column:disabled;comment:是否禁用" json:"disabled"`
+	LastLoginAt  *time.Time `gorm:"column:last_login_at;comment:最后登录时间" json:"last_login_at"`
+}
+
+// TableName 指定表名
+func (User) TableName() string {
+	return "users"
+}
+
+// UserSession 界面登录会话，只保存令牌的摘要
+type UserSession struct {
+	ID        uint      `gorm:"primarykey;column:id" json:"id"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+
+	UserID    uint      `gorm:"not null;index;column:user_id;comment:用户ID" json:"user_id"`
+	TokenHash string    `gorm:"size:64;not null;uniqueIndex;column:token_hash;comment:会话令牌SHA-256摘要" json:"-"`
+	ExpiresAt time.Time `gorm:"not null;column:expires_at;comment:过期时间" json:"expires_at"`
+	IP        string    `gorm:"size:64;column:ip;comment:登录IP" json:"ip"`
+	UserAgent string    `gorm:"size:255;column:user_agent;comment:登录客户端" json:"user_agent"`
+}
+
+// TableName 指定表名
+func (UserSession) TableName() string {
+	return "user_sessions"
+}
+
+// APIToken 个人 API 令牌，供脚本调用接口，只保存令牌的摘要
+type APIToken struct {
+	ID        uint      `gorm:"primarykey;column:id" json:"id"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+
+	UserID     uint       `gorm:"not null;index;column:user_id;comment:用户ID" json:"user_id"`
+	Name       string     `gorm:"size:100;not null;column:name;comment:令牌名称" json:"name"`
+	TokenHash  string     `gorm:"size:64;not null;uniqueIndex;column:token_hash;comment:令牌SHA-256摘要" json:"-"`
+	Prefix     string     `gorm:"size:16;not null;column:prefix;comment:令牌前几位, 用于识别" json:"prefix"`
+	ExpiresAt  *time.Time `gorm:"column:expires_at;comment:过期时间, 为空表示不过期" json:"expires_at"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at;comment:最后使用时间" json:"last_used_at"`
+}
+
+// TableName 指定表名
+func (APIToken) TableName() string {
+	return "api_tokens"
+}
+
+// RoleBinding 用户在实例上的角色授权。
+// InstanceID 和 TagExpr 都为空时作用于全部实例；系统管理员不受授权限制。
+type RoleBinding struct {
+	ID        uint      `gorm:"primarykey;column:id" json:"id"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+
+	UserID     uint   `gorm:"not null;index;column:user_id;comment:用户ID" json:"user_id"`
+	Role       string `gorm:"size:20;not null;column:role;comment:角色: viewer、operator、writer、admin" json:"role"`
+	InstanceID uint   `gorm:"not null;default:0;column:instance_id;comment:作用的实例ID, 0 表示不按实例限定" json:"instance_id"`
+	TagExpr    string `gorm:"size:255;column:tag_expr;comment:作用的实例标签表达式, 为空表示不按标签限定" json:"tag_expr"`
+}
+
+// TableName 指定表名
+func (RoleBinding) TableName() string {
+	return "role_bindings"
+}
@@ -0,0 +1,61 @@
+package model
+
+import "time"
+
+// LoginRequest 登录请求
+type LoginRequest struct {
+	Username string `json:"username"` // 用户名
+	Password string `json:"password"` // 密码
+}
+
+// ChangePasswordRequest 修改密码请求
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password"` // 原密码
+	NewPassword string `json:"new_password"` // 新密码
+}
+
+// CreateUserRequest 创建用户请求
+type CreateUserRequest struct {
+	Username    string `json:"username"`     // 用户名
+	Password    string `json:"password"`     // 初始密码
+	DisplayName string `json:"display_name"` // 显示名称
+	IsAdmin     bool   `json:"is_admin"`     // 是否管理员
+}
+
+// UpdateUserRequest 更新用户请求，密码为空时不修改
+type UpdateUserRequest struct {
+	DisplayName string `json:"display_name"` // 显示名称
+	IsAdmin     bool   `json:"is_admin"`     // 是否管理员
+	Disabled    bool   `json:"disabled"`     // 是否禁用
+	Password    string `json:"password"`     // 重置密码
+}
+
+// CreateAPITokenRequest 创建 API 令牌请求
+type CreateAPITokenRequest struct {
+	Name          string `json:"name"`            // 令牌名称
+	ExpiresInDays int    `json:"expires_in_days"` // 有效天数，0 表示不过期
+}
+
+// CreateAPITokenResponse 创建 API 令牌响应，明文令牌只返回这一次
+type CreateAPITokenResponse struct {
+	Token string    `json:"token"` // 明文令牌
+	Item  *APIToken `json:"item"`  // 令牌信息
+}
+
+// LoginResponse 登录响应
+type LoginResponse struct {
+	User      *User     `json:"user"`       // 当前用户
+	ExpiresAt time.Time `json:"expires_at"` // 会话过期时间
+}
+
+// RoleBindingItem 一条角色授权，实例和标签表达式最多填写一个，都不填表示全部实例
+type RoleBindingItem struct {
+	Role       string `json:"role"`        // 角色
+	InstanceID uint   `json:"instance_id"` // 实例ID
+	TagExpr    string `json:"tag_expr"`    // 实例标签表达式
+}
+
+// SaveRoleBindingsRequest 保存用户授权请求，整体替换该用户的授权
+type SaveRoleBindingsRequest struct {
+	Bindings []RoleBindingItem `json:"bindings"`
+}
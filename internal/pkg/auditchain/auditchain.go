@@ -0,0 +1,67 @@
+// Package auditchain 计算审计事件的哈希链，用于发现记录被篡改、删除或插入
+package auditchain
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// Genesis 第一条事件的前序哈希
+const Genesis = ""
+
+// Entry 参与哈希计算的事件字段
+type Entry struct {
+	Time       time.Time
+	ActorID    uint
+	Actor      string
+	IP         string
+	Action     string
+	TargetType string
+	TargetIDs  string
+	Detail     []byte
+}
+
+// Link 链上的一条事件及其哈希
+type Link struct {
+	Entry
+	PrevHash string
+	Hash     string
+}
+
+// Hash 计算事件哈希：前序哈希与各字段按长度前缀拼接后取 SHA-256，避免字段边界产生歧义
+func Hash(prev string, e Entry) string {
+	h := sha256.New()
+	fields := [][]byte{
+		[]byte(prev),
+		[]byte(e.Time.UTC().Format(time.RFC3339Nano)),
+		[]byte(strconv.FormatUint(uint64(e.ActorID), 10)),
+		[]byte(e.Actor),
+		[]byte(e.IP),
+		[]byte(e.Action),
+		[]byte(e.TargetType),
+		[]byte(e.TargetIDs),
+		e.Detail,
+	}
+	var size [8]byte
+	for _, f := range fields {
+		binary.BigEndian.PutUint64(size[:], uint64(len(f)))
+		h.Write(size[:])
+		h.Write(f)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Verify 从前序哈希 prev 开始按顺序校验事件
+// 返回最后一条事件的哈希和第一条不一致事件的下标，全部一致时下标为 -1。
+func Verify(prev string, links []Link) (string, int) {
+	for i, l := range links {
+		if l.PrevHash != prev || Hash(prev, l.Entry) != l.Hash {
+			return prev, i
+		}
+		prev = l.Hash
+	}
+	return prev, -1
+}
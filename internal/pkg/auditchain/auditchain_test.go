@@ -0,0 +1,91 @@
+package auditchain
+
+import (
+	"testing"
+	"time"
+)
+
+func chain(entries ...Entry) []Link {
+	links := make([]Link, 0, len(entries))
+	prev := Genesis
+	for _, e := range entries {
+		hash := Hash(prev, e)
+		links = append(links, Link{Entry: e, PrevHash: prev, Hash: hash})
+		prev = hash
+	}
+	return links
+}
+
+func TestHash(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 6000, time.UTC)
+	base := Entry{Time: at, ActorID: 1, Actor: "admin", IP: "10.0.0.1", Action: "instance.create", TargetType: "instance", TargetIDs: "3", Detail: []byte(`{"name":"a"}`)}
+	shifted := base
+	shifted.Actor, shifted.IP = "admin1", "0.0.0.1"
+	local := base
+	local.Time = at.In(time.FixedZone("CST", 8*3600))
+	changed := base
+	changed.Detail = []byte(`{"name":"b"}`)
+
+	tests := []struct {
+		name  string
+		prev  string
+		entry Entry
+		same  bool
+	}{
+		{name: "相同内容", entry: base, same: true},
+		{name: "时区不影响", entry: local, same: true},
+		{name: "字段边界移动", entry: shifted, same: false},
+		{name: "详情变化", entry: changed, same: false},
+		{name: "前序哈希变化", prev: "x", entry: base, same: false},
+	}
+	want := Hash(Genesis, base)
+	for _, tt := range tests {
+		if got := Hash(tt.prev, tt.entry); (got == want) != tt.same {
+			t.Errorf("%s: Hash same = %v, want %v", tt.name, got == want, tt.same)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	entries := []Entry{
+		{Time: at, ActorID: 1, Actor: "admin", Action: "auth.login"},
+		{Time: at.Add(time.Second), ActorID: 1, Actor: "admin", Action: "instance.delete", TargetType: "instance", TargetIDs: "1,2"},
+		{Time: at.Add(2 * time.Second), ActorID: 2, Actor: "bob", Action: "query_task.run", TargetType: "query_task", TargetIDs: "7"},
+	}
+
+	tampered := chain(entries...)
+	tampered[1].TargetIDs = "1"
+	removed := chain(entries...)
+	removed = append(removed[:1], removed[2:]...)
+	rehashed := chain(entries...)
+	rehashed[1].Actor = "bob"
+	rehashed[1].Hash = Hash(rehashed[1].PrevHash, rehashed[1].Entry)
+
+	tests := []struct {
+		name  string
+		links []Link
+		want  int
+	}{
+		{name: "空链", links: nil, want: -1},
+		{name: "完整", links: chain(entries...), want: -1},
+		{name: "修改字段", links: tampered, want: 1},
+		{name: "删除中间记录", links: removed, want: 1},
+		{name: "修改后重算哈希", links: rehashed, want: 2},
+	}
+	for _, tt := range tests {
+		if _, got := Verify(Genesis, tt.links); got != tt.want {
+			t.Errorf("%s: Verify = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+
+	// 分段校验时用上一段的最后哈希继续
+	full := chain(entries...)
+	last, broken := Verify(Genesis, full[:2])
+	if broken != -1 {
+		t.Fatalf("first segment broken at %d", broken)
+	}
+	if _, broken := Verify(last, full[2:]); broken != -1 {
+		t.Errorf("second segment broken at %d", broken)
+	}
+}
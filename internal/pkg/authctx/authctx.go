@@ -0,0 +1,73 @@
+// Package authctx 在请求上下文中传递当前登录用户
+package authctx
+
+import (
+	"context"
+
+	"my-bulker/internal/model"
+)
+
+type userKey struct{}
+
+// WithUser 返回携带当前用户的上下文
+func WithUser(ctx context.Context, user *model.User) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// User 获取上下文中的当前用户，未登录时返回 nil
+func User(ctx context.Context) *model.User {
+	if ctx == nil {
+		return nil
+	}
+	user, _ := ctx.Value(userKey{}).(*model.User)
+	return user
+}
+
+// UserID 获取当前用户ID，未登录时返回 0
+func UserID(ctx context.Context) uint {
+	if user := User(ctx); user != nil {
+		return user.ID
+	}
+	return 0
+}
+
+// Username 获取当前用户名，未登录时返回空字符串
+func Username(ctx context.Context) string {
+	if user := User(ctx); user != nil {
+		return user.Username
+	}
+	return ""
+}
+
+type systemKey struct{}
+
+// WithSystem 返回标记为系统内部调用的上下文，用于定时同步、新建实例后的同步等没有请求用户的后台操作
+// 只有带此标记的上下文在没有用户时不受授权限制，其余没有用户的上下文一律按无权限处理。
+func WithSystem(ctx context.Context) context.Context {
+	return context.WithValue(ctx, systemKey{}, true)
+}
+
+// IsSystem 判断上下文是否为系统内部调用
+func IsSystem(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	system, _ := ctx.Value(systemKey{}).(bool)
+	return system
+}
+
+type clientIPKey struct{}
+
+// WithClientIP 返回携带客户端IP的上下文
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// ClientIP 获取上下文中的客户端IP，非请求上下文时返回空字符串
+func ClientIP(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
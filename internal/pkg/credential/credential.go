@@ -0,0 +1,89 @@
+// Package credential 提供用户密码哈希及会话、API 令牌的生成与摘要
+package credential
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// SessionPrefix 会话令牌前缀
+	SessionPrefix = "mbs_"
+	// APITokenPrefix API 令牌前缀
+	APITokenPrefix = "mbt_"
+
+	// MinPasswordLength 密码最小长度
+	MinPasswordLength = 8
+	// maxPasswordBytes bcrypt 只使用前 72 字节
+	maxPasswordBytes = 72
+
+	tokenBytes = 32
+)
+
+var (
+	ErrPasswordTooShort = errors.New("密码长度不能少于 8 位")
+	ErrPasswordTooLong  = errors.New("密码长度不能超过 72 字节")
+)
+
+// ValidatePassword 校验密码长度
+func ValidatePassword(password string) error {
+	if utf8.RuneCountInString(password) < MinPasswordLength {
+		return ErrPasswordTooShort
+	}
+	if len(password) > maxPasswordBytes {
+		return ErrPasswordTooLong
+	}
+	return nil
+}
+
+// HashPassword 使用 bcrypt 生成密码哈希
+func HashPassword(password string) (string, error) {
+	if err := ValidatePassword(password); err != nil {
+		return "", err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword 校验密码与哈希是否匹配
+func CheckPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// NewToken 生成带前缀的随机令牌
+func NewToken(prefix string) (string, error) {
+	buf := make([]byte, tokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(buf), nil
+}
+
+// HashToken 计算令牌的 SHA-256 摘要，数据库只保存摘要
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsAPIToken 判断是否为 API 令牌
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
+
+// DisplayPrefix 令牌用于识别的前几位
+func DisplayPrefix(token string) string {
+	const n = 12
+	if len(token) <= n {
+		return token
+	}
+	return token[:n]
+}
@@ -0,0 +1,81 @@
+package credential
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidatePassword(t *testing.T) {
+	tests := []struct {
+		password string
+		want     error
+	}{
+		{password: "short", want: ErrPasswordTooShort},
+		{password: "12345678", want: nil},
+		{password: "密码密码密码密码", want: nil},
+		{password: strings.Repeat("a", 72), want: nil},
+		{password: strings.Repeat("a", 73), want: ErrPasswordTooLong},
+	}
+	for _, tt := range tests {
+		if err := ValidatePassword(tt.password); !errors.Is(err, tt.want) {
+			t.Errorf("ValidatePassword(%q) = %v, want %v", tt.password, err, tt.want)
+		}
+	}
+}
+
+func TestHashAndCheckPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatalf("HashPassword error: %v", err)
+	}
+	tests := []struct {
+		password string
+		want     bool
+	}{
+		{password: "correct horse", want: true},
+		{password: "correct horse ", want: false},
+		{password: "", want: false},
+	}
+	for _, tt := range tests {
+		if got := CheckPassword(hash, tt.password); got != tt.want {
+			t.Errorf("CheckPassword(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+	if _, err := HashPassword("short"); !errors.Is(err, ErrPasswordTooShort) {
+		t.Errorf("HashPassword(short) error = %v, want %v", err, ErrPasswordTooShort)
+	}
+}
+
+func TestToken(t *testing.T) {
+	tests := []struct {
+		prefix string
+		isAPI  bool
+	}{
+		{prefix: SessionPrefix, isAPI: false},
+		{prefix: APITokenPrefix, isAPI: true},
+	}
+	for _, tt := range tests {
+		token, err := NewToken(tt.prefix)
+		if err != nil {
+			t.Fatalf("NewToken(%q) error: %v", tt.prefix, err)
+		}
+		if !strings.HasPrefix(token, tt.prefix) || len(token) != len(tt.prefix)+2*tokenBytes {
+			t.Errorf("NewToken(%q) = %q, unexpected format", tt.prefix, token)
+		}
+		if got := IsAPIToken(token); got != tt.isAPI {
+			t.Errorf("IsAPIToken(%q) = %v, want %v", token, got, tt.isAPI)
+		}
+		if HashToken(token) != HashToken(token) || HashToken(token) == token {
+			t.Errorf("HashToken(%q) is not a stable digest", token)
+		}
+		if got := DisplayPrefix(token); got != token[:12] {
+			t.Errorf("DisplayPrefix(%q) = %q", token, got)
+		}
+	}
+	a, _ := NewToken(APITokenPrefix)
+	b, _ := NewToken(APITokenPrefix)
+	if a == b {
+		t.Error("NewToken returned the same token twice")
+	}
+}
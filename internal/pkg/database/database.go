@@ -1,23 +1,23 @@
 package database
 
 import (
-	"database/sql"
 	"fmt"
 	"my-bulker/internal/model"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/glebarez/sqlite"
-	_ "github.com/go-sql-driver/mysql"
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
+// dataDir 应用数据目录，存放应用库 app.db 和主密钥
+var dataDir = "./data"
+
 var (
 	db   *gorm.DB
 	once sync.Once
@@ -28,13 +28,13 @@ func Init() error {
 	var initErr error
 	once.Do(func() {
 		// 确保 data 目录存在
-		if err := os.MkdirAll("./data", 0755); err != nil {
+		if err := os.MkdirAll(dataDir, 0755); err != nil {
 			initErr = err
 			return
 		}
 
 		// 连接数据库
-		dbPath := filepath.Join("./data", "app.db")
+		dbPath := filepath.Join(dataDir, "app.db")
 		var err error
 		db, err = gorm.Open(sqlite.Open(dbPath), &gorm.Config{
 			Logger: logger.Default.LogMode(logger.Error), // 开启 SQL 日志
@@ -78,17 +78,8 @@ func Init() error {
 		}
 
 		// 自动迁移数据库结构
-		// 注意：按照依赖关系顺序进行迁移
-		if err := db.AutoMigrate(
-			&model.Instance{},           // 实例表（无依赖）
-			&model.Database{},           // 数据库表（依赖 Instance）
-			&model.QueryTask{},          // 查询任务表（无依赖）
-			&model.QueryTaskSQL{},       // 查询任务SQL表（依赖 QueryTask）
-			&model.QueryTaskExecution{}, // 任务执行表（依赖 QueryTask、QueryTaskSQL、Instance）
-			&model.Config{},             // 配置表（无依赖）
-			&model.DbDocTask{},          // 数据库文档生成任务表（依赖 Instance, Database）
-		); err != nil {
-			initErr = fmt.Errorf("failed to migrate database: %v", err)
+		if err := Migrate(db); err != nil {
+			initErr = err
 			return
 		}
 	})
@@ -96,17 +87,64 @@ func Init() error {
 	return initErr
 }
 
+// Migrate 自动迁移应用库结构并创建审计触发器
+func Migrate(db *gorm.DB) error {
+	// 注意：按照依赖关系顺序进行迁移
+	if err := db.AutoMigrate(
+		&model.Instance{},             // 实例表（无依赖）
+		&model.Database{},             // 数据库表（依赖 Instance）
+		&model.DatabaseTable{},        // 数据库表结构表（依赖 Instance）
+		&model.TableInfo{},            // 表元数据表（依赖 Instance）
+		&model.DatabaseSizeSnapshot{}, // 数据库大小快照表（依赖 Instance）
+		&model.TableSizeSnapshot{},    // 表大小快照表（依赖 Instance）
+		&model.SchemaVersion{},        // 库结构版本表（依赖 Instance）
+		&model.SchemaChange{},         // 库结构变更表（依赖 SchemaVersion）
+		&model.SchemaCompareJob{},     // 库结构一致性检查任务表（依赖 Instance）
+		&model.SchemaCompareResult{},  // 库结构比较结果表（依赖 SchemaCompareJob）
+		&model.QueryTask{},            // 查询任务表（无依赖）
+		&model.QueryTaskSQL{},         // 查询任务SQL表（依赖 QueryTask）
+		&model.QueryTaskExecution{},   // 任务执行表（依赖 QueryTask、QueryTaskSQL、Instance）
+		&model.QueryTaskReview{},      // 任务审批记录表（依赖 QueryTask）
+		&model.Config{},               // 配置表（无依赖）
+		&model.DbDocTask{},            // 数据库文档生成任务表（依赖 Instance, Database）
+		&model.User{},                 // 用户表（无依赖）
+		&model.UserSession{},          // 登录会话表（依赖 User）
+		&model.APIToken{},             // API 令牌表（依赖 User）
+		&model.RoleBinding{},          // 角色授权表（依赖 User、Instance）
+		&model.AuditEvent{},           // 审计事件表（无依赖）
+	); err != nil {
+		return fmt.Errorf("failed to migrate database: %v", err)
+	}
+
+	// 审计事件只允许追加，由触发器拒绝修改和删除
+	for _, stmt := range []string{
+		"CREATE TRIGGER IF NOT EXISTS audit_events_no_update BEFORE UPDATE ON audit_events BEGIN SELECT RAISE(ABORT, 'audit events are append-only'); END;",
+		"CREATE TRIGGER IF NOT EXISTS audit_events_no_delete BEFORE DELETE ON audit_events BEGIN SELECT RAISE(ABORT, 'audit events are append-only'); END;",
+	} {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("failed to create audit trigger: %v", err)
+		}
+	}
+	return nil
+}
+
+// SetDB 替换应用库连接，用于测试中使用迁移好的内存库
+func SetDB(d *gorm.DB) {
+	db = d
+}
+
 // GetDB 获取数据库连接实例
 func GetDB() *gorm.DB {
 	return db
 }
 
 // buildDSN 构建MySQL数据源名称 (DSN)
-func buildDSN(instance *model.Instance, dbName string) string {
+func buildDSN(instance *model.Instance, dbName string) (string, error) {
 	// 基础DSN
-	baseDSN := fmt.Sprintf("%s:%s@tcp(%s:%d)/",
+	baseDSN := fmt.Sprintf("%s:%s@%s(%s:%d)/",
 		instance.Username,
 		instance.Password,
+		dialNetwork(instance),
 		instance.Host,
 		instance.Port,
 	)
@@ -115,48 +153,34 @@ func buildDSN(instance *model.Instance, dbName string) string {
 		baseDSN += dbName
 	}
 	// 固定参数
-	dsn := baseDSN + "?charset=utf8mb4&parseTime=True&loc=Local"
+	dsn := baseDSN + "?charset=utf8mb4&parseTime=True&loc=Local&timeout=" + dialTimeout.String() +
+		"&readTimeout=" + ioTimeout.String() + "&writeTimeout=" + ioTimeout.String()
 
 	// 添加额外参数
 	if len(instance.Params) > 0 {
 		extraParams := make([]string, 0, len(instance.Params))
 		for _, paramMap := range instance.Params {
-			for key, value := range paramMap {
-				extraParams = append(extraParams, fmt.Sprintf("%s=%s", url.QueryEscape(key), url.QueryEscape(value)))
+			// 按键排序，保证相同配置生成的 DSN 一致
+			keys := make([]string, 0, len(paramMap))
+			for key := range paramMap {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				extraParams = append(extraParams, fmt.Sprintf("%s=%s", url.QueryEscape(key), url.QueryEscape(paramMap[key])))
 			}
 		}
 		dsn += "&" + strings.Join(extraParams, "&")
 	}
 
-	return dsn
-}
-
-// NewMySQLDB 根据实例信息创建 MySQL sql.DB 连接
-func NewMySQLDB(instance *model.Instance) (*sql.DB, error) {
-	dsn := buildDSN(instance, "") // 不指定数据库
-	db, err := sql.Open("mysql", dsn)
-	if err != nil {
-		return nil, fmt.Errorf("连接数据库失败 [%s]: %v", instance.Name, err)
-	}
-	// 设置连接超时
-	db.SetConnMaxLifetime(time.Second * 5)
-	return db, nil
-}
-
-// NewMySQLGormDB 根据实例信息创建 MySQL gorm.DB 连接
-func NewMySQLGormDB(instance *model.Instance, dbName string, maxConn int) (*gorm.DB, error) {
-	dsn := buildDSN(instance, dbName)
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Silent),
-	})
+	// TLS 设置放在额外参数之后，优先于额外参数中的 tls
+	tlsValue, err := tlsParam(instance)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	sqlDB, err := db.DB()
-	if err != nil {
-		return nil, err
+	if tlsValue != "" {
+		dsn += "&tls=" + url.QueryEscape(tlsValue)
 	}
-	sqlDB.SetMaxIdleConns(maxConn)
-	sqlDB.SetMaxOpenConns(maxConn)
-	return db, nil
+
+	return dsn, nil
 }
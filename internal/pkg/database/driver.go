@@ -0,0 +1,149 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"my-bulker/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// Queryer sql.DB 与 sql.Conn 共有的查询方法
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Driver 数据库引擎驱动，屏蔽不同引擎在连接、元数据查询及会话控制上的差异
+// 实例下的"数据库"在 MySQL 中为 database，在 PostgreSQL 中为连接库内的 schema，在 SQLite 中为匹配通配符的文件。
+type Driver interface {
+	// DSN 构建实例连接串，不指定具体数据库
+	DSN(instance *model.Instance) (string, error)
+	// Open 根据连接串打开 sql.DB
+	Open(instance *model.Instance, dsn string) (*sql.DB, error)
+	// Dialector 基于已打开的连接创建 gorm 方言
+	Dialector(db *sql.DB) gorm.Dialector
+
+	// Version 获取数据库版本
+	Version(ctx context.Context, q Queryer) (string, error)
+	// TLSStatus 获取当前会话协商的 TLS 版本与加密套件，未使用 TLS 时返回空字符串
+	TLSStatus(ctx context.Context, conn *sql.Conn) (version, cipher string, err error)
+	// ListDatabases 获取实例下的业务数据库（不含系统库）及其大小、表数量
+	ListDatabases(ctx context.Context, db *sql.DB) ([]model.Database, error)
+	// ListColumns 获取实例下所有业务数据库中表的字段名，按库、表、字段顺序排列
+	ListColumns(ctx context.Context, db *sql.DB) ([]model.ColumnRef, error)
+	// ListTables 获取实例下所有业务数据库中表的行数估算、数据与索引大小等元数据，按库、表顺序排列
+	ListTables(ctx context.Context, db *sql.DB) ([]model.TableInfo, error)
+	// DescribeSchema 获取指定数据库下所有表的字段和索引结构，按表名排列
+	// q 应为已切换到该库的连接（见 Pool.WithDatabase）
+	DescribeSchema(ctx context.Context, q Queryer, dbName string) ([]model.SchemaTable, error)
+	// ReplicaLag 在从库上获取复制延迟秒数，ok 为 false 表示无法获取（如复制未运行）
+	ReplicaLag(ctx context.Context, db *sql.DB) (lag float64, ok bool, err error)
+
+	// UseDatabase 将当前连接切换到指定数据库
+	UseDatabase(tx *gorm.DB, dbName string) error
+	// SetReadOnly 将当前连接的会话设为只读，用于只读保护模式的实例
+	SetReadOnly(tx *gorm.DB) error
+	// BeginReadOnly 在当前连接上开启只读事务，由调用方以 ROLLBACK 结束
+	BeginReadOnly(tx *gorm.DB) error
+	// SessionID 获取当前连接的会话ID，用于中断查询
+	SessionID(tx *gorm.DB) (int64, error)
+	// KillQuery 中断指定会话正在执行的语句，会话本身保留
+	KillQuery(ctx context.Context, db *sql.DB, sessionID int64) error
+	// ResetSession 清除当前连接的会话变量、临时表等状态，引擎不支持时返回 errResetUnsupported，由连接池丢弃该连接
+	ResetSession(tx *gorm.DB) error
+}
+
+// errResetUnsupported 引擎无法通过语句重置会话
+var errResetUnsupported = errors.New("不支持重置会话")
+
+// DriverFor 获取实例对应的引擎驱动
+func DriverFor(instance *model.Instance) (Driver, error) {
+	switch instance.EngineName() {
+	case model.EngineMySQL:
+		return mysqlDriver{}, nil
+	case model.EnginePostgres:
+		return postgresDriver{}, nil
+	case model.EngineSQLite:
+		return sqliteDriver{glob: instance.FileGlob}, nil
+	}
+	return nil, fmt.Errorf("不支持的数据库引擎: %s", instance.Engine)
+}
+
+// OpenDB 根据实例信息创建独立的 sql.DB 连接，适用于测试连接等一次性操作，使用后需关闭
+func OpenDB(instance *model.Instance) (*sql.DB, Driver, error) {
+	d, err := DriverFor(instance)
+	if err != nil {
+		return nil, nil, err
+	}
+	dsn, err := d.DSN(instance)
+	if err != nil {
+		return nil, nil, err
+	}
+	db, err := d.Open(instance, dsn)
+	if err != nil {
+		return nil, nil, fmt.Errorf("连接数据库失败 [%s]: %v", instance.Name, err)
+	}
+	// 设置连接超时
+	db.SetConnMaxLifetime(time.Second * 5)
+	return db, d, nil
+}
+
+// scanColumnRefs 读取库名、表名、字段名三列的查询结果并关闭 rows
+func scanColumnRefs(rows *sql.Rows) ([]model.ColumnRef, error) {
+	defer rows.Close()
+	var refs []model.ColumnRef
+	for rows.Next() {
+		var ref model.ColumnRef
+		if err := rows.Scan(&ref.DatabaseName, &ref.TableName, &ref.ColumnName); err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	return refs, rows.Err()
+}
+
+// scanTableInfos 读取库名、表名、引擎、排序规则、行数、数据大小、索引大小、自增值、建表时间、更新时间十列的查询结果并关闭 rows
+func scanTableInfos(rows *sql.Rows) ([]model.TableInfo, error) {
+	defer rows.Close()
+	var tables []model.TableInfo
+	for rows.Next() {
+		var t model.TableInfo
+		var autoIncrement sql.NullInt64
+		var createTime, updateTime sql.NullTime
+		if err := rows.Scan(&t.DatabaseName, &t.Name, &t.Engine, &t.Collation, &t.Rows, &t.DataSize, &t.IndexSize,
+			&autoIncrement, &createTime, &updateTime); err != nil {
+			return nil, err
+		}
+		if autoIncrement.Valid {
+			t.AutoIncrement = &autoIncrement.Int64
+		}
+		if createTime.Valid {
+			t.CreateTime = &createTime.Time
+		}
+		if updateTime.Valid {
+			t.UpdateTime = &updateTime.Time
+		}
+		t.TotalSize = t.DataSize + t.IndexSize
+		tables = append(tables, t)
+	}
+	return tables, rows.Err()
+}
+
+// sortIndexes 按名称排列索引，主键排在最前
+func sortIndexes(tables []model.SchemaTable) {
+	for i := range tables {
+		indexes := tables[i].Indexes
+		sort.SliceStable(indexes, func(a, b int) bool {
+			if indexes[a].Primary != indexes[b].Primary {
+				return indexes[a].Primary
+			}
+			return indexes[a].Name < indexes[b].Name
+		})
+	}
+}
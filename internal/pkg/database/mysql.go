@@ -0,0 +1,326 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"my-bulker/internal/model"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// mysqlTimestampDefault 不需要加括号的表达式默认值
+var mysqlTimestampDefault = regexp.MustCompile(`(?i)^(current_timestamp|now|localtime|localtimestamp)(\(\d*\))?$`)
+
+// mysqlDriver MySQL 引擎驱动
+type mysqlDriver struct{}
+
+func (mysqlDriver) DSN(instance *model.Instance) (string, error) {
+	return buildDSN(instance, "")
+}
+
+func (mysqlDriver) Open(_ *model.Instance, dsn string) (*sql.DB, error) {
+	return sql.Open("mysql", dsn)
+}
+
+func (mysqlDriver) Dialector(db *sql.DB) gorm.Dialector {
+	return mysql.New(mysql.Config{Conn: db})
+}
+
+func (mysqlDriver) Version(ctx context.Context, q Queryer) (string, error) {
+	var version string
+	if err := q.QueryRowContext(ctx, "SELECT VERSION()").Scan(&version); err != nil {
+		return "", err
+	}
+	return version, nil
+}
+
+func (mysqlDriver) TLSStatus(ctx context.Context, conn *sql.Conn) (string, string, error) {
+	rows, err := conn.QueryContext(ctx, "SHOW SESSION STATUS WHERE Variable_name IN ('Ssl_version', 'Ssl_cipher')")
+	if err != nil {
+		return "", "", err
+	}
+	defer rows.Close()
+
+	var version, cipher string
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return "", "", err
+		}
+		switch name {
+		case "Ssl_version":
+			version = value
+		case "Ssl_cipher":
+			cipher = value
+		}
+	}
+	return version, cipher, rows.Err()
+}
+
+func (mysqlDriver) ListDatabases(ctx context.Context, db *sql.DB) ([]model.Database, error) {
+	// 一次性获取所有数据库信息
+	rows, err := db.QueryContext(ctx, `
+		SELECT
+			s.SCHEMA_NAME,
+			s.DEFAULT_CHARACTER_SET_NAME,
+			s.DEFAULT_COLLATION_NAME,
+			COALESCE(SUM(t.data_length + t.index_length), 0) as size,
+			COUNT(t.TABLE_NAME) as table_count
+		FROM information_schema.SCHEMATA s
+		LEFT JOIN information_schema.TABLES t ON s.SCHEMA_NAME = t.TABLE_SCHEMA
+		WHERE s.SCHEMA_NAME NOT IN ('information_schema', 'performance_schema', 'mysql', 'sys')
+		GROUP BY s.SCHEMA_NAME, s.DEFAULT_CHARACTER_SET_NAME, s.DEFAULT_COLLATION_NAME
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var databases []model.Database
+	for rows.Next() {
+		var item model.Database
+		if err := rows.Scan(&item.Name, &item.CharacterSet, &item.Collation, &item.Size, &item.TableCount); err != nil {
+			return nil, err
+		}
+		databases = append(databases, item)
+	}
+	return databases, rows.Err()
+}
+
+func (mysqlDriver) ListColumns(ctx context.Context, db *sql.DB) ([]model.ColumnRef, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT c.TABLE_SCHEMA, c.TABLE_NAME, c.COLUMN_NAME
+		FROM information_schema.COLUMNS c
+		JOIN information_schema.TABLES t ON t.TABLE_SCHEMA = c.TABLE_SCHEMA AND t.TABLE_NAME = c.TABLE_NAME
+		WHERE t.TABLE_TYPE = 'BASE TABLE'
+			AND c.TABLE_SCHEMA NOT IN ('information_schema', 'performance_schema', 'mysql', 'sys')
+		ORDER BY c.TABLE_SCHEMA, c.TABLE_NAME, c.ORDINAL_POSITION
+	`)
+	if err != nil {
+		return nil, err
+	}
+	return scanColumnRefs(rows)
+}
+
+// ListTables 读取 information_schema.TABLES，InnoDB 的行数为统计信息估算值
+func (mysqlDriver) ListTables(ctx context.Context, db *sql.DB) ([]model.TableInfo, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT TABLE_SCHEMA, TABLE_NAME, COALESCE(ENGINE, ''), COALESCE(TABLE_COLLATION, ''),
+			COALESCE(TABLE_ROWS, 0), COALESCE(DATA_LENGTH, 0), COALESCE(INDEX_LENGTH, 0),
+			AUTO_INCREMENT, CREATE_TIME, UPDATE_TIME
+		FROM information_schema.TABLES
+		WHERE TABLE_TYPE = 'BASE TABLE'
+			AND TABLE_SCHEMA NOT IN ('information_schema', 'performance_schema', 'mysql', 'sys')
+		ORDER BY TABLE_SCHEMA, TABLE_NAME
+	`)
+	if err != nil {
+		return nil, err
+	}
+	return scanTableInfos(rows)
+}
+
+func (mysqlDriver) DescribeSchema(ctx context.Context, q Queryer, dbName string) ([]model.SchemaTable, error) {
+	// 获取所有表及其描述
+	rows, err := q.QueryContext(ctx, `
+		SELECT TABLE_NAME, TABLE_COMMENT, COALESCE(TABLE_COLLATION, '')
+		FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = ? AND TABLE_TYPE = 'BASE TABLE'
+		ORDER BY TABLE_NAME
+	`, dbName)
+	if err != nil {
+		return nil, fmt.Errorf("获取表列表失败: %v", err)
+	}
+	var tables []model.SchemaTable
+	tableIndex := make(map[string]int)
+	tableCollations := make(map[string]string)
+	for rows.Next() {
+		var table model.SchemaTable
+		var collation string
+		if err := rows.Scan(&table.Name, &table.Comment, &collation); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		tableIndex[table.Name] = len(tables)
+		tableCollations[table.Name] = collation
+		tables = append(tables, table)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// 批量获取所有表的字段信息
+	rows, err = q.QueryContext(ctx, `
+		SELECT TABLE_NAME, COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE, COLUMN_DEFAULT, COLUMN_COMMENT,
+			COALESCE(COLLATION_NAME, ''), EXTRA, COALESCE(GENERATION_EXPRESSION, '')
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = ?
+		ORDER BY TABLE_NAME, ORDINAL_POSITION
+	`, dbName)
+	if err != nil {
+		return nil, fmt.Errorf("批量获取字段信息失败: %v", err)
+	}
+	for rows.Next() {
+		var tableName, nullable, collation, extra string
+		var col model.SchemaColumn
+		var def sql.NullString
+		if err := rows.Scan(&tableName, &col.Name, &col.Type, &nullable, &def, &col.Comment, &collation, &extra, &col.Generated); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		i, ok := tableIndex[tableName]
+		if !ok {
+			// 视图等非基础表
+			continue
+		}
+		col.Nullable = nullable == "YES"
+		if collation != tableCollations[tableName] {
+			col.Collation = collation
+		}
+		// 8.0 的表达式默认值在 EXTRA 中标记为 DEFAULT_GENERATED，COLUMN_DEFAULT 不含括号，
+		// 补上括号以便与字符串默认值区分；CURRENT_TIMESTAMP 同样带此标记，保持原样。
+		generatedDefault := strings.Contains(extra, "DEFAULT_GENERATED")
+		col.Extra = strings.Join(strings.Fields(strings.ReplaceAll(extra, "DEFAULT_GENERATED", "")), " ")
+		if def.Valid {
+			value := def.String
+			if generatedDefault && !mysqlTimestampDefault.MatchString(value) {
+				value = "(" + value + ")"
+			}
+			col.Default = &value
+		}
+		tables[i].Columns = append(tables[i].Columns, col)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// 批量获取所有表的索引信息
+	rows, err = q.QueryContext(ctx, `
+		SELECT TABLE_NAME, INDEX_NAME, NON_UNIQUE, COLUMN_NAME
+		FROM information_schema.STATISTICS
+		WHERE TABLE_SCHEMA = ?
+		ORDER BY TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX
+	`, dbName)
+	if err != nil {
+		return nil, fmt.Errorf("批量获取索引信息失败: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tableName, indexName string
+		var nonUnique int
+		var columnName sql.NullString
+		if err := rows.Scan(&tableName, &indexName, &nonUnique, &columnName); err != nil {
+			return nil, err
+		}
+		i, ok := tableIndex[tableName]
+		if !ok {
+			continue
+		}
+		// 函数索引的字段名为空
+		column := columnName.String
+		indexes := tables[i].Indexes
+		if n := len(indexes); n > 0 && indexes[n-1].Name == indexName {
+			indexes[n-1].Columns = append(indexes[n-1].Columns, column)
+			continue
+		}
+		tables[i].Indexes = append(indexes, model.SchemaIndex{
+			Name:    indexName,
+			Columns: []string{column},
+			Unique:  nonUnique == 0,
+			Primary: indexName == "PRIMARY",
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sortIndexes(tables)
+	return tables, nil
+}
+
+// ReplicaLag 通过 SHOW REPLICA STATUS 获取从库延迟，兼容旧版本的 SHOW SLAVE STATUS
+// 多源复制时取所有通道中的最大值。
+func (mysqlDriver) ReplicaLag(ctx context.Context, db *sql.DB) (float64, bool, error) {
+	rows, err := db.QueryContext(ctx, "SHOW REPLICA STATUS")
+	if err != nil {
+		rows, err = db.QueryContext(ctx, "SHOW SLAVE STATUS")
+		if err != nil {
+			return 0, false, err
+		}
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return 0, false, err
+	}
+	lagIndex := -1
+	for i, col := range cols {
+		if col == "Seconds_Behind_Source" || col == "Seconds_Behind_Master" {
+			lagIndex = i
+			break
+		}
+	}
+	if lagIndex < 0 {
+		return 0, false, fmt.Errorf("复制状态中缺少延迟字段")
+	}
+
+	var maxLag float64
+	found := false
+	values := make([]sql.RawBytes, len(cols))
+	dest := make([]interface{}, len(cols))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return 0, false, err
+		}
+		if values[lagIndex] == nil {
+			continue
+		}
+		lag, err := strconv.ParseFloat(string(values[lagIndex]), 64)
+		if err != nil {
+			return 0, false, err
+		}
+		if !found || lag > maxLag {
+			maxLag = lag
+		}
+		found = true
+	}
+	return maxLag, found, rows.Err()
+}
+
+func (mysqlDriver) UseDatabase(tx *gorm.DB, dbName string) error {
+	return tx.Exec("USE " + QuoteIdentifier(dbName)).Error
+}
+
+func (mysqlDriver) SetReadOnly(tx *gorm.DB) error {
+	return tx.Exec("SET SESSION TRANSACTION READ ONLY").Error
+}
+
+func (mysqlDriver) BeginReadOnly(tx *gorm.DB) error {
+	return tx.Exec("START TRANSACTION READ ONLY").Error
+}
+
+func (mysqlDriver) SessionID(tx *gorm.DB) (int64, error) {
+	var id int64
+	err := tx.Raw("SELECT CONNECTION_ID()").Scan(&id).Error
+	return id, err
+}
+
+func (mysqlDriver) KillQuery(ctx context.Context, db *sql.DB, sessionID int64) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf("KILL QUERY %d", sessionID))
+	return err
+}
+
+// ResetSession MySQL 没有重置会话的语句，执行过非只读语句的连接直接丢弃
+func (mysqlDriver) ResetSession(*gorm.DB) error {
+	return errResetUnsupported
+}
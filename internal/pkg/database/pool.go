@@ -0,0 +1,469 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"my-bulker/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	// poolIdleTimeout 无人引用的连接池空闲超过该时长后被回收
+	poolIdleTimeout = 5 * time.Minute
+	// poolJanitorInterval 空闲连接池检查间隔
+	poolJanitorInterval = time.Minute
+	// poolConnMaxIdleTime 池内单个空闲连接的最长保留时间
+	poolConnMaxIdleTime = 2 * time.Minute
+	// killQueryTimeout 中断语句的超时时间
+	killQueryTimeout = 5 * time.Second
+	// databaseMaxConns 按库独立连接时单个库的最大连接数
+	databaseMaxConns = 2
+	// dialTimeout 建立连接的超时时间，避免无法访问的实例长时间阻塞，可在实例额外参数中覆盖
+	dialTimeout = 10 * time.Second
+	// ioTimeout MySQL 连接单次读写的超时时间，用于发现失联的连接，可在实例额外参数中覆盖
+	// 语句整体的执行时长由调用方 ctx 的截止时间约束，此处仅取宽松的上限以免中断正常的长查询。
+	ioTimeout = 10 * time.Minute
+)
+
+// Pool 实例级连接池
+// 同一实例下的所有数据库共享一个连接池，执行时由引擎驱动切换库（MySQL 使用 USE，PostgreSQL 使用 search_path），
+// 多个任务之间按引用计数复用，无人引用且空闲超时后由后台回收。
+type Pool struct {
+	instanceID   uint
+	instanceName string
+	fingerprint  string
+	readOnly     bool
+	driver       Driver
+	db           *gorm.DB
+	sqlDB        *sql.DB
+
+	// databases 按库独立连接的引擎（见 databaseOpener）各库的连接
+	databasesMu sync.Mutex
+	databases   map[string]*gorm.DB
+
+	// 以下字段由 poolManager.mu 保护
+	refs     int
+	maxConn  int
+	lastUsed time.Time
+	retired  bool
+	created  time.Time
+}
+
+type poolManager struct {
+	mu      sync.Mutex
+	pools   map[uint]*Pool
+	retired []*Pool
+	once    sync.Once
+}
+
+var pools = &poolManager{pools: make(map[uint]*Pool)}
+
+// AcquirePool 获取实例连接池并增加引用计数，使用完毕后必须调用 Release
+// 实例连接参数变化时会新建连接池，旧连接池在引用全部释放后关闭。
+func AcquirePool(instance *model.Instance, maxConn int) (*Pool, error) {
+	if maxConn < 1 {
+		maxConn = 1
+	}
+	pools.once.Do(func() { go pools.janitor() })
+
+	driver, err := DriverFor(instance)
+	if err != nil {
+		return nil, err
+	}
+	dsn, err := driver.DSN(instance)
+	if err != nil {
+		return nil, err
+	}
+	fingerprint := poolFingerprint(instance, dsn)
+	if p := pools.reuse(instance, fingerprint, maxConn); p != nil {
+		return p, nil
+	}
+
+	// 建立连接可能较慢，在锁外进行，避免阻塞其他实例
+	p, err := openPool(instance, driver, dsn, fingerprint, maxConn)
+	if err != nil {
+		return nil, err
+	}
+
+	pools.mu.Lock()
+	defer pools.mu.Unlock()
+	if existing, ok := pools.pools[instance.ID]; ok {
+		if existing.fingerprint == fingerprint {
+			// 并发创建时保留先创建的连接池
+			p.close()
+			existing.refs++
+			existing.lastUsed = time.Now()
+			return existing, nil
+		}
+		pools.retireLocked(existing)
+	}
+	p.refs = 1
+	pools.pools[instance.ID] = p
+	return p, nil
+}
+
+// reuse 复用参数一致的已有连接池，不存在时返回 nil
+func (m *poolManager) reuse(instance *model.Instance, fingerprint string, maxConn int) *Pool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.pools[instance.ID]
+	if !ok {
+		return nil
+	}
+	if p.fingerprint != fingerprint {
+		// 连接参数已变更，旧连接池在引用释放后关闭
+		m.retireLocked(p)
+		return nil
+	}
+	p.refs++
+	p.lastUsed = time.Now()
+	p.instanceName = instance.Name
+	if p.maxConn != maxConn {
+		// 以最近一次传入的上限为准，便于配置修改后即时生效
+		p.maxConn = maxConn
+		p.sqlDB.SetMaxOpenConns(maxConn)
+		p.sqlDB.SetMaxIdleConns(maxConn)
+	}
+	return p
+}
+
+// Release 释放连接池引用
+func (p *Pool) Release() {
+	pools.mu.Lock()
+	defer pools.mu.Unlock()
+	if p.refs > 0 {
+		p.refs--
+	}
+	p.lastUsed = time.Now()
+	if p.retired && p.refs == 0 {
+		pools.closeRetiredLocked(p)
+	}
+}
+
+// DB 获取连接池对应的 gorm.DB，适用于不依赖当前库的查询
+func (p *Pool) DB() *gorm.DB {
+	return p.db
+}
+
+// SQLDB 获取连接池对应的 sql.DB
+func (p *Pool) SQLDB() *sql.DB {
+	return p.sqlDB
+}
+
+// Driver 获取连接池对应实例的引擎驱动
+func (p *Pool) Driver() Driver {
+	return p.driver
+}
+
+// WithDatabase 从连接池取出一个专用连接并切换到指定库后执行 fc
+// 连接在 fc 返回后归还连接池；dbName 为空时不切换库。
+// ctx 超时或取消时，除驱动断开连接外还会在服务端中断该会话正在执行的语句。
+// fc 中通过 MarkSessionDirty 标记过的连接在归还前重置会话，无法重置时丢弃，避免会话状态带入其他任务。
+func (p *Pool) WithDatabase(ctx context.Context, dbName string, fc func(tx *gorm.DB) error) error {
+	state := &sessionState{}
+	ctx = context.WithValue(ctx, sessionStateKey{}, state)
+	if opener, ok := p.driver.(databaseOpener); ok && dbName != "" {
+		db, err := p.databaseDB(opener, dbName)
+		if err != nil {
+			return err
+		}
+		return db.WithContext(ctx).Connection(func(tx *gorm.DB) error {
+			defer p.resetSession(tx, state)
+			if err := p.setReadOnly(tx); err != nil {
+				return err
+			}
+			return fc(tx)
+		})
+	}
+	return p.db.WithContext(ctx).Connection(func(tx *gorm.DB) error {
+		defer p.resetSession(tx, state)
+		if dbName != "" {
+			if err := p.driver.UseDatabase(tx, dbName); err != nil {
+				return fmt.Errorf("切换数据库失败 [%s]: %w", dbName, err)
+			}
+		}
+		if err := p.setReadOnly(tx); err != nil {
+			return err
+		}
+		// 获取会话ID失败不影响执行，仅无法在服务端中断语句
+		sessionID, idErr := p.driver.SessionID(tx)
+		err := fc(tx)
+		if ctx.Err() != nil && idErr == nil {
+			p.killQuery(sessionID)
+		}
+		return err
+	})
+}
+
+// WithReadOnlyTransaction 在指定库的只读事务中执行 fc，结束后总是回滚
+// 用于执行用户提供的查询语句，即使语句分类有遗漏也无法写入。
+func (p *Pool) WithReadOnlyTransaction(ctx context.Context, dbName string, fc func(tx *gorm.DB) error) error {
+	return p.WithDatabase(ctx, dbName, func(tx *gorm.DB) error {
+		if err := p.driver.BeginReadOnly(tx); err != nil {
+			return fmt.Errorf("开启只读事务失败: %w", err)
+		}
+		defer func() {
+			// 原连接的 ctx 可能已超时，回滚使用独立的超时，失败时丢弃该连接
+			rollbackCtx, cancel := context.WithTimeout(context.Background(), killQueryTimeout)
+			defer cancel()
+			if err := tx.WithContext(rollbackCtx).Exec("ROLLBACK").Error; err != nil {
+				MarkSessionDirty(tx)
+			}
+		}()
+		return fc(tx)
+	})
+}
+
+// sessionState 记录连接在 WithDatabase 期间是否执行过可能改变会话状态的语句
+type sessionState struct {
+	dirty bool
+}
+
+type sessionStateKey struct{}
+
+// MarkSessionDirty 标记 WithDatabase 中的当前连接执行过可能改变会话状态的语句（会话变量、临时表、SET 等）
+// tx 应为 WithDatabase 传入的连接或由其派生，其他连接上调用无效果。
+func MarkSessionDirty(tx *gorm.DB) {
+	if state, ok := tx.Statement.Context.Value(sessionStateKey{}).(*sessionState); ok {
+		state.dirty = true
+	}
+}
+
+// resetSession 重置标记过的连接，引擎不支持或重置失败时丢弃该连接
+func (p *Pool) resetSession(tx *gorm.DB, state *sessionState) {
+	if !state.dirty {
+		return
+	}
+	// 原连接的 ctx 可能已超时，重置使用独立的超时
+	ctx, cancel := context.WithTimeout(context.Background(), killQueryTimeout)
+	defer cancel()
+	if err := p.driver.ResetSession(tx.WithContext(ctx)); err == nil {
+		return
+	}
+	if conn, ok := tx.Statement.ConnPool.(*sql.Conn); ok {
+		// 返回 ErrBadConn 使 database/sql 关闭该连接而不是放回连接池
+		_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+	}
+}
+
+// setReadOnly 只读实例的连接在执行前设为只读会话
+// 保护模式变化时连接池会重建（见 poolFingerprint），非只读实例的连接不会残留只读设置。
+func (p *Pool) setReadOnly(tx *gorm.DB) error {
+	if !p.readOnly {
+		return nil
+	}
+	if err := p.driver.SetReadOnly(tx); err != nil {
+		return fmt.Errorf("设置只读会话失败: %w", err)
+	}
+	return nil
+}
+
+// databaseDB 获取按库独立连接的引擎中指定库的连接，不存在时打开
+func (p *Pool) databaseDB(opener databaseOpener, dbName string) (*gorm.DB, error) {
+	p.databasesMu.Lock()
+	defer p.databasesMu.Unlock()
+	if db, ok := p.databases[dbName]; ok {
+		return db, nil
+	}
+	sqlDB, err := opener.OpenDatabase(dbName)
+	if err != nil {
+		return nil, err
+	}
+	db, err := gorm.Open(p.driver.Dialector(sqlDB), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("打开数据库失败 [%s]: %w", dbName, err)
+	}
+	// 单个库的并发由实例级名额控制，这里只保留少量连接
+	sqlDB.SetMaxOpenConns(databaseMaxConns)
+	sqlDB.SetMaxIdleConns(1)
+	sqlDB.SetConnMaxIdleTime(poolConnMaxIdleTime)
+	if p.databases == nil {
+		p.databases = make(map[string]*gorm.DB)
+	}
+	p.databases[dbName] = db
+	return db, nil
+}
+
+// close 关闭实例连接及各库的独立连接
+func (p *Pool) close() {
+	p.sqlDB.Close()
+	p.databasesMu.Lock()
+	defer p.databasesMu.Unlock()
+	for name, db := range p.databases {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+		delete(p.databases, name)
+	}
+}
+
+// killQuery 中断会话正在执行的语句，断开连接后服务端语句可能仍在执行
+func (p *Pool) killQuery(sessionID int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), killQueryTimeout)
+	defer cancel()
+	if err := p.driver.KillQuery(ctx, p.sqlDB, sessionID); err != nil {
+		log.Printf("WARN: failed to kill query of session %d on instance %s: %v", sessionID, p.instanceName, err)
+	}
+}
+
+// QuoteIdentifier 使用反引号转义 MySQL 标识符
+func QuoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// PoolStats 获取所有实例连接池的统计信息
+func PoolStats() []model.PoolStat {
+	pools.mu.Lock()
+	defer pools.mu.Unlock()
+
+	stats := make([]model.PoolStat, 0, len(pools.pools)+len(pools.retired))
+	for _, p := range pools.pools {
+		stats = append(stats, p.statLocked())
+	}
+	for _, p := range pools.retired {
+		stats = append(stats, p.statLocked())
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].InstanceID != stats[j].InstanceID {
+			return stats[i].InstanceID < stats[j].InstanceID
+		}
+		return !stats[i].Retired && stats[j].Retired
+	})
+	return stats
+}
+
+// ClosePool 关闭指定实例的连接池（如实例被删除时），使用中的连接池在引用释放后关闭
+func ClosePool(instanceID uint) {
+	pools.mu.Lock()
+	defer pools.mu.Unlock()
+	if p, ok := pools.pools[instanceID]; ok {
+		pools.retireLocked(p)
+	}
+}
+
+// openPool 创建实例连接池
+func openPool(instance *model.Instance, driver Driver, dsn, fingerprint string, maxConn int) (*Pool, error) {
+	sqlDB, err := driver.Open(instance, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("连接数据库失败 [%s]: %v", instance.Name, err)
+	}
+	db, err := gorm.Open(driver.Dialector(sqlDB), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("连接数据库失败 [%s]: %v", instance.Name, err)
+	}
+	sqlDB.SetMaxOpenConns(maxConn)
+	sqlDB.SetMaxIdleConns(maxConn)
+	sqlDB.SetConnMaxIdleTime(poolConnMaxIdleTime)
+
+	now := time.Now()
+	return &Pool{
+		instanceID:   instance.ID,
+		instanceName: instance.Name,
+		fingerprint:  fingerprint,
+		readOnly:     instance.Protection() == model.ProtectionReadOnly,
+		driver:       driver,
+		db:           db,
+		sqlDB:        sqlDB,
+		maxConn:      maxConn,
+		lastUsed:     now,
+		created:      now,
+	}, nil
+}
+
+// retireLocked 将连接池移出可用列表，无引用时立即关闭，调用方需持有锁
+func (m *poolManager) retireLocked(p *Pool) {
+	delete(m.pools, p.instanceID)
+	p.retired = true
+	if p.refs == 0 {
+		p.close()
+		return
+	}
+	m.retired = append(m.retired, p)
+}
+
+// closeRetiredLocked 关闭已退役且无引用的连接池，调用方需持有锁
+func (m *poolManager) closeRetiredLocked(p *Pool) {
+	for i, r := range m.retired {
+		if r == p {
+			m.retired = append(m.retired[:i], m.retired[i+1:]...)
+			break
+		}
+	}
+	p.close()
+}
+
+// janitor 定期回收空闲的连接池
+func (m *poolManager) janitor() {
+	ticker := time.NewTicker(poolJanitorInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		m.evictIdle(time.Now())
+	}
+}
+
+// evictIdle 关闭无引用且空闲超时的连接池
+func (m *poolManager) evictIdle(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, p := range m.pools {
+		if p.refs == 0 && now.Sub(p.lastUsed) > poolIdleTimeout {
+			delete(m.pools, id)
+			p.close()
+		}
+	}
+}
+
+func (p *Pool) statLocked() model.PoolStat {
+	s := p.sqlDB.Stats()
+	return model.PoolStat{
+		InstanceID:     p.instanceID,
+		InstanceName:   p.instanceName,
+		Refs:           p.refs,
+		MaxOpen:        s.MaxOpenConnections,
+		Open:           s.OpenConnections,
+		InUse:          s.InUse,
+		Idle:           s.Idle,
+		WaitCount:      s.WaitCount,
+		WaitDurationMs: s.WaitDuration.Milliseconds(),
+		MaxIdleClosed:  s.MaxIdleClosed,
+		IdleTimeClosed: s.MaxIdleTimeClosed,
+		LifetimeClosed: s.MaxLifetimeClosed,
+		Retired:        p.retired,
+		CreatedAt:      p.created,
+		LastUsedAt:     p.lastUsed,
+	}
+}
+
+// poolFingerprint 计算实例连接参数指纹，用于判断连接池是否需要重建
+// 除连接串外还包含不体现在连接串中的跳板机和证书配置。
+func poolFingerprint(instance *model.Instance, dsn string) string {
+	h := sha256.New()
+	parts := []string{instance.EngineName(), dsn, instance.TLS.Mode, instance.TLS.CA, instance.TLS.Cert, instance.TLS.Key, instance.Protection()}
+	if instance.SSH.Enabled {
+		parts = append(parts, sshConfig(instance).Key())
+	}
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
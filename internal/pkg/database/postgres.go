@@ -0,0 +1,371 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/sshtunnel"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// defaultConnectDatabase PostgreSQL 实例未指定连接库时使用的数据库
+const defaultConnectDatabase = "postgres"
+
+// postgresDriver PostgreSQL 引擎驱动
+// 一个实例只连接一个库，实例下的"数据库"对应该库中的 schema，执行时通过 search_path 切换。
+type postgresDriver struct{}
+
+// DSN 构建 keyword/value 格式的连接串
+func (postgresDriver) DSN(instance *model.Instance) (string, error) {
+	dbName := instance.ConnectDatabase
+	if dbName == "" {
+		dbName = defaultConnectDatabase
+	}
+	parts := []string{
+		"host=" + quotePostgresValue(instance.Host),
+		"port=" + strconv.Itoa(instance.Port),
+		"user=" + quotePostgresValue(instance.Username),
+		"password=" + quotePostgresValue(instance.Password),
+		"dbname=" + quotePostgresValue(dbName),
+		// pgx 没有读写超时参数，语句的执行时长由调用方 ctx 的截止时间约束
+		"connect_timeout=" + strconv.Itoa(int(dialTimeout.Seconds())),
+	}
+
+	// 添加额外参数，按键排序保证相同配置生成的 DSN 一致
+	for _, paramMap := range instance.Params {
+		keys := make([]string, 0, len(paramMap))
+		for key := range paramMap {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			parts = append(parts, key+"="+quotePostgresValue(paramMap[key]))
+		}
+	}
+
+	// TLS 设置放在额外参数之后，优先于额外参数中的 sslmode
+	switch instance.TLS.Mode {
+	case "":
+	case model.TLSModeDisabled:
+		parts = append(parts, "sslmode=disable")
+	case model.TLSModePreferred:
+		parts = append(parts, "sslmode=prefer")
+	case model.TLSModeRequired, model.TLSModeVerifyCA, model.TLSModeVerifyIdentity:
+		// 证书校验由 Open 中构建的 tls.Config 完成
+		parts = append(parts, "sslmode=require")
+	default:
+		return "", fmt.Errorf("不支持的 TLS 模式: %s", instance.TLS.Mode)
+	}
+
+	return strings.Join(parts, " "), nil
+}
+
+func (postgresDriver) Open(instance *model.Instance, dsn string) (*sql.DB, error) {
+	config, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if instance.SSH.Enabled {
+		sshCfg := sshConfig(instance)
+		config.DialFunc = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			ctx, cancel := withDialTimeout(ctx)
+			defer cancel()
+			return sshtunnel.Dial(ctx, sshCfg, network, addr)
+		}
+		// 主机名交由跳板机解析
+		config.LookupFunc = func(_ context.Context, host string) ([]string, error) {
+			return []string{host}, nil
+		}
+	}
+
+	switch instance.TLS.Mode {
+	case model.TLSModeRequired, model.TLSModeVerifyCA, model.TLSModeVerifyIdentity:
+		tlsConfig, err := buildTLSConfig(instance.Host, instance.TLS)
+		if err != nil {
+			return nil, err
+		}
+		config.TLSConfig = tlsConfig
+		config.Fallbacks = nil
+	}
+
+	return stdlib.OpenDB(*config), nil
+}
+
+func (postgresDriver) Dialector(db *sql.DB) gorm.Dialector {
+	return postgres.New(postgres.Config{Conn: db})
+}
+
+func (postgresDriver) Version(ctx context.Context, q Queryer) (string, error) {
+	var version string
+	if err := q.QueryRowContext(ctx, "SHOW server_version").Scan(&version); err != nil {
+		return "", err
+	}
+	return version, nil
+}
+
+func (postgresDriver) TLSStatus(ctx context.Context, conn *sql.Conn) (string, string, error) {
+	var version, cipher string
+	err := conn.QueryRowContext(ctx, `
+		SELECT COALESCE(version, ''), COALESCE(cipher, '')
+		FROM pg_stat_ssl
+		WHERE pid = pg_backend_pid() AND ssl
+	`).Scan(&version, &cipher)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", nil
+	}
+	return version, cipher, err
+}
+
+func (postgresDriver) ListDatabases(ctx context.Context, db *sql.DB) ([]model.Database, error) {
+	// 分区表只统计父表，大小包含索引及 TOAST
+	rows, err := db.QueryContext(ctx, `
+		SELECT
+			n.nspname,
+			pg_encoding_to_char(d.encoding)::text,
+			d.datcollate::text,
+			COALESCE(SUM(pg_total_relation_size(c.oid)), 0)::bigint AS size,
+			COUNT(c.oid) FILTER (WHERE NOT c.relispartition) AS table_count
+		FROM pg_namespace n
+		JOIN pg_database d ON d.datname = current_database()
+		LEFT JOIN pg_class c ON c.relnamespace = n.oid AND c.relkind IN ('r', 'p')
+		WHERE n.nspname <> 'information_schema' AND n.nspname NOT LIKE 'pg\_%'
+		GROUP BY n.nspname, d.encoding, d.datcollate
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var databases []model.Database
+	for rows.Next() {
+		var item model.Database
+		if err := rows.Scan(&item.Name, &item.CharacterSet, &item.Collation, &item.Size, &item.TableCount); err != nil {
+			return nil, err
+		}
+		databases = append(databases, item)
+	}
+	return databases, rows.Err()
+}
+
+func (postgresDriver) ListColumns(ctx context.Context, db *sql.DB) ([]model.ColumnRef, error) {
+	// 分区只保留父表
+	rows, err := db.QueryContext(ctx, `
+		SELECT n.nspname, c.relname, a.attname
+		FROM pg_attribute a
+		JOIN pg_class c ON c.oid = a.attrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'p') AND NOT c.relispartition
+			AND a.attnum > 0 AND NOT a.attisdropped
+			AND n.nspname <> 'information_schema' AND n.nspname NOT LIKE 'pg\_%'
+		ORDER BY n.nspname, c.relname, a.attnum
+	`)
+	if err != nil {
+		return nil, err
+	}
+	return scanColumnRefs(rows)
+}
+
+// ListTables 行数取自 reltuples（未分析过的表为 0），数据大小含 TOAST
+// 分区只保留父表，父表的行数和大小汇总直接子分区；PostgreSQL 没有存储引擎、表级排序规则，也不记录自增值、建表及更新时间。
+func (postgresDriver) ListTables(ctx context.Context, db *sql.DB) ([]model.TableInfo, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT n.nspname, c.relname, '', '',
+			GREATEST(CASE WHEN c.relkind = 'p'
+				THEN (SELECT COALESCE(SUM(GREATEST(p.reltuples, 0)), 0) FROM pg_inherits i JOIN pg_class p ON p.oid = i.inhrelid WHERE i.inhparent = c.oid)
+				ELSE c.reltuples END, 0)::bigint,
+			CASE WHEN c.relkind = 'p'
+				THEN (SELECT COALESCE(SUM(pg_table_size(i.inhrelid)), 0) FROM pg_inherits i WHERE i.inhparent = c.oid)
+				ELSE pg_table_size(c.oid) END::bigint,
+			CASE WHEN c.relkind = 'p'
+				THEN (SELECT COALESCE(SUM(pg_indexes_size(i.inhrelid)), 0) FROM pg_inherits i WHERE i.inhparent = c.oid)
+				ELSE pg_indexes_size(c.oid) END::bigint,
+			NULL::bigint, NULL::timestamptz, NULL::timestamptz
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'p') AND NOT c.relispartition
+			AND n.nspname <> 'information_schema' AND n.nspname NOT LIKE 'pg\_%'
+		ORDER BY n.nspname, c.relname
+	`)
+	if err != nil {
+		return nil, err
+	}
+	return scanTableInfos(rows)
+}
+
+func (postgresDriver) DescribeSchema(ctx context.Context, q Queryer, dbName string) ([]model.SchemaTable, error) {
+	// 获取所有表及其描述，分区只保留父表
+	rows, err := q.QueryContext(ctx, `
+		SELECT c.relname, COALESCE(obj_description(c.oid, 'pg_class'), '')
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relkind IN ('r', 'p') AND NOT c.relispartition
+		ORDER BY c.relname
+	`, dbName)
+	if err != nil {
+		return nil, fmt.Errorf("获取表列表失败: %v", err)
+	}
+	var tables []model.SchemaTable
+	tableIndex := make(map[string]int)
+	for rows.Next() {
+		var table model.SchemaTable
+		if err := rows.Scan(&table.Name, &table.Comment); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		tableIndex[table.Name] = len(tables)
+		tables = append(tables, table)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// 批量获取所有表的字段信息
+	rows, err = q.QueryContext(ctx, `
+		SELECT c.relname, a.attname, format_type(a.atttypid, a.atttypmod), NOT a.attnotnull,
+			pg_get_expr(d.adbin, d.adrelid), COALESCE(col_description(c.oid, a.attnum), '')
+		FROM pg_attribute a
+		JOIN pg_class c ON c.oid = a.attrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE n.nspname = $1 AND c.relkind IN ('r', 'p') AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY c.relname, a.attnum
+	`, dbName)
+	if err != nil {
+		return nil, fmt.Errorf("批量获取字段信息失败: %v", err)
+	}
+	for rows.Next() {
+		var tableName string
+		var col model.SchemaColumn
+		var def sql.NullString
+		if err := rows.Scan(&tableName, &col.Name, &col.Type, &col.Nullable, &def, &col.Comment); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		i, ok := tableIndex[tableName]
+		if !ok {
+			continue
+		}
+		if def.Valid {
+			col.Default = &def.String
+		}
+		tables[i].Columns = append(tables[i].Columns, col)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// 批量获取所有表的索引信息，表达式索引的字段名为空
+	rows, err = q.QueryContext(ctx, `
+		SELECT t.relname, i.relname, ix.indisunique, ix.indisprimary, COALESCE(a.attname, '')
+		FROM pg_index ix
+		JOIN pg_class t ON t.oid = ix.indrelid
+		JOIN pg_class i ON i.oid = ix.indexrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		CROSS JOIN LATERAL unnest(ix.indkey::int2[]) WITH ORDINALITY AS k(attnum, ord)
+		LEFT JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+		WHERE n.nspname = $1
+		ORDER BY t.relname, i.relname, k.ord
+	`, dbName)
+	if err != nil {
+		return nil, fmt.Errorf("批量获取索引信息失败: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tableName, indexName, column string
+		var unique, primary bool
+		if err := rows.Scan(&tableName, &indexName, &unique, &primary, &column); err != nil {
+			return nil, err
+		}
+		i, ok := tableIndex[tableName]
+		if !ok {
+			continue
+		}
+		indexes := tables[i].Indexes
+		if n := len(indexes); n > 0 && indexes[n-1].Name == indexName {
+			indexes[n-1].Columns = append(indexes[n-1].Columns, column)
+			continue
+		}
+		tables[i].Indexes = append(indexes, model.SchemaIndex{
+			Name:    indexName,
+			Columns: []string{column},
+			Unique:  unique,
+			Primary: primary,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sortIndexes(tables)
+	return tables, nil
+}
+
+// ReplicaLag 在备库上根据最后回放事务的时间计算延迟
+// WAL 已全部回放时延迟为 0；非备库返回 ok 为 false。
+func (postgresDriver) ReplicaLag(ctx context.Context, db *sql.DB) (float64, bool, error) {
+	var lag sql.NullFloat64
+	err := db.QueryRowContext(ctx, `
+		SELECT CASE
+			WHEN NOT pg_is_in_recovery() THEN NULL
+			WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+			ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())
+		END::float8
+	`).Scan(&lag)
+	if err != nil {
+		return 0, false, err
+	}
+	return lag.Float64, lag.Valid, nil
+}
+
+func (postgresDriver) UseDatabase(tx *gorm.DB, dbName string) error {
+	return tx.Exec("SET search_path TO " + quotePostgresIdentifier(dbName)).Error
+}
+
+func (postgresDriver) SetReadOnly(tx *gorm.DB) error {
+	return tx.Exec("SET SESSION CHARACTERISTICS AS TRANSACTION READ ONLY").Error
+}
+
+func (postgresDriver) BeginReadOnly(tx *gorm.DB) error {
+	return tx.Exec("BEGIN READ ONLY").Error
+}
+
+func (postgresDriver) SessionID(tx *gorm.DB) (int64, error) {
+	var id int64
+	err := tx.Raw("SELECT pg_backend_pid()").Scan(&id).Error
+	return id, err
+}
+
+func (postgresDriver) KillQuery(ctx context.Context, db *sql.DB, sessionID int64) error {
+	_, err := db.ExecContext(ctx, "SELECT pg_cancel_backend($1)", sessionID)
+	return err
+}
+
+// ResetSession DISCARD ALL 清除会话参数、临时表、预处理语句和咨询锁
+func (postgresDriver) ResetSession(tx *gorm.DB) error {
+	return tx.Exec("DISCARD ALL").Error
+}
+
+// quotePostgresIdentifier 使用双引号转义 PostgreSQL 标识符
+func quotePostgresIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// quotePostgresValue 转义 keyword/value 连接串中的值
+func quotePostgresValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
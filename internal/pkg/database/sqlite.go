@@ -0,0 +1,645 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"my-bulker/internal/model"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// errSQLiteUnsupported SQLite 文件不支持的操作
+var errSQLiteUnsupported = errors.New("SQLite 实例不支持该操作")
+
+// errSQLiteDataDir 实例文件范围包含应用数据目录
+var errSQLiteDataDir = errors.New("SQLite 文件路径不能包含应用数据目录")
+
+// databaseOpener 由每个数据库需要独立连接的引擎实现（如 SQLite 每个文件一个库）
+// 连接池对这类引擎按库分别建立连接，而不是在同一连接上切换库。
+type databaseOpener interface {
+	// OpenDatabase 打开指定数据库的连接
+	OpenDatabase(dbName string) (*sql.DB, error)
+}
+
+// sqliteDriver SQLite 文件引擎驱动
+// 实例指向一个文件路径通配符，每个匹配的文件作为一个数据库，库名为文件相对通配符固定目录部分的路径。
+// 实例级连接为内存库，仅用于获取版本及汇总文件信息。
+type sqliteDriver struct {
+	glob string
+}
+
+// DSN SQLite 实例没有连接串，以通配符作为连接参数
+func (d sqliteDriver) DSN(_ *model.Instance) (string, error) {
+	if err := ValidFileGlob(d.glob); err != nil {
+		return "", err
+	}
+	return "file-glob:" + d.glob, nil
+}
+
+func (d sqliteDriver) Open(_ *model.Instance, _ string) (*sql.DB, error) {
+	base := globBase(d.glob)
+	if info, err := os.Stat(base); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("SQLite 文件目录 %s 不存在", base)
+	}
+	return sql.Open(sqlite.DriverName, ":memory:")
+}
+
+func (sqliteDriver) Dialector(db *sql.DB) gorm.Dialector {
+	return &sqlite.Dialector{Conn: db}
+}
+
+// OpenDatabase 打开库名对应的文件，文件必须存在且匹配实例通配符
+func (d sqliteDriver) OpenDatabase(dbName string) (*sql.DB, error) {
+	path, err := d.databasePath(dbName)
+	if err != nil {
+		return nil, err
+	}
+	// 不存在的文件会被 SQLite 自动创建，打开前先确认
+	if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("SQLite 文件 %s 不存在", path)
+	}
+	return sql.Open(sqlite.DriverName, path+"?_pragma=busy_timeout(5000)")
+}
+
+// databasePath 将库名还原为文件路径，拒绝通配符范围之外的路径
+func (d sqliteDriver) databasePath(dbName string) (string, error) {
+	pattern := filepath.Clean(d.glob)
+	path := filepath.Join(globBase(pattern), filepath.FromSlash(dbName))
+	if matched, err := filepath.Match(pattern, path); err != nil || !matched {
+		return "", fmt.Errorf("数据库 %s 不在实例文件范围内", dbName)
+	}
+	if inDataDir(path) {
+		return "", fmt.Errorf("数据库 %s 位于应用数据目录内", dbName)
+	}
+	return path, nil
+}
+
+func (sqliteDriver) Version(ctx context.Context, q Queryer) (string, error) {
+	var version string
+	if err := q.QueryRowContext(ctx, "SELECT sqlite_version()").Scan(&version); err != nil {
+		return "", err
+	}
+	return "SQLite " + version, nil
+}
+
+func (sqliteDriver) TLSStatus(context.Context, *sql.Conn) (string, string, error) {
+	return "", "", nil
+}
+
+// ListDatabases 列出通配符匹配的文件，依次附加到内存库上统计表数量
+// 无法识别为 SQLite 的文件会被跳过。
+func (d sqliteDriver) ListDatabases(ctx context.Context, db *sql.DB) ([]model.Database, error) {
+	files, err := d.listFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var databases []model.Database
+	for _, f := range files {
+		item := model.Database{Name: f.name, Size: f.size, Collation: "BINARY"}
+		if err := inspectSQLiteFile(ctx, conn, f.path, &item); err != nil {
+			log.Printf("WARN: skip sqlite file %s: %v", f.path, err)
+			continue
+		}
+		databases = append(databases, item)
+	}
+	return databases, nil
+}
+
+// ListColumns 依次附加匹配的文件读取各表字段，无法识别为 SQLite 的文件会被跳过
+func (d sqliteDriver) ListColumns(ctx context.Context, db *sql.DB) ([]model.ColumnRef, error) {
+	files, err := d.listFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var refs []model.ColumnRef
+	for _, f := range files {
+		fileRefs, err := listSQLiteColumns(ctx, conn, f)
+		if err != nil {
+			log.Printf("WARN: skip sqlite file %s: %v", f.path, err)
+			continue
+		}
+		refs = append(refs, fileRefs...)
+	}
+	return refs, nil
+}
+
+// ListTables 依次附加匹配的文件读取各表的行数和占用空间，无法识别为 SQLite 的文件会被跳过
+func (d sqliteDriver) ListTables(ctx context.Context, db *sql.DB) ([]model.TableInfo, error) {
+	files, err := d.listFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var tables []model.TableInfo
+	for _, f := range files {
+		fileTables, err := listSQLiteTables(ctx, conn, f)
+		if err != nil {
+			log.Printf("WARN: skip sqlite file %s: %v", f.path, err)
+			continue
+		}
+		tables = append(tables, fileTables...)
+	}
+	return tables, nil
+}
+
+// sqliteFile 通配符匹配到的库文件
+type sqliteFile struct {
+	path string // 文件路径
+	name string // 库名称，即相对通配符固定前缀的路径
+	size int64  // 文件大小
+}
+
+// listFiles 列出通配符匹配的普通文件，按路径排列
+func (d sqliteDriver) listFiles() ([]sqliteFile, error) {
+	pattern := filepath.Clean(d.glob)
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+
+	base := globBase(pattern)
+	var files []sqliteFile
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() || inDataDir(path) {
+			continue
+		}
+		name, err := filepath.Rel(base, path)
+		if err != nil {
+			continue
+		}
+		files = append(files, sqliteFile{path: path, name: filepath.ToSlash(name), size: info.Size()})
+	}
+	return files, nil
+}
+
+// listSQLiteColumns 附加文件并读取所有表的字段名
+func listSQLiteColumns(ctx context.Context, conn *sql.Conn, f sqliteFile) ([]model.ColumnRef, error) {
+	if _, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS target", f.path); err != nil {
+		return nil, err
+	}
+	defer conn.ExecContext(context.Background(), "DETACH DATABASE target")
+
+	rows, err := conn.QueryContext(ctx, `
+		SELECT ?, m.name, p.name
+		FROM target.sqlite_master m
+		JOIN pragma_table_info(m.name, 'target') p
+		WHERE m.type = 'table' AND m.name NOT LIKE 'sqlite\_%' ESCAPE '\'
+		ORDER BY m.name, p.cid
+	`, f.name)
+	if err != nil {
+		return nil, err
+	}
+	return scanColumnRefs(rows)
+}
+
+// listSQLiteTables 附加文件并读取各表的元数据
+// 行数取自 ANALYZE 生成的 sqlite_stat1，没有统计时记为 0，避免逐表 COUNT(*) 扫描大文件；
+// 大小取自 dbstat 虚拟表，索引大小按所属表汇总。
+func listSQLiteTables(ctx context.Context, conn *sql.Conn, f sqliteFile) ([]model.TableInfo, error) {
+	if _, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS target", f.path); err != nil {
+		return nil, err
+	}
+	defer conn.ExecContext(context.Background(), "DETACH DATABASE target")
+
+	// 各对象占用的页大小，表和索引分别累加到所属表
+	rows, err := conn.QueryContext(ctx, `
+		SELECT m.type, m.tbl_name, COALESCE(SUM(s.pgsize), 0)
+		FROM dbstat('target') s
+		JOIN target.sqlite_master m ON m.name = s.name
+		WHERE m.type IN ('table', 'index')
+		GROUP BY m.type, m.tbl_name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	dataSize := make(map[string]int64)
+	indexSize := make(map[string]int64)
+	for rows.Next() {
+		var typ, table string
+		var size int64
+		if err := rows.Scan(&typ, &table, &size); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if typ == "table" {
+			dataSize[table] = size
+		} else {
+			indexSize[table] = size
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// AUTOINCREMENT 表的当前序号保存在 sqlite_sequence 中，该表仅在使用过 AUTOINCREMENT 时存在
+	sequences := make(map[string]int64)
+	hasSequence, err := sqliteHasTable(ctx, conn, "sqlite_sequence")
+	if err != nil {
+		return nil, err
+	}
+	if hasSequence {
+		rows, err := conn.QueryContext(ctx, "SELECT name, seq FROM target.sqlite_sequence")
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var name string
+			var seq int64
+			if err := rows.Scan(&name, &seq); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			sequences[name] = seq + 1
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	// sqlite_stat1 每个索引一行，stat 的第一个数为表的行数；没有索引的表 idx 为 NULL
+	estimates := make(map[string]int64)
+	hasStat, err := sqliteHasTable(ctx, conn, "sqlite_stat1")
+	if err != nil {
+		return nil, err
+	}
+	if hasStat {
+		rows, err := conn.QueryContext(ctx, "SELECT tbl, stat FROM target.sqlite_stat1")
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var table, stat string
+			if err := rows.Scan(&table, &stat); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			fields := strings.Fields(stat)
+			if len(fields) == 0 {
+				continue
+			}
+			if n, err := strconv.ParseInt(fields[0], 10, 64); err == nil && n > estimates[table] {
+				estimates[table] = n
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err = conn.QueryContext(ctx, `
+		SELECT name FROM target.sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite\_%' ESCAPE '\'
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	tables := make([]model.TableInfo, 0, len(names))
+	for _, name := range names {
+		t := model.TableInfo{
+			DatabaseName: f.name,
+			Name:         name,
+			Engine:       "SQLite",
+			Collation:    "BINARY",
+			Rows:         estimates[name],
+			DataSize:     dataSize[name],
+			IndexSize:    indexSize[name],
+		}
+		t.TotalSize = t.DataSize + t.IndexSize
+		if seq, ok := sequences[name]; ok {
+			t.AutoIncrement = &seq
+		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
+
+// sqliteHasTable 判断附加的文件中是否存在指定的表，用于 sqlite_sequence、sqlite_stat1 等按需创建的内部表
+func sqliteHasTable(ctx context.Context, conn *sql.Conn, name string) (bool, error) {
+	var count int
+	if err := conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM target.sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// inspectSQLiteFile 附加文件并读取编码和表数量
+func inspectSQLiteFile(ctx context.Context, conn *sql.Conn, path string, item *model.Database) error {
+	if _, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS target", path); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), "DETACH DATABASE target")
+
+	if err := conn.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM target.sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite\_%' ESCAPE '\'
+	`).Scan(&item.TableCount); err != nil {
+		return err
+	}
+	return conn.QueryRowContext(ctx, "PRAGMA target.encoding").Scan(&item.CharacterSet)
+}
+
+// DescribeSchema 获取库结构，q 需为该库文件的连接
+func (sqliteDriver) DescribeSchema(ctx context.Context, q Queryer, _ string) ([]model.SchemaTable, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT name FROM sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite\_%' ESCAPE '\'
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("获取表列表失败: %v", err)
+	}
+	var tables []model.SchemaTable
+	tableIndex := make(map[string]int)
+	for rows.Next() {
+		var table model.SchemaTable
+		if err := rows.Scan(&table.Name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		tableIndex[table.Name] = len(tables)
+		tables = append(tables, table)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// 批量获取所有表的字段信息，同时记录主键字段
+	rows, err = q.QueryContext(ctx, `
+		SELECT m.name, p.name, p.type, p."notnull", p.dflt_value, p.pk
+		FROM sqlite_master m
+		JOIN pragma_table_info(m.name) p
+		WHERE m.type = 'table'
+		ORDER BY m.name, p.cid
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("批量获取字段信息失败: %v", err)
+	}
+	primaryKeys := make(map[string][]string)
+	pkOrder := make(map[string][]int)
+	for rows.Next() {
+		var tableName string
+		var col model.SchemaColumn
+		var notNull, pk int
+		var def sql.NullString
+		if err := rows.Scan(&tableName, &col.Name, &col.Type, &notNull, &def, &pk); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		i, ok := tableIndex[tableName]
+		if !ok {
+			continue
+		}
+		col.Nullable = notNull == 0
+		if def.Valid {
+			col.Default = &def.String
+		}
+		tables[i].Columns = append(tables[i].Columns, col)
+		if pk > 0 {
+			primaryKeys[tableName] = append(primaryKeys[tableName], col.Name)
+			pkOrder[tableName] = append(pkOrder[tableName], pk)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// 批量获取所有表的索引信息，表达式索引的字段名为空
+	rows, err = q.QueryContext(ctx, `
+		SELECT m.name, il.name, il."unique", il.origin, COALESCE(ii.name, '')
+		FROM sqlite_master m
+		JOIN pragma_index_list(m.name) il
+		JOIN pragma_index_info(il.name) ii
+		WHERE m.type = 'table'
+		ORDER BY m.name, il.name, ii.seqno
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("批量获取索引信息失败: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tableName, indexName, origin, column string
+		var unique int
+		if err := rows.Scan(&tableName, &indexName, &unique, &origin, &column); err != nil {
+			return nil, err
+		}
+		i, ok := tableIndex[tableName]
+		if !ok {
+			continue
+		}
+		indexes := tables[i].Indexes
+		if n := len(indexes); n > 0 && indexes[n-1].Name == indexName {
+			indexes[n-1].Columns = append(indexes[n-1].Columns, column)
+			continue
+		}
+		tables[i].Indexes = append(indexes, model.SchemaIndex{
+			Name:    indexName,
+			Columns: []string{column},
+			Unique:  unique == 1,
+			Primary: origin == "pk",
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// INTEGER PRIMARY KEY 为 rowid 别名，不产生索引，按字段信息补充主键
+	for i := range tables {
+		cols := primaryKeys[tables[i].Name]
+		if len(cols) == 0 || hasPrimary(tables[i].Indexes) {
+			continue
+		}
+		order := pkOrder[tables[i].Name]
+		sort.Sort(byPKOrder{cols: cols, order: order})
+		tables[i].Indexes = append(tables[i].Indexes, model.SchemaIndex{
+			Name:    "PRIMARY",
+			Columns: cols,
+			Unique:  true,
+			Primary: true,
+		})
+	}
+	sortIndexes(tables)
+	return tables, nil
+}
+
+func (sqliteDriver) ReplicaLag(context.Context, *sql.DB) (float64, bool, error) {
+	return 0, false, errSQLiteUnsupported
+}
+
+// UseDatabase SQLite 按库独立连接，无需切换
+func (sqliteDriver) UseDatabase(*gorm.DB, string) error {
+	return nil
+}
+
+func (sqliteDriver) SetReadOnly(tx *gorm.DB) error {
+	return tx.Exec("PRAGMA query_only = ON").Error
+}
+
+// BeginReadOnly SQLite 没有只读事务，通过 query_only 禁止写入
+// 该设置保留在连接上，标记会话后连接在归还时被丢弃。
+func (sqliteDriver) BeginReadOnly(tx *gorm.DB) error {
+	MarkSessionDirty(tx)
+	if err := tx.Exec("PRAGMA query_only = ON").Error; err != nil {
+		return err
+	}
+	return tx.Exec("BEGIN").Error
+}
+
+func (sqliteDriver) SessionID(*gorm.DB) (int64, error) {
+	return 0, errSQLiteUnsupported
+}
+
+// KillQuery SQLite 在进程内执行，ctx 取消时由驱动中断语句
+func (sqliteDriver) KillQuery(context.Context, *sql.DB, int64) error {
+	return nil
+}
+
+// ResetSession SQLite 连接按库独立且重新打开的代价很小，直接丢弃
+func (sqliteDriver) ResetSession(*gorm.DB) error {
+	return errResetUnsupported
+}
+
+// ValidFileGlob 校验 SQLite 文件路径通配符
+func ValidFileGlob(pattern string) error {
+	if strings.TrimSpace(pattern) == "" {
+		return errors.New("SQLite 文件路径不能为空")
+	}
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return fmt.Errorf("SQLite 文件路径通配符无效: %w", err)
+	}
+	// 应用数据目录不能作为实例文件：通配符所在目录、应用库路径及当前匹配的文件都需检查
+	clean := filepath.Clean(pattern)
+	if inDataDir(clean) {
+		return errSQLiteDataDir
+	}
+	if appDB, err := filepath.Abs(filepath.Join(dataDir, "app.db")); err == nil {
+		if absPattern, err := filepath.Abs(clean); err == nil {
+			if matched, _ := filepath.Match(absPattern, appDB); matched {
+				return errSQLiteDataDir
+			}
+		}
+	}
+	matches, _ := filepath.Glob(clean)
+	for _, path := range matches {
+		if inDataDir(path) {
+			return errSQLiteDataDir
+		}
+	}
+	return nil
+}
+
+// inDataDir 判断路径解析符号链接后是否位于应用数据目录内
+func inDataDir(path string) bool {
+	dir, err := resolvePath(dataDir)
+	if err != nil {
+		return false
+	}
+	target, err := resolvePath(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(dir, target)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// resolvePath 获取绝对路径并解析符号链接，路径不存在时解析其已存在的上级目录
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	var rest []string
+	for {
+		if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+			return filepath.Join(append([]string{resolved}, rest...)...), nil
+		}
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return filepath.Join(append([]string{abs}, rest...)...), nil
+		}
+		rest = append([]string{filepath.Base(abs)}, rest...)
+		abs = parent
+	}
+}
+
+// globBase 获取通配符中不含通配字符的目录部分
+func globBase(pattern string) string {
+	dir := filepath.Dir(filepath.Clean(pattern))
+	for strings.ContainsAny(dir, "*?[") && dir != filepath.Dir(dir) {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
+
+func hasPrimary(indexes []model.SchemaIndex) bool {
+	for _, idx := range indexes {
+		if idx.Primary {
+			return true
+		}
+	}
+	return false
+}
+
+// byPKOrder 按主键序号排列复合主键字段
+type byPKOrder struct {
+	cols  []string
+	order []int
+}
+
+func (b byPKOrder) Len() int           { return len(b.cols) }
+func (b byPKOrder) Less(i, j int) bool { return b.order[i] < b.order[j] }
+func (b byPKOrder) Swap(i, j int) {
+	b.cols[i], b.cols[j] = b.cols[j], b.cols[i]
+	b.order[i], b.order[j] = b.order[j], b.order[i]
+}
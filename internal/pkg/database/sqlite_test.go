@@ -0,0 +1,353 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"my-bulker/internal/model"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestGlobBase(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{pattern: "/data/customers/*.db", want: "/data/customers"},
+		{pattern: "/data/*/app.db", want: "/data"},
+		{pattern: "/data/cust-[0-9]*/shard?/*.sqlite", want: "/data"},
+		{pattern: "customers/*.db", want: "customers"},
+		{pattern: "*.db", want: "."},
+		{pattern: "/data/fixed.db", want: "/data"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			if got := globBase(filepath.FromSlash(tt.pattern)); got != filepath.FromSlash(tt.want) {
+				t.Errorf("globBase(%q) = %q, want %q", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDatabasePath(t *testing.T) {
+	d := sqliteDriver{glob: filepath.FromSlash("/data/*/app.db")}
+	tests := []struct {
+		name    string
+		dbName  string
+		want    string
+		wantErr bool
+	}{
+		{name: "match", dbName: "acme/app.db", want: "/data/acme/app.db"},
+		{name: "wrong file", dbName: "acme/other.db", wantErr: true},
+		{name: "too deep", dbName: "acme/x/app.db", wantErr: true},
+		{name: "traversal", dbName: "../etc/app.db", wantErr: true},
+		{name: "traversal inside", dbName: "acme/../../app.db", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := d.databasePath(tt.dbName)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("databasePath(%q) error = %v, wantErr %v", tt.dbName, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != filepath.FromSlash(tt.want) {
+				t.Errorf("databasePath(%q) = %q, want %q", tt.dbName, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidFileGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		wantErr bool
+	}{
+		{pattern: "/data/*.db"},
+		{pattern: "", wantErr: true},
+		{pattern: "  ", wantErr: true},
+		{pattern: "/data/[a-.db", wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := ValidFileGlob(tt.pattern); (err != nil) != tt.wantErr {
+			t.Errorf("ValidFileGlob(%q) error = %v, wantErr %v", tt.pattern, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSQLiteDataDir(t *testing.T) {
+	dir := t.TempDir()
+	saved := dataDir
+	dataDir = filepath.Join(dir, "data")
+	defer func() { dataDir = saved }()
+
+	createSQLiteFile(t, filepath.Join(dir, "data", "app.db"), "CREATE TABLE users (id INTEGER PRIMARY KEY)")
+	createSQLiteFile(t, filepath.Join(dir, "tenants", "acme", "app.db"), "CREATE TABLE users (id INTEGER PRIMARY KEY)")
+	// 通过符号链接指向应用数据目录
+	if err := os.Symlink(filepath.Join(dir, "data"), filepath.Join(dir, "tenants", "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		pattern string
+		wantErr bool
+	}{
+		{name: "outside data dir", pattern: filepath.Join(dir, "tenants", "acme", "*.db")},
+		{name: "data dir", pattern: filepath.Join(dir, "data", "*.db"), wantErr: true},
+		{name: "parent covers app db", pattern: filepath.Join(dir, "*", "app.db"), wantErr: true},
+		{name: "symlink into data dir", pattern: filepath.Join(dir, "tenants", "*", "app.db"), wantErr: true},
+		{name: "relative traversal", pattern: filepath.Join(dir, "tenants", "..", "data", "app.db"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidFileGlob(tt.pattern); (err != nil) != tt.wantErr {
+				t.Errorf("ValidFileGlob(%q) error = %v, wantErr %v", tt.pattern, err, tt.wantErr)
+			}
+		})
+	}
+
+	// 已保存的通配符匹配到应用数据目录时，列出文件和打开库都会跳过
+	d := sqliteDriver{glob: filepath.Join(dir, "tenants", "*", "app.db")}
+	files, err := d.listFiles()
+	if err != nil {
+		t.Fatalf("listFiles() error: %v", err)
+	}
+	if len(files) != 1 || files[0].name != "acme/app.db" {
+		t.Errorf("listFiles() = %+v, want only acme/app.db", files)
+	}
+	if _, err := d.databasePath("link/app.db"); err == nil {
+		t.Error("databasePath(link/app.db) should reject the app data dir")
+	}
+}
+
+// createSQLiteFile 创建包含指定语句的 SQLite 文件
+func createSQLiteFile(t *testing.T, path string, stmts ...string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open(sqlite.DriverName, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("exec %q: %v", stmt, err)
+		}
+	}
+}
+
+func TestSQLitePool(t *testing.T) {
+	dir := t.TempDir()
+	createSQLiteFile(t, filepath.Join(dir, "acme", "app.db"),
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT NOT NULL DEFAULT '', name TEXT)",
+		"CREATE UNIQUE INDEX idx_users_email ON users (email)",
+		"CREATE TABLE tags (user_id INTEGER, tag TEXT, PRIMARY KEY (tag, user_id))",
+		"INSERT INTO users (email, name) VALUES ('a@acme.test', 'a'), ('b@acme.test', 'b')",
+		"ANALYZE",
+	)
+	// 未执行 ANALYZE，没有行数统计
+	createSQLiteFile(t, filepath.Join(dir, "globex", "app.db"),
+		"CREATE TABLE users (id INTEGER PRIMARY KEY)",
+		"INSERT INTO users (id) VALUES (1), (2), (3)",
+	)
+	// 不是 SQLite 文件，同步时跳过
+	if err := os.MkdirAll(filepath.Join(dir, "broken"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "broken", "app.db"), []byte("not a database file, just some plain text"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	instance := &model.Instance{ID: 9001, Name: "files", Engine: model.EngineSQLite, FileGlob: filepath.Join(dir, "*", "app.db")}
+	pool, err := AcquirePool(instance, 2)
+	if err != nil {
+		t.Fatalf("AcquirePool() error: %v", err)
+	}
+	defer ClosePool(instance.ID)
+	defer pool.Release()
+	ctx := context.Background()
+
+	databases, err := pool.Driver().ListDatabases(ctx, pool.SQLDB())
+	if err != nil {
+		t.Fatalf("ListDatabases() error: %v", err)
+	}
+	if len(databases) != 2 || databases[0].Name != "acme/app.db" || databases[1].Name != "globex/app.db" {
+		t.Fatalf("ListDatabases() = %+v, want acme/app.db and globex/app.db", databases)
+	}
+	if databases[0].TableCount != 2 || databases[0].CharacterSet != "UTF-8" {
+		t.Errorf("acme/app.db = %+v, want 2 tables in UTF-8", databases[0])
+	}
+
+	refs, err := pool.Driver().ListColumns(ctx, pool.SQLDB())
+	if err != nil {
+		t.Fatalf("ListColumns() error: %v", err)
+	}
+	wantRefs := []model.ColumnRef{
+		{DatabaseName: "acme/app.db", TableName: "tags", ColumnName: "user_id"},
+		{DatabaseName: "acme/app.db", TableName: "tags", ColumnName: "tag"},
+		{DatabaseName: "acme/app.db", TableName: "users", ColumnName: "id"},
+		{DatabaseName: "acme/app.db", TableName: "users", ColumnName: "email"},
+		{DatabaseName: "acme/app.db", TableName: "users", ColumnName: "name"},
+		{DatabaseName: "globex/app.db", TableName: "users", ColumnName: "id"},
+	}
+	if len(refs) != len(wantRefs) {
+		t.Fatalf("ListColumns() = %+v, want %+v", refs, wantRefs)
+	}
+	for i := range wantRefs {
+		if refs[i] != wantRefs[i] {
+			t.Errorf("ListColumns()[%d] = %+v, want %+v", i, refs[i], wantRefs[i])
+		}
+	}
+
+	tableInfos, err := pool.Driver().ListTables(ctx, pool.SQLDB())
+	if err != nil {
+		t.Fatalf("ListTables() error: %v", err)
+	}
+	if len(tableInfos) != 3 || tableInfos[0].Name != "tags" || tableInfos[1].Name != "users" || tableInfos[2].DatabaseName != "globex/app.db" {
+		t.Fatalf("ListTables() = %+v, want acme tags, users and globex users", tableInfos)
+	}
+	for _, tt := range []struct {
+		info     model.TableInfo
+		rows     int64
+		hasIndex bool
+	}{
+		{info: tableInfos[0], rows: 0, hasIndex: true},
+		{info: tableInfos[1], rows: 2, hasIndex: true},
+		{info: tableInfos[2], rows: 0, hasIndex: false},
+	} {
+		got := tt.info
+		if got.Rows != tt.rows || got.DataSize <= 0 || (got.IndexSize > 0) != tt.hasIndex ||
+			got.TotalSize != got.DataSize+got.IndexSize || got.AutoIncrement != nil {
+			t.Errorf("ListTables() %s.%s = %+v", got.DatabaseName, got.Name, got)
+		}
+	}
+
+	var count int64
+	err = pool.WithDatabase(ctx, "acme/app.db", func(tx *gorm.DB) error {
+		return tx.Raw("SELECT COUNT(*) FROM users").Scan(&count).Error
+	})
+	if err != nil || count != 2 {
+		t.Fatalf("count users = %d, %v, want 2", count, err)
+	}
+	if err := pool.WithDatabase(ctx, "missing/app.db", func(*gorm.DB) error { return nil }); err == nil {
+		t.Error("WithDatabase() on missing file succeeded, want error")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "missing")); !os.IsNotExist(err) {
+		t.Error("WithDatabase() created a missing database file")
+	}
+
+	var tables []model.SchemaTable
+	err = pool.WithDatabase(ctx, "acme/app.db", func(tx *gorm.DB) error {
+		tables, err = pool.Driver().DescribeSchema(ctx, tx.Statement.ConnPool, "acme/app.db")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("DescribeSchema() error: %v", err)
+	}
+	if len(tables) != 2 || tables[0].Name != "tags" || tables[1].Name != "users" {
+		t.Fatalf("DescribeSchema() tables = %+v", tables)
+	}
+	users := tables[1]
+	if len(users.Columns) != 3 || users.Columns[1].Nullable || users.Columns[1].Default == nil || !users.Columns[2].Nullable {
+		t.Errorf("users columns = %+v", users.Columns)
+	}
+	if len(users.Indexes) != 2 || !users.Indexes[0].Primary || users.Indexes[0].Columns[0] != "id" ||
+		users.Indexes[1].Name != "idx_users_email" || !users.Indexes[1].Unique {
+		t.Errorf("users indexes = %+v", users.Indexes)
+	}
+	tags := tables[0]
+	if len(tags.Indexes) != 1 || !tags.Indexes[0].Primary || len(tags.Indexes[0].Columns) != 2 || tags.Indexes[0].Columns[0] != "tag" {
+		t.Errorf("tags indexes = %+v", tags.Indexes)
+	}
+}
+
+func TestSQLiteReadOnlyPool(t *testing.T) {
+	dir := t.TempDir()
+	createSQLiteFile(t, filepath.Join(dir, "app.db"),
+		"CREATE TABLE users (id INTEGER PRIMARY KEY)",
+		"INSERT INTO users (id) VALUES (1)",
+	)
+
+	instance := &model.Instance{ID: 9002, Name: "readonly", Engine: model.EngineSQLite, FileGlob: filepath.Join(dir, "*.db"),
+		ProtectionMode: model.ProtectionReadOnly}
+	pool, err := AcquirePool(instance, 2)
+	if err != nil {
+		t.Fatalf("AcquirePool() error: %v", err)
+	}
+	defer ClosePool(instance.ID)
+	defer pool.Release()
+	ctx := context.Background()
+
+	var count int64
+	err = pool.WithDatabase(ctx, "app.db", func(tx *gorm.DB) error {
+		return tx.Raw("SELECT COUNT(*) FROM users").Scan(&count).Error
+	})
+	if err != nil || count != 1 {
+		t.Fatalf("count users = %d, %v, want 1", count, err)
+	}
+	err = pool.WithDatabase(ctx, "app.db", func(tx *gorm.DB) error {
+		return tx.Exec("DELETE FROM users").Error
+	})
+	if err == nil {
+		t.Error("DELETE on read-only instance succeeded, want error")
+	}
+
+	// 解除只读后重建连接池，写入恢复正常
+	instance.ProtectionMode = model.ProtectionUnrestricted
+	writable, err := AcquirePool(instance, 2)
+	if err != nil {
+		t.Fatalf("AcquirePool() error: %v", err)
+	}
+	defer writable.Release()
+	if writable == pool {
+		t.Fatal("AcquirePool() reused the read-only pool after protection mode changed")
+	}
+	err = writable.WithDatabase(ctx, "app.db", func(tx *gorm.DB) error {
+		return tx.Exec("DELETE FROM users").Error
+	})
+	if err != nil {
+		t.Errorf("DELETE on unrestricted instance error: %v", err)
+	}
+}
+
+func TestSQLiteReadOnlyTransaction(t *testing.T) {
+	dir := t.TempDir()
+	createSQLiteFile(t, filepath.Join(dir, "app.db"),
+		"CREATE TABLE users (id INTEGER PRIMARY KEY)",
+		"INSERT INTO users (id) VALUES (1)",
+	)
+
+	instance := &model.Instance{ID: 9003, Name: "probe", Engine: model.EngineSQLite, FileGlob: filepath.Join(dir, "*.db")}
+	pool, err := AcquirePool(instance, 1)
+	if err != nil {
+		t.Fatalf("AcquirePool() error: %v", err)
+	}
+	defer ClosePool(instance.ID)
+	defer pool.Release()
+	ctx := context.Background()
+
+	err = pool.WithReadOnlyTransaction(ctx, "app.db", func(tx *gorm.DB) error {
+		return tx.Exec("DELETE FROM users").Error
+	})
+	if err == nil {
+		t.Error("DELETE in read-only transaction succeeded, want error")
+	}
+
+	// 只读设置不会残留到后续使用的连接上
+	var count int64
+	err = pool.WithDatabase(ctx, "app.db", func(tx *gorm.DB) error {
+		if err := tx.Exec("INSERT INTO users (id) VALUES (2)").Error; err != nil {
+			return err
+		}
+		return tx.Raw("SELECT COUNT(*) FROM users").Scan(&count).Error
+	})
+	if err != nil || count != 2 {
+		t.Fatalf("count users = %d, %v, want 2", count, err)
+	}
+}
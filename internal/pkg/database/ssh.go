@@ -0,0 +1,56 @@
+package database
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/sshtunnel"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// sshDialers 已注册的 SSH 拨号网络名称
+var sshDialers sync.Map
+
+// dialNetwork 返回 DSN 中使用的网络名称
+// 启用 SSH 隧道时按跳板机配置注册自定义拨号器，连接经由共享的 SSH 客户端转发。
+func dialNetwork(instance *model.Instance) string {
+	if !instance.SSH.Enabled {
+		return "tcp"
+	}
+	cfg := sshConfig(instance)
+	name := "ssh-" + cfg.Key()[:16]
+	if _, loaded := sshDialers.LoadOrStore(name, struct{}{}); !loaded {
+		mysql.RegisterDialContext(name, func(ctx context.Context, addr string) (net.Conn, error) {
+			ctx, cancel := withDialTimeout(ctx)
+			defer cancel()
+			return sshtunnel.Dial(ctx, cfg, "tcp", addr)
+		})
+	}
+	return name
+}
+
+// withDialTimeout 在 ctx 未设置截止时间时补上 dialTimeout，保证经由跳板机的拨号不会无限等待
+// 驱动已按 DSN 中的连接超时设置截止时间时保持不变，以尊重实例额外参数中的覆盖值。
+func withDialTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, dialTimeout)
+}
+
+// sshConfig 根据实例的跳板机配置生成隧道配置
+func sshConfig(instance *model.Instance) sshtunnel.Config {
+	return sshtunnel.Config{
+		Host:             instance.SSH.Host,
+		Port:             instance.SSH.Port,
+		User:             instance.SSH.User,
+		Password:         instance.SSH.Password,
+		PrivateKey:       instance.SSH.PrivateKey,
+		KeyPassphrase:    instance.SSH.KeyPassphrase,
+		KnownHosts:       instance.SSH.KnownHosts,
+		SkipHostKeyCheck: instance.SSH.SkipHostKeyCheck,
+	}
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+
+	"my-bulker/internal/model"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// tlsConfigs 已注册的 TLS 配置名称
+var tlsConfigs sync.Map
+
+// tlsParam 返回 DSN 中 tls 参数的值，未设置 TLS 模式时返回空字符串
+// 需要证书的模式按配置内容注册自定义 TLS 配置，相同配置复用同一名称。
+func tlsParam(instance *model.Instance) (string, error) {
+	settings := instance.TLS
+	switch settings.Mode {
+	case "":
+		return "", nil
+	case model.TLSModeDisabled:
+		return "false", nil
+	case model.TLSModePreferred:
+		// 驱动的 preferred 模式不携带客户端证书，避免证书被静默忽略
+		if settings.Cert != "" || settings.Key != "" {
+			return "", errors.New("PREFERRED 模式不支持客户端证书，请使用 REQUIRED 或更严格的模式")
+		}
+		return "preferred", nil
+	case model.TLSModeRequired:
+		if settings.Cert == "" && settings.Key == "" {
+			return "skip-verify", nil
+		}
+	case model.TLSModeVerifyCA, model.TLSModeVerifyIdentity:
+	default:
+		return "", fmt.Errorf("不支持的 TLS 模式: %s", settings.Mode)
+	}
+
+	sum := sha256.Sum256([]byte(settings.Mode + "\x00" + instance.Host + "\x00" + settings.CA + "\x00" + settings.Cert + "\x00" + settings.Key))
+	name := "tls-" + hex.EncodeToString(sum[:8])
+	if _, ok := tlsConfigs.Load(name); ok {
+		return name, nil
+	}
+
+	config, err := buildTLSConfig(instance.Host, settings)
+	if err != nil {
+		return "", err
+	}
+	if err := mysql.RegisterTLSConfig(name, config); err != nil {
+		return "", err
+	}
+	tlsConfigs.Store(name, struct{}{})
+	return name, nil
+}
+
+// buildTLSConfig 根据实例 TLS 配置构建 tls.Config
+func buildTLSConfig(host string, settings model.InstanceTLS) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if settings.Cert != "" || settings.Key != "" {
+		cert, err := tls.X509KeyPair([]byte(settings.Cert), []byte(settings.Key))
+		if err != nil {
+			return nil, fmt.Errorf("解析客户端证书失败: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	var roots *x509.CertPool
+	if settings.CA != "" {
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM([]byte(settings.CA)) {
+			return nil, errors.New("解析 CA 证书失败")
+		}
+	}
+
+	switch settings.Mode {
+	case model.TLSModeRequired:
+		config.InsecureSkipVerify = true
+	case model.TLSModeVerifyCA:
+		if roots == nil {
+			return nil, errors.New("verify-ca 模式需要提供 CA 证书")
+		}
+		// 只校验证书链，不校验主机名
+		config.InsecureSkipVerify = true
+		config.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return verifyChain(rawCerts, roots)
+		}
+	case model.TLSModeVerifyIdentity:
+		// 未提供 CA 时使用系统根证书
+		config.RootCAs = roots
+		config.ServerName = host
+	}
+	return config, nil
+}
+
+// verifyChain 校验服务端证书链由指定 CA 签发
+func verifyChain(rawCerts [][]byte, roots *x509.CertPool) error {
+	if len(rawCerts) == 0 {
+		return errors.New("服务端未提供证书")
+	}
+	certs := make([]*x509.Certificate, 0, len(rawCerts))
+	for _, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return fmt.Errorf("解析服务端证书失败: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	if _, err := certs[0].Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates}); err != nil {
+		return fmt.Errorf("服务端证书校验失败: %w", err)
+	}
+	return nil
+}
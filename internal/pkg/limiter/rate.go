@@ -0,0 +1,58 @@
+package limiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// KeyedRateLimiter 按键划分的速率限制器
+// 每个键按固定间隔发放执行机会（不允许突发），多个调用方共享同一个键的速率。
+type KeyedRateLimiter struct {
+	mu   sync.Mutex
+	next map[uint]time.Time
+	now  func() time.Time
+}
+
+// NewKeyedRateLimiter 创建按键划分的速率限制器
+func NewKeyedRateLimiter() *KeyedRateLimiter {
+	return &KeyedRateLimiter{
+		next: make(map[uint]time.Time),
+		now:  time.Now,
+	}
+}
+
+// Wait 等待指定键的下一个执行机会，perSecond 小于 1 表示不限制
+func (r *KeyedRateLimiter) Wait(ctx context.Context, key uint, perSecond int) error {
+	delay := r.reserve(key, perSecond)
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// reserve 预留下一个执行时间点，返回需要等待的时长
+func (r *KeyedRateLimiter) reserve(key uint, perSecond int) time.Duration {
+	if perSecond < 1 {
+		return 0
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	at := r.next[key]
+	if at.Before(now) {
+		at = now
+	}
+	r.next[key] = at.Add(time.Second / time.Duration(perSecond))
+	return at.Sub(now)
+}
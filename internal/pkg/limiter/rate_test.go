@@ -0,0 +1,38 @@
+package limiter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeyedRateLimiterReserve(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := NewKeyedRateLimiter()
+	r.now = func() time.Time { return base }
+
+	tests := []struct {
+		name      string
+		key       uint
+		perSecond int
+		want      time.Duration
+	}{
+		{name: "unlimited", key: 1, perSecond: 0, want: 0},
+		{name: "first call runs immediately", key: 1, perSecond: 4, want: 0},
+		{name: "second call waits one interval", key: 1, perSecond: 4, want: 250 * time.Millisecond},
+		{name: "third call waits two intervals", key: 1, perSecond: 4, want: 500 * time.Millisecond},
+		{name: "other key is independent", key: 2, perSecond: 4, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.reserve(tt.key, tt.perSecond); got != tt.want {
+				t.Fatalf("reserve() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// 时间推进后不累积历史配额
+	r.now = func() time.Time { return base.Add(10 * time.Second) }
+	if got := r.reserve(1, 4); got != 0 {
+		t.Fatalf("reserve() after idle = %v, want 0", got)
+	}
+}
@@ -0,0 +1,131 @@
+package limiter
+
+import (
+	"context"
+	"sync"
+)
+
+// KeyedSemaphore 按键划分的计数信号量
+// 每个键（如实例ID）独立计数，等待者按先来先得的顺序获得名额，
+// 保证多个任务共享同一个实例时不会被某个任务长期占满。
+type KeyedSemaphore struct {
+	mu    sync.Mutex
+	slots map[uint]*slot
+}
+
+type slot struct {
+	limit   int
+	inUse   int
+	waiters []chan struct{}
+}
+
+// SlotStats 单个键的占用情况
+type SlotStats struct {
+	Key     uint `json:"key"`
+	Limit   int  `json:"limit"`
+	InUse   int  `json:"in_use"`
+	Waiting int  `json:"waiting"`
+}
+
+// NewKeyedSemaphore 创建按键划分的信号量
+func NewKeyedSemaphore() *KeyedSemaphore {
+	return &KeyedSemaphore{slots: make(map[uint]*slot)}
+}
+
+// Acquire 获取指定键的一个名额，limit 为该键当前允许的最大并发数
+// limit 小于 1 时按 1 处理；上下文取消时返回其错误且不占用名额
+func (s *KeyedSemaphore) Acquire(ctx context.Context, key uint, limit int) error {
+	if limit < 1 {
+		limit = 1
+	}
+
+	s.mu.Lock()
+	sl, ok := s.slots[key]
+	if !ok {
+		sl = &slot{}
+		s.slots[key] = sl
+	}
+	// 以最近一次传入的上限为准，便于配置修改后即时生效；上限调大时先按排队顺序唤醒已在等待的请求
+	sl.limit = limit
+	sl.wake()
+	if sl.inUse < sl.limit && len(sl.waiters) == 0 {
+		sl.inUse++
+		s.mu.Unlock()
+		return nil
+	}
+	ready := make(chan struct{})
+	sl.waiters = append(sl.waiters, ready)
+	s.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		select {
+		case <-ready:
+			// 取消与唤醒同时发生，名额已转交给本等待者，需要归还
+			s.releaseLocked(key)
+		default:
+			for i, w := range sl.waiters {
+				if w == ready {
+					sl.waiters = append(sl.waiters[:i], sl.waiters[i+1:]...)
+					break
+				}
+			}
+			s.dispatchLocked(key, sl)
+		}
+		return ctx.Err()
+	}
+}
+
+// Release 归还指定键的一个名额
+func (s *KeyedSemaphore) Release(key uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.releaseLocked(key)
+}
+
+func (s *KeyedSemaphore) releaseLocked(key uint) {
+	sl, ok := s.slots[key]
+	if !ok || sl.inUse == 0 {
+		return
+	}
+	sl.inUse--
+	s.dispatchLocked(key, sl)
+}
+
+// dispatchLocked 唤醒等待者，没有占用和等待时删除该键，避免每个用过的键都留下空记录
+func (s *KeyedSemaphore) dispatchLocked(key uint, sl *slot) {
+	sl.wake()
+	if sl.inUse == 0 && len(sl.waiters) == 0 && s.slots[key] == sl {
+		delete(s.slots, key)
+	}
+}
+
+// wake 按排队顺序唤醒等待者，直到占满上限
+func (sl *slot) wake() {
+	for sl.inUse < sl.limit && len(sl.waiters) > 0 {
+		next := sl.waiters[0]
+		sl.waiters = sl.waiters[1:]
+		sl.inUse++
+		close(next)
+	}
+}
+
+// Stats 返回当前所有键的占用情况
+func (s *KeyedSemaphore) Stats() []SlotStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := make([]SlotStats, 0, len(s.slots))
+	for key, sl := range s.slots {
+		stats = append(stats, SlotStats{
+			Key:     key,
+			Limit:   sl.limit,
+			InUse:   sl.inUse,
+			Waiting: len(sl.waiters),
+		})
+	}
+	return stats
+}
@@ -0,0 +1,131 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestKeyedSemaphoreLimit(t *testing.T) {
+	s := NewKeyedSemaphore()
+	ctx := context.Background()
+
+	if err := s.Acquire(ctx, 1, 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.Acquire(ctx, 1, 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 其他键不受影响
+	if err := s.Acquire(ctx, 2, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := s.Acquire(timeoutCtx, 1, 2); err == nil {
+		t.Fatalf("expected acquire to block when limit reached")
+	}
+
+	s.Release(1)
+	if err := s.Acquire(ctx, 1, 2); err != nil {
+		t.Fatalf("unexpected error after release: %v", err)
+	}
+}
+
+func TestKeyedSemaphoreFIFO(t *testing.T) {
+	s := NewKeyedSemaphore()
+	ctx := context.Background()
+	if err := s.Acquire(ctx, 1, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	order := make(chan int, 3)
+	for i := 0; i < 3; i++ {
+		go func(n int) {
+			_ = s.Acquire(ctx, 1, 1)
+			order <- n
+			s.Release(1)
+		}(i)
+		// 等待 goroutine 进入等待队列，保证排队顺序确定
+		waitForWaiters(t, s, 1, i+1)
+	}
+
+	s.Release(1)
+	for want := 0; want < 3; want++ {
+		if got := <-order; got != want {
+			t.Fatalf("expected waiter %d, got %d", want, got)
+		}
+	}
+}
+
+func TestKeyedSemaphoreCancelDoesNotLeak(t *testing.T) {
+	s := NewKeyedSemaphore()
+	ctx := context.Background()
+	_ = s.Acquire(ctx, 1, 1)
+
+	cancelCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() { done <- s.Acquire(cancelCtx, 1, 1) }()
+	waitForWaiters(t, s, 1, 1)
+	cancel()
+	if err := <-done; err == nil {
+		t.Fatalf("expected cancel error")
+	}
+
+	s.Release(1)
+	if stats := s.Stats(); len(stats) != 0 {
+		t.Fatalf("expected no remaining slots, got %+v", stats)
+	}
+}
+
+func TestKeyedSemaphoreRaiseLimitWakesWaiters(t *testing.T) {
+	s := NewKeyedSemaphore()
+	ctx := context.Background()
+	_ = s.Acquire(ctx, 1, 1)
+
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() { done <- s.Acquire(ctx, 1, 1) }()
+		waitForWaiters(t, s, 1, i+1)
+	}
+
+	// 上限调大到 4：已排队的两个请求和新请求都应立即获得名额，无需等待持有者归还
+	if err := s.Acquire(ctx, 1, 4); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("queued waiter was not woken after the limit was raised")
+		}
+	}
+	if stats := s.Stats(); len(stats) != 1 || stats[0].InUse != 4 || stats[0].Waiting != 0 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	for i := 0; i < 4; i++ {
+		s.Release(1)
+	}
+	if stats := s.Stats(); len(stats) != 0 {
+		t.Fatalf("expected no remaining slots, got %+v", stats)
+	}
+}
+
+func waitForWaiters(t *testing.T, s *KeyedSemaphore, key uint, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		for _, st := range s.Stats() {
+			if st.Key == key && st.Waiting >= n {
+				return
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d waiters", n)
+}
@@ -0,0 +1,88 @@
+// Package namepattern 匹配数据库名称模式，用于按名称批量选择数据库。
+//
+// 支持两种写法：
+//
+//	tenant_%      通配符：% 或 * 匹配任意个字符，? 匹配单个字符，需完整匹配名称
+//	/^t_\d+$/     正则：以斜杠包裹，按 Go 正则语法在名称中查找
+//
+// 与 SQL LIKE 不同，通配符中的 _ 按字面匹配，便于书写 tenant_test_% 这类名称。
+package namepattern
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Pattern 已编译的名称模式
+type Pattern struct {
+	source string
+	re     *regexp.Regexp
+}
+
+// Compile 编译名称模式
+func Compile(pattern string) (*Pattern, error) {
+	source := strings.TrimSpace(pattern)
+	if source == "" {
+		return nil, errors.New("名称模式不能为空")
+	}
+
+	if len(source) >= 2 && strings.HasPrefix(source, "/") && strings.HasSuffix(source, "/") {
+		re, err := regexp.Compile(source[1 : len(source)-1])
+		if err != nil {
+			return nil, fmt.Errorf("正则 %s 无效: %v", source, err)
+		}
+		return &Pattern{source: source, re: re}, nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("^")
+	for _, r := range source {
+		switch r {
+		case '%', '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+	return &Pattern{source: source, re: regexp.MustCompile(sb.String())}, nil
+}
+
+// Match 判断名称是否匹配
+func (p *Pattern) Match(name string) bool {
+	return p.re.MatchString(name)
+}
+
+func (p *Pattern) String() string {
+	return p.source
+}
+
+// Set 一组名称模式，任意一个匹配即视为匹配
+type Set []*Pattern
+
+// CompileSet 编译一组名称模式
+func CompileSet(patterns []string) (Set, error) {
+	set := make(Set, 0, len(patterns))
+	for _, pattern := range patterns {
+		p, err := Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, p)
+	}
+	return set, nil
+}
+
+// Match 判断名称是否匹配其中任意一个模式
+func (s Set) Match(name string) bool {
+	for _, p := range s {
+		if p.Match(name) {
+			return true
+		}
+	}
+	return false
+}
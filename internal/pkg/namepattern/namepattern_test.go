@@ -0,0 +1,73 @@
+package namepattern
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{pattern: "tenant_%", name: "tenant_acme", want: true},
+		{pattern: "tenant_%", name: "tenant_", want: true},
+		{pattern: "tenant_%", name: "tenantX", want: false},
+		{pattern: "tenant_%", name: "old_tenant_acme", want: false},
+		{pattern: "tenant_*", name: "tenant_acme", want: true},
+		{pattern: "shard_?", name: "shard_1", want: true},
+		{pattern: "shard_?", name: "shard_12", want: false},
+		{pattern: "app", name: "app", want: true},
+		{pattern: "app", name: "app_v2", want: false},
+		{pattern: "a.b", name: "axb", want: false},
+		{pattern: "a.b", name: "a.b", want: true},
+		{pattern: "  tenant_%  ", name: "tenant_acme", want: true},
+		{pattern: `/^tenant_\d+$/`, name: "tenant_42", want: true},
+		{pattern: `/^tenant_\d+$/`, name: "tenant_x", want: false},
+		{pattern: `/test/`, name: "tenant_test_1", want: true},
+		{pattern: "acme/%.db", name: "acme/app.db", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+"~"+tt.name, func(t *testing.T) {
+			p, err := Compile(tt.pattern)
+			if err != nil {
+				t.Fatalf("Compile(%q) error: %v", tt.pattern, err)
+			}
+			if got := p.Match(tt.name); got != tt.want {
+				t.Errorf("Compile(%q).Match(%q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, pattern := range []string{"", "   ", "/[a-/"} {
+		if _, err := Compile(pattern); err == nil {
+			t.Errorf("Compile(%q) succeeded, want error", pattern)
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	set, err := CompileSet([]string{"tenant_test_%", "/_tmp$/"})
+	if err != nil {
+		t.Fatalf("CompileSet() error: %v", err)
+	}
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "tenant_test_acme", want: true},
+		{name: "tenant_acme_tmp", want: true},
+		{name: "tenant_acme", want: false},
+	}
+	for _, tt := range tests {
+		if got := set.Match(tt.name); got != tt.want {
+			t.Errorf("Set.Match(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if Set(nil).Match("anything") {
+		t.Error("empty Set matched, want no match")
+	}
+	if _, err := CompileSet([]string{"ok_%", "/(/"}); err == nil {
+		t.Error("CompileSet() with invalid regex succeeded, want error")
+	}
+}
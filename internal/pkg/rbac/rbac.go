@@ -0,0 +1,156 @@
+// Package rbac 按实例或实例标签授予角色，并判断用户能否对实例执行某类操作。
+//
+// 角色由低到高依次为：
+//
+//	viewer   查看实例和任务结果
+//	operator 运行只读任务
+//	writer   运行包含写入语句的任务
+//	admin    管理实例、查看实例密码
+//
+// 高级角色包含低级角色的全部权限。一条授权可以作用于全部实例、单个实例，
+// 或标签表达式匹配的实例；同一实例命中多条授权时取最高的角色。
+package rbac
+
+import (
+	"fmt"
+	"strings"
+
+	"my-bulker/internal/pkg/tagexpr"
+)
+
+// Role 角色
+type Role string
+
+const (
+	RoleViewer   Role = "viewer"
+	RoleOperator Role = "operator"
+	RoleWriter   Role = "writer"
+	RoleAdmin    Role = "admin"
+)
+
+var roleLevels = map[Role]int{
+	RoleViewer:   1,
+	RoleOperator: 2,
+	RoleWriter:   3,
+	RoleAdmin:    4,
+}
+
+// ParseRole 解析角色名称，不区分大小写
+func ParseRole(name string) (Role, error) {
+	role := Role(strings.ToLower(strings.TrimSpace(name)))
+	if _, ok := roleLevels[role]; !ok {
+		return "", fmt.Errorf("不支持的角色 %q，可选值为 viewer、operator、writer、admin", name)
+	}
+	return role, nil
+}
+
+// Action 对实例执行的操作，数值越大需要的角色越高
+type Action int
+
+const (
+	ActionView     Action = iota + 1 // 查看实例、任务结果及导出
+	ActionRunRead                    // 创建和运行只读任务、同步元数据
+	ActionRunWrite                   // 创建、审批和运行包含写入语句的任务
+	ActionManage                     // 新建、修改、删除实例，查看密码，导入导出配置
+)
+
+// String 返回操作的中文描述，用于提示信息
+func (a Action) String() string {
+	switch a {
+	case ActionView:
+		return "查看"
+	case ActionRunRead:
+		return "执行只读任务"
+	case ActionRunWrite:
+		return "执行写入任务"
+	case ActionManage:
+		return "管理"
+	}
+	return fmt.Sprintf("Action(%d)", int(a))
+}
+
+// Allows 判断角色是否允许执行操作
+func (r Role) Allows(action Action) bool {
+	return roleLevels[r] >= int(action)
+}
+
+// Instance 被访问的实例，新建中的实例 ID 为 0
+type Instance struct {
+	ID   uint
+	Tags map[string]string
+}
+
+// Binding 一条授权。InstanceID 和 Tags 都为空时作用于全部实例
+type Binding struct {
+	Role       Role
+	InstanceID uint
+	Tags       tagexpr.Expr
+}
+
+// Global 判断授权是否作用于全部实例
+func (b Binding) Global() bool {
+	return b.InstanceID == 0 && b.Tags == nil
+}
+
+// Covers 判断授权是否作用于实例
+func (b Binding) Covers(instance Instance) bool {
+	switch {
+	case b.InstanceID != 0:
+		return instance.ID != 0 && b.InstanceID == instance.ID
+	case b.Tags != nil:
+		return b.Tags.Match(instance.Tags)
+	}
+	return true
+}
+
+// Policy 一个用户的全部授权。Superuser 为系统管理员，不受授权限制
+type Policy struct {
+	Superuser bool
+	Bindings  []Binding
+}
+
+// Role 返回用户在实例上的最高角色，没有任何授权时返回空字符串
+func (p Policy) Role(instance Instance) Role {
+	if p.Superuser {
+		return RoleAdmin
+	}
+	var best Role
+	for _, b := range p.Bindings {
+		if roleLevels[b.Role] > roleLevels[best] && b.Covers(instance) {
+			best = b.Role
+		}
+	}
+	return best
+}
+
+// Can 判断用户能否对实例执行操作
+func (p Policy) Can(action Action, instance Instance) bool {
+	return p.Role(instance).Allows(action)
+}
+
+// CanAll 判断用户能否对全部实例（包括之后新增的实例）执行操作，
+// 用于跳过逐个实例的检查。
+func (p Policy) CanAll(action Action) bool {
+	if p.Superuser {
+		return true
+	}
+	for _, b := range p.Bindings {
+		if b.Global() && b.Role.Allows(action) {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAny 判断用户是否在至少一个范围内拥有执行操作的角色
+func (p Policy) CanAny(action Action) bool {
+	if p.Superuser {
+		return true
+	}
+	for _, b := range p.Bindings {
+		if b.Role.Allows(action) {
+			return true
+		}
+	}
+	return false
+}
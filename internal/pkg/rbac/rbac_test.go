@@ -0,0 +1,210 @@
+package rbac
+
+import (
+	"testing"
+
+	"my-bulker/internal/pkg/tagexpr"
+)
+
+func mustTags(t *testing.T, input string) tagexpr.Expr {
+	t.Helper()
+	expr, err := tagexpr.Parse(input)
+	if err != nil {
+		t.Fatalf("tagexpr.Parse(%q) error: %v", input, err)
+	}
+	return expr
+}
+
+func TestRoleAllows(t *testing.T) {
+	tests := []struct {
+		role   Role
+		action Action
+		want   bool
+	}{
+		{role: RoleViewer, action: ActionView, want: true},
+		{role: RoleViewer, action: ActionRunRead, want: false},
+		{role: RoleOperator, action: ActionRunRead, want: true},
+		{role: RoleOperator, action: ActionRunWrite, want: false},
+		{role: RoleWriter, action: ActionRunWrite, want: true},
+		{role: RoleWriter, action: ActionManage, want: false},
+		{role: RoleAdmin, action: ActionManage, want: true},
+		{role: RoleAdmin, action: ActionView, want: true},
+		{role: "", action: ActionView, want: false},
+		{role: "owner", action: ActionView, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.role)+"/"+tt.action.String(), func(t *testing.T) {
+			if got := tt.role.Allows(tt.action); got != tt.want {
+				t.Errorf("%q.Allows(%v) = %v, want %v", tt.role, tt.action, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRole(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Role
+		wantErr bool
+	}{
+		{input: "viewer", want: RoleViewer},
+		{input: " Writer ", want: RoleWriter},
+		{input: "ADMIN", want: RoleAdmin},
+		{input: "", wantErr: true},
+		{input: "root", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRole(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRole(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseRole(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestPolicyCan(t *testing.T) {
+	prod := Instance{ID: 1, Tags: map[string]string{"env": "prod", "team": "pay"}}
+	staging := Instance{ID: 2, Tags: map[string]string{"env": "staging", "team": "pay"}}
+	untagged := Instance{ID: 3}
+	newProd := Instance{Tags: map[string]string{"env": "prod"}}
+
+	tests := []struct {
+		name     string
+		policy   Policy
+		action   Action
+		instance Instance
+		want     bool
+	}{
+		{name: "no bindings", policy: Policy{}, action: ActionView, instance: prod, want: false},
+		{name: "superuser", policy: Policy{Superuser: true}, action: ActionManage, instance: prod, want: true},
+		{
+			name:     "global viewer can view",
+			policy:   Policy{Bindings: []Binding{{Role: RoleViewer}}},
+			action:   ActionView,
+			instance: untagged,
+			want:     true,
+		},
+		{
+			name:     "global viewer cannot run",
+			policy:   Policy{Bindings: []Binding{{Role: RoleViewer}}},
+			action:   ActionRunRead,
+			instance: untagged,
+			want:     false,
+		},
+		{
+			name:     "instance binding matches id",
+			policy:   Policy{Bindings: []Binding{{Role: RoleWriter, InstanceID: 2}}},
+			action:   ActionRunWrite,
+			instance: staging,
+			want:     true,
+		},
+		{
+			name:     "instance binding other id",
+			policy:   Policy{Bindings: []Binding{{Role: RoleWriter, InstanceID: 2}}},
+			action:   ActionView,
+			instance: prod,
+			want:     false,
+		},
+		{
+			name:     "instance binding never covers new instance",
+			policy:   Policy{Bindings: []Binding{{Role: RoleAdmin, InstanceID: 2}}},
+			action:   ActionManage,
+			instance: Instance{},
+			want:     false,
+		},
+		{
+			name:     "tag binding matches",
+			policy:   Policy{Bindings: []Binding{{Role: RoleOperator, Tags: mustTags(t, "team=pay")}}},
+			action:   ActionRunRead,
+			instance: prod,
+			want:     true,
+		},
+		{
+			name:     "tag binding does not match untagged",
+			policy:   Policy{Bindings: []Binding{{Role: RoleOperator, Tags: mustTags(t, "team=pay")}}},
+			action:   ActionView,
+			instance: untagged,
+			want:     false,
+		},
+		{
+			name:     "tag admin can create matching instance",
+			policy:   Policy{Bindings: []Binding{{Role: RoleAdmin, Tags: mustTags(t, "env=prod")}}},
+			action:   ActionManage,
+			instance: newProd,
+			want:     true,
+		},
+		{
+			name: "highest matching role wins",
+			policy: Policy{Bindings: []Binding{
+				{Role: RoleViewer},
+				{Role: RoleWriter, Tags: mustTags(t, "env=staging")},
+			}},
+			action:   ActionRunWrite,
+			instance: staging,
+			want:     true,
+		},
+		{
+			name: "higher role on other scope does not leak",
+			policy: Policy{Bindings: []Binding{
+				{Role: RoleViewer},
+				{Role: RoleWriter, Tags: mustTags(t, "env=staging")},
+			}},
+			action:   ActionRunWrite,
+			instance: prod,
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Can(tt.action, tt.instance); got != tt.want {
+				t.Errorf("Can(%v) = %v, want %v", tt.action, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyCanAll(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		action Action
+		want   bool
+	}{
+		{name: "superuser", policy: Policy{Superuser: true}, action: ActionManage, want: true},
+		{name: "global operator runs reads", policy: Policy{Bindings: []Binding{{Role: RoleOperator}}}, action: ActionRunRead, want: true},
+		{name: "global operator cannot write", policy: Policy{Bindings: []Binding{{Role: RoleOperator}}}, action: ActionRunWrite, want: false},
+		{name: "scoped admin is not global", policy: Policy{Bindings: []Binding{{Role: RoleAdmin, Tags: mustTags(t, "env")}}}, action: ActionView, want: false},
+		{name: "instance admin is not global", policy: Policy{Bindings: []Binding{{Role: RoleAdmin, InstanceID: 1}}}, action: ActionView, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.CanAll(tt.action); got != tt.want {
+				t.Errorf("CanAll(%v) = %v, want %v", tt.action, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyCanAny(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		action Action
+		want   bool
+	}{
+		{name: "no bindings", policy: Policy{}, action: ActionView, want: false},
+		{name: "superuser", policy: Policy{Superuser: true}, action: ActionManage, want: true},
+		{name: "instance admin", policy: Policy{Bindings: []Binding{{Role: RoleAdmin, InstanceID: 3}}}, action: ActionManage, want: true},
+		{name: "writers cannot manage", policy: Policy{Bindings: []Binding{{Role: RoleWriter}, {Role: RoleViewer, InstanceID: 1}}}, action: ActionManage, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.CanAny(tt.action); got != tt.want {
+				t.Errorf("CanAny(%v) = %v, want %v", tt.action, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+// Package retention 计算时间序列采样点的保留与降采样
+// 近期的点全部保留；更早的点每个序列每天只保留当天最后一个；超过保留期的点全部删除。
+package retention
+
+import (
+	"sort"
+	"time"
+)
+
+// Policy 保留策略
+type Policy struct {
+	Raw  time.Duration // 保留全部采样点的时长，更早的点降采样为每天一个
+	Keep time.Duration // 总保留时长，更早的点全部删除，0 表示不限
+}
+
+// Point 一个采样点
+type Point struct {
+	ID     uint      // 采样点ID
+	Series string    // 所属序列，如 实例ID/库名
+	At     time.Time // 采样时间
+}
+
+// Prune 返回按策略应删除的采样点ID，按ID升序排列
+// 按自然日分桶时使用 loc 所在时区，同一天同一时刻的多个点保留ID最大的一个。
+func Prune(points []Point, now time.Time, p Policy, loc *time.Location) []uint {
+	rawCutoff := now.Add(-p.Raw)
+	var keepCutoff time.Time
+	if p.Keep > 0 {
+		keepCutoff = now.Add(-p.Keep)
+	}
+
+	type bucket struct {
+		series string
+		day    string
+	}
+	latest := make(map[bucket]Point)
+	var drop []uint
+	for _, pt := range points {
+		if p.Keep > 0 && pt.At.Before(keepCutoff) {
+			drop = append(drop, pt.ID)
+			continue
+		}
+		if !pt.At.Before(rawCutoff) {
+			continue
+		}
+		key := bucket{series: pt.Series, day: pt.At.In(loc).Format(time.DateOnly)}
+		cur, ok := latest[key]
+		if !ok {
+			latest[key] = pt
+			continue
+		}
+		if pt.At.After(cur.At) || (pt.At.Equal(cur.At) && pt.ID > cur.ID) {
+			latest[key] = pt
+			drop = append(drop, cur.ID)
+		} else {
+			drop = append(drop, pt.ID)
+		}
+	}
+	sort.Slice(drop, func(i, j int) bool { return drop[i] < drop[j] })
+	return drop
+}
@@ -0,0 +1,98 @@
+package retention
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPrune(t *testing.T) {
+	loc := time.FixedZone("CST", 8*3600)
+	now := time.Date(2026, 3, 20, 12, 0, 0, 0, loc)
+	at := func(day, hour int) time.Time { return time.Date(2026, 3, day, hour, 0, 0, 0, loc) }
+	policy := Policy{Raw: 7 * 24 * time.Hour, Keep: 30 * 24 * time.Hour}
+
+	tests := []struct {
+		name   string
+		points []Point
+		policy Policy
+		want   []uint
+	}{
+		{name: "空", points: nil, policy: policy, want: nil},
+		{
+			name: "近期全部保留",
+			points: []Point{
+				{ID: 1, Series: "a", At: at(19, 1)},
+				{ID: 2, Series: "a", At: at(19, 2)},
+				{ID: 3, Series: "a", At: at(20, 3)},
+			},
+			policy: policy,
+			want:   nil,
+		},
+		{
+			name: "早期每天保留最后一个",
+			points: []Point{
+				{ID: 1, Series: "a", At: at(10, 1)},
+				{ID: 2, Series: "a", At: at(10, 23)},
+				{ID: 3, Series: "a", At: at(10, 5)},
+				{ID: 4, Series: "a", At: at(11, 1)},
+			},
+			policy: policy,
+			want:   []uint{1, 3},
+		},
+		{
+			name: "序列互不影响",
+			points: []Point{
+				{ID: 1, Series: "a", At: at(10, 1)},
+				{ID: 2, Series: "b", At: at(10, 2)},
+				{ID: 3, Series: "a", At: at(10, 3)},
+			},
+			policy: policy,
+			want:   []uint{1},
+		},
+		{
+			name: "按时区划分自然日",
+			points: []Point{
+				// 本地 3 月 11 日 00:30，UTC 仍为 3 月 10 日
+				{ID: 1, Series: "a", At: time.Date(2026, 3, 10, 16, 30, 0, 0, time.UTC)},
+				{ID: 2, Series: "a", At: at(10, 23)},
+			},
+			policy: policy,
+			want:   nil,
+		},
+		{
+			name: "相同时刻保留ID大的",
+			points: []Point{
+				{ID: 5, Series: "a", At: at(10, 1)},
+				{ID: 4, Series: "a", At: at(10, 1)},
+			},
+			policy: policy,
+			want:   []uint{4},
+		},
+		{
+			name: "超过保留期全部删除",
+			points: []Point{
+				{ID: 1, Series: "a", At: time.Date(2026, 2, 1, 1, 0, 0, 0, loc)},
+				{ID: 2, Series: "a", At: at(18, 1)},
+			},
+			policy: policy,
+			want:   []uint{1},
+		},
+		{
+			name: "不限保留期",
+			points: []Point{
+				{ID: 1, Series: "a", At: time.Date(2020, 1, 1, 0, 0, 0, 0, loc)},
+				{ID: 2, Series: "a", At: time.Date(2020, 1, 1, 1, 0, 0, 0, loc)},
+			},
+			policy: Policy{Raw: 7 * 24 * time.Hour},
+			want:   []uint{1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Prune(tt.points, now, tt.policy, loc); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Prune() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,393 @@
+package schemadiff
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"my-bulker/internal/model"
+)
+
+// mysqlRawDefault MySQL 返回的默认值中不需要加引号的部分：数字、NULL 及函数表达式
+var mysqlRawDefault = regexp.MustCompile(`(?i)^(-?\d+(\.\d+)?|null|true|false|(current_timestamp|localtime|localtimestamp)(\(\d*\))?|now\(\d*\)|\(.*\)|b'[01]*'|0x[0-9a-f]+)$`)
+
+// postgresSequenceDefault serial 等字段的默认值 nextval('users_id_seq'::regclass)，分组为序列名
+var postgresSequenceDefault = regexp.MustCompile(`(?i)^nextval\('((?:[^']|'')+)'::regclass\)$`)
+
+// AlterStatements 生成将 from 中已存在的表变更为与 to 一致的语句
+// 每张表依次删除索引、删除字段、新增字段、修改字段、新增索引；引擎不支持的变更、缺少或多出的表以注释说明。
+// dialect 为 model.EngineMySQL、model.EnginePostgres 或 model.EngineSQLite，空字符串视为 MySQL。
+func AlterStatements(dialect string, from, to []model.SchemaTable) []string {
+	return statements(dialect, from, to, false)
+}
+
+// MigrationStatements 生成将 from 整体变更为与 to 一致的语句，依次为缺少的表建表、变更同名表、删除多出的表。
+// 同名表的变更与 AlterStatements 相同；PostgreSQL 以序列为默认值的字段会先创建序列，外键和表选项不在采集的结构中，不会出现在建表语句里。
+func MigrationStatements(dialect string, from, to []model.SchemaTable) []string {
+	return statements(dialect, from, to, true)
+}
+
+func statements(dialect string, from, to []model.SchemaTable, full bool) []string {
+	g := ddlGen{dialect: dialect}
+	if g.dialect == "" {
+		g.dialect = model.EngineMySQL
+	}
+	from, to = Normalize(from), Normalize(to)
+	fromTables := make(map[string]model.SchemaTable, len(from))
+	for _, t := range from {
+		fromTables[t.Name] = t
+	}
+	toTables := make(map[string]bool, len(to))
+	var creates, alters, drops []string
+	for _, t := range to {
+		toTables[t.Name] = true
+		old, ok := fromTables[t.Name]
+		switch {
+		case ok:
+			alters = append(alters, g.alterTable(old, t)...)
+		case full:
+			creates = append(creates, g.createTable(t)...)
+		default:
+			creates = append(creates, fmt.Sprintf("-- 缺少表 %s，需要手动创建", t.Name))
+		}
+	}
+	for _, t := range from {
+		switch {
+		case toTables[t.Name]:
+		case full:
+			drops = append(drops, fmt.Sprintf("DROP TABLE %s;", g.quoteIdent(t.Name)))
+		default:
+			drops = append(drops, fmt.Sprintf("-- 多出表 %s，未生成删除语句", t.Name))
+		}
+	}
+	stmts := append(creates, alters...)
+	return append(stmts, drops...)
+}
+
+// ddlGen 按方言生成 DDL 语句
+type ddlGen struct {
+	dialect string
+}
+
+// alterTable 生成同名表之间的变更语句
+func (g ddlGen) alterTable(from, to model.SchemaTable) []string {
+	var drops, dropCols, addCols, modCols, adds []string
+	for _, c := range diffTable(from, to) {
+		switch c.Kind {
+		case TableChanged:
+			modCols = append(modCols, g.tableComment(to)...)
+		case ColumnAdded:
+			addCols = append(addCols, g.addColumn(to, c.Object))
+		case ColumnDropped:
+			dropCols = append(dropCols, g.dropColumn(to.Name, c.Object))
+		case ColumnChanged:
+			modCols = append(modCols, g.modifyColumn(to, findColumn(from, c.Object), findColumn(to, c.Object))...)
+		case IndexAdded:
+			adds = append(adds, g.addIndex(to.Name, findIndex(to, c.Object)))
+		case IndexDropped:
+			drops = append(drops, g.dropIndex(to.Name, findIndex(from, c.Object)))
+		case IndexChanged:
+			drops = append(drops, g.dropIndex(to.Name, findIndex(from, c.Object)))
+			adds = append(adds, g.addIndex(to.Name, findIndex(to, c.Object)))
+		}
+	}
+	var stmts []string
+	for _, group := range [][]string{drops, dropCols, addCols, modCols, adds} {
+		stmts = append(stmts, group...)
+	}
+	return stmts
+}
+
+// createTable 建表语句：主键和 SQLite 的唯一约束写在表定义中，其余索引和 PostgreSQL 的注释单独生成
+func (g ddlGen) createTable(t model.SchemaTable) []string {
+	defs := make([]string, 0, len(t.Columns)+len(t.Indexes))
+	for _, c := range t.Columns {
+		defs = append(defs, g.quoteIdent(c.Name)+" "+g.columnDef(c))
+	}
+	var after []string
+	for _, idx := range t.Indexes {
+		cols := "(" + g.quoteIdents(idx.Columns) + ")"
+		switch {
+		case hasExpression(idx):
+			after = append(after, g.addIndex(t.Name, idx))
+		case idx.Primary && g.dialect == model.EnginePostgres:
+			defs = append(defs, fmt.Sprintf("CONSTRAINT %s PRIMARY KEY %s", g.quoteIdent(idx.Name), cols))
+		case idx.Primary:
+			defs = append(defs, "PRIMARY KEY "+cols)
+		case g.dialect == model.EngineSQLite && strings.HasPrefix(idx.Name, "sqlite_autoindex_"):
+			defs = append(defs, "UNIQUE "+cols)
+		case g.dialect == model.EngineMySQL && idx.Unique:
+			defs = append(defs, fmt.Sprintf("UNIQUE KEY %s %s", g.quoteIdent(idx.Name), cols))
+		case g.dialect == model.EngineMySQL:
+			defs = append(defs, fmt.Sprintf("KEY %s %s", g.quoteIdent(idx.Name), cols))
+		default:
+			after = append(after, g.addIndex(t.Name, idx))
+		}
+	}
+	stmt := fmt.Sprintf("CREATE TABLE %s (\n  %s\n)", g.quoteIdent(t.Name), strings.Join(defs, ",\n  "))
+	if g.dialect == model.EngineMySQL && t.Comment != "" {
+		stmt += " COMMENT = " + quote(t.Comment)
+	}
+	var stmts []string
+	for _, c := range t.Columns {
+		if create, _, ok := g.sequence(t.Name, c); ok {
+			stmts = append(stmts, create)
+		}
+	}
+	stmts = append(stmts, stmt+";")
+	stmts = append(stmts, after...)
+	if g.dialect == model.EnginePostgres {
+		for _, c := range t.Columns {
+			if _, own, ok := g.sequence(t.Name, c); ok {
+				stmts = append(stmts, own)
+			}
+		}
+		if t.Comment != "" {
+			stmts = append(stmts, g.tableComment(t)...)
+		}
+		for _, c := range t.Columns {
+			if c.Comment != "" {
+				stmts = append(stmts, g.columnComment(t.Name, c))
+			}
+		}
+	}
+	return stmts
+}
+
+func (g ddlGen) quoteIdent(name string) string {
+	if g.dialect == model.EngineMySQL {
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (g ddlGen) quoteIdents(names []string) string {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = g.quoteIdent(n)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// defaultValue 默认值在 DDL 中的写法，MySQL 返回的是值本身，需要为字符串加引号
+func (g ddlGen) defaultValue(v string) string {
+	if g.dialect == model.EngineMySQL && !mysqlRawDefault.MatchString(v) {
+		return quote(v)
+	}
+	if v == "" {
+		return "''"
+	}
+	return v
+}
+
+// columnDef 字段定义，不含字段名
+// MySQL 的 MODIFY COLUMN 会整体替换字段定义，排序规则、生成列、自增和 ON UPDATE 属性都需要写出，否则会被去掉。
+func (g ddlGen) columnDef(c model.SchemaColumn) string {
+	s := c.Type
+	extra := strings.ToLower(c.Extra)
+	if g.dialect == model.EngineMySQL {
+		if c.Collation != "" {
+			s += " COLLATE " + c.Collation
+		}
+		if c.Generated != "" {
+			kind := "VIRTUAL"
+			if strings.Contains(extra, "stored") {
+				kind = "STORED"
+			}
+			s += fmt.Sprintf(" GENERATED ALWAYS AS (%s) %s", c.Generated, kind)
+		}
+	}
+	if !c.Nullable {
+		s += " NOT NULL"
+	} else if g.dialect == model.EngineMySQL {
+		s += " NULL"
+	}
+	if c.Default != nil && c.Generated == "" {
+		s += " DEFAULT " + g.defaultValue(*c.Default)
+	}
+	if g.dialect == model.EngineMySQL {
+		if strings.Contains(extra, "auto_increment") {
+			s += " AUTO_INCREMENT"
+		}
+		if i := strings.Index(extra, "on update "); i >= 0 {
+			s += " ON UPDATE " + c.Extra[i+len("on update "):]
+		}
+		if strings.Contains(extra, "invisible") {
+			s += " INVISIBLE"
+		}
+		if c.Comment != "" {
+			s += " COMMENT " + quote(c.Comment)
+		}
+	}
+	return s
+}
+
+func (g ddlGen) alter(table, clause string) string {
+	return fmt.Sprintf("ALTER TABLE %s %s;", g.quoteIdent(table), clause)
+}
+
+func (g ddlGen) addColumn(t model.SchemaTable, name string) string {
+	col := findColumn(t, name)
+	clause := "ADD COLUMN " + g.quoteIdent(name) + " " + g.columnDef(col)
+	if g.dialect == model.EngineMySQL {
+		// 保持与参照表相同的字段顺序
+		for i, c := range t.Columns {
+			if c.Name != name {
+				continue
+			}
+			if i == 0 {
+				clause += " FIRST"
+			} else {
+				clause += " AFTER " + g.quoteIdent(t.Columns[i-1].Name)
+			}
+		}
+	}
+	stmt := g.alter(t.Name, clause)
+	if create, own, ok := g.sequence(t.Name, col); ok {
+		stmt = create + "\n" + stmt + "\n" + own
+	}
+	if g.dialect == model.EnginePostgres && col.Comment != "" {
+		stmt += "\n" + g.columnComment(t.Name, col)
+	}
+	return stmt
+}
+
+// sequence PostgreSQL 字段以序列为默认值时，返回需要在使用前创建序列的语句和将序列归属到该字段的语句
+// 序列名按默认值中的写法原样使用，归属字段后删除表或字段时序列随之删除，与 serial 字段一致。
+func (g ddlGen) sequence(table string, c model.SchemaColumn) (create, own string, ok bool) {
+	if g.dialect != model.EnginePostgres || c.Default == nil {
+		return "", "", false
+	}
+	m := postgresSequenceDefault.FindStringSubmatch(strings.TrimSpace(*c.Default))
+	if m == nil {
+		return "", "", false
+	}
+	name := strings.ReplaceAll(m[1], "''", "'")
+	create = fmt.Sprintf("CREATE SEQUENCE IF NOT EXISTS %s;", name)
+	own = fmt.Sprintf("ALTER SEQUENCE %s OWNED BY %s.%s;", name, g.quoteIdent(table), g.quoteIdent(c.Name))
+	return create, own, true
+}
+
+func (g ddlGen) dropColumn(table, name string) string {
+	return g.alter(table, "DROP COLUMN "+g.quoteIdent(name))
+}
+
+func (g ddlGen) modifyColumn(t model.SchemaTable, from, to model.SchemaColumn) []string {
+	switch g.dialect {
+	case model.EngineMySQL:
+		return []string{g.alter(t.Name, "MODIFY COLUMN "+g.quoteIdent(to.Name)+" "+g.columnDef(to))}
+	case model.EnginePostgres:
+		col := g.quoteIdent(to.Name)
+		var stmts []string
+		if from.Type != to.Type {
+			stmts = append(stmts, g.alter(t.Name, fmt.Sprintf("ALTER COLUMN %s TYPE %s", col, to.Type)))
+		}
+		if from.Nullable != to.Nullable {
+			if to.Nullable {
+				stmts = append(stmts, g.alter(t.Name, fmt.Sprintf("ALTER COLUMN %s DROP NOT NULL", col)))
+			} else {
+				stmts = append(stmts, g.alter(t.Name, fmt.Sprintf("ALTER COLUMN %s SET NOT NULL", col)))
+			}
+		}
+		if !sameDefault(from.Default, to.Default) {
+			if to.Default == nil {
+				stmts = append(stmts, g.alter(t.Name, fmt.Sprintf("ALTER COLUMN %s DROP DEFAULT", col)))
+			} else if create, own, ok := g.sequence(t.Name, to); ok {
+				stmts = append(stmts, create, g.alter(t.Name, fmt.Sprintf("ALTER COLUMN %s SET DEFAULT %s", col, *to.Default)), own)
+			} else {
+				stmts = append(stmts, g.alter(t.Name, fmt.Sprintf("ALTER COLUMN %s SET DEFAULT %s", col, g.defaultValue(*to.Default))))
+			}
+		}
+		if from.Comment != to.Comment {
+			stmts = append(stmts, g.columnComment(t.Name, to))
+		}
+		return stmts
+	}
+	return []string{fmt.Sprintf("-- SQLite 不支持修改字段 %s.%s（%s → %s），需要重建表", t.Name, to.Name, DescribeColumn(from), DescribeColumn(to))}
+}
+
+func (g ddlGen) columnComment(table string, c model.SchemaColumn) string {
+	return fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s;", g.quoteIdent(table), g.quoteIdent(c.Name), quote(c.Comment))
+}
+
+func (g ddlGen) tableComment(t model.SchemaTable) []string {
+	switch g.dialect {
+	case model.EngineMySQL:
+		return []string{g.alter(t.Name, "COMMENT = "+quote(t.Comment))}
+	case model.EnginePostgres:
+		return []string{fmt.Sprintf("COMMENT ON TABLE %s IS %s;", g.quoteIdent(t.Name), quote(t.Comment))}
+	}
+	return nil
+}
+
+func (g ddlGen) addIndex(table string, idx model.SchemaIndex) string {
+	if hasExpression(idx) {
+		return fmt.Sprintf("-- 索引 %s.%s 包含表达式，需要手动创建", table, idx.Name)
+	}
+	cols := "(" + g.quoteIdents(idx.Columns) + ")"
+	switch {
+	case idx.Primary && g.dialect == model.EngineSQLite:
+		return fmt.Sprintf("-- SQLite 不支持修改主键 %s%s，需要重建表", table, cols)
+	case idx.Primary:
+		return g.alter(table, "ADD PRIMARY KEY "+cols)
+	case g.dialect == model.EngineSQLite && strings.HasPrefix(idx.Name, "sqlite_autoindex_"):
+		return fmt.Sprintf("-- SQLite 的唯一约束 %s%s 需要在建表时定义，需要重建表", table, cols)
+	}
+	kind := "INDEX"
+	if idx.Unique {
+		kind = "UNIQUE INDEX"
+	}
+	if g.dialect == model.EngineMySQL {
+		return g.alter(table, fmt.Sprintf("ADD %s %s %s", kind, g.quoteIdent(idx.Name), cols))
+	}
+	return fmt.Sprintf("CREATE %s %s ON %s %s;", kind, g.quoteIdent(idx.Name), g.quoteIdent(table), cols)
+}
+
+func (g ddlGen) dropIndex(table string, idx model.SchemaIndex) string {
+	switch {
+	case g.dialect == model.EngineSQLite && (idx.Primary || strings.HasPrefix(idx.Name, "sqlite_autoindex_")):
+		return fmt.Sprintf("-- SQLite 不支持删除约束 %s.%s，需要重建表", table, idx.Name)
+	case idx.Primary && g.dialect == model.EngineMySQL:
+		return g.alter(table, "DROP PRIMARY KEY")
+	case idx.Primary:
+		return g.alter(table, "DROP CONSTRAINT "+g.quoteIdent(idx.Name))
+	case g.dialect == model.EngineMySQL:
+		return g.alter(table, "DROP INDEX "+g.quoteIdent(idx.Name))
+	}
+	return fmt.Sprintf("DROP INDEX %s;", g.quoteIdent(idx.Name))
+}
+
+// hasExpression 表达式索引的字段名为空
+func hasExpression(idx model.SchemaIndex) bool {
+	for _, c := range idx.Columns {
+		if c == "" {
+			return true
+		}
+	}
+	return len(idx.Columns) == 0
+}
+
+func sameDefault(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func findColumn(t model.SchemaTable, name string) model.SchemaColumn {
+	for _, c := range t.Columns {
+		if c.Name == name {
+			return c
+		}
+	}
+	return model.SchemaColumn{Name: name}
+}
+
+func findIndex(t model.SchemaTable, name string) model.SchemaIndex {
+	for _, idx := range t.Indexes {
+		if idx.Name == name {
+			return idx
+		}
+	}
+	return model.SchemaIndex{Name: name}
+}
@@ -0,0 +1,235 @@
+package schemadiff
+
+import (
+	"reflect"
+	"testing"
+
+	"my-bulker/internal/model"
+)
+
+func TestAlterStatements(t *testing.T) {
+	// 目标库相对参照库：email 类型较短且可空，缺少 age 字段，多出 legacy 字段，索引 idx_email 不唯一，多出 idx_legacy
+	reference := []model.SchemaTable{users(), {Name: "orders", Columns: []model.SchemaColumn{{Name: "id", Type: "int"}}}}
+	reference[0].Columns = append(reference[0].Columns, model.SchemaColumn{Name: "age", Type: "int", Default: strPtr("0"), Comment: "年龄"})
+	target := []model.SchemaTable{users(), {Name: "logs", Columns: []model.SchemaColumn{{Name: "id", Type: "int"}}}}
+	target[0].Columns[1] = model.SchemaColumn{Name: "email", Type: "varchar(32)", Nullable: true}
+	target[0].Columns = append(target[0].Columns, model.SchemaColumn{Name: "legacy", Type: "text", Nullable: true})
+	target[0].Indexes[1].Unique = false
+	target[0].Indexes = append(target[0].Indexes, model.SchemaIndex{Name: "idx_legacy", Columns: []string{"legacy"}})
+
+	tests := []struct {
+		dialect string
+		want    []string
+	}{
+		{
+			dialect: model.EngineMySQL,
+			want: []string{
+				"-- 缺少表 orders，需要手动创建",
+				"ALTER TABLE `users` DROP INDEX `idx_email`;",
+				"ALTER TABLE `users` DROP INDEX `idx_legacy`;",
+				"ALTER TABLE `users` DROP COLUMN `legacy`;",
+				"ALTER TABLE `users` ADD COLUMN `age` int NOT NULL DEFAULT 0 COMMENT '年龄' AFTER `name`;",
+				"ALTER TABLE `users` MODIFY COLUMN `email` varchar(64) NOT NULL DEFAULT '';",
+				"ALTER TABLE `users` ADD UNIQUE INDEX `idx_email` (`email`);",
+				"-- 多出表 logs，未生成删除语句",
+			},
+		},
+		{
+			dialect: model.EnginePostgres,
+			want: []string{
+				"-- 缺少表 orders，需要手动创建",
+				`DROP INDEX "idx_email";`,
+				`DROP INDEX "idx_legacy";`,
+				`ALTER TABLE "users" DROP COLUMN "legacy";`,
+				"ALTER TABLE \"users\" ADD COLUMN \"age\" int NOT NULL DEFAULT 0;\nCOMMENT ON COLUMN \"users\".\"age\" IS '年龄';",
+				`ALTER TABLE "users" ALTER COLUMN "email" TYPE varchar(64);`,
+				`ALTER TABLE "users" ALTER COLUMN "email" SET NOT NULL;`,
+				`ALTER TABLE "users" ALTER COLUMN "email" SET DEFAULT '';`,
+				`CREATE UNIQUE INDEX "idx_email" ON "users" ("email");`,
+				"-- 多出表 logs，未生成删除语句",
+			},
+		},
+		{
+			dialect: model.EngineSQLite,
+			want: []string{
+				"-- 缺少表 orders，需要手动创建",
+				`DROP INDEX "idx_email";`,
+				`DROP INDEX "idx_legacy";`,
+				`ALTER TABLE "users" DROP COLUMN "legacy";`,
+				`ALTER TABLE "users" ADD COLUMN "age" int NOT NULL DEFAULT 0;`,
+				"-- SQLite 不支持修改字段 users.email（varchar(32) → varchar(64) NOT NULL DEFAULT ''），需要重建表",
+				`CREATE UNIQUE INDEX "idx_email" ON "users" ("email");`,
+				"-- 多出表 logs，未生成删除语句",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dialect, func(t *testing.T) {
+			got := AlterStatements(tt.dialect, target, reference)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AlterStatements() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAlterStatementsConstraints(t *testing.T) {
+	withPK := []model.SchemaTable{users()}
+	withoutPK := []model.SchemaTable{users()}
+	withoutPK[0].Indexes = withoutPK[0].Indexes[1:]
+	expr := []model.SchemaTable{users()}
+	expr[0].Indexes = append(expr[0].Indexes, model.SchemaIndex{Name: "idx_lower", Columns: []string{""}})
+
+	tests := []struct {
+		name     string
+		dialect  string
+		from, to []model.SchemaTable
+		want     []string
+	}{
+		{name: "MySQL 新增主键", dialect: "", from: withoutPK, to: withPK, want: []string{"ALTER TABLE `users` ADD PRIMARY KEY (`id`);"}},
+		{name: "MySQL 删除主键", dialect: model.EngineMySQL, from: withPK, to: withoutPK, want: []string{"ALTER TABLE `users` DROP PRIMARY KEY;"}},
+		{name: "PostgreSQL 删除主键", dialect: model.EnginePostgres, from: withPK, to: withoutPK, want: []string{`ALTER TABLE "users" DROP CONSTRAINT "PRIMARY";`}},
+		{name: "SQLite 新增主键", dialect: model.EngineSQLite, from: withoutPK, to: withPK, want: []string{"-- SQLite 不支持修改主键 users(\"id\")，需要重建表"}},
+		{name: "表达式索引", dialect: model.EngineMySQL, from: withPK, to: expr, want: []string{"-- 索引 users.idx_lower 包含表达式，需要手动创建"}},
+		{name: "无差异", dialect: model.EngineMySQL, from: withPK, to: withPK, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AlterStatements(tt.dialect, tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AlterStatements() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrationStatements(t *testing.T) {
+	// 目标库只有 logs 表，参照库只有 users 表：建 users、删 logs
+	table := users()
+	table.Comment = "用户"
+	table.Columns[2].Comment = "姓名"
+	table.Indexes = append(table.Indexes, model.SchemaIndex{Name: "idx_name", Columns: []string{"name"}})
+	reference := []model.SchemaTable{table}
+	target := []model.SchemaTable{{Name: "logs", Columns: []model.SchemaColumn{{Name: "id", Type: "int"}}}}
+
+	tests := []struct {
+		dialect string
+		want    []string
+	}{
+		{
+			dialect: model.EngineMySQL,
+			want: []string{
+				"CREATE TABLE `users` (\n  `id` bigint NOT NULL,\n  `email` varchar(64) NOT NULL DEFAULT '',\n  `name` varchar(32) NULL COMMENT '姓名',\n" +
+					"  PRIMARY KEY (`id`),\n  UNIQUE KEY `idx_email` (`email`),\n  KEY `idx_name` (`name`)\n) COMMENT = '用户';",
+				"DROP TABLE `logs`;",
+			},
+		},
+		{
+			dialect: model.EnginePostgres,
+			want: []string{
+				"CREATE TABLE \"users\" (\n  \"id\" bigint NOT NULL,\n  \"email\" varchar(64) NOT NULL DEFAULT '',\n  \"name\" varchar(32),\n" +
+					"  CONSTRAINT \"PRIMARY\" PRIMARY KEY (\"id\")\n);",
+				`CREATE UNIQUE INDEX "idx_email" ON "users" ("email");`,
+				`CREATE INDEX "idx_name" ON "users" ("name");`,
+				`COMMENT ON TABLE "users" IS '用户';`,
+				`COMMENT ON COLUMN "users"."name" IS '姓名';`,
+				`DROP TABLE "logs";`,
+			},
+		},
+		{
+			dialect: model.EngineSQLite,
+			want: []string{
+				"CREATE TABLE \"users\" (\n  \"id\" bigint NOT NULL,\n  \"email\" varchar(64) NOT NULL DEFAULT '',\n  \"name\" varchar(32),\n" +
+					"  PRIMARY KEY (\"id\")\n);",
+				`CREATE UNIQUE INDEX "idx_email" ON "users" ("email");`,
+				`CREATE INDEX "idx_name" ON "users" ("name");`,
+				`DROP TABLE "logs";`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dialect, func(t *testing.T) {
+			got := MigrationStatements(tt.dialect, target, reference)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MigrationStatements() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+
+	// 同名表的变更与 AlterStatements 一致
+	from := []model.SchemaTable{users()}
+	if got, want := MigrationStatements(model.EngineMySQL, from, reference), AlterStatements(model.EngineMySQL, from, reference); !reflect.DeepEqual(got, want) {
+		t.Errorf("MigrationStatements() = %q, want %q", got, want)
+	}
+}
+
+func TestPostgresSequenceDefault(t *testing.T) {
+	// 参照库 orders 的 id 为 serial 字段，users 新增了 serial 字段 seq
+	reference := []model.SchemaTable{
+		{Name: "orders", Columns: []model.SchemaColumn{{Name: "id", Type: "integer", Default: strPtr("nextval('orders_id_seq'::regclass)")}}},
+		{Name: "users", Columns: []model.SchemaColumn{
+			{Name: "id", Type: "bigint"},
+			{Name: "seq", Type: "bigint", Default: strPtr(`nextval('"Users_seq_seq"'::regclass)`)},
+		}},
+	}
+	target := []model.SchemaTable{{Name: "users", Columns: []model.SchemaColumn{{Name: "id", Type: "bigint"}}}}
+	want := []string{
+		"CREATE SEQUENCE IF NOT EXISTS orders_id_seq;",
+		"CREATE TABLE \"orders\" (\n  \"id\" integer NOT NULL DEFAULT nextval('orders_id_seq'::regclass)\n);",
+		`ALTER SEQUENCE orders_id_seq OWNED BY "orders"."id";`,
+		"CREATE SEQUENCE IF NOT EXISTS \"Users_seq_seq\";\n" +
+			"ALTER TABLE \"users\" ADD COLUMN \"seq\" bigint NOT NULL DEFAULT nextval('\"Users_seq_seq\"'::regclass);\n" +
+			`ALTER SEQUENCE "Users_seq_seq" OWNED BY "users"."seq";`,
+	}
+	if got := MigrationStatements(model.EnginePostgres, target, reference); !reflect.DeepEqual(got, want) {
+		t.Errorf("MigrationStatements() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestMySQLColumnAttributes(t *testing.T) {
+	// 目标库的字段缺少各项附加属性，MODIFY COLUMN 需要完整写出参照库的定义
+	reference := []model.SchemaTable{{Name: "events", Columns: []model.SchemaColumn{
+		{Name: "id", Type: "bigint unsigned", Extra: "auto_increment"},
+		{Name: "code", Type: "varchar(32)", Collation: "utf8mb4_bin", Default: strPtr("(uuid())")},
+		{Name: "updated_at", Type: "datetime(3)", Default: strPtr("CURRENT_TIMESTAMP(3)"), Extra: "on update CURRENT_TIMESTAMP(3)"},
+		{Name: "total", Type: "int", Nullable: true, Extra: "STORED GENERATED", Generated: "(`id` * 2)"},
+		{Name: "note", Type: "text", Nullable: true, Extra: "INVISIBLE", Comment: "备注"},
+	}}}
+	target := []model.SchemaTable{{Name: "events", Columns: []model.SchemaColumn{
+		{Name: "id", Type: "bigint unsigned"},
+		{Name: "code", Type: "varchar(32)", Default: strPtr("abc")},
+		{Name: "updated_at", Type: "datetime(3)", Default: strPtr("CURRENT_TIMESTAMP(3)")},
+		{Name: "total", Type: "int", Nullable: true},
+		{Name: "note", Type: "text", Nullable: true, Comment: "备注"},
+	}}}
+	want := []string{
+		"ALTER TABLE `events` MODIFY COLUMN `id` bigint unsigned NOT NULL AUTO_INCREMENT;",
+		"ALTER TABLE `events` MODIFY COLUMN `code` varchar(32) COLLATE utf8mb4_bin NOT NULL DEFAULT (uuid());",
+		"ALTER TABLE `events` MODIFY COLUMN `updated_at` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3);",
+		"ALTER TABLE `events` MODIFY COLUMN `total` int GENERATED ALWAYS AS ((`id` * 2)) STORED NULL;",
+		"ALTER TABLE `events` MODIFY COLUMN `note` text NULL INVISIBLE COMMENT '备注';",
+	}
+	if got := AlterStatements(model.EngineMySQL, target, reference); !reflect.DeepEqual(got, want) {
+		t.Errorf("AlterStatements() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestMySQLDefaultValue(t *testing.T) {
+	g := ddlGen{dialect: model.EngineMySQL}
+	tests := map[string]string{
+		"0":                    "0",
+		"-1.5":                 "-1.5",
+		"abc":                  "'abc'",
+		"it's":                 "'it''s'",
+		"":                     "''",
+		"CURRENT_TIMESTAMP":    "CURRENT_TIMESTAMP",
+		"current_timestamp(3)": "current_timestamp(3)",
+		"NULL":                 "NULL",
+		"(uuid())":             "(uuid())",
+		"b'0'":                 "b'0'",
+	}
+	for in, want := range tests {
+		if got := g.defaultValue(in); got != want {
+			t.Errorf("defaultValue(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+// Package schemadiff 计算库结构指纹并比较两个库结构之间的差异
+package schemadiff
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"my-bulker/internal/model"
+)
+
+// 变更类型
+const (
+	TableAdded    = "table_added"
+	TableDropped  = "table_dropped"
+	TableChanged  = "table_changed" // 表注释变化
+	ColumnAdded   = "column_added"
+	ColumnDropped = "column_dropped"
+	ColumnChanged = "column_changed" // 类型、可空、默认值、注释或 MySQL 的排序规则、附加属性变化
+	IndexAdded    = "index_added"
+	IndexDropped  = "index_dropped"
+	IndexChanged  = "index_changed" // 字段、唯一性或主键变化
+)
+
+// Change 一项结构变更，Before/After 为变更前后的可读描述
+type Change struct {
+	Kind   string `json:"kind"`
+	Table  string `json:"table_name"`
+	Object string `json:"object_name"` // 字段名或索引名，表级变更为空
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// Normalize 返回规范化后的库结构：表和索引按名称排列，字段保持定义顺序，类型统一为小写并去除首尾空白
+// 不修改传入的切片。
+func Normalize(tables []model.SchemaTable) []model.SchemaTable {
+	out := make([]model.SchemaTable, len(tables))
+	for i, t := range tables {
+		n := model.SchemaTable{
+			Name:    t.Name,
+			Comment: t.Comment,
+			Columns: make([]model.SchemaColumn, len(t.Columns)),
+			Indexes: make([]model.SchemaIndex, len(t.Indexes)),
+		}
+		for j, c := range t.Columns {
+			c.Type = strings.ToLower(strings.TrimSpace(c.Type))
+			n.Columns[j] = c
+		}
+		for j, idx := range t.Indexes {
+			idx.Columns = append([]string(nil), idx.Columns...)
+			n.Indexes[j] = idx
+		}
+		sort.SliceStable(n.Indexes, func(a, b int) bool { return n.Indexes[a].Name < n.Indexes[b].Name })
+		out[i] = n
+	}
+	sort.SliceStable(out, func(a, b int) bool { return out[a].Name < out[b].Name })
+	return out
+}
+
+// Fingerprint 计算库结构指纹，结构相同（含注释）的库指纹相同
+func Fingerprint(tables []model.SchemaTable) string {
+	data, _ := json.Marshal(Normalize(tables))
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Diff 比较两个库结构，返回从 from 变为 to 的变更
+// 变更按表名排列；同一张表内依次为表、字段（按 to 中的顺序，删除的字段在后）、索引（按名称）。
+func Diff(from, to []model.SchemaTable) []Change {
+	from, to = Normalize(from), Normalize(to)
+	fromTables := make(map[string]model.SchemaTable, len(from))
+	for _, t := range from {
+		fromTables[t.Name] = t
+	}
+	toTables := make(map[string]model.SchemaTable, len(to))
+	names := make([]string, 0, len(from)+len(to))
+	for _, t := range to {
+		toTables[t.Name] = t
+		names = append(names, t.Name)
+	}
+	for _, t := range from {
+		if _, ok := toTables[t.Name]; !ok {
+			names = append(names, t.Name)
+		}
+	}
+	sort.Strings(names)
+
+	var changes []Change
+	for _, name := range names {
+		before, inFrom := fromTables[name]
+		after, inTo := toTables[name]
+		switch {
+		case !inFrom:
+			changes = append(changes, Change{Kind: TableAdded, Table: name, After: DescribeTable(after)})
+		case !inTo:
+			changes = append(changes, Change{Kind: TableDropped, Table: name, Before: DescribeTable(before)})
+		default:
+			changes = append(changes, diffTable(before, after)...)
+		}
+	}
+	return changes
+}
+
+// diffTable 比较同名表的注释、字段和索引
+func diffTable(from, to model.SchemaTable) []Change {
+	var changes []Change
+	if from.Comment != to.Comment {
+		changes = append(changes, Change{Kind: TableChanged, Table: to.Name, Before: quote(from.Comment), After: quote(to.Comment)})
+	}
+
+	fromCols := make(map[string]model.SchemaColumn, len(from.Columns))
+	for _, c := range from.Columns {
+		fromCols[c.Name] = c
+	}
+	toCols := make(map[string]bool, len(to.Columns))
+	for _, c := range to.Columns {
+		toCols[c.Name] = true
+		old, ok := fromCols[c.Name]
+		if !ok {
+			changes = append(changes, Change{Kind: ColumnAdded, Table: to.Name, Object: c.Name, After: DescribeColumn(c)})
+		} else if a, b := DescribeColumn(old), DescribeColumn(c); a != b {
+			changes = append(changes, Change{Kind: ColumnChanged, Table: to.Name, Object: c.Name, Before: a, After: b})
+		}
+	}
+	for _, c := range from.Columns {
+		if !toCols[c.Name] {
+			changes = append(changes, Change{Kind: ColumnDropped, Table: to.Name, Object: c.Name, Before: DescribeColumn(c)})
+		}
+	}
+
+	fromIdx := make(map[string]model.SchemaIndex, len(from.Indexes))
+	for _, idx := range from.Indexes {
+		fromIdx[idx.Name] = idx
+	}
+	toIdx := make(map[string]model.SchemaIndex, len(to.Indexes))
+	idxNames := make([]string, 0, len(from.Indexes)+len(to.Indexes))
+	for _, idx := range to.Indexes {
+		toIdx[idx.Name] = idx
+		idxNames = append(idxNames, idx.Name)
+	}
+	for _, idx := range from.Indexes {
+		if _, ok := toIdx[idx.Name]; !ok {
+			idxNames = append(idxNames, idx.Name)
+		}
+	}
+	sort.Strings(idxNames)
+	for _, name := range idxNames {
+		old, inFrom := fromIdx[name]
+		cur, inTo := toIdx[name]
+		switch {
+		case !inFrom:
+			changes = append(changes, Change{Kind: IndexAdded, Table: to.Name, Object: name, After: DescribeIndex(cur)})
+		case !inTo:
+			changes = append(changes, Change{Kind: IndexDropped, Table: to.Name, Object: name, Before: DescribeIndex(old)})
+		default:
+			if a, b := DescribeIndex(old), DescribeIndex(cur); a != b {
+				changes = append(changes, Change{Kind: IndexChanged, Table: to.Name, Object: name, Before: a, After: b})
+			}
+		}
+	}
+	return changes
+}
+
+// DescribeTable 表的可读描述，如 3 columns, 2 indexes
+func DescribeTable(t model.SchemaTable) string {
+	return fmt.Sprintf("%d columns, %d indexes", len(t.Columns), len(t.Indexes))
+}
+
+// DescribeColumn 字段的可读描述，如 varchar(64) NOT NULL DEFAULT x COMMENT '名称'
+func DescribeColumn(c model.SchemaColumn) string {
+	s := c.Type
+	if c.Collation != "" {
+		s += " COLLATE " + c.Collation
+	}
+	if c.Generated != "" {
+		s += " AS (" + c.Generated + ")"
+	}
+	if !c.Nullable {
+		s += " NOT NULL"
+	}
+	// 默认值按驱动返回的原样展示（SQLite 返回 SQL 字面量，MySQL 返回值本身）
+	if c.Default != nil {
+		if *c.Default == "" {
+			s += " DEFAULT ''"
+		} else {
+			s += " DEFAULT " + *c.Default
+		}
+	}
+	if c.Extra != "" {
+		s += " " + c.Extra
+	}
+	if c.Comment != "" {
+		s += " COMMENT " + quote(c.Comment)
+	}
+	return s
+}
+
+// DescribeIndex 索引的可读描述，如 UNIQUE (a, b)
+func DescribeIndex(idx model.SchemaIndex) string {
+	cols := "(" + strings.Join(idx.Columns, ", ") + ")"
+	switch {
+	case idx.Primary:
+		return "PRIMARY KEY " + cols
+	case idx.Unique:
+		return "UNIQUE " + cols
+	}
+	return "INDEX " + cols
+}
+
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,134 @@
+package schemadiff
+
+import (
+	"reflect"
+	"testing"
+
+	"my-bulker/internal/model"
+)
+
+func strPtr(s string) *string { return &s }
+
+func users() model.SchemaTable {
+	return model.SchemaTable{
+		Name: "users",
+		Columns: []model.SchemaColumn{
+			{Name: "id", Type: "bigint"},
+			{Name: "email", Type: "varchar(64)", Default: strPtr("")},
+			{Name: "name", Type: "varchar(32)", Nullable: true},
+		},
+		Indexes: []model.SchemaIndex{
+			{Name: "PRIMARY", Columns: []string{"id"}, Unique: true, Primary: true},
+			{Name: "idx_email", Columns: []string{"email"}, Unique: true},
+		},
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	base := []model.SchemaTable{users(), {Name: "orders", Columns: []model.SchemaColumn{{Name: "id", Type: "int"}}}}
+	reordered := []model.SchemaTable{base[1], users()}
+	reordered[1].Indexes = []model.SchemaIndex{reordered[1].Indexes[1], reordered[1].Indexes[0]}
+	upper := []model.SchemaTable{users(), base[1]}
+	upper[0].Columns[0].Type = " BIGINT "
+	changed := []model.SchemaTable{users(), base[1]}
+	changed[0].Columns[2].Nullable = false
+	comment := []model.SchemaTable{users(), base[1]}
+	comment[0].Comment = "用户"
+
+	tests := []struct {
+		name   string
+		tables []model.SchemaTable
+		same   bool
+	}{
+		{name: "表和索引顺序无关", tables: reordered, same: true},
+		{name: "类型大小写和空白无关", tables: upper, same: true},
+		{name: "字段可空变化", tables: changed, same: false},
+		{name: "注释变化", tables: comment, same: false},
+		{name: "空库", tables: nil, same: false},
+	}
+	want := Fingerprint(base)
+	if len(want) != 64 {
+		t.Fatalf("Fingerprint() = %q, want 64 hex chars", want)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Fingerprint(tt.tables); (got == want) != tt.same {
+				t.Errorf("Fingerprint() same = %v, want %v", got == want, tt.same)
+			}
+		})
+	}
+	if Fingerprint(nil) != Fingerprint([]model.SchemaTable{}) {
+		t.Error("Fingerprint(nil) != Fingerprint(empty)")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(to []model.SchemaTable) []model.SchemaTable
+		want   []Change
+	}{
+		{
+			name:   "无变化",
+			modify: func(to []model.SchemaTable) []model.SchemaTable { return to },
+			want:   nil,
+		},
+		{
+			name: "新增和删除表",
+			modify: func(to []model.SchemaTable) []model.SchemaTable {
+				return []model.SchemaTable{{Name: "accounts", Columns: []model.SchemaColumn{{Name: "id", Type: "int"}}}}
+			},
+			want: []Change{
+				{Kind: TableAdded, Table: "accounts", After: "1 columns, 0 indexes"},
+				{Kind: TableDropped, Table: "users", Before: "3 columns, 2 indexes"},
+			},
+		},
+		{
+			name: "字段变更",
+			modify: func(to []model.SchemaTable) []model.SchemaTable {
+				to[0].Columns[1].Type = "varchar(128)"
+				to[0].Columns = append(to[0].Columns[:2], model.SchemaColumn{Name: "age", Type: "int", Nullable: true})
+				return to
+			},
+			want: []Change{
+				{Kind: ColumnChanged, Table: "users", Object: "email", Before: "varchar(64) NOT NULL DEFAULT ''", After: "varchar(128) NOT NULL DEFAULT ''"},
+				{Kind: ColumnAdded, Table: "users", Object: "age", After: "int"},
+				{Kind: ColumnDropped, Table: "users", Object: "name", Before: "varchar(32)"},
+			},
+		},
+		{
+			name: "索引变更",
+			modify: func(to []model.SchemaTable) []model.SchemaTable {
+				to[0].Indexes[1].Unique = false
+				to[0].Indexes = append(to[0].Indexes, model.SchemaIndex{Name: "idx_name", Columns: []string{"name", "id"}})
+				to[0].Indexes = to[0].Indexes[1:]
+				return to
+			},
+			want: []Change{
+				{Kind: IndexDropped, Table: "users", Object: "PRIMARY", Before: "PRIMARY KEY (id)"},
+				{Kind: IndexChanged, Table: "users", Object: "idx_email", Before: "UNIQUE (email)", After: "INDEX (email)"},
+				{Kind: IndexAdded, Table: "users", Object: "idx_name", After: "INDEX (name, id)"},
+			},
+		},
+		{
+			name: "表注释变更",
+			modify: func(to []model.SchemaTable) []model.SchemaTable {
+				to[0].Comment = "it's users"
+				return to
+			},
+			want: []Change{{Kind: TableChanged, Table: "users", Before: "''", After: "'it''s users'"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from := []model.SchemaTable{users()}
+			got := Diff(from, tt.modify([]model.SchemaTable{users()}))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(from, []model.SchemaTable{users()}) {
+				t.Error("Diff() modified its input")
+			}
+		})
+	}
+}
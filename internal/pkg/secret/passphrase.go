@@ -0,0 +1,105 @@
+package secret
+
+import (
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+const (
+	// passphrasePrefix 口令加密的密文前缀，格式为 enc:p1:<base64(盐)>:<base64(nonce+密文)>
+	passphrasePrefix = "enc:p1:"
+	// pbkdf2Iterations 口令派生密钥的迭代次数
+	pbkdf2Iterations = 600000
+	saltSize         = 16
+)
+
+var ErrWrongPassphrase = errors.New("口令错误或数据已损坏")
+
+// PassphraseCipher 基于口令的加解密器，用于导出文件等离开本机的场景
+// 同一个加解密器使用同一个盐，派生出的密钥会被缓存，避免逐条重复计算。
+type PassphraseCipher struct {
+	passphrase string
+	salt       []byte
+	keys       map[string]cipher.AEAD
+}
+
+// NewPassphraseCipher 创建基于口令的加解密器
+func NewPassphraseCipher(passphrase string) (*PassphraseCipher, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return &PassphraseCipher{
+		passphrase: passphrase,
+		salt:       salt,
+		keys:       make(map[string]cipher.AEAD),
+	}, nil
+}
+
+// IsPassphraseEncrypted 判断字符串是否为口令加密的密文
+func IsPassphraseEncrypted(value string) bool {
+	return strings.HasPrefix(value, passphrasePrefix)
+}
+
+// Encrypt 使用口令加密，空字符串原样返回
+func (p *PassphraseCipher) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	encodedSalt := base64.StdEncoding.EncodeToString(p.salt)
+	aead, err := p.aead(encodedSalt)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := seal(aead, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return passphrasePrefix + encodedSalt + ":" + sealed, nil
+}
+
+// Decrypt 解密口令密文，非口令密文原样返回
+func (p *PassphraseCipher) Decrypt(value string) (string, error) {
+	if !IsPassphraseEncrypted(value) {
+		return value, nil
+	}
+	encodedSalt, data, ok := strings.Cut(strings.TrimPrefix(value, passphrasePrefix), ":")
+	if !ok {
+		return "", ErrMalformed
+	}
+	aead, err := p.aead(encodedSalt)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(aead, data)
+	if err != nil {
+		return "", ErrWrongPassphrase
+	}
+	return string(plaintext), nil
+}
+
+// aead 按盐派生并缓存密钥
+func (p *PassphraseCipher) aead(encodedSalt string) (cipher.AEAD, error) {
+	if aead, ok := p.keys[encodedSalt]; ok {
+		return aead, nil
+	}
+	salt, err := base64.StdEncoding.DecodeString(encodedSalt)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	key, err := pbkdf2.Key(sha256.New, p.passphrase, salt, pbkdf2Iterations, keySize)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	p.keys[encodedSalt] = aead
+	return aead, nil
+}
@@ -0,0 +1,268 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	// EnvMasterKey 主密钥环境变量（base64 编码的 32 字节密钥）
+	EnvMasterKey = "MY_BULKER_MASTER_KEY"
+	// EnvMasterKeyFile 主密钥文件路径环境变量
+	EnvMasterKeyFile = "MY_BULKER_MASTER_KEY_FILE"
+	// EnvOldMasterKeys 轮换前的旧主密钥，多个以逗号分隔，仅用于解密
+	EnvOldMasterKeys = "MY_BULKER_OLD_MASTER_KEYS"
+
+	// defaultKeyFile 未配置主密钥时自动生成的密钥文件名
+	defaultKeyFile = "master.key"
+	// prefix 主密钥加密的密文前缀，格式为 enc:v1:<密钥ID>:<base64(nonce+密文)>
+	prefix  = "enc:v1:"
+	keySize = 32
+)
+
+var (
+	ErrNotInitialized = errors.New("主密钥未初始化")
+	ErrUnknownKey     = errors.New("密文使用了未知的主密钥，请通过 " + EnvOldMasterKeys + " 提供旧密钥")
+	ErrMalformed      = errors.New("密文格式错误")
+)
+
+// keyring 主密钥集合，primary 用于加密，所有密钥均可用于解密
+type keyring struct {
+	primaryID string
+	keys      map[string]cipher.AEAD
+}
+
+var (
+	mu   sync.RWMutex
+	ring *keyring
+)
+
+// Init 加载主密钥
+// 优先使用环境变量，其次使用密钥文件，都未配置时在 dataDir 下生成并保存新的密钥文件。
+func Init(dataDir string) error {
+	primary, err := loadPrimaryKey(dataDir)
+	if err != nil {
+		return err
+	}
+
+	r := &keyring{keys: make(map[string]cipher.AEAD)}
+	r.primaryID, err = r.add(primary)
+	if err != nil {
+		return err
+	}
+	for _, encoded := range strings.Split(os.Getenv(EnvOldMasterKeys), ",") {
+		encoded = strings.TrimSpace(encoded)
+		if encoded == "" {
+			continue
+		}
+		key, err := decodeKey(encoded)
+		if err != nil {
+			return fmt.Errorf("解析旧主密钥失败: %w", err)
+		}
+		if _, err := r.add(key); err != nil {
+			return err
+		}
+	}
+
+	mu.Lock()
+	ring = r
+	mu.Unlock()
+	return nil
+}
+
+// GenerateKey 生成新的 base64 编码主密钥
+func GenerateKey() (string, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// IsEncrypted 判断字符串是否为主密钥加密的密文
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// NeedsRotation 判断值是否需要（重新）使用当前主密钥加密：明文或旧密钥密文
+func NeedsRotation(value string) bool {
+	if value == "" {
+		return false
+	}
+	if !IsEncrypted(value) {
+		return true
+	}
+	id, _, ok := splitCiphertext(value)
+	if !ok {
+		return false
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	return ring != nil && id != ring.primaryID
+}
+
+// Encrypt 使用当前主密钥加密，空字符串和已加密的值原样返回
+func Encrypt(plaintext string) (string, error) {
+	if plaintext == "" || IsEncrypted(plaintext) {
+		return plaintext, nil
+	}
+	mu.RLock()
+	r := ring
+	mu.RUnlock()
+	if r == nil {
+		return "", ErrNotInitialized
+	}
+
+	aead := r.keys[r.primaryID]
+	sealed, err := seal(aead, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return prefix + r.primaryID + ":" + sealed, nil
+}
+
+// Decrypt 解密主密钥密文，非密文（历史明文）原样返回
+func Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	mu.RLock()
+	r := ring
+	mu.RUnlock()
+	if r == nil {
+		return "", ErrNotInitialized
+	}
+
+	id, data, ok := splitCiphertext(value)
+	if !ok {
+		return "", ErrMalformed
+	}
+	aead, ok := r.keys[id]
+	if !ok {
+		return "", ErrUnknownKey
+	}
+	plaintext, err := open(aead, data)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// loadPrimaryKey 按优先级加载主密钥
+func loadPrimaryKey(dataDir string) ([]byte, error) {
+	if encoded := strings.TrimSpace(os.Getenv(EnvMasterKey)); encoded != "" {
+		key, err := decodeKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("解析环境变量 %s 失败: %w", EnvMasterKey, err)
+		}
+		return key, nil
+	}
+
+	path := os.Getenv(EnvMasterKeyFile)
+	if path == "" {
+		path = filepath.Join(dataDir, defaultKeyFile)
+	}
+	content, err := os.ReadFile(path)
+	if err == nil {
+		key, err := decodeKey(strings.TrimSpace(string(content)))
+		if err != nil {
+			return nil, fmt.Errorf("解析主密钥文件 %s 失败: %w", path, err)
+		}
+		return key, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) || os.Getenv(EnvMasterKeyFile) != "" {
+		// 显式指定的密钥文件不存在时不自动生成，避免误用新密钥导致无法解密
+		return nil, fmt.Errorf("读取主密钥文件 %s 失败: %w", path, err)
+	}
+
+	encoded, err := GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(encoded+"\n"), 0600); err != nil {
+		return nil, fmt.Errorf("保存主密钥文件失败: %w", err)
+	}
+	return decodeKey(encoded)
+}
+
+// add 加入密钥并返回其ID
+func (r *keyring) add(key []byte) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	id := keyID(key)
+	r.keys[id] = aead
+	return id, nil
+}
+
+func decodeKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != keySize {
+		return nil, fmt.Errorf("密钥长度必须为 %d 字节，实际为 %d 字节", keySize, len(key))
+	}
+	return key, nil
+}
+
+// keyID 密钥指纹，用于在密文中标识加密所用的密钥
+func keyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:4])
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal 加密并返回 base64(nonce+密文)
+func seal(aead cipher.AEAD, plaintext []byte) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, plaintext, nil)), nil
+}
+
+// open 解密 base64(nonce+密文)
+func open(aead cipher.AEAD, encoded string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(data) < aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("解密失败: %w", err)
+	}
+	return plaintext, nil
+}
+
+// splitCiphertext 拆分密文为密钥ID和数据部分
+func splitCiphertext(value string) (string, string, bool) {
+	rest := strings.TrimPrefix(value, prefix)
+	id, data, ok := strings.Cut(rest, ":")
+	if !ok || id == "" || data == "" {
+		return "", "", false
+	}
+	return id, data, true
+}
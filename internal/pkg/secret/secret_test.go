@@ -0,0 +1,159 @@
+package secret
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func initWithKeys(t *testing.T, primary, old string) {
+	t.Helper()
+	t.Setenv(EnvMasterKey, primary)
+	t.Setenv(EnvMasterKeyFile, "")
+	t.Setenv(EnvOldMasterKeys, old)
+	if err := Init(t.TempDir()); err != nil {
+		t.Fatalf("Init() error: %v", err)
+	}
+}
+
+func mustKey(t *testing.T) string {
+	t.Helper()
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() error: %v", err)
+	}
+	return key
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	initWithKeys(t, mustKey(t), "")
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "empty", input: ""},
+		{name: "ascii", input: "p@ssw0rd"},
+		{name: "unicode", input: "密码:with:colons"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc, err := Encrypt(tt.input)
+			if err != nil {
+				t.Fatalf("Encrypt() error: %v", err)
+			}
+			if tt.input != "" && (!IsEncrypted(enc) || enc == tt.input) {
+				t.Fatalf("Encrypt() = %q, want ciphertext", enc)
+			}
+			// 重复加密不应产生嵌套密文
+			if again, _ := Encrypt(enc); again != enc {
+				t.Fatalf("Encrypt() on ciphertext changed value")
+			}
+			dec, err := Decrypt(enc)
+			if err != nil {
+				t.Fatalf("Decrypt() error: %v", err)
+			}
+			if dec != tt.input {
+				t.Fatalf("Decrypt() = %q, want %q", dec, tt.input)
+			}
+		})
+	}
+
+	// 历史明文原样返回并需要加密
+	if got, _ := Decrypt("legacy"); got != "legacy" {
+		t.Fatalf("Decrypt(plaintext) = %q", got)
+	}
+	if !NeedsRotation("legacy") {
+		t.Fatalf("plaintext should need rotation")
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldKey, newKey := mustKey(t), mustKey(t)
+
+	initWithKeys(t, oldKey, "")
+	enc, err := Encrypt("secret")
+	if err != nil {
+		t.Fatalf("Encrypt() error: %v", err)
+	}
+	if NeedsRotation(enc) {
+		t.Fatalf("value encrypted with primary key should not need rotation")
+	}
+
+	// 仅配置新密钥时无法解密旧密文
+	initWithKeys(t, newKey, "")
+	if _, err := Decrypt(enc); err != ErrUnknownKey {
+		t.Fatalf("Decrypt() error = %v, want ErrUnknownKey", err)
+	}
+
+	initWithKeys(t, newKey, oldKey)
+	if !NeedsRotation(enc) {
+		t.Fatalf("value encrypted with old key should need rotation")
+	}
+	dec, err := Decrypt(enc)
+	if err != nil || dec != "secret" {
+		t.Fatalf("Decrypt() = %q, %v", dec, err)
+	}
+	rotated, err := Encrypt(dec)
+	if err != nil || NeedsRotation(rotated) {
+		t.Fatalf("rotated value should use primary key: %q, %v", rotated, err)
+	}
+}
+
+func TestInitGeneratesKeyFile(t *testing.T) {
+	t.Setenv(EnvMasterKey, "")
+	t.Setenv(EnvMasterKeyFile, "")
+	t.Setenv(EnvOldMasterKeys, "")
+	dir := t.TempDir()
+
+	if err := Init(dir); err != nil {
+		t.Fatalf("Init() error: %v", err)
+	}
+	path := filepath.Join(dir, defaultKeyFile)
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("key file not created: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("key file mode = %v, want 0600", info.Mode().Perm())
+	}
+	enc, _ := Encrypt("x")
+
+	// 再次初始化复用同一密钥文件
+	if err := Init(dir); err != nil {
+		t.Fatalf("Init() error: %v", err)
+	}
+	if dec, err := Decrypt(enc); err != nil || dec != "x" {
+		t.Fatalf("Decrypt() after reload = %q, %v", dec, err)
+	}
+
+	// 显式指定的密钥文件不存在时报错
+	t.Setenv(EnvMasterKeyFile, filepath.Join(dir, "missing.key"))
+	if err := Init(dir); err == nil {
+		t.Fatalf("expected error for missing key file")
+	}
+}
+
+func TestPassphraseCipher(t *testing.T) {
+	c, err := NewPassphraseCipher("correct horse")
+	if err != nil {
+		t.Fatalf("NewPassphraseCipher() error: %v", err)
+	}
+	enc, err := c.Encrypt("db-password")
+	if err != nil {
+		t.Fatalf("Encrypt() error: %v", err)
+	}
+	if !IsPassphraseEncrypted(enc) || IsEncrypted(enc) {
+		t.Fatalf("unexpected ciphertext format: %q", enc)
+	}
+
+	reader, _ := NewPassphraseCipher("correct horse")
+	if dec, err := reader.Decrypt(enc); err != nil || dec != "db-password" {
+		t.Fatalf("Decrypt() = %q, %v", dec, err)
+	}
+
+	wrong, _ := NewPassphraseCipher("wrong")
+	if _, err := wrong.Decrypt(enc); err != ErrWrongPassphrase {
+		t.Fatalf("Decrypt() error = %v, want ErrWrongPassphrase", err)
+	}
+}
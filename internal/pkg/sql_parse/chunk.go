@@ -0,0 +1,184 @@
+package sql_parse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ChunkStatement 可分批执行的单表 UPDATE/DELETE 语句
+type ChunkStatement struct {
+	Verb   string // UPDATE 或 DELETE
+	Schema string // 表所属库，未指定时为空
+	Table  string // 表名（已去除反引号）
+
+	sql   string // 去除注释和结尾分号后的原语句
+	head  string // WHERE 之前的部分
+	where string // WHERE 条件，不含 WHERE 关键字
+}
+
+// 表引用前可能出现的修饰符
+var chunkModifiers = map[string]bool{
+	"LOW_PRIORITY": true,
+	"QUICK":        true,
+	"IGNORE":       true,
+}
+
+// ParseChunkStatement 解析可分批执行的语句。
+// 仅支持单表 UPDATE/DELETE，且不能包含 JOIN、USING 或已有的 LIMIT，UPDATE 不能包含 ORDER BY。
+func ParseChunkStatement(sql string) (*ChunkStatement, error) {
+	sql = strings.TrimSpace(trimSQLTerminator(removeComments(sql)))
+	verb := strings.ToUpper(firstKeyword(sql))
+	if verb != "UPDATE" && verb != "DELETE" {
+		return nil, fmt.Errorf("仅 UPDATE 和 DELETE 语句支持分批执行")
+	}
+	for _, keyword := range []string{"JOIN", "USING"} {
+		if hasTopLevelKeyword(sql, keyword) {
+			return nil, fmt.Errorf("分批执行不支持多表语句（%s）", keyword)
+		}
+	}
+	if hasTopLevelKeyword(sql, "LIMIT") {
+		return nil, fmt.Errorf("分批执行的语句不能包含 LIMIT")
+	}
+	// UPDATE 按主键区间分批，无法保留原语句的执行顺序
+	if verb == "UPDATE" && hasTopLevelKeyword(sql, "ORDER") {
+		return nil, fmt.Errorf("分批执行的 UPDATE 语句不能包含 ORDER BY")
+	}
+
+	// 定位表引用：UPDATE 在 SET 之前，DELETE 在 FROM 之后
+	var tableRef string
+	var tableEnd int
+	switch verb {
+	case "UPDATE":
+		setIndex := findTopLevelKeyword(sql, "SET", len(verb))
+		if setIndex < 0 {
+			return nil, fmt.Errorf("UPDATE 语句缺少 SET")
+		}
+		tableRef = sql[len(verb):setIndex]
+		tableEnd = setIndex
+	case "DELETE":
+		fromIndex := findTopLevelKeyword(sql, "FROM", len(verb))
+		if fromIndex < 0 {
+			return nil, fmt.Errorf("DELETE 语句缺少 FROM")
+		}
+		// DELETE 与 FROM 之间只允许出现修饰符，否则为多表删除
+		for _, token := range strings.Fields(sql[len(verb):fromIndex]) {
+			if !chunkModifiers[strings.ToUpper(token)] {
+				return nil, fmt.Errorf("分批执行不支持多表语句")
+			}
+		}
+		tableEnd = len(sql)
+		for _, keyword := range []string{"WHERE", "ORDER", "PARTITION"} {
+			if idx := findTopLevelKeyword(sql, keyword, fromIndex); idx >= 0 && idx < tableEnd {
+				tableEnd = idx
+			}
+		}
+		tableRef = sql[fromIndex+len("FROM") : tableEnd]
+	}
+	if len(splitTopLevelComma(tableRef)) > 1 {
+		return nil, fmt.Errorf("分批执行不支持多表语句")
+	}
+
+	schema, table, err := parseTableName(tableRef)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := &ChunkStatement{
+		Verb:   verb,
+		Schema: schema,
+		Table:  table,
+		sql:    sql,
+		head:   strings.TrimSpace(sql),
+	}
+	if whereIndex := findTopLevelKeyword(sql, "WHERE", tableEnd); whereIndex >= 0 {
+		stmt.head = strings.TrimSpace(sql[:whereIndex])
+		whereEnd := len(sql)
+		if orderIndex := findTopLevelKeyword(sql, "ORDER", whereIndex); orderIndex >= 0 {
+			whereEnd = orderIndex
+		}
+		stmt.where = strings.TrimSpace(sql[whereIndex+len("WHERE") : whereEnd])
+	} else if orderIndex := findTopLevelKeyword(sql, "ORDER", tableEnd); orderIndex >= 0 {
+		stmt.head = strings.TrimSpace(sql[:orderIndex])
+	}
+	return stmt, nil
+}
+
+// LimitSQL 生成按 LIMIT 分批的语句，适用于执行后不再匹配条件的 DELETE。
+func (c *ChunkStatement) LimitSQL(chunkSize int) string {
+	return fmt.Sprintf("%s LIMIT %d", c.sql, chunkSize)
+}
+
+// RangeSQL 生成按主键闭区间分批的语句，区间上下界以两个占位参数传入。
+func (c *ChunkStatement) RangeSQL(pkColumn string) string {
+	rangeCond := fmt.Sprintf("%s >= ? AND %s <= ?", quoteIdentifier(pkColumn), quoteIdentifier(pkColumn))
+	if c.where == "" {
+		return c.head + " WHERE " + rangeCond
+	}
+	return c.head + " WHERE (" + c.where + ") AND " + rangeCond
+}
+
+// QualifiedTable 返回带反引号的完整表名
+func (c *ChunkStatement) QualifiedTable() string {
+	if c.Schema == "" {
+		return quoteIdentifier(c.Table)
+	}
+	return quoteIdentifier(c.Schema) + "." + quoteIdentifier(c.Table)
+}
+
+// parseTableName 从表引用中解析库名和表名，忽略别名
+func parseTableName(ref string) (string, string, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return "", "", fmt.Errorf("无法识别语句中的表名")
+	}
+
+	// 截取第一个标识符（可能带库名），遇到反引号外的空白即结束
+	end := 0
+	inBacktick := false
+	for end < len(ref) {
+		ch := ref[end]
+		if ch == '`' {
+			inBacktick = !inBacktick
+		} else if !inBacktick && isSQLSpace(ch) {
+			break
+		}
+		end++
+	}
+	name := ref[:end]
+
+	parts := make([]string, 0, 2)
+	var current strings.Builder
+	inBacktick = false
+	for i := 0; i < len(name); i++ {
+		ch := name[i]
+		switch {
+		case ch == '`':
+			if inBacktick && i+1 < len(name) && name[i+1] == '`' {
+				current.WriteByte('`')
+				i++
+				continue
+			}
+			inBacktick = !inBacktick
+		case ch == '.' && !inBacktick:
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(ch)
+		}
+	}
+	parts = append(parts, current.String())
+
+	switch len(parts) {
+	case 1:
+		return "", parts[0], nil
+	case 2:
+		return parts[0], parts[1], nil
+	default:
+		return "", "", fmt.Errorf("无法识别语句中的表名: %s", name)
+	}
+}
+
+// quoteIdentifier 使用反引号转义标识符
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
@@ -0,0 +1,78 @@
+package sql_parse
+
+import "testing"
+
+func TestParseChunkStatement(t *testing.T) {
+	tests := []struct {
+		name       string
+		sql        string
+		wantErr    bool
+		wantSchema string
+		wantTable  string
+		wantLimit  string
+		wantRange  string
+	}{
+		{
+			name:      "delete with where",
+			sql:       "DELETE FROM logs WHERE created_at < '2024-01-01';",
+			wantTable: "logs",
+			wantLimit: "DELETE FROM logs WHERE created_at < '2024-01-01' LIMIT 500",
+			wantRange: "DELETE FROM logs WHERE (created_at < '2024-01-01') AND `id` >= ? AND `id` <= ?",
+		},
+		{
+			name:       "qualified quoted table with alias",
+			sql:        "UPDATE `app`.`user log` AS l SET l.flag = 1 WHERE l.flag = 0 OR l.flag IS NULL",
+			wantSchema: "app",
+			wantTable:  "user log",
+			wantLimit:  "UPDATE `app`.`user log` AS l SET l.flag = 1 WHERE l.flag = 0 OR l.flag IS NULL LIMIT 500",
+			wantRange:  "UPDATE `app`.`user log` AS l SET l.flag = 1 WHERE (l.flag = 0 OR l.flag IS NULL) AND `id` >= ? AND `id` <= ?",
+		},
+		{
+			name:      "update without where",
+			sql:       "UPDATE t SET a = 1",
+			wantTable: "t",
+			wantLimit: "UPDATE t SET a = 1 LIMIT 500",
+			wantRange: "UPDATE t SET a = 1 WHERE `id` >= ? AND `id` <= ?",
+		},
+		{
+			name:      "delete with modifiers and comment",
+			sql:       "/* purge */ DELETE LOW_PRIORITY QUICK FROM t WHERE a = 'x;y' ORDER BY id",
+			wantTable: "t",
+			wantLimit: "DELETE LOW_PRIORITY QUICK FROM t WHERE a = 'x;y' ORDER BY id LIMIT 500",
+			wantRange: "DELETE LOW_PRIORITY QUICK FROM t WHERE (a = 'x;y') AND `id` >= ? AND `id` <= ?",
+		},
+		{name: "select is rejected", sql: "SELECT * FROM t", wantErr: true},
+		{name: "existing limit is rejected", sql: "DELETE FROM t WHERE a = 1 LIMIT 10", wantErr: true},
+		{name: "update with limit is rejected", sql: "UPDATE t SET a = 1 WHERE b = 2 LIMIT 10", wantErr: true},
+		{name: "update with order by is rejected", sql: "UPDATE t SET a = 1 WHERE b = 2 ORDER BY id DESC", wantErr: true},
+		{name: "multi table delete is rejected", sql: "DELETE t1 FROM t1 JOIN t2 ON t1.id = t2.id", wantErr: true},
+		{name: "delete using is rejected", sql: "DELETE FROM t1 USING t1, t2 WHERE t1.id = t2.id", wantErr: true},
+		{name: "comma update is rejected", sql: "UPDATE t1, t2 SET t1.a = t2.a", wantErr: true},
+		{name: "limit inside subquery is allowed", sql: "DELETE FROM t WHERE id IN (SELECT id FROM (SELECT id FROM t LIMIT 5) x)", wantTable: "t",
+			wantLimit: "DELETE FROM t WHERE id IN (SELECT id FROM (SELECT id FROM t LIMIT 5) x) LIMIT 500",
+			wantRange: "DELETE FROM t WHERE (id IN (SELECT id FROM (SELECT id FROM t LIMIT 5) x)) AND `id` >= ? AND `id` <= ?"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, err := ParseChunkStatement(tt.sql)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", stmt)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if stmt.Schema != tt.wantSchema || stmt.Table != tt.wantTable {
+				t.Fatalf("table = %q.%q, want %q.%q", stmt.Schema, stmt.Table, tt.wantSchema, tt.wantTable)
+			}
+			if got := stmt.LimitSQL(500); got != tt.wantLimit {
+				t.Fatalf("LimitSQL() = %q, want %q", got, tt.wantLimit)
+			}
+			if got := stmt.RangeSQL("id"); got != tt.wantRange {
+				t.Fatalf("RangeSQL() = %q, want %q", got, tt.wantRange)
+			}
+		})
+	}
+}
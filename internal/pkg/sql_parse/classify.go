@@ -0,0 +1,145 @@
+package sql_parse
+
+import "strings"
+
+// StatementKind SQL 语句类别
+type StatementKind string
+
+const (
+	StatementRead  StatementKind = "read"  // 只读查询
+	StatementWrite StatementKind = "write" // 数据修改（DML）
+	StatementDDL   StatementKind = "ddl"   // 结构变更
+	StatementOther StatementKind = "other" // 会话、事务控制等
+)
+
+// ClassifyStatement 根据首个关键字判断单条 SQL 的类别。
+// 存储过程调用无法确认是否写入，按写入处理；查询语句中嵌套的写入（CTE 中的 DML、
+// SELECT ... INTO OUTFILE/DUMPFILE 或建表、EXPLAIN ANALYZE 执行的 DML）同样按写入处理。
+func ClassifyStatement(sql string) StatementKind {
+	sql = trimSQLTerminator(removeComments(sql))
+	switch keyword := strings.ToUpper(firstKeyword(sql)); keyword {
+	case "SELECT", "WITH", "TABLE", "VALUES":
+		if containsWrite(sql) {
+			return StatementWrite
+		}
+		return StatementRead
+	case "EXPLAIN", "DESC", "DESCRIBE":
+		if hasTopLevelKeyword(sql, "ANALYZE") && containsWrite(sql) {
+			return StatementWrite
+		}
+		return StatementRead
+	case "SHOW":
+		return StatementRead
+	case "INSERT", "UPDATE", "DELETE", "REPLACE", "LOAD", "CALL":
+		return StatementWrite
+	case "CREATE", "ALTER", "DROP", "TRUNCATE", "RENAME":
+		return StatementDDL
+	case "":
+		// 以括号开头的查询，如 (SELECT ...) UNION (SELECT ...)
+		if strings.HasPrefix(sql, "(") {
+			if containsWrite(sql) {
+				return StatementWrite
+			}
+			return StatementRead
+		}
+		return StatementOther
+	default:
+		return StatementOther
+	}
+}
+
+// containsWrite 判断查询任意层级中是否包含写入：DML 关键字（同名函数调用和 FOR UPDATE 加锁读除外），
+// 以及 INTO 子句（INTO @变量 只给会话变量赋值除外）
+func containsWrite(sql string) bool {
+	words := sqlWords(sql)
+	for i, w := range words {
+		switch w.upper {
+		case "INSERT", "REPLACE":
+			// INSERT()、REPLACE() 为字符串函数
+			if !w.call {
+				return true
+			}
+		case "DELETE", "MERGE":
+			return true
+		case "UPDATE":
+			// FOR UPDATE、FOR NO KEY UPDATE 为加锁读
+			if i == 0 || (words[i-1].upper != "FOR" && words[i-1].upper != "KEY") {
+				return true
+			}
+		case "INTO":
+			if i+1 >= len(words) || !words[i+1].variable {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sqlWord 语句中引号和注释之外的单词
+type sqlWord struct {
+	upper    string // 大写形式
+	call     bool   // 紧跟左括号，为函数调用
+	variable bool   // 以 @ 开头的变量
+}
+
+// sqlWords 按顺序提取语句中引号、反引号和注释之外的单词，不区分括号层级
+func sqlWords(sql string) []sqlWord {
+	sql = removeComments(sql)
+	var words []sqlWord
+	var quote byte
+	for i := 0; i < len(sql); i++ {
+		char := sql[i]
+		if quote != 0 {
+			if char == quote && (quote == '`' || !isEscaped(sql, i)) {
+				quote = 0
+			}
+			continue
+		}
+		switch {
+		case char == '\'' || char == '"' || char == '`':
+			quote = char
+		case isIdentifierChar(char):
+			start := i
+			for i < len(sql) && isIdentifierChar(sql[i]) {
+				i++
+			}
+			next := skipLeadingSpaces(sql, i)
+			words = append(words, sqlWord{
+				upper:    strings.ToUpper(sql[start:i]),
+				call:     next < len(sql) && sql[next] == '(',
+				variable: start > 0 && sql[start-1] == '@',
+			})
+			i--
+		}
+	}
+	return words
+}
+
+// IsWrite 判断 SQL 是否会修改数据或结构。
+func IsWrite(sql string) bool {
+	kind := ClassifyStatement(sql)
+	return kind == StatementWrite || kind == StatementDDL
+}
+
+// StatementKeyword 返回语句的首个关键字（大写），忽略前置注释。
+func StatementKeyword(sql string) string {
+	return strings.ToUpper(firstKeyword(strings.TrimSpace(removeComments(sql))))
+}
+
+// sessionFunctions 只读查询中也能改变会话状态的写法：用户变量赋值、会话参数函数和锁
+var sessionFunctions = []string{"@", "SET_CONFIG", "GET_LOCK", "ADVISORY_LOCK"}
+
+// MayChangeSession 判断 SQL 执行后是否可能在连接上留下会话状态（会话变量、临时表、锁等）
+// 非只读语句一律视为可能；只读查询按用户变量和会话函数判断，宁可多判。
+func MayChangeSession(sql string) bool {
+	if ClassifyStatement(sql) != StatementRead {
+		return true
+	}
+	upper := strings.ToUpper(removeComments(sql))
+	for _, s := range sessionFunctions {
+		if strings.Contains(upper, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package sql_parse
+
+import "testing"
+
+func TestClassifyStatement(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want StatementKind
+	}{
+		{name: "select", sql: "SELECT * FROM t", want: StatementRead},
+		{name: "lowercase show", sql: "show tables;", want: StatementRead},
+		{name: "parenthesized union", sql: "(SELECT 1) UNION (SELECT 2)", want: StatementRead},
+		{name: "cte select", sql: "WITH x AS (SELECT 1) SELECT * FROM x", want: StatementRead},
+		{name: "cte update", sql: "WITH x AS (SELECT id FROM t) UPDATE t JOIN x USING (id) SET a = 1", want: StatementWrite},
+		{name: "update", sql: "UPDATE t SET a = 1 WHERE id = 2", want: StatementWrite},
+		{name: "delete with leading comment", sql: "/* cleanup */ DELETE FROM t WHERE a = 1", want: StatementWrite},
+		{name: "insert after line comment", sql: "-- seed\nINSERT INTO t VALUES (1)", want: StatementWrite},
+		{name: "call", sql: "CALL proc()", want: StatementWrite},
+		{name: "alter", sql: "ALTER TABLE t ADD COLUMN b INT", want: StatementDDL},
+		{name: "truncate", sql: "TRUNCATE TABLE t", want: StatementDDL},
+		{name: "set", sql: "SET @a = 1", want: StatementOther},
+		{name: "keyword inside string", sql: "SELECT 'DELETE FROM t'", want: StatementRead},
+		{name: "data modifying cte", sql: "WITH d AS (DELETE FROM orders RETURNING *) SELECT count(*) FROM d", want: StatementWrite},
+		{name: "nested data modifying cte", sql: "WITH a AS (SELECT 1), b AS (WITH c AS (UPDATE t SET x = 1 RETURNING id) SELECT * FROM c) SELECT * FROM b", want: StatementWrite},
+		{name: "cte insert", sql: "with n as (insert into t (a) values (1) returning a) select a from n", want: StatementWrite},
+		{name: "into outfile", sql: "SELECT * FROM t INTO OUTFILE '/tmp/t.csv'", want: StatementWrite},
+		{name: "into dumpfile", sql: "SELECT data INTO DUMPFILE '/tmp/blob' FROM t LIMIT 1", want: StatementWrite},
+		{name: "select into new table", sql: "SELECT * INTO backup_t FROM t", want: StatementWrite},
+		{name: "parenthesized into outfile", sql: "(SELECT 1) UNION (SELECT 2) INTO OUTFILE '/tmp/x'", want: StatementWrite},
+		{name: "select into variable", sql: "SELECT id INTO @last_id FROM t LIMIT 1", want: StatementRead},
+		{name: "select for update", sql: "SELECT * FROM t WHERE id = 1 FOR UPDATE", want: StatementRead},
+		{name: "cte for no key update", sql: "WITH x AS (SELECT * FROM t FOR NO KEY UPDATE) SELECT * FROM x", want: StatementRead},
+		{name: "replace function", sql: "SELECT REPLACE(name, 'a', 'b'), INSERT(name, 1, 2, 'x') FROM t", want: StatementRead},
+		{name: "dml inside string in cte", sql: "WITH x AS (SELECT 'DELETE FROM t' AS s) SELECT * FROM x", want: StatementRead},
+		{name: "explain analyze delete", sql: "EXPLAIN ANALYZE DELETE FROM t", want: StatementWrite},
+		{name: "explain delete", sql: "EXPLAIN DELETE FROM t", want: StatementRead},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyStatement(tt.sql); got != tt.want {
+				t.Fatalf("ClassifyStatement(%q) = %q, want %q", tt.sql, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsWrite(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want bool
+	}{
+		{sql: "SELECT 1", want: false},
+		{sql: "UPDATE t SET a = 1", want: true},
+		{sql: "DROP TABLE t", want: true},
+		{sql: "USE db", want: false},
+	}
+	for _, tt := range tests {
+		if got := IsWrite(tt.sql); got != tt.want {
+			t.Fatalf("IsWrite(%q) = %v, want %v", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestMayChangeSession(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want bool
+	}{
+		{sql: "SELECT * FROM t", want: false},
+		{sql: "SHOW TABLES", want: false},
+		{sql: "SET sql_mode = ''", want: true},
+		{sql: "SET @a = 1", want: true},
+		{sql: "SELECT @a := id FROM t", want: true},
+		{sql: "SELECT set_config('search_path', 'x', false)", want: true},
+		{sql: "SELECT GET_LOCK('k', 10)", want: true},
+		{sql: "SELECT pg_advisory_lock(1)", want: true},
+		{sql: "CREATE TEMPORARY TABLE tmp (id INT)", want: true},
+		{sql: "UPDATE t SET a = 1", want: true},
+	}
+	for _, tt := range tests {
+		if got := MayChangeSession(tt.sql); got != tt.want {
+			t.Errorf("MayChangeSession(%q) = %v, want %v", tt.sql, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package sshtunnel
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	// idleTimeout 无连接使用的 SSH 客户端空闲超过该时长后关闭
+	idleTimeout = 5 * time.Minute
+	// janitorInterval 空闲客户端检查间隔
+	janitorInterval = time.Minute
+	// keepaliveInterval 心跳间隔，心跳失败的客户端会被丢弃，下次拨号时重连
+	keepaliveInterval = 30 * time.Second
+	// handshakeTimeout 连接跳板机及握手的超时时间
+	handshakeTimeout = 10 * time.Second
+	// pingTimeout 心跳等待应答的超时时间，半开的连接不会一直阻塞
+	pingTimeout = 10 * time.Second
+)
+
+// Config 跳板机连接配置
+type Config struct {
+	Host             string
+	Port             int
+	User             string
+	Password         string
+	PrivateKey       string // PEM 格式私钥
+	KeyPassphrase    string // 私钥口令
+	KnownHosts       string // known_hosts 格式的主机公钥，为空时使用 ~/.ssh/known_hosts
+	SkipHostKeyCheck bool   // 跳过主机公钥校验（不安全）
+}
+
+// Key 配置指纹，相同配置共享同一个 SSH 客户端
+func (c Config) Key() string {
+	h := sha256.New()
+	for _, part := range []string{
+		c.Host, strconv.Itoa(c.Port), c.User, c.Password, c.PrivateKey, c.KeyPassphrase,
+		c.KnownHosts, strconv.FormatBool(c.SkipHostKeyCheck),
+	} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// address 跳板机地址，未指定端口时使用 22
+func (c Config) address() string {
+	port := c.Port
+	if port == 0 {
+		port = 22
+	}
+	return net.JoinHostPort(c.Host, strconv.Itoa(port))
+}
+
+// entry 同一配置的共享 SSH 客户端
+// refs 为经由该客户端建立且尚未关闭的连接数，为 0 且空闲超时后客户端被关闭。
+type entry struct {
+	cfg Config
+
+	// connectMu 保证同一配置同时只建立一个 SSH 连接
+	connectMu sync.Mutex
+
+	// 以下字段由 manager.mu 保护
+	client   *ssh.Client
+	refs     int
+	lastUsed time.Time
+}
+
+type manager struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+	once    sync.Once
+}
+
+var tunnels = &manager{entries: make(map[string]*entry)}
+
+// Dial 经由跳板机连接目标地址，ctx 的截止时间和取消同样约束经由隧道的连接
+// 相同配置的拨号共享一个 SSH 客户端，客户端断开后下次拨号自动重连。
+func Dial(ctx context.Context, cfg Config, network, addr string) (net.Conn, error) {
+	tunnels.once.Do(func() { go tunnels.janitor() })
+
+	e := tunnels.entry(cfg)
+	client, err := tunnels.client(ctx, e)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := client.DialContext(ctx, network, addr)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("通过跳板机 %s 连接 %s 失败: %w", cfg.address(), addr, err)
+		}
+		if ping(client) == nil {
+			// 跳板机正常，目标地址不可达
+			return nil, fmt.Errorf("通过跳板机 %s 连接 %s 失败: %w", cfg.address(), addr, err)
+		}
+		// 客户端已失效，丢弃后重连一次
+		tunnels.discard(e, client)
+		if client, err = tunnels.client(ctx, e); err != nil {
+			return nil, err
+		}
+		if conn, err = client.DialContext(ctx, network, addr); err != nil {
+			return nil, fmt.Errorf("通过跳板机 %s 连接 %s 失败: %w", cfg.address(), addr, err)
+		}
+	}
+
+	tunnels.mu.Lock()
+	e.refs++
+	e.lastUsed = time.Now()
+	tunnels.mu.Unlock()
+	return &trackedConn{Conn: conn, entry: e}, nil
+}
+
+// entry 获取或创建配置对应的共享客户端记录
+func (m *manager) entry(cfg Config) *entry {
+	key := cfg.Key()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[key]
+	if !ok {
+		e = &entry{cfg: cfg}
+		m.entries[key] = e
+	}
+	// 刷新使用时间，避免拨号过程中被回收
+	e.lastUsed = time.Now()
+	return e
+}
+
+// client 返回可用的 SSH 客户端，不存在时建立连接
+func (m *manager) client(ctx context.Context, e *entry) (*ssh.Client, error) {
+	e.connectMu.Lock()
+	defer e.connectMu.Unlock()
+
+	m.mu.Lock()
+	client := e.client
+	m.mu.Unlock()
+	if client != nil {
+		return client, nil
+	}
+
+	client, err := connect(ctx, e.cfg)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	e.client = client
+	m.mu.Unlock()
+	go m.keepalive(e, client)
+	return client, nil
+}
+
+// discard 丢弃失效的客户端，已建立的隧道连接随之断开
+func (m *manager) discard(e *entry, client *ssh.Client) {
+	m.mu.Lock()
+	if e.client == client {
+		e.client = nil
+	}
+	m.mu.Unlock()
+	client.Close()
+}
+
+// keepalive 定期发送心跳，失败时丢弃客户端
+func (m *manager) keepalive(e *entry, client *ssh.Client) {
+	ticker := time.NewTicker(keepaliveInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		m.mu.Lock()
+		current := e.client == client
+		m.mu.Unlock()
+		if !current {
+			return
+		}
+		if err := ping(client); err != nil {
+			log.Printf("WARN: ssh tunnel %s keepalive failed: %v", e.cfg.address(), err)
+			m.discard(e, client)
+			return
+		}
+	}
+}
+
+// ping 发送心跳并等待应答，超过 pingTimeout 未应答视为失败
+func ping(client *ssh.Client) error {
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+		done <- err
+	}()
+	timer := time.NewTimer(pingTimeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("心跳超过 %s 未应答", pingTimeout)
+	}
+}
+
+// janitor 定期关闭无连接且空闲超时的客户端
+func (m *manager) janitor() {
+	ticker := time.NewTicker(janitorInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		m.evictIdle(time.Now())
+	}
+}
+
+func (m *manager) evictIdle(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, e := range m.entries {
+		if e.refs == 0 && now.Sub(e.lastUsed) > idleTimeout {
+			if e.client != nil {
+				e.client.Close()
+				e.client = nil
+			}
+			delete(m.entries, key)
+		}
+	}
+}
+
+// trackedConn 隧道连接，关闭时释放对 SSH 客户端的引用
+type trackedConn struct {
+	net.Conn
+	entry *entry
+	once  sync.Once
+}
+
+func (c *trackedConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(func() {
+		tunnels.mu.Lock()
+		c.entry.refs--
+		c.entry.lastUsed = time.Now()
+		tunnels.mu.Unlock()
+	})
+	return err
+}
+
+// connect 连接跳板机并完成认证
+func connect(ctx context.Context, cfg Config) (*ssh.Client, error) {
+	auth, err := authMethods(cfg)
+	if err != nil {
+		return nil, err
+	}
+	hostKeyCallback, err := hostKeyCallback(cfg)
+	if err != nil {
+		return nil, err
+	}
+	clientConfig := &ssh.ClientConfig{
+		User:            cfg.User,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         handshakeTimeout,
+	}
+
+	addr := cfg.address()
+	dialer := net.Dialer{Timeout: handshakeTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("连接跳板机 %s 失败: %w", addr, err)
+	}
+	// 握手阶段同样受超时约束
+	conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, clientConfig)
+	if err != nil {
+		conn.Close()
+		var keyErr *knownhosts.KeyError
+		if errors.As(err, &keyErr) {
+			if len(keyErr.Want) == 0 {
+				return nil, fmt.Errorf("跳板机 %s 的主机公钥不在 known_hosts 中", addr)
+			}
+			return nil, fmt.Errorf("跳板机 %s 的主机公钥与 known_hosts 不一致，可能存在中间人攻击", addr)
+		}
+		return nil, fmt.Errorf("跳板机 %s 认证失败: %w", addr, err)
+	}
+	conn.SetDeadline(time.Time{})
+	return ssh.NewClient(sshConn, chans, reqs), nil
+}
+
+// authMethods 根据配置生成认证方式，同时配置私钥和密码时优先使用私钥
+func authMethods(cfg Config) ([]ssh.AuthMethod, error) {
+	var methods []ssh.AuthMethod
+	if cfg.PrivateKey != "" {
+		var signer ssh.Signer
+		var err error
+		if cfg.KeyPassphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(cfg.PrivateKey), []byte(cfg.KeyPassphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey([]byte(cfg.PrivateKey))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析 SSH 私钥失败: %w", err)
+		}
+		methods = append(methods, ssh.PublicKeys(signer))
+	}
+	if cfg.Password != "" {
+		methods = append(methods, ssh.Password(cfg.Password))
+	}
+	if len(methods) == 0 {
+		return nil, errors.New("未配置 SSH 密码或私钥")
+	}
+	return methods, nil
+}
+
+// hostKeyCallback 生成主机公钥校验函数
+func hostKeyCallback(cfg Config) (ssh.HostKeyCallback, error) {
+	if cfg.SkipHostKeyCheck {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+	if cfg.KnownHosts == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("未配置主机公钥且无法定位 ~/.ssh/known_hosts: %w", err)
+		}
+		callback, err := knownhosts.New(filepath.Join(home, ".ssh", "known_hosts"))
+		if err != nil {
+			return nil, fmt.Errorf("未配置主机公钥且读取 ~/.ssh/known_hosts 失败: %w", err)
+		}
+		return callback, nil
+	}
+
+	// knownhosts 只支持从文件加载，借助临时文件解析配置的内容
+	file, err := os.CreateTemp("", "my-bulker-known-hosts-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(cfg.KnownHosts)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	callback, err := knownhosts.New(file.Name())
+	if err != nil {
+		return nil, fmt.Errorf("解析主机公钥失败: %w", err)
+	}
+	return callback, nil
+}
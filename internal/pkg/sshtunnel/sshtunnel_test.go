@@ -0,0 +1,254 @@
+package sshtunnel
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func TestConfigKey(t *testing.T) {
+	base := Config{Host: "bastion", Port: 22, User: "ops", Password: "pw"}
+	if base.Key() != base.Key() {
+		t.Fatalf("Key() should be stable")
+	}
+	changed := base
+	changed.Password = "other"
+	if base.Key() == changed.Key() {
+		t.Fatalf("Key() should change with credentials")
+	}
+	// 字段之间有分隔，拼接结果相同的配置不应冲突
+	a := Config{Host: "ab", User: "c"}
+	b := Config{Host: "a", User: "bc"}
+	if a.Key() == b.Key() {
+		t.Fatalf("Key() collision between %+v and %+v", a, b)
+	}
+}
+
+func TestAuthMethods(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		want    int
+		wantErr bool
+	}{
+		{name: "none", cfg: Config{}, wantErr: true},
+		{name: "password", cfg: Config{Password: "pw"}, want: 1},
+		{name: "invalid key", cfg: Config{PrivateKey: "not a key"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			methods, err := authMethods(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("authMethods() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(methods) != tt.want {
+				t.Fatalf("authMethods() returned %d methods, want %d", len(methods), tt.want)
+			}
+		})
+	}
+}
+
+func TestHostKeyCallback(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	known, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	other, _ := ssh.NewPublicKey(otherPub)
+
+	addr := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 2222}
+	line := knownhosts.Line([]string{knownhosts.Normalize("10.0.0.1:2222")}, known)
+
+	callback, err := hostKeyCallback(Config{KnownHosts: line + "\n"})
+	if err != nil {
+		t.Fatalf("hostKeyCallback() error: %v", err)
+	}
+	if err := callback("10.0.0.1:2222", addr, known); err != nil {
+		t.Fatalf("known key rejected: %v", err)
+	}
+	if err := callback("10.0.0.1:2222", addr, other); err == nil {
+		t.Fatalf("mismatched key accepted")
+	}
+
+	insecure, err := hostKeyCallback(Config{SkipHostKeyCheck: true})
+	if err != nil {
+		t.Fatalf("hostKeyCallback() error: %v", err)
+	}
+	if err := insecure("10.0.0.1:2222", addr, other); err != nil {
+		t.Fatalf("insecure callback rejected key: %v", err)
+	}
+}
+
+// startServer 启动仅支持 direct-tcpip 转发的测试 SSH 服务
+func startServer(t *testing.T, password string) (string, ssh.PublicKey) {
+	t.Helper()
+	_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(_ ssh.ConnMetadata, p []byte) (*ssh.Permissions, error) {
+			if string(p) != password {
+				return nil, fmt.Errorf("wrong password")
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveConn(conn, config)
+		}
+	}()
+	return listener.Addr().String(), hostSigner.PublicKey()
+}
+
+func serveConn(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "direct-tcpip" {
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported")
+			continue
+		}
+		var payload struct {
+			Host       string
+			Port       uint32
+			OriginHost string
+			OriginPort uint32
+		}
+		ssh.Unmarshal(newChannel.ExtraData(), &payload)
+		target, err := net.Dial("tcp", net.JoinHostPort(payload.Host, strconv.Itoa(int(payload.Port))))
+		if err != nil {
+			newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			target.Close()
+			continue
+		}
+		go ssh.DiscardRequests(requests)
+		go func() {
+			io.Copy(channel, target)
+			channel.Close()
+		}()
+		go func() {
+			io.Copy(target, channel)
+			target.Close()
+		}()
+	}
+}
+
+func TestDialThroughTunnel(t *testing.T) {
+	// 目标服务：回显收到的数据
+	echo, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer echo.Close()
+	go func() {
+		for {
+			conn, err := echo.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				io.Copy(conn, conn)
+				conn.Close()
+			}()
+		}
+	}()
+
+	addr, hostKey := startServer(t, "secret")
+	host, portStr, _ := net.SplitHostPort(addr)
+	port, _ := strconv.Atoi(portStr)
+	cfg := Config{
+		Host:       host,
+		Port:       port,
+		User:       "ops",
+		Password:   "secret",
+		KnownHosts: knownhosts.Line([]string{knownhosts.Normalize(addr)}, hostKey),
+	}
+
+	ctx := context.Background()
+	first, err := Dial(ctx, cfg, "tcp", echo.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial() error: %v", err)
+	}
+	second, err := Dial(ctx, cfg, "tcp", echo.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial() error: %v", err)
+	}
+
+	e := tunnels.entry(cfg)
+	tunnels.mu.Lock()
+	refs := e.refs
+	tunnels.mu.Unlock()
+	if refs != 2 {
+		t.Fatalf("refs = %d, want 2 (shared client)", refs)
+	}
+
+	if _, err := first.Write([]byte("ping")); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(first, buf); err != nil || string(buf) != "ping" {
+		t.Fatalf("echo = %q, %v", buf, err)
+	}
+
+	first.Close()
+	first.Close() // 重复关闭不应重复释放引用
+	second.Close()
+	tunnels.mu.Lock()
+	refs = e.refs
+	tunnels.mu.Unlock()
+	if refs != 0 {
+		t.Fatalf("refs = %d after close, want 0", refs)
+	}
+
+	// 主机公钥不匹配时拒绝连接
+	_, otherPriv, _ := ed25519.GenerateKey(rand.Reader)
+	otherSigner, _ := ssh.NewSignerFromKey(otherPriv)
+	bad := cfg
+	bad.KnownHosts = knownhosts.Line([]string{knownhosts.Normalize(addr)}, otherSigner.PublicKey())
+	if _, err := Dial(ctx, bad, "tcp", echo.Addr().String()); err == nil {
+		t.Fatalf("Dial() with mismatched host key should fail")
+	}
+
+	// 密码错误时拒绝连接
+	wrong := cfg
+	wrong.Password = "nope"
+	if _, err := Dial(ctx, wrong, "tcp", echo.Addr().String()); err == nil {
+		t.Fatalf("Dial() with wrong password should fail")
+	}
+}
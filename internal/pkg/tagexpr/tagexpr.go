@@ -0,0 +1,324 @@
+// Package tagexpr 解析并计算实例标签表达式，用于按标签选择实例。
+//
+// 语法示例：
+//
+//	env=prod AND region!=cn
+//	(env=prod OR env=staging) AND NOT team="data platform"
+//	backup
+//
+// 条件形式为 key=value、key!=value 或单独的 key（表示存在该标签）；
+// 条件之间用 AND、OR、NOT（不区分大小写）和括号组合，AND 优先于 OR。
+// 实例缺少某个标签时，key!=value 视为成立。
+package tagexpr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Expr 已解析的标签表达式
+type Expr interface {
+	// Match 判断标签集合是否满足表达式
+	Match(tags map[string]string) bool
+	String() string
+}
+
+// Parse 解析标签表达式
+func Parse(input string) (Expr, error) {
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("标签表达式不能为空")
+	}
+	p := &parser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("标签表达式第 %d 个字符附近有多余内容: %s", p.peek().offset+1, p.peek().text)
+	}
+	return expr, nil
+}
+
+// ValidKey 判断标签键是否可直接用于表达式（字母、数字及 _ . - / :）
+func ValidKey(key string) bool {
+	if key == "" || isKeyword(key) {
+		return false
+	}
+	for _, r := range key {
+		if !isWordRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenString
+	tokenEq
+	tokenNe
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	offset int
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_.-/:", r)
+}
+
+func isKeyword(word string) bool {
+	switch strings.ToUpper(word) {
+	case "AND", "OR", "NOT":
+		return true
+	}
+	return false
+}
+
+// tokenize 将表达式拆分为词法单元，值可用单引号或双引号包裹
+func tokenize(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", offset: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", offset: i})
+			i++
+		case r == '=':
+			tokens = append(tokens, token{kind: tokenEq, text: "=", offset: i})
+			i++
+		case r == '!' && i+1 < len(runes) && runes[i+1] == '=':
+			tokens = append(tokens, token{kind: tokenNe, text: "!=", offset: i})
+			i += 2
+		case r == '\'' || r == '"':
+			start := i
+			var sb strings.Builder
+			i++
+			for i < len(runes) && runes[i] != r {
+				// 反斜杠转义下一个字符
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("标签表达式第 %d 个字符处的引号未闭合", start+1)
+			}
+			i++
+			tokens = append(tokens, token{kind: tokenString, text: sb.String(), offset: start})
+		case isWordRune(r):
+			start := i
+			for i < len(runes) && isWordRune(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: string(runes[start:i]), offset: start})
+		default:
+			return nil, fmt.Errorf("标签表达式第 %d 个字符无效: %c", i+1, r)
+		}
+	}
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+// acceptKeyword 当前为指定关键字时前进一位
+func (p *parser) acceptKeyword(keyword string) bool {
+	if p.done() {
+		return false
+	}
+	t := p.peek()
+	if t.kind == tokenWord && strings.EqualFold(t.text, keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("AND") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	if p.done() {
+		return nil, errors.New("标签表达式不完整")
+	}
+	if p.acceptKeyword("NOT") {
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{inner: inner}, nil
+	}
+	t := p.peek()
+	if t.kind == tokenLParen {
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.done() || p.peek().kind != tokenRParen {
+			return nil, fmt.Errorf("标签表达式第 %d 个字符处的括号未闭合", t.offset+1)
+		}
+		p.pos++
+		return inner, nil
+	}
+	return p.parseCondition()
+}
+
+// parseCondition 解析 key、key=value 或 key!=value
+func (p *parser) parseCondition() (Expr, error) {
+	t := p.peek()
+	if (t.kind != tokenWord && t.kind != tokenString) || (t.kind == tokenWord && isKeyword(t.text)) {
+		return nil, fmt.Errorf("标签表达式第 %d 个字符处应为标签名: %s", t.offset+1, t.text)
+	}
+	p.pos++
+	key := t.text
+
+	if p.done() || (p.peek().kind != tokenEq && p.peek().kind != tokenNe) {
+		return hasExpr{key: key}, nil
+	}
+	op := p.peek()
+	p.pos++
+	if p.done() {
+		return nil, fmt.Errorf("标签表达式第 %d 个字符处缺少标签值", op.offset+1)
+	}
+	v := p.peek()
+	if v.kind != tokenWord && v.kind != tokenString {
+		return nil, fmt.Errorf("标签表达式第 %d 个字符处应为标签值: %s", v.offset+1, v.text)
+	}
+	p.pos++
+	return compareExpr{key: key, value: v.text, negate: op.kind == tokenNe}, nil
+}
+
+type hasExpr struct {
+	key string
+}
+
+func (e hasExpr) Match(tags map[string]string) bool {
+	_, ok := tags[e.key]
+	return ok
+}
+
+func (e hasExpr) String() string {
+	return quote(e.key)
+}
+
+type compareExpr struct {
+	key    string
+	value  string
+	negate bool
+}
+
+func (e compareExpr) Match(tags map[string]string) bool {
+	value, ok := tags[e.key]
+	return (ok && value == e.value) != e.negate
+}
+
+func (e compareExpr) String() string {
+	op := "="
+	if e.negate {
+		op = "!="
+	}
+	return quote(e.key) + op + quote(e.value)
+}
+
+type andExpr struct {
+	left, right Expr
+}
+
+func (e andExpr) Match(tags map[string]string) bool {
+	return e.left.Match(tags) && e.right.Match(tags)
+}
+
+func (e andExpr) String() string {
+	return "(" + e.left.String() + " AND " + e.right.String() + ")"
+}
+
+type orExpr struct {
+	left, right Expr
+}
+
+func (e orExpr) Match(tags map[string]string) bool {
+	return e.left.Match(tags) || e.right.Match(tags)
+}
+
+func (e orExpr) String() string {
+	return "(" + e.left.String() + " OR " + e.right.String() + ")"
+}
+
+type notExpr struct {
+	inner Expr
+}
+
+func (e notExpr) Match(tags map[string]string) bool {
+	return !e.inner.Match(tags)
+}
+
+func (e notExpr) String() string {
+	return "NOT " + e.inner.String()
+}
+
+// quote 需要时为标签名或值加上双引号
+func quote(s string) string {
+	if ValidKey(s) {
+		return s
+	}
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
@@ -0,0 +1,112 @@
+package tagexpr
+
+import "testing"
+
+func TestParseAndMatch(t *testing.T) {
+	prodEU := map[string]string{"env": "prod", "region": "eu"}
+	prodCN := map[string]string{"env": "prod", "region": "cn"}
+	staging := map[string]string{"env": "staging", "team": "data platform", "backup": ""}
+	untagged := map[string]string{}
+
+	tests := []struct {
+		expr string
+		tags map[string]string
+		want bool
+	}{
+		{expr: "env=prod", tags: prodEU, want: true},
+		{expr: "env=prod", tags: staging, want: false},
+		{expr: "env=prod AND region!=cn", tags: prodEU, want: true},
+		{expr: "env=prod AND region!=cn", tags: prodCN, want: false},
+		{expr: "env=prod and region != cn", tags: prodEU, want: true},
+		{expr: "region!=cn", tags: untagged, want: true},
+		{expr: "env=prod OR env=staging", tags: staging, want: true},
+		{expr: "env=prod OR env=staging AND region=eu", tags: prodCN, want: true},
+		{expr: "(env=prod OR env=staging) AND region=eu", tags: staging, want: false},
+		{expr: "NOT env=prod", tags: staging, want: true},
+		{expr: "not (env=prod or env=staging)", tags: untagged, want: true},
+		{expr: `team="data platform"`, tags: staging, want: true},
+		{expr: `team='data platform'`, tags: prodEU, want: false},
+		{expr: "backup", tags: staging, want: true},
+		{expr: "backup", tags: prodEU, want: false},
+		{expr: "NOT backup AND env=prod", tags: prodEU, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			expr, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.expr, err)
+			}
+			if got := expr.Match(tt.tags); got != tt.want {
+				t.Errorf("Parse(%q).Match(%v) = %v, want %v", tt.expr, tt.tags, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"   ",
+		"env=",
+		"env=prod AND",
+		"(env=prod",
+		"env=prod)",
+		"env==prod",
+		"AND env=prod",
+		"env=prod region=eu",
+		`team="data`,
+		"env=prod; DROP",
+		"= prod",
+	}
+	for _, input := range tests {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", input)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{expr: "env=prod AND region!=cn", want: "(env=prod AND region!=cn)"},
+		{expr: "a OR b AND c", want: "(a OR (b AND c))"},
+		{expr: `NOT team="data platform"`, want: `NOT team="data platform"`},
+		{expr: `k="say \"hi\""`, want: `k="say \"hi\""`},
+	}
+	for _, tt := range tests {
+		expr, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", tt.expr, err)
+		}
+		if got := expr.String(); got != tt.want {
+			t.Errorf("Parse(%q).String() = %q, want %q", tt.expr, got, tt.want)
+		}
+		// 输出的表达式可再次解析为等价表达式
+		again, err := Parse(expr.String())
+		if err != nil || again.String() != tt.want {
+			t.Errorf("re-parse of %q = %v, %v", expr.String(), again, err)
+		}
+	}
+}
+
+func TestValidKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{key: "env", want: true},
+		{key: "k8s.io/zone", want: true},
+		{key: "cost-center_2", want: true},
+		{key: "", want: false},
+		{key: "two words", want: false},
+		{key: "and", want: false},
+		{key: "a=b", want: false},
+	}
+	for _, tt := range tests {
+		if got := ValidKey(tt.key); got != tt.want {
+			t.Errorf("ValidKey(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
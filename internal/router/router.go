@@ -13,11 +13,20 @@ func Register(app *fiber.App) {
 	health := handler.NewHealth()
 	instanceHandler := handler.NewInstanceHandler()
 	databaseHandler := handler.NewDatabaseHandler()
+	tableInfoHandler := handler.NewTableInfoHandler()
+	sizeHistoryHandler := handler.NewSizeHistoryHandler()
+	schemaVersionHandler := handler.NewSchemaVersionHandler()
+	schemaCompareHandler := handler.NewSchemaCompareHandler()
+	schemaMigrationHandler := handler.NewSchemaMigrationHandler()
 	queryTaskHandler := handler.NewQueryTaskHandler()
 	sqlHandler := handler.NewSQLHandler()
 	configHandler := handler.NewConfigHandler()
 	dashboardHandler := handler.NewDashboardHandler()
 	dbDocHandler := handler.NewDbDocHandler()
+	diagnosticsHandler := handler.NewDiagnosticsHandler()
+	authHandler := handler.NewAuthHandler()
+	userHandler := handler.NewUserHandler()
+	auditHandler := handler.NewAuditHandler()
 
 	// 全局中间件
 	app.Use(middleware.CORS())
@@ -28,13 +37,50 @@ func Register(app *fiber.App) {
 	// API 路由组
 	api := app.Group("/api")
 	{
-		api.Get("/dashboard/stats", dashboardHandler.GetStats) // 仪表盘统计
+		// 登录无需认证，需注册在认证中间件之前
+		api.Post("/auth/login", authHandler.Login)
+		api.Use(middleware.Auth())
+
+		// 当前用户及 API 令牌
+		auth := api.Group("/auth")
+		{
+			auth.Post("/logout", authHandler.Logout)            // 退出登录
+			auth.Get("/me", authHandler.Me)                     // 获取当前用户
+			auth.Post("/password", authHandler.ChangePassword)  // 修改密码
+			auth.Get("/tokens", authHandler.ListTokens)         // 获取 API 令牌
+			auth.Post("/tokens", authHandler.CreateToken)       // 创建 API 令牌
+			auth.Delete("/tokens/:id", authHandler.DeleteToken) // 删除 API 令牌
+		}
+
+		// 用户管理，仅管理员
+		users := api.Group("/users", middleware.RequireAdmin())
+		{
+			users.Get("", userHandler.List)                // 获取用户列表
+			users.Post("", userHandler.Create)             // 创建用户
+			users.Put("/:id", userHandler.Update)          // 更新用户
+			users.Delete("/:id", userHandler.Delete)       // 删除用户
+			users.Get("/:id/roles", userHandler.Roles)     // 获取角色授权
+			users.Put("/:id/roles", userHandler.SaveRoles) // 保存角色授权
+		}
+
+		// 审计日志，仅管理员
+		audit := api.Group("/audit", middleware.RequireAdmin())
+		{
+			audit.Get("", auditHandler.List)          // 查询审计事件
+			audit.Get("/export", auditHandler.Export) // 导出审计事件(JSONL)
+			audit.Get("/verify", auditHandler.Verify) // 校验哈希链
+		}
+
+		api.Get("/dashboard/stats", dashboardHandler.GetStats)  // 仪表盘统计
+		api.Get("/diagnostics/pools", diagnosticsHandler.Pools) // 连接池诊断
 		// 实例管理
 		instances := api.Group("/instances")
 		{
 			instances.Post("/export", instanceHandler.ExportInstances)         // 导出实例配置
 			instances.Post("/import", instanceHandler.ImportInstances)         // 导入实例配置
 			instances.Get("/options", instanceHandler.Options)                 // 获取实例选项
+			instances.Get("/tags", instanceHandler.TagOptions)                 // 获取已使用的标签
+			instances.Post("/batch-tags", instanceHandler.BatchTag)            // 批量修改实例标签
 			instances.Get("/:id/password", instanceHandler.GetPassword)        // 获取实例密码
 			instances.Post("/test-connection", instanceHandler.TestConnection) // 测试连接
 			instances.Post("/sync-databases", instanceHandler.SyncDatabases)   // 同步数据库
@@ -54,17 +100,59 @@ func Register(app *fiber.App) {
 			databases.Post("/batch-list", databaseHandler.BatchList) // 批量查询数据库
 		}
 
+		// 表元数据
+		tables := api.Group("/tables")
+		{
+			tables.Get("", tableInfoHandler.List)            // 查询表元数据
+			tables.Get("/largest", tableInfoHandler.Largest) // 获取最大的表
+		}
+
+		// 大小历史
+		sizeHistory := api.Group("/size-history")
+		{
+			sizeHistory.Get("/growth", sizeHistoryHandler.Growth)          // 获取大小趋势
+			sizeHistory.Get("/top-growing", sizeHistoryHandler.TopGrowing) // 获取增长排行
+		}
+
+		// 库结构版本与变更
+		schema := api.Group("/schema")
+		{
+			schema.Get("/versions", schemaVersionHandler.ListVersions)              // 查询结构版本
+			schema.Get("/versions/:id", schemaVersionHandler.GetVersion)            // 获取结构版本详情
+			schema.Get("/changes", schemaVersionHandler.ListChanges)                // 查询结构变更记录
+			schema.Get("/diff", schemaVersionHandler.Diff)                          // 比较两个结构版本
+			schema.Post("/migration", schemaMigrationHandler.Generate)              // 比较两个库并生成迁移语句
+			schema.Post("/migration/query-task", schemaMigrationHandler.CreateTask) // 将迁移语句创建为查询任务
+		}
+
+		// 库结构一致性检查
+		schemaCompares := api.Group("/schema-compares")
+		{
+			schemaCompares.Post("", schemaCompareHandler.Create)             // 创建并运行检查任务
+			schemaCompares.Get("", schemaCompareHandler.List)                // 获取检查任务列表
+			schemaCompares.Get("/:id", schemaCompareHandler.Get)             // 获取检查任务详情
+			schemaCompares.Get("/:id/results", schemaCompareHandler.Results) // 获取比较结果
+			schemaCompares.Post("/:id/run", schemaCompareHandler.Run)        // 重新运行检查任务
+			schemaCompares.Delete("/:id", schemaCompareHandler.Delete)       // 删除检查任务
+		}
+
 		// 查询任务管理
 		queryTasks := api.Group("/query-tasks")
 		{
 			queryTasks.Post("", queryTaskHandler.Create)                                   // 创建查询任务
 			queryTasks.Delete("", queryTaskHandler.BatchDeleteTasks)                       // 批量删除任务
 			queryTasks.Get("", queryTaskHandler.List)                                      // 获取查询任务列表
+			queryTasks.Get("/queue", queryTaskHandler.Queue)                               // 获取执行队列状态
+			queryTasks.Post("/preview-targets", queryTaskHandler.PreviewTargets)           // 预览目标数据库
 			queryTasks.Get("/:id", queryTaskHandler.Get)                                   // 获取查询任务详情
 			queryTasks.Post("/:id/toggle-favorite", queryTaskHandler.ToggleFavoriteStatus) // 切换常用状态
 			queryTasks.Get("/:taskId/sqls", queryTaskHandler.GetSQLs)                      // 获取查询任务SQL语句列表
 			queryTasks.Get(":taskId/sqls/executions", queryTaskHandler.GetSQLExecutions)   // 获取SQL执行明细
 			queryTasks.Post(":id/run", queryTaskHandler.Run)                               // 运行查询任务
+			queryTasks.Post(":id/cancel", queryTaskHandler.Cancel)                         // 取消排队或执行中的任务
+			queryTasks.Post(":id/approve", queryTaskHandler.Approve)                       // 审批通过任务
+			queryTasks.Post(":id/reject", queryTaskHandler.Reject)                         // 驳回任务
+			queryTasks.Get(":id/reviews", queryTaskHandler.Reviews)                        // 获取任务审批记录
 			queryTasks.Get("/sqls/:sqlId/results", queryTaskHandler.GetSQLResult)          // 查询SQL结果表
 			queryTasks.Get("/sqls/:sqlId/export", queryTaskHandler.ExportSQLResult)        // 导出SQL结果表
 			queryTasks.Get(":taskId/execution-stats", queryTaskHandler.GetExecutionStats)  // 查询任务执行统计
@@ -73,10 +161,10 @@ func Register(app *fiber.App) {
 		api.Post("/sql/validate", sqlHandler.Validate) // SQL合法性校验
 
 		// 配置管理
-		api.Get("/configs/get", configHandler.GetConfig)              // 获取配置
-		api.Post("/configs/set", configHandler.SetConfig)             // 保存配置
-		api.Post("/configs/save", configHandler.SaveConfigs)          // 批量保存配置
-		api.Post("/configs/batch-get", configHandler.BatchGetConfigs) // 批量获取配置
+		api.Get("/configs/get", configHandler.GetConfig)                                // 获取配置
+		api.Post("/configs/set", middleware.RequireAdmin(), configHandler.SetConfig)    // 保存配置，仅管理员
+		api.Post("/configs/save", middleware.RequireAdmin(), configHandler.SaveConfigs) // 批量保存配置，仅管理员
+		api.Post("/configs/batch-get", configHandler.BatchGetConfigs)                   // 批量获取配置
 
 		// 数据库文档管理
 		dbDocs := api.Group("/db-docs")
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/authctx"
+	"my-bulker/internal/pkg/rbac"
+	"my-bulker/internal/pkg/sql_parse"
+	"my-bulker/internal/pkg/tagexpr"
+
+	"gorm.io/gorm"
+)
+
+var ErrForbidden = errors.New("没有权限执行该操作")
+
+// accessPolicy 加载当前用户的角色授权。
+// 接口请求都会经过认证中间件写入用户；没有用户时仅 authctx.WithSystem 标记的内部调用不受授权限制，其余一律无权限。
+func accessPolicy(ctx context.Context, db *gorm.DB) (rbac.Policy, error) {
+	user := authctx.User(ctx)
+	if user == nil {
+		if authctx.IsSystem(ctx) {
+			return rbac.Policy{Superuser: true}, nil
+		}
+		return rbac.Policy{}, nil
+	}
+	if user.IsAdmin {
+		return rbac.Policy{Superuser: true}, nil
+	}
+	var rows []model.RoleBinding
+	if err := db.Where("user_id = ?", user.ID).Find(&rows).Error; err != nil {
+		return rbac.Policy{}, err
+	}
+	policy := rbac.Policy{Bindings: make([]rbac.Binding, 0, len(rows))}
+	for _, row := range rows {
+		binding, err := toBinding(row.Role, row.InstanceID, row.TagExpr)
+		if err != nil {
+			// 保存时已校验，这里只会是手工修改过的数据，跳过并记录
+			log.Printf("WARN: skip invalid role binding %d of user %d: %v", row.ID, row.UserID, err)
+			continue
+		}
+		policy.Bindings = append(policy.Bindings, binding)
+	}
+	return policy, nil
+}
+
+// toBinding 解析一条角色授权
+func toBinding(role string, instanceID uint, tagExpr string) (rbac.Binding, error) {
+	parsed, err := rbac.ParseRole(role)
+	if err != nil {
+		return rbac.Binding{}, err
+	}
+	binding := rbac.Binding{Role: parsed, InstanceID: instanceID}
+	if tagExpr = strings.TrimSpace(tagExpr); tagExpr != "" {
+		if instanceID != 0 {
+			return rbac.Binding{}, errors.New("实例和标签表达式只能填写一个")
+		}
+		if binding.Tags, err = tagexpr.Parse(tagExpr); err != nil {
+			return rbac.Binding{}, err
+		}
+	}
+	return binding, nil
+}
+
+// rbacInstance 转换为授权判断使用的实例
+func rbacInstance(instance *model.Instance) rbac.Instance {
+	return rbac.Instance{ID: instance.ID, Tags: instance.Tags}
+}
+
+// requireAccess 检查当前用户能否对全部实例执行操作，不能时返回 ErrForbidden 并列出无权限的实例
+func requireAccess(ctx context.Context, db *gorm.DB, action rbac.Action, instanceIDs []uint) error {
+	policy, err := accessPolicy(ctx, db)
+	if err != nil {
+		return err
+	}
+	return policyAllows(db, policy, action, instanceIDs)
+}
+
+// policyAllows 检查授权能否对全部实例执行操作，已不存在的实例不参与检查
+func policyAllows(db *gorm.DB, policy rbac.Policy, action rbac.Action, instanceIDs []uint) error {
+	if policy.CanAll(action) || len(instanceIDs) == 0 {
+		return nil
+	}
+	var instances []model.Instance
+	if err := db.Select("id, name, tags").Where("id IN ?", instanceIDs).Find(&instances).Error; err != nil {
+		return err
+	}
+	var denied []string
+	for i := range instances {
+		if !policy.Can(action, rbacInstance(&instances[i])) {
+			denied = append(denied, instances[i].Name)
+		}
+	}
+	if len(denied) > 0 {
+		sort.Strings(denied)
+		return fmt.Errorf("%w：缺少以下实例的%s权限: %s", ErrForbidden, action, strings.Join(denied, ", "))
+	}
+	return nil
+}
+
+// accessibleInstanceIDs 获取当前用户可执行操作的实例ID。all 为 true 时不受限制，ids 无意义
+func accessibleInstanceIDs(ctx context.Context, db *gorm.DB, action rbac.Action) (ids []uint, all bool, err error) {
+	policy, err := accessPolicy(ctx, db)
+	if err != nil {
+		return nil, false, err
+	}
+	if policy.CanAll(action) {
+		return nil, true, nil
+	}
+	var instances []model.Instance
+	if err := db.Select("id, tags").Order("id ASC").Find(&instances).Error; err != nil {
+		return nil, false, err
+	}
+	ids = make([]uint, 0, len(instances))
+	for i := range instances {
+		if policy.Can(action, rbacInstance(&instances[i])) {
+			ids = append(ids, instances[i].ID)
+		}
+	}
+	return ids, false, nil
+}
+
+// taskAction 创建、运行任务需要的操作，与保护模式一致，除只读查询外的语句都按写入处理
+func taskAction(statements []string) rbac.Action {
+	for _, stmt := range statements {
+		if sql_parse.ClassifyStatement(stmt) != sql_parse.StatementRead {
+			return rbac.ActionRunWrite
+		}
+	}
+	return rbac.ActionRunRead
+}
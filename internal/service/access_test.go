@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/authctx"
+	"my-bulker/internal/pkg/rbac"
+)
+
+func TestTaskAction(t *testing.T) {
+	tests := []struct {
+		name       string
+		statements []string
+		want       rbac.Action
+	}{
+		{name: "empty", want: rbac.ActionRunRead},
+		{name: "select", statements: []string{"SELECT 1", "SHOW TABLES"}, want: rbac.ActionRunRead},
+		{name: "dml", statements: []string{"SELECT 1", "DELETE FROM t"}, want: rbac.ActionRunWrite},
+		{name: "ddl", statements: []string{"ALTER TABLE t ADD c INT"}, want: rbac.ActionRunWrite},
+		{name: "session statement", statements: []string{"SET NAMES utf8mb4"}, want: rbac.ActionRunWrite},
+		{name: "cte write", statements: []string{"WITH d AS (DELETE FROM t RETURNING *) SELECT * FROM d"}, want: rbac.ActionRunWrite},
+		{name: "select into outfile", statements: []string{"SELECT * FROM t INTO OUTFILE '/tmp/t'"}, want: rbac.ActionRunWrite},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := taskAction(tt.statements); got != tt.want {
+				t.Errorf("taskAction() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireAccess(t *testing.T) {
+	const (
+		viewer = 2
+		writer = 3
+	)
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		action      rbac.Action
+		instanceIDs []uint
+		wantErr     error
+		wantDenied  string // 错误信息中应列出的无权限实例
+	}{
+		{name: "no user", ctx: context.Background(), action: rbac.ActionView, instanceIDs: []uint{1}, wantErr: ErrForbidden},
+		{name: "no user without instances", ctx: context.Background(), action: rbac.ActionRunWrite},
+		{name: "system", ctx: authctx.WithSystem(context.Background()), action: rbac.ActionManage, instanceIDs: []uint{1, 2}},
+		{name: "admin", ctx: authctx.WithUser(context.Background(), &model.User{ID: 1, IsAdmin: true}), action: rbac.ActionManage, instanceIDs: []uint{1, 2}},
+		{name: "viewer reads by tag", ctx: authctx.WithUser(context.Background(), &model.User{ID: viewer}), action: rbac.ActionView, instanceIDs: []uint{1}},
+		{name: "viewer cannot run", ctx: authctx.WithUser(context.Background(), &model.User{ID: viewer}), action: rbac.ActionRunRead, instanceIDs: []uint{1},
+			wantErr: ErrForbidden, wantDenied: "eu"},
+		{name: "viewer outside tag", ctx: authctx.WithUser(context.Background(), &model.User{ID: viewer}), action: rbac.ActionView, instanceIDs: []uint{1, 2},
+			wantErr: ErrForbidden, wantDenied: "us"},
+		{name: "writer on bound instance", ctx: authctx.WithUser(context.Background(), &model.User{ID: writer}), action: rbac.ActionRunWrite, instanceIDs: []uint{2}},
+		{name: "writer lists all denied", ctx: authctx.WithUser(context.Background(), &model.User{ID: writer}), action: rbac.ActionRunWrite, instanceIDs: []uint{1, 2, 3},
+			wantErr: ErrForbidden, wantDenied: "eu, us2"},
+		{name: "deleted instance is skipped", ctx: authctx.WithUser(context.Background(), &model.User{ID: writer}), action: rbac.ActionRunWrite, instanceIDs: []uint{2, 99}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openTestDB(t)
+			instances := []model.Instance{
+				{ID: 1, Name: "eu", Engine: model.EngineMySQL, Tags: model.InstanceTags{"region": "eu"}},
+				{ID: 2, Name: "us", Engine: model.EngineMySQL, Tags: model.InstanceTags{"region": "us"}},
+				{ID: 3, Name: "us2", Engine: model.EngineMySQL, Tags: model.InstanceTags{"region": "us"}},
+			}
+			bindings := []model.RoleBinding{
+				{UserID: viewer, Role: string(rbac.RoleViewer), TagExpr: "region=eu"},
+				{UserID: writer, Role: string(rbac.RoleWriter), InstanceID: 2},
+			}
+			if err := db.Create(&instances).Error; err != nil {
+				t.Fatal(err)
+			}
+			if err := db.Create(&bindings).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			err := requireAccess(tt.ctx, db, tt.action, tt.instanceIDs)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("requireAccess() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantDenied != "" && !strings.HasSuffix(err.Error(), ": "+tt.wantDenied) {
+				t.Errorf("requireAccess() error = %v, want denied instances %q", err, tt.wantDenied)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/auditchain"
+	"my-bulker/internal/pkg/authctx"
+	"my-bulker/internal/pkg/database"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidAuditFilter 审计查询条件无效
+var ErrInvalidAuditFilter = errors.New("审计查询条件无效")
+
+// auditBatchSize 导出和校验时每批读取的事件数
+const auditBatchSize = 500
+
+// AuditService 审计日志服务
+// 追加事件时需要读取上一条事件的哈希，进程内串行写入保证哈希链不分叉。
+type AuditService struct {
+	db *gorm.DB
+	mu sync.Mutex
+}
+
+var (
+	auditServiceInstance *AuditService
+	auditOnce            sync.Once
+)
+
+// NewAuditService 单例
+func NewAuditService() *AuditService {
+	auditOnce.Do(func() {
+		auditServiceInstance = &AuditService{db: database.GetDB()}
+	})
+	return auditServiceInstance
+}
+
+// Record 追加一条审计事件，操作人和客户端IP取自上下文，detail 序列化为 JSON 保存
+func (s *AuditService) Record(ctx context.Context, action, targetType string, targetIDs []uint, detail interface{}) error {
+	var raw json.RawMessage
+	if detail != nil {
+		b, err := json.Marshal(detail)
+		if err != nil {
+			return fmt.Errorf("序列化审计详情失败: %v", err)
+		}
+		raw = b
+	}
+	ids := make([]string, len(targetIDs))
+	for i, id := range targetIDs {
+		ids[i] = strconv.FormatUint(uint64(id), 10)
+	}
+	event := model.AuditEvent{
+		ActorID:    authctx.UserID(ctx),
+		Actor:      authctx.Username(ctx),
+		IP:         authctx.ClientIP(ctx),
+		Action:     action,
+		TargetType: targetType,
+		TargetIDs:  strings.Join(ids, ","),
+		Detail:     raw,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var last model.AuditEvent
+		err := tx.Select("id", "hash").Order("id DESC").Limit(1).Find(&last).Error
+		if err != nil {
+			return fmt.Errorf("读取审计链失败: %v", err)
+		}
+		event.PrevHash = last.Hash
+		// 保存到微秒，避免不同驱动的时间精度导致校验时哈希不一致
+		event.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+		event.Hash = auditchain.Hash(event.PrevHash, auditEntry(&event))
+		return tx.Create(&event).Error
+	})
+}
+
+// Log 追加审计事件，失败时只记录日志，用于操作已完成后的留痕
+func (s *AuditService) Log(ctx context.Context, action, targetType string, targetIDs []uint, detail interface{}) {
+	if err := s.Record(ctx, action, targetType, targetIDs, detail); err != nil {
+		log.Printf("ERROR: failed to record audit event %s: %v", action, err)
+	}
+}
+
+// List 按条件分页查询审计事件，按时间倒序
+func (s *AuditService) List(req *model.AuditListRequest) (*model.AuditListResponse, error) {
+	query, err := s.filter(req)
+	if err != nil {
+		return nil, err
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+	var items []model.AuditEvent
+	if err := query.Order("id DESC").Offset(req.GetOffset()).Limit(req.GetLimit()).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return &model.AuditListResponse{Total: total, Items: items}, nil
+}
+
+// Export 按条件导出审计事件，每行一个 JSON 对象，按时间正序
+func (s *AuditService) Export(req *model.AuditListRequest, w io.Writer) (int, error) {
+	query, err := s.filter(req)
+	if err != nil {
+		return 0, err
+	}
+	enc := json.NewEncoder(w)
+	count := 0
+	var batch []model.AuditEvent
+	err = query.Order("id ASC").FindInBatches(&batch, auditBatchSize, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			if err := enc.Encode(&batch[i]); err != nil {
+				return err
+			}
+		}
+		count += len(batch)
+		return nil
+	}).Error
+	return count, err
+}
+
+// Verify 从第一条事件开始校验整个哈希链
+func (s *AuditService) Verify() (*model.AuditVerifyResponse, error) {
+	result := &model.AuditVerifyResponse{Valid: true}
+	prev := auditchain.Genesis
+	var batch []model.AuditEvent
+	err := s.db.Model(&model.AuditEvent{}).Order("id ASC").FindInBatches(&batch, auditBatchSize, func(tx *gorm.DB, _ int) error {
+		links := make([]auditchain.Link, len(batch))
+		for i := range batch {
+			links[i] = auditchain.Link{Entry: auditEntry(&batch[i]), PrevHash: batch[i].PrevHash, Hash: batch[i].Hash}
+		}
+		last, broken := auditchain.Verify(prev, links)
+		if broken >= 0 {
+			result.Valid = false
+			result.BrokenID = batch[broken].ID
+			result.Total += int64(broken)
+			return errAuditChainBroken
+		}
+		prev = last
+		result.Total += int64(len(batch))
+		return nil
+	}).Error
+	if err != nil && !errors.Is(err, errAuditChainBroken) {
+		return nil, err
+	}
+	if result.Valid {
+		result.Message = fmt.Sprintf("哈希链完整，共 %d 条事件", result.Total)
+	} else {
+		result.Message = fmt.Sprintf("哈希链在事件 #%d 处不一致，该事件或其之前的记录可能被修改、删除或插入", result.BrokenID)
+	}
+	return result, nil
+}
+
+// errAuditChainBroken 校验发现不一致时用于提前结束分批读取
+var errAuditChainBroken = errors.New("audit chain broken")
+
+// filter 根据查询条件构造查询
+func (s *AuditService) filter(req *model.AuditListRequest) (*gorm.DB, error) {
+	query := s.db.Model(&model.AuditEvent{})
+	if actor := strings.TrimSpace(req.Actor); actor != "" {
+		query = query.Where("actor = ?", actor)
+	}
+	if action := strings.TrimSpace(req.Action); action != "" {
+		// 以 . 结尾时按前缀匹配，如 instance. 匹配全部实例操作
+		if strings.HasSuffix(action, ".") {
+			query = query.Where("action LIKE ?", action+"%")
+		} else {
+			query = query.Where("action = ?", action)
+		}
+	}
+	if req.TargetType != "" {
+		query = query.Where("target_type = ?", req.TargetType)
+	}
+	if req.TargetID > 0 {
+		query = query.Where("(',' || target_ids || ',') LIKE ?", fmt.Sprintf("%%,%d,%%", req.TargetID))
+	}
+	if ip := strings.TrimSpace(req.IP); ip != "" {
+		query = query.Where("ip = ?", ip)
+	}
+	if req.Start != "" {
+		start, err := parseAuditTime(req.Start)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("created_at >= ?", start)
+	}
+	if req.End != "" {
+		end, err := parseAuditTime(req.End)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("created_at <= ?", end)
+	}
+	return query, nil
+}
+
+// parseAuditTime 解析查询时间，未带时区时按服务器本地时间
+func parseAuditTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := time.ParseInLocation(time.DateTime, value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: 无法解析时间 %q", ErrInvalidAuditFilter, value)
+	}
+	return t.UTC(), nil
+}
+
+// auditEntry 事件中参与哈希计算的字段
+func auditEntry(e *model.AuditEvent) auditchain.Entry {
+	return auditchain.Entry{
+		Time:       e.CreatedAt,
+		ActorID:    e.ActorID,
+		Actor:      e.Actor,
+		IP:         e.IP,
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetIDs:  e.TargetIDs,
+		Detail:     e.Detail,
+	}
+}
+
+// TaskDetail 查询任务的审计详情：任务名称、SQL 语句和目标实例
+// 删除任务前需先取得详情，任务不存在时返回仅含ID的详情。
+func (s *AuditService) TaskDetail(taskID uint) map[string]interface{} {
+	detail := map[string]interface{}{"task_id": taskID}
+	var task model.QueryTask
+	if err := s.db.Select("id", "task_name", "total_dbs").First(&task, taskID).Error; err != nil {
+		return detail
+	}
+	detail["task_name"] = task.TaskName
+	detail["total_dbs"] = task.TotalDBs
+	var statements []string
+	if err := s.db.Model(&model.QueryTaskSQL{}).Where("task_id = ?", taskID).Order("sql_order ASC").Pluck("sql_content", &statements).Error; err == nil {
+		detail["sqls"] = statements
+	}
+	if ids, err := taskInstanceIDs(s.db, []uint{taskID}); err == nil {
+		detail["instance_ids"] = ids
+	}
+	return detail
+}
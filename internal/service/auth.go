@@ -0,0 +1,229 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/credential"
+	"my-bulker/internal/pkg/database"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// sessionTTL 界面登录会话有效期
+	sessionTTL = 7 * 24 * time.Hour
+	// tokenTouchInterval API 令牌最后使用时间的更新间隔，避免每次请求都写库
+	tokenTouchInterval = time.Minute
+)
+
+var (
+	ErrInvalidCredentials = errors.New("用户名或密码错误")
+	ErrUnauthenticated    = errors.New("未登录或登录已过期")
+	ErrUserDisabled       = errors.New("用户已被禁用")
+)
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+// AuthService 登录会话与 API 令牌服务
+type AuthService struct {
+	db *gorm.DB
+}
+
+// NewAuthService 创建认证服务
+func NewAuthService() *AuthService {
+	return &AuthService{db: database.GetDB()}
+}
+
+// Login 校验用户名密码并创建会话，返回明文会话令牌
+func (s *AuthService) Login(req *model.LoginRequest, ip, userAgent string) (string, *model.UserSession, *model.User, error) {
+	var user model.User
+	err := s.db.Where("username = ?", strings.TrimSpace(req.Username)).First(&user).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil, nil, err
+		}
+		// 用户不存在时同样执行一次比对，避免通过响应时间判断用户名是否存在
+		credential.CheckPassword(getDummyHash(), req.Password)
+		return "", nil, nil, ErrInvalidCredentials
+	}
+	if !credential.CheckPassword(user.PasswordHash, req.Password) {
+		return "", nil, nil, ErrInvalidCredentials
+	}
+	if user.Disabled {
+		return "", nil, nil, ErrUserDisabled
+	}
+
+	token, err := credential.NewToken(credential.SessionPrefix)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	now := time.Now()
+	session := &model.UserSession{
+		UserID:    user.ID,
+		TokenHash: credential.HashToken(token),
+		ExpiresAt: now.Add(sessionTTL),
+		IP:        ip,
+		UserAgent: truncate(userAgent, 255),
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// 顺带清理已过期的会话
+		if err := tx.Where("expires_at < ?", now).Delete(&model.UserSession{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(session).Error; err != nil {
+			return err
+		}
+		return tx.Model(&user).UpdateColumn("last_login_at", now).Error
+	})
+	if err != nil {
+		return "", nil, nil, err
+	}
+	user.LastLoginAt = &now
+	return token, session, &user, nil
+}
+
+// Logout 注销会话
+func (s *AuthService) Logout(token string) error {
+	if token == "" || credential.IsAPIToken(token) {
+		return nil
+	}
+	return s.db.Where("token_hash = ?", credential.HashToken(token)).Delete(&model.UserSession{}).Error
+}
+
+// Authenticate 根据会话令牌或 API 令牌获取当前用户
+func (s *AuthService) Authenticate(token string) (*model.User, error) {
+	if token == "" {
+		return nil, ErrUnauthenticated
+	}
+	now := time.Now()
+	hash := credential.HashToken(token)
+
+	var userID uint
+	if credential.IsAPIToken(token) {
+		var apiToken model.APIToken
+		if err := s.db.Where("token_hash = ?", hash).First(&apiToken).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrUnauthenticated
+			}
+			return nil, err
+		}
+		if apiToken.ExpiresAt != nil && apiToken.ExpiresAt.Before(now) {
+			return nil, ErrUnauthenticated
+		}
+		if apiToken.LastUsedAt == nil || now.Sub(*apiToken.LastUsedAt) > tokenTouchInterval {
+			if err := s.db.Model(&apiToken).UpdateColumn("last_used_at", now).Error; err != nil {
+				return nil, err
+			}
+		}
+		userID = apiToken.UserID
+	} else {
+		var session model.UserSession
+		if err := s.db.Where("token_hash = ? AND expires_at > ?", hash, now).First(&session).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrUnauthenticated
+			}
+			return nil, err
+		}
+		userID = session.UserID
+	}
+
+	var user model.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUnauthenticated
+		}
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+	return &user, nil
+}
+
+// ChangePassword 修改当前用户密码，并注销除当前会话外的其他会话
+func (s *AuthService) ChangePassword(user *model.User, req *model.ChangePasswordRequest, currentToken string) error {
+	if !credential.CheckPassword(user.PasswordHash, req.OldPassword) {
+		return ErrInvalidCredentials
+	}
+	hash, err := credential.HashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Update("password_hash", hash).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ? AND token_hash <> ?", user.ID, credential.HashToken(currentToken)).
+			Delete(&model.UserSession{}).Error
+	})
+}
+
+// ListAPITokens 获取用户的 API 令牌
+func (s *AuthService) ListAPITokens(userID uint) ([]model.APIToken, error) {
+	var tokens []model.APIToken
+	if err := s.db.Where("user_id = ?", userID).Order("id DESC").Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// CreateAPIToken 创建 API 令牌，明文令牌只在创建时返回
+func (s *AuthService) CreateAPIToken(userID uint, req *model.CreateAPITokenRequest) (*model.CreateAPITokenResponse, error) {
+	token, err := credential.NewToken(credential.APITokenPrefix)
+	if err != nil {
+		return nil, err
+	}
+	item := &model.APIToken{
+		UserID:    userID,
+		Name:      strings.TrimSpace(req.Name),
+		TokenHash: credential.HashToken(token),
+		Prefix:    credential.DisplayPrefix(token),
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		item.ExpiresAt = &expiresAt
+	}
+	if err := s.db.Create(item).Error; err != nil {
+		return nil, err
+	}
+	return &model.CreateAPITokenResponse{Token: token, Item: item}, nil
+}
+
+// DeleteAPIToken 删除用户自己的 API 令牌
+func (s *AuthService) DeleteAPIToken(userID, id uint) error {
+	result := s.db.Where("id = ? AND user_id = ?", id, userID).Delete(&model.APIToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// getDummyHash 用于用户不存在时的密码比对
+func getDummyHash() string {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = credential.HashPassword("my-bulker-dummy-password")
+	})
+	return dummyHash
+}
+
+// truncate 按字节截断字符串，不截断多字节字符
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max]
+}
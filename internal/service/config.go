@@ -1,8 +1,10 @@
 package service
 
 import (
+	"log"
 	"my-bulker/internal/model"
 	"my-bulker/internal/pkg/database"
+	"strconv"
 	"sync"
 
 	"gorm.io/gorm"
@@ -113,3 +115,17 @@ func (s *ConfigService) BatchGetConfig(keys []string) ([]model.Config, error) {
 	}
 	return configs, nil
 }
+
+// GetIntConfig 获取整数配置，未设置或解析失败时返回默认值
+func (s *ConfigService) GetIntConfig(key string, defaultValue int) int {
+	str, err := s.GetConfig(key)
+	if err != nil {
+		return defaultValue
+	}
+	val, err := strconv.Atoi(str)
+	if err != nil {
+		log.Printf("WARN: Could not parse %s '%s', using default %d. Error: %v", key, str, defaultValue, err)
+		return defaultValue
+	}
+	return val
+}
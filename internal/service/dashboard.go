@@ -33,6 +33,7 @@ type TaskSummary struct {
 	Running   int64 `json:"running"`
 	Completed int64 `json:"completed"`
 	Failed    int64 `json:"failed"`
+	Queued    int64 `json:"queued"`
 }
 
 // RecentTask 最近的任务信息
@@ -57,10 +58,11 @@ func (s *DashboardService) GetStats(ctx context.Context) (*DashboardStats, error
 			SUM(CASE WHEN status = 0 THEN 1 ELSE 0 END) as pending,
 			SUM(CASE WHEN status = 1 THEN 1 ELSE 0 END) as running,
 			SUM(CASE WHEN status = 2 THEN 1 ELSE 0 END) as completed,
-			SUM(CASE WHEN status = 3 THEN 1 ELSE 0 END) as failed
+			SUM(CASE WHEN status = 3 THEN 1 ELSE 0 END) as failed,
+			SUM(CASE WHEN status = 4 THEN 1 ELSE 0 END) as queued
 		`).
 		Row().
-		Scan(&taskSummary.Total, &taskSummary.Pending, &taskSummary.Running, &taskSummary.Completed, &taskSummary.Failed)
+		Scan(&taskSummary.Total, &taskSummary.Pending, &taskSummary.Running, &taskSummary.Completed, &taskSummary.Failed, &taskSummary.Queued)
 
 	if err != nil {
 		return nil, err
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/rbac"
 
 	"gorm.io/gorm"
 )
@@ -19,12 +20,19 @@ func NewDatabaseService(db *gorm.DB) *DatabaseService {
 	return &DatabaseService{db: db}
 }
 
-// List 获取数据库列表
+// List 获取当前用户可查看实例的数据库列表
 func (s *DatabaseService) List(ctx context.Context, req *model.DatabaseListRequest) (*model.DatabaseListResponse, error) {
 	var total int64
 	var databases []model.Database
 
 	query := s.db.Model(&model.Database{}).Preload("Instance")
+	visible, all, err := accessibleInstanceIDs(ctx, s.db, rbac.ActionView)
+	if err != nil {
+		return nil, err
+	}
+	if !all {
+		query = query.Where("instance_id IN ?", visible)
+	}
 
 	// 应用过滤条件
 	if req.Name != "" {
@@ -83,6 +91,9 @@ func (s *DatabaseService) Get(ctx context.Context, id uint) (*model.DatabaseResp
 	if err := s.db.Preload("Instance").First(&db, id).Error; err != nil {
 		return nil, err
 	}
+	if err := requireAccess(ctx, s.db, rbac.ActionView, []uint{db.InstanceID}); err != nil {
+		return nil, err
+	}
 
 	// 转换为响应格式
 	return &model.DatabaseResponse{
@@ -103,3 +114,22 @@ func (s *DatabaseService) Get(ctx context.Context, id uint) (*model.DatabaseResp
 		},
 	}, nil
 }
+
+// VisibleInstanceIDs 从实例ID中筛选出当前用户可查看的实例
+func (s *DatabaseService) VisibleInstanceIDs(ctx context.Context, instanceIDs []uint) ([]uint, error) {
+	visible, all, err := accessibleInstanceIDs(ctx, s.db, rbac.ActionView)
+	if err != nil || all {
+		return instanceIDs, err
+	}
+	allowed := make(map[uint]bool, len(visible))
+	for _, id := range visible {
+		allowed[id] = true
+	}
+	result := make([]uint, 0, len(instanceIDs))
+	for _, id := range instanceIDs {
+		if allowed[id] {
+			result = append(result, id)
+		}
+	}
+	return result, nil
+}
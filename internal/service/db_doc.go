@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -8,7 +9,9 @@ import (
 	"time"
 
 	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/authctx"
 	"my-bulker/internal/pkg/database"
+	"my-bulker/internal/pkg/rbac"
 
 	"gorm.io/gorm"
 )
@@ -23,8 +26,11 @@ func NewDbDocService(db *gorm.DB) *DbDocService {
 	return &DbDocService{db: db}
 }
 
-// CreateTask 创建任务
-func (s *DbDocService) CreateTask(req *model.DbDocTaskRequest) (*model.DbDocTask, error) {
+// CreateTask 创建任务，需要实例的运行权限
+func (s *DbDocService) CreateTask(ctx context.Context, req *model.DbDocTaskRequest) (*model.DbDocTask, error) {
+	if err := requireAccess(ctx, s.db, rbac.ActionRunRead, []uint{req.InstanceID}); err != nil {
+		return nil, err
+	}
 	task := &model.DbDocTask{
 		TaskName:     req.TaskName,
 		InstanceID:   req.InstanceID,
@@ -34,6 +40,9 @@ func (s *DbDocService) CreateTask(req *model.DbDocTaskRequest) (*model.DbDocTask
 		Config:       req.Config,
 		SyncInterval: req.SyncInterval,
 		IsEnable:     req.IsEnable,
+
+		CreatedBy:     authctx.UserID(ctx),
+		CreatedByName: authctx.Username(ctx),
 	}
 
 	if err := s.db.Create(task).Error; err != nil {
@@ -43,12 +52,15 @@ func (s *DbDocService) CreateTask(req *model.DbDocTaskRequest) (*model.DbDocTask
 	return task, nil
 }
 
-// UpdateTask 更新任务
-func (s *DbDocService) UpdateTask(id uint, req *model.DbDocTaskRequest) (*model.DbDocTask, error) {
+// UpdateTask 更新任务，需要原实例和新实例的运行权限
+func (s *DbDocService) UpdateTask(ctx context.Context, id uint, req *model.DbDocTaskRequest) (*model.DbDocTask, error) {
 	var task model.DbDocTask
 	if err := s.db.First(&task, id).Error; err != nil {
 		return nil, err
 	}
+	if err := requireAccess(ctx, s.db, rbac.ActionRunRead, []uint{task.InstanceID, req.InstanceID}); err != nil {
+		return nil, err
+	}
 
 	task.TaskName = req.TaskName
 	task.InstanceID = req.InstanceID
@@ -65,26 +77,43 @@ func (s *DbDocService) UpdateTask(id uint, req *model.DbDocTaskRequest) (*model.
 	return &task, nil
 }
 
-// DeleteTask 删除任务
-func (s *DbDocService) DeleteTask(id uint) error {
+// DeleteTask 删除任务，需要实例的运行权限
+func (s *DbDocService) DeleteTask(ctx context.Context, id uint) error {
+	var instanceIDs []uint
+	if err := s.db.Model(&model.DbDocTask{}).Where("id = ?", id).Pluck("instance_id", &instanceIDs).Error; err != nil {
+		return err
+	}
+	if err := requireAccess(ctx, s.db, rbac.ActionRunRead, instanceIDs); err != nil {
+		return err
+	}
 	return s.db.Delete(&model.DbDocTask{}, id).Error
 }
 
-// GetTask 获取任务详情
-func (s *DbDocService) GetTask(id uint) (*model.DbDocTask, error) {
+// GetTask 获取任务详情，需要实例的查看权限
+func (s *DbDocService) GetTask(ctx context.Context, id uint) (*model.DbDocTask, error) {
 	var task model.DbDocTask
 	if err := s.db.Preload("Instance").First(&task, id).Error; err != nil {
 		return nil, err
 	}
+	if err := requireAccess(ctx, s.db, rbac.ActionView, []uint{task.InstanceID}); err != nil {
+		return nil, err
+	}
 	return &task, nil
 }
 
-// ListTasks 获取任务列表
-func (s *DbDocService) ListTasks(req *model.DbDocTaskListRequest) ([]model.DbDocTask, int64, error) {
+// ListTasks 获取当前用户可查看实例的任务列表
+func (s *DbDocService) ListTasks(ctx context.Context, req *model.DbDocTaskListRequest) ([]model.DbDocTask, int64, error) {
 	var tasks []model.DbDocTask
 	var total int64
 
 	query := s.db.Model(&model.DbDocTask{}).Preload("Instance")
+	visible, all, err := accessibleInstanceIDs(ctx, s.db, rbac.ActionView)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !all {
+		query = query.Where("instance_id IN ?", visible)
+	}
 	if req.TaskName != "" {
 		query = query.Where("task_name LIKE ?", "%"+req.TaskName+"%")
 	}
@@ -108,12 +137,15 @@ func (s *DbDocService) ListTasks(req *model.DbDocTaskListRequest) ([]model.DbDoc
 	return tasks, total, nil
 }
 
-// RunTask 手动运行任务
-func (s *DbDocService) RunTask(id uint) error {
-	task, err := s.GetTask(id)
+// RunTask 手动运行任务，需要实例的运行权限
+func (s *DbDocService) RunTask(ctx context.Context, id uint) error {
+	task, err := s.GetTask(ctx, id)
 	if err != nil {
 		return err
 	}
+	if err := requireAccess(ctx, s.db, rbac.ActionRunRead, []uint{task.InstanceID}); err != nil {
+		return err
+	}
 
 	// 更新状态为运行中 (这里可以简单处理，因为生成通常很快)
 	now := time.Now()
@@ -134,120 +166,68 @@ func (s *DbDocService) RunTask(id uint) error {
 // GenerateDoc 生成文档的核心逻辑
 func (s *DbDocService) GenerateDoc(task *model.DbDocTask) error {
 	// 1. 获取实例连接
-	targetDB, err := database.NewMySQLGormDB(&task.Instance, task.Database, 10)
+	pool, err := database.AcquirePool(&task.Instance, task.Instance.EffectiveMaxConnections(NewConfigService().GetIntConfig("max_conn", model.DefaultConfigValues.MaxConn)))
 	if err != nil {
 		return fmt.Errorf("连接数据库失败: %v", err)
 	}
+	defer pool.Release()
 
-	// 2. 获取所有表及其描述
-	type TableInfo struct {
-		TableName    string `gorm:"column:TABLE_NAME"`
-		TableComment string `gorm:"column:TABLE_COMMENT"`
-	}
-	var tables []TableInfo
-	err = targetDB.Raw(`
-		SELECT TABLE_NAME, TABLE_COMMENT 
-		FROM information_schema.TABLES 
-		WHERE TABLE_SCHEMA = ? AND TABLE_TYPE = 'BASE TABLE'
-	`, task.Database).Scan(&tables).Error
-	if err != nil {
-		return fmt.Errorf("获取表列表失败: %v", err)
-	}
-
-	// 3. 批量获取所有表的字段信息
-	type ColumnInfo struct {
-		TableName     string  `gorm:"column:TABLE_NAME"`
-		ColumnName    string  `gorm:"column:COLUMN_NAME"`
-		ColumnType    string  `gorm:"column:COLUMN_TYPE"`
-		IsNullable    string  `gorm:"column:IS_NULLABLE"`
-		ColumnDefault *string `gorm:"column:COLUMN_DEFAULT"`
-		ColumnComment string  `gorm:"column:COLUMN_COMMENT"`
-	}
-	var allColumns []ColumnInfo
-	err = targetDB.Raw(`
-		SELECT TABLE_NAME, COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE, COLUMN_DEFAULT, COLUMN_COMMENT
-		FROM information_schema.COLUMNS
-		WHERE TABLE_SCHEMA = ?
-		ORDER BY TABLE_NAME, ORDINAL_POSITION
-	`, task.Database).Scan(&allColumns).Error
-	if err != nil {
-		return fmt.Errorf("批量获取字段信息失败: %v", err)
-	}
-
-	// 按表名对字段进行分组
-	columnsByTable := make(map[string][]ColumnInfo)
-	for _, col := range allColumns {
-		columnsByTable[col.TableName] = append(columnsByTable[col.TableName], col)
-	}
-
-	// 4. 批量获取所有表的索引信息
-	type IndexInfo struct {
-		TableName  string `gorm:"column:TABLE_NAME"`
-		IndexName  string `gorm:"column:INDEX_NAME"`
-		NonUnique  int    `gorm:"column:NON_UNIQUE"`
-		ColumnName string `gorm:"column:COLUMN_NAME"`
-	}
-	var allIndexes []IndexInfo
-	err = targetDB.Raw(`
-		SELECT TABLE_NAME, INDEX_NAME, NON_UNIQUE, COLUMN_NAME
-		FROM information_schema.STATISTICS
-		WHERE TABLE_SCHEMA = ?
-		ORDER BY TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX
-	`, task.Database).Scan(&allIndexes).Error
+	// 2. 在目标库的连接上获取所有表的字段和索引结构
+	ctx := context.Background()
+	var tables []model.SchemaTable
+	err = pool.WithDatabase(ctx, task.Database, func(tx *gorm.DB) error {
+		tables, err = pool.Driver().DescribeSchema(ctx, tx.Statement.ConnPool, task.Database)
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("批量获取索引信息失败: %v", err)
-	}
-
-	// 按表名对索引进行分组
-	indexesByTable := make(map[string]map[string][]string) // TableName -> IndexName -> [ColumnNames]
-	indexUniqueness := make(map[string]map[string]bool)    // TableName -> IndexName -> IsUnique
-	for _, idx := range allIndexes {
-		if _, ok := indexesByTable[idx.TableName]; !ok {
-			indexesByTable[idx.TableName] = make(map[string][]string)
-			indexUniqueness[idx.TableName] = make(map[string]bool)
-		}
-		indexesByTable[idx.TableName][idx.IndexName] = append(indexesByTable[idx.TableName][idx.IndexName], idx.ColumnName)
-		indexUniqueness[idx.TableName][idx.IndexName] = idx.NonUnique == 0
+		return err
 	}
 
-	// 5. 构建 Markdown 内容
+	// 3. 构建 Markdown 内容
 	mdContent := fmt.Sprintf("# 数据库文档: %s\n\n", task.Database)
 	mdContent += fmt.Sprintf("- 生成时间: %s\n", time.Now().Format("2006-01-02 15:04:05"))
 	mdContent += fmt.Sprintf("- 数据库名称: %s\n", task.Database)
-	mdContent += fmt.Sprintf("- 实例地址: %s:%d\n\n", task.Instance.Host, task.Instance.Port)
+	if task.Instance.EngineName() == model.EngineSQLite {
+		mdContent += fmt.Sprintf("- 文件路径: %s\n\n", task.Instance.FileGlob)
+	} else {
+		mdContent += fmt.Sprintf("- 实例地址: %s:%d\n\n", task.Instance.Host, task.Instance.Port)
+	}
 
 	mdContent += "## 目录\n\n"
 	for _, table := range tables {
-		comment := table.TableComment
+		comment := table.Comment
 		if comment == "" {
 			comment = "无描述"
 		}
-		mdContent += fmt.Sprintf("- [%s (%s)](#%s)\n", table.TableName, comment, table.TableName)
+		mdContent += fmt.Sprintf("- [%s (%s)](#%s)\n", table.Name, comment, table.Name)
 	}
 	mdContent += "\n---\n\n"
 
 	for _, table := range tables {
-		mdContent += fmt.Sprintf("### <a name=\"%s\"></a> %s\n\n", table.TableName, table.TableName)
-		if table.TableComment != "" {
-			mdContent += fmt.Sprintf("**表描述**: %s\n\n", table.TableComment)
+		mdContent += fmt.Sprintf("### <a name=\"%s\"></a> %s\n\n", table.Name, table.Name)
+		if table.Comment != "" {
+			mdContent += fmt.Sprintf("**表描述**: %s\n\n", table.Comment)
 		}
 
 		mdContent += "#### 字段信息\n\n"
 		mdContent += "| 字段名 | 类型 | 允许为空 | 默认值 | 备注 |\n"
 		mdContent += "| :--- | :--- | :--- | :--- | :--- |\n"
 
-		columns := columnsByTable[table.TableName]
-		for _, col := range columns {
+		for _, col := range table.Columns {
 			defVal := "-"
-			if col.ColumnDefault != nil {
-				defVal = *col.ColumnDefault
+			if col.Default != nil {
+				defVal = *col.Default
+			}
+			nullable := "NO"
+			if col.Nullable {
+				nullable = "YES"
 			}
-			comment := col.ColumnComment
+			comment := col.Comment
 			if comment == "" {
 				comment = "-"
 			}
 			mdContent += fmt.Sprintf("| %s | %s | %s | %s | %s |\n",
-				col.ColumnName, col.ColumnType, col.IsNullable, defVal, comment)
+				col.Name, col.Type, nullable, defVal, comment)
 		}
 		mdContent += "\n"
 
@@ -255,25 +235,24 @@ func (s *DbDocService) GenerateDoc(task *model.DbDocTask) error {
 		mdContent += "#### 索引信息\n\n"
 		mdContent += "| 索引名 | 唯一性 | 包含字段 |\n"
 		mdContent += "| :--- | :--- | :--- |\n"
-		tableIndexes := indexesByTable[table.TableName]
-		if len(tableIndexes) == 0 {
+		if len(table.Indexes) == 0 {
 			mdContent += "| - | - | - |\n"
 		} else {
-			for idxName, cols := range tableIndexes {
+			for _, idx := range table.Indexes {
 				unique := "普通索引"
-				if idxName == "PRIMARY" {
+				if idx.Primary {
 					unique = "主键"
-				} else if indexUniqueness[table.TableName][idxName] {
+				} else if idx.Unique {
 					unique = "唯一索引"
 				}
 				mdContent += fmt.Sprintf("| %s | %s | %s |\n",
-					idxName, unique, fmt.Sprintf("`%s`", fmt.Sprintf("%v", cols)))
+					idx.Name, unique, fmt.Sprintf("`%s`", fmt.Sprintf("%v", idx.Columns)))
 			}
 		}
 		mdContent += "\n"
 	}
 
-	// 6. 写入文件
+	// 4. 写入文件
 	paths := strings.Split(task.OutputPath, ",")
 	var lastErr error
 	for _, path := range paths {
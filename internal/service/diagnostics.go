@@ -0,0 +1,23 @@
+package service
+
+import (
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/database"
+)
+
+// DiagnosticsService 运行诊断服务
+type DiagnosticsService struct{}
+
+// NewDiagnosticsService 创建运行诊断服务
+func NewDiagnosticsService() *DiagnosticsService {
+	return &DiagnosticsService{}
+}
+
+// GetPoolDiagnostics 获取实例连接池、并发名额及执行队列的实时状态
+func (s *DiagnosticsService) GetPoolDiagnostics() *model.PoolDiagnosticsResponse {
+	return &model.PoolDiagnosticsResponse{
+		Pools:         database.PoolStats(),
+		InstanceSlots: instanceSlots.Stats(),
+		Queue:         GetQueryTaskQueue().Status(),
+	}
+}
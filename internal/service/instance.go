@@ -2,17 +2,22 @@ package service
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log"
 	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/authctx"
 	"my-bulker/internal/pkg/database"
+	"my-bulker/internal/pkg/rbac"
+	"my-bulker/internal/pkg/secret"
+	"my-bulker/internal/pkg/tagexpr"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
 	"golang.org/x/sync/errgroup"
 	"gorm.io/gorm"
 )
@@ -20,6 +25,15 @@ import (
 var (
 	ErrInstanceNameExists = errors.New("实例名称已存在")
 	ErrConnectionFailed   = errors.New("数据库连接失败")
+	ErrInvalidLimits      = errors.New("连接数、并发数和速率限制不能为负数")
+	ErrInvalidReplicas    = errors.New("从库配置无效：延迟阈值不能为负数，从库不能包含实例自身且必须存在")
+	ErrInvalidSSH         = errors.New("SSH 隧道配置无效：需填写跳板机地址、用户名以及密码或私钥，端口范围为 0-65535")
+	ErrInvalidTLS         = errors.New("TLS 配置无效：模式不支持，客户端证书与私钥未成对填写，或 PREFERRED 模式下填写了客户端证书")
+	ErrInvalidEngine      = errors.New("不支持的数据库引擎，可选值为 mysql、postgres、sqlite")
+	ErrInvalidFileGlob    = errors.New("SQLite 文件路径无效：不能为空、需为合法的通配符，且不能包含应用数据目录")
+	ErrInvalidTagExpr     = errors.New("标签表达式无效")
+	ErrInvalidTags        = errors.New("标签无效：标签名只能包含字母、数字及 _ . - / :，且不能为 AND、OR、NOT")
+	ErrInvalidProtection  = errors.New("不支持的保护模式，可选值为 unrestricted、approval、readonly")
 )
 
 // InstanceService 实例服务
@@ -42,29 +56,83 @@ func (s *InstanceService) checkNameExists(name string, excludeID uint) bool {
 }
 
 // Create 创建实例
-func (s *InstanceService) Create(req *model.CreateInstanceRequest) (*model.Instance, error) {
+func (s *InstanceService) Create(ctx context.Context, req *model.CreateInstanceRequest) (*model.Instance, error) {
 	// 检查名称是否已存在
 	if s.checkNameExists(req.Name, 0) {
 		return nil, ErrInstanceNameExists
 	}
-
-	// 获取数据库版本
-	version, err := s.getMySQLVersion(req.Host, req.Port, req.Username, req.Password, req.Params)
+	if req.MaxConnections < 0 || req.MaxConcurrency < 0 || req.RateLimit < 0 {
+		return nil, ErrInvalidLimits
+	}
+	if !s.validReplicas(0, req.ReplicaIDs, req.MaxReplicaLagSec) {
+		return nil, ErrInvalidReplicas
+	}
+	if !validSSH(req.SSH) {
+		return nil, ErrInvalidSSH
+	}
+	if !validTLS(req.TLS) {
+		return nil, ErrInvalidTLS
+	}
+	if err := validEngine(req.Engine, req.FileGlob); err != nil {
+		return nil, err
+	}
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+	protection, err := normalizeProtection(req.ProtectionMode)
 	if err != nil {
 		return nil, err
 	}
+	policy, err := accessPolicy(ctx, database.GetDB())
+	if err != nil {
+		return nil, err
+	}
+	if !policy.Can(rbac.ActionManage, rbac.Instance{Tags: tags}) {
+		return nil, fmt.Errorf("%w：没有在该标签范围内新建实例的权限", ErrForbidden)
+	}
 
 	instance := &model.Instance{
 		Name:         req.Name,
+		Engine:       req.Engine,
 		Host:         req.Host,
 		Port:         req.Port,
 		Username:     req.Username,
 		Password:     req.Password,
 		Params:       req.Params,
 		Remark:       req.Remark,
-		Version:      version,
+		Tags:         tags,
 		SyncInterval: req.SyncInterval,
+
+		ProtectionMode: protection,
+
+		ConnectDatabase: req.ConnectDatabase,
+		FileGlob:        req.FileGlob,
+
+		MaxConnections: req.MaxConnections,
+		MaxConcurrency: req.MaxConcurrency,
+		RateLimit:      req.RateLimit,
+
+		ReplicaIDs:       req.ReplicaIDs,
+		LagCheckQuery:    req.LagCheckQuery,
+		MaxReplicaLagSec: req.MaxReplicaLagSec,
+		LagCheckFailOpen: req.LagCheckFailOpen,
+
+		SSH: req.SSH,
+		TLS: req.TLS,
+
+		CreatedBy:     authctx.UserID(ctx),
+		CreatedByName: authctx.Username(ctx),
+	}
+
+	instance.Engine = instance.EngineName()
+
+	// 获取数据库版本
+	version, err := s.getVersion(instance)
+	if err != nil {
+		return nil, err
 	}
+	instance.Version = version
 
 	if err := database.GetDB().Create(instance).Error; err != nil {
 		return nil, err
@@ -72,7 +140,11 @@ func (s *InstanceService) Create(req *model.CreateInstanceRequest) (*model.Insta
 
 	// 异步同步数据库信息
 	go func() {
-		if err := s.SyncDatabases([]uint{instance.ID}); err != nil {
+		summary, err := s.SyncDatabases(authctx.WithSystem(context.Background()), []uint{instance.ID})
+		if err == nil {
+			err = summary.Err()
+		}
+		if err != nil {
 			log.Printf("ERROR: failed to sync databases for new instance %s (ID: %d): %v", instance.Name, instance.ID, err)
 		}
 	}()
@@ -80,28 +152,70 @@ func (s *InstanceService) Create(req *model.CreateInstanceRequest) (*model.Insta
 	return instance, nil
 }
 
-// Update 更新实例
-func (s *InstanceService) Update(id uint, req *model.UpdateInstanceRequest) (*model.Instance, error) {
+// Update 更新实例，需要实例修改前后的标签范围内都有管理权限
+func (s *InstanceService) Update(ctx context.Context, id uint, req *model.UpdateInstanceRequest) (*model.Instance, error) {
 	// 检查名称是否已存在（排除当前实例）
 	if s.checkNameExists(req.Name, id) {
 		return nil, ErrInstanceNameExists
 	}
+	if req.MaxConnections < 0 || req.MaxConcurrency < 0 || req.RateLimit < 0 {
+		return nil, ErrInvalidLimits
+	}
+	if !s.validReplicas(id, req.ReplicaIDs, req.MaxReplicaLagSec) {
+		return nil, ErrInvalidReplicas
+	}
+	if err := validEngine(req.Engine, req.FileGlob); err != nil {
+		return nil, err
+	}
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+	protection, err := normalizeProtection(req.ProtectionMode)
+	if err != nil {
+		return nil, err
+	}
 
 	instance := &model.Instance{}
 	if err := database.GetDB().First(instance, id).Error; err != nil {
 		return nil, err
 	}
+	policy, err := accessPolicy(ctx, database.GetDB())
+	if err != nil {
+		return nil, err
+	}
+	if !policy.Can(rbac.ActionManage, rbacInstance(instance)) || !policy.Can(rbac.ActionManage, rbac.Instance{ID: id, Tags: tags}) {
+		return nil, fmt.Errorf("%w：没有管理该实例或该标签范围的权限", ErrForbidden)
+	}
 
-	// 确定要使用的密码（如果请求中密码为空，则使用原密码）
-	passwordToUse := req.Password
-	if passwordToUse == "" {
-		passwordToUse = instance.Password
+	// 请求中为空的密码、私钥等沿用原值
+	candidate := &model.Instance{
+		Engine:          req.Engine,
+		Host:            req.Host,
+		Port:            req.Port,
+		Username:        req.Username,
+		Password:        req.Password,
+		Params:          req.Params,
+		ConnectDatabase: req.ConnectDatabase,
+		FileGlob:        req.FileGlob,
+		SSH:             req.SSH,
+		TLS:             req.TLS,
+	}
+	candidate.Engine = candidate.EngineName()
+	fillSecrets(candidate, instance, req.ClearSecrets)
+	if !validSSH(candidate.SSH) {
+		return nil, ErrInvalidSSH
+	}
+	if !validTLS(candidate.TLS) {
+		return nil, ErrInvalidTLS
 	}
 
 	// 如果连接信息或参数发生变化，重新获取版本
-	if instance.Host != req.Host || instance.Port != req.Port ||
-		instance.Username != req.Username || instance.Password != passwordToUse || !s.areParamsEqual(instance.Params, req.Params) {
-		version, err := s.getMySQLVersion(req.Host, req.Port, req.Username, passwordToUse, req.Params)
+	if instance.EngineName() != candidate.Engine || instance.ConnectDatabase != candidate.ConnectDatabase || instance.FileGlob != candidate.FileGlob ||
+		instance.Host != candidate.Host || instance.Port != candidate.Port ||
+		instance.Username != candidate.Username || instance.Password != candidate.Password ||
+		!s.areParamsEqual(instance.Params, candidate.Params) || instance.SSH != candidate.SSH || instance.TLS != candidate.TLS {
+		version, err := s.getVersion(candidate)
 		if err != nil {
 			return nil, err
 		}
@@ -109,16 +223,27 @@ func (s *InstanceService) Update(id uint, req *model.UpdateInstanceRequest) (*mo
 	}
 
 	instance.Name = req.Name
+	instance.Engine = candidate.Engine
+	instance.ConnectDatabase = req.ConnectDatabase
+	instance.FileGlob = req.FileGlob
 	instance.Host = req.Host
 	instance.Port = req.Port
 	instance.Username = req.Username
 	instance.SyncInterval = req.SyncInterval
-	// 只有当密码不为空时才更新密码
-	if req.Password != "" {
-		instance.Password = req.Password
-	}
+	instance.Password = candidate.Password
+	instance.SSH = candidate.SSH
+	instance.TLS = candidate.TLS
 	instance.Params = req.Params
 	instance.Remark = req.Remark
+	instance.Tags = tags
+	instance.ProtectionMode = protection
+	instance.MaxConnections = req.MaxConnections
+	instance.MaxConcurrency = req.MaxConcurrency
+	instance.RateLimit = req.RateLimit
+	instance.ReplicaIDs = req.ReplicaIDs
+	instance.LagCheckQuery = req.LagCheckQuery
+	instance.MaxReplicaLagSec = req.MaxReplicaLagSec
+	instance.LagCheckFailOpen = req.LagCheckFailOpen
 
 	if err := database.GetDB().Save(instance).Error; err != nil {
 		return nil, err
@@ -128,12 +253,37 @@ func (s *InstanceService) Update(id uint, req *model.UpdateInstanceRequest) (*mo
 }
 
 // Delete 删除实例
-func (s *InstanceService) Delete(id uint) error {
-	return database.GetDB().Transaction(func(tx *gorm.DB) error {
+func (s *InstanceService) Delete(ctx context.Context, id uint) error {
+	if err := requireAccess(ctx, database.GetDB(), rbac.ActionManage, []uint{id}); err != nil {
+		return err
+	}
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
 		// 首先硬删除与该实例关联的数据库记录
 		if err := tx.Unscoped().Where("instance_id = ?", id).Delete(&model.Database{}).Error; err != nil {
 			return err
 		}
+		if err := tx.Where("instance_id = ?", id).Delete(&model.DatabaseTable{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("instance_id = ?", id).Delete(&model.TableInfo{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("instance_id = ?", id).Delete(&model.DatabaseSizeSnapshot{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("instance_id = ?", id).Delete(&model.TableSizeSnapshot{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("instance_id = ?", id).Delete(&model.SchemaVersion{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("instance_id = ?", id).Delete(&model.SchemaChange{}).Error; err != nil {
+			return err
+		}
+		// 实例ID可能被新实例复用，一并删除按实例的授权
+		if err := tx.Where("instance_id = ?", id).Delete(&model.RoleBinding{}).Error; err != nil {
+			return err
+		}
 
 		// 然后硬删除实例本身
 		if err := tx.Unscoped().Delete(&model.Instance{}, id).Error; err != nil {
@@ -142,19 +292,47 @@ func (s *InstanceService) Delete(id uint) error {
 
 		return nil
 	})
+	if err == nil {
+		database.ClosePool(id)
+	}
+	return err
 }
 
 // BatchDelete 批量删除实例
-func (s *InstanceService) BatchDelete(ids []uint) error {
+func (s *InstanceService) BatchDelete(ctx context.Context, ids []uint) error {
 	if len(ids) == 0 {
 		return nil
 	}
+	if err := requireAccess(ctx, database.GetDB(), rbac.ActionManage, ids); err != nil {
+		return err
+	}
 
-	return database.GetDB().Transaction(func(tx *gorm.DB) error {
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
 		// 首先硬删除与这些实例关联的数据库记录
 		if err := tx.Unscoped().Where("instance_id IN ?", ids).Delete(&model.Database{}).Error; err != nil {
 			return err
 		}
+		if err := tx.Where("instance_id IN ?", ids).Delete(&model.DatabaseTable{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("instance_id IN ?", ids).Delete(&model.TableInfo{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("instance_id IN ?", ids).Delete(&model.DatabaseSizeSnapshot{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("instance_id IN ?", ids).Delete(&model.TableSizeSnapshot{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("instance_id IN ?", ids).Delete(&model.SchemaVersion{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("instance_id IN ?", ids).Delete(&model.SchemaChange{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("instance_id IN ?", ids).Delete(&model.RoleBinding{}).Error; err != nil {
+			return err
+		}
 
 		// 然后批量硬删除实例本身
 		if err := tx.Unscoped().Where("id IN ?", ids).Delete(&model.Instance{}).Error; err != nil {
@@ -163,40 +341,38 @@ func (s *InstanceService) BatchDelete(ids []uint) error {
 
 		return nil
 	})
+	if err == nil {
+		for _, id := range ids {
+			database.ClosePool(id)
+		}
+	}
+	return err
 }
 
 // Get 获取实例
-func (s *InstanceService) Get(id uint) (*model.InstanceResponse, error) {
+func (s *InstanceService) Get(ctx context.Context, id uint) (*model.InstanceResponse, error) {
 	instance := &model.Instance{}
 	if err := database.GetDB().First(instance, id).Error; err != nil {
 		return nil, err
 	}
-
-	var lastSyncAt *string
-	if instance.LastSyncAt != nil {
-		formattedTime := instance.LastSyncAt.Format(time.RFC3339)
-		lastSyncAt = &formattedTime
+	policy, err := accessPolicy(ctx, database.GetDB())
+	if err != nil {
+		return nil, err
+	}
+	if !policy.Can(rbac.ActionView, rbacInstance(instance)) {
+		return nil, fmt.Errorf("%w：没有查看该实例的权限", ErrForbidden)
 	}
 
 	// 转换为响应格式
-	return &model.InstanceResponse{
-		ID:           instance.ID,
-		CreatedAt:    instance.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:    instance.UpdatedAt.Format(time.RFC3339),
-		Name:         instance.Name,
-		Host:         instance.Host,
-		Port:         instance.Port,
-		Username:     instance.Username,
-		Version:      instance.Version,
-		Params:       instance.Params,
-		Remark:       instance.Remark,
-		SyncInterval: instance.SyncInterval,
-		LastSyncAt:   lastSyncAt,
-	}, nil
+	resp := s.toResponse(instance)
+	return &resp, nil
 }
 
-// GetPassword 获取实例密码
-func (s *InstanceService) GetPassword(id uint) (*model.InstancePasswordResponse, error) {
+// GetPassword 获取实例密码，需要实例的管理权限
+func (s *InstanceService) GetPassword(ctx context.Context, id uint) (*model.InstancePasswordResponse, error) {
+	if err := requireAccess(ctx, database.GetDB(), rbac.ActionManage, []uint{id}); err != nil {
+		return nil, err
+	}
 	instance := &model.Instance{}
 	if err := database.GetDB().Select("password").First(instance, id).Error; err != nil {
 		return nil, err
@@ -207,8 +383,8 @@ func (s *InstanceService) GetPassword(id uint) (*model.InstancePasswordResponse,
 	}, nil
 }
 
-// List 获取实例列表
-func (s *InstanceService) List(req *model.InstanceListRequest) (*model.InstanceListResponse, error) {
+// List 获取实例列表，只包含当前用户可查看的实例
+func (s *InstanceService) List(ctx context.Context, req *model.InstanceListRequest) (*model.InstanceListResponse, error) {
 	var total int64
 	var instances []model.Instance
 
@@ -217,6 +393,13 @@ func (s *InstanceService) List(req *model.InstanceListRequest) (*model.InstanceL
 
 	// 构建查询条件
 	query := database.GetDB().Model(&model.Instance{})
+	visible, all, err := accessibleInstanceIDs(ctx, database.GetDB(), rbac.ActionView)
+	if err != nil {
+		return nil, err
+	}
+	if !all {
+		query = query.Where("id IN ?", visible)
+	}
 
 	// 添加筛选条件
 	if req.Name != "" {
@@ -231,6 +414,17 @@ func (s *InstanceService) List(req *model.InstanceListRequest) (*model.InstanceL
 	if req.Remark != "" {
 		query = query.Where("remark LIKE ?", "%"+req.Remark+"%")
 	}
+	if strings.TrimSpace(req.Tags) != "" {
+		expr, err := tagexpr.Parse(req.Tags)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidTagExpr, err)
+		}
+		ids, err := matchInstanceIDs(database.GetDB(), expr)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("id IN ?", ids)
+	}
 
 	// 获取总数
 	if err := query.Count(&total).Error; err != nil {
@@ -248,26 +442,8 @@ func (s *InstanceService) List(req *model.InstanceListRequest) (*model.InstanceL
 
 	// 转换为响应格式
 	items := make([]model.InstanceResponse, len(instances))
-	for i, instance := range instances {
-		var lastSyncAt *string
-		if instance.LastSyncAt != nil {
-			formattedTime := instance.LastSyncAt.Format(time.RFC3339)
-			lastSyncAt = &formattedTime
-		}
-		items[i] = model.InstanceResponse{
-			ID:           instance.ID,
-			CreatedAt:    instance.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:    instance.UpdatedAt.Format(time.RFC3339),
-			Name:         instance.Name,
-			Host:         instance.Host,
-			Port:         instance.Port,
-			Username:     instance.Username,
-			Version:      instance.Version,
-			Params:       instance.Params,
-			Remark:       instance.Remark,
-			SyncInterval: instance.SyncInterval,
-			LastSyncAt:   lastSyncAt,
-		}
+	for i := range instances {
+		items[i] = s.toResponse(&instances[i])
 	}
 
 	return &model.InstanceListResponse{
@@ -276,104 +452,205 @@ func (s *InstanceService) List(req *model.InstanceListRequest) (*model.InstanceL
 	}, nil
 }
 
-// SyncDatabases 同步数据库信息
-func (s *InstanceService) SyncDatabases(instanceIDs []uint) error {
+// validReplicas 校验从库配置，selfID 为 0 表示新建实例
+func (s *InstanceService) validReplicas(selfID uint, replicaIDs model.InstanceIDList, maxLagSec int) bool {
+	if maxLagSec < 0 {
+		return false
+	}
+	if len(replicaIDs) == 0 {
+		return true
+	}
+	for _, id := range replicaIDs {
+		if id == selfID {
+			return false
+		}
+	}
+	var count int64
+	database.GetDB().Model(&model.Instance{}).Where("id IN ?", []uint(replicaIDs)).Count(&count)
+	return int(count) == len(replicaIDs)
+}
+
+// toResponse 转换为实例响应格式
+func (s *InstanceService) toResponse(instance *model.Instance) model.InstanceResponse {
+	var lastSyncAt *string
+	if instance.LastSyncAt != nil {
+		formattedTime := instance.LastSyncAt.Format(time.RFC3339)
+		lastSyncAt = &formattedTime
+	}
+	return model.InstanceResponse{
+		ID:        instance.ID,
+		CreatedAt: instance.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: instance.UpdatedAt.Format(time.RFC3339),
+		Name:      instance.Name,
+		Engine:    instance.EngineName(),
+		Host:      instance.Host,
+		Port:      instance.Port,
+		Username:  instance.Username,
+		Version:   instance.Version,
+		Params:    instance.Params,
+		Remark:    instance.Remark,
+		Tags:      instance.Tags,
+
+		ProtectionMode: instance.Protection(),
+		SyncInterval:   instance.SyncInterval,
+		LastSyncAt:     lastSyncAt,
+
+		LastSyncStatus:     instance.LastSyncStatus,
+		LastSyncError:      instance.LastSyncError,
+		LastSyncDurationMs: instance.LastSyncDurationMs,
+
+		ConnectDatabase: instance.ConnectDatabase,
+		FileGlob:        instance.FileGlob,
+
+		MaxConnections: instance.MaxConnections,
+		MaxConcurrency: instance.MaxConcurrency,
+		RateLimit:      instance.RateLimit,
+
+		ReplicaIDs:       instance.ReplicaIDs,
+		LagCheckQuery:    instance.LagCheckQuery,
+		MaxReplicaLagSec: instance.MaxReplicaLagSec,
+		LagCheckFailOpen: instance.LagCheckFailOpen,
+
+		SSH: instance.SSH.Redacted(),
+		TLS: instance.TLS.Redacted(),
+
+		CreatedBy:     instance.CreatedBy,
+		CreatedByName: instance.CreatedByName,
+	}
+}
+
+// SyncDatabases 并发同步实例的数据库信息，单个实例失败不影响其他实例
+// 每个实例的结果、耗时和失败原因写回实例并在摘要中返回；只有权限检查或读取实例失败时返回错误
+func (s *InstanceService) SyncDatabases(ctx context.Context, instanceIDs []uint) (*model.SyncSummary, error) {
+	if err := requireAccess(ctx, database.GetDB(), rbac.ActionRunRead, instanceIDs); err != nil {
+		return nil, err
+	}
+
 	// 获取所有指定的实例
 	var instances []model.Instance
 	if err := database.GetDB().Find(&instances, instanceIDs).Error; err != nil {
-		return fmt.Errorf("获取实例失败: %v", err)
+		return nil, fmt.Errorf("获取实例失败: %v", err)
 	}
 
-	// 使用 errgroup 进行并发处理
-	g, _ := errgroup.WithContext(context.Background())
-	sem := make(chan struct{}, 5) // 限制最大并发数为5
-
-	for _, instance := range instances {
-		instance := instance // 创建副本避免闭包问题
+	configSvc := NewConfigService()
+	maxConn := configSvc.GetIntConfig("max_conn", model.DefaultConfigValues.MaxConn)
+	instanceMaxConn := configSvc.GetIntConfig("instance_max_conn", model.DefaultConfigValues.InstanceMaxConn)
+	concurrency := configSvc.GetIntConfig("sync_concurrency", model.DefaultConfigValues.SyncConcurrency)
+	timeout := time.Duration(configSvc.GetIntConfig("sync_timeout_sec", model.DefaultConfigValues.SyncTimeoutSec)) * time.Second
+
+	start := time.Now()
+	results := make([]model.SyncResult, len(instances))
+	var g errgroup.Group
+	g.SetLimit(max(concurrency, 1))
+	for i := range instances {
+		instance := &instances[i]
 		g.Go(func() error {
-			sem <- struct{}{}        // 获取信号量
-			defer func() { <-sem }() // 释放信号量
-
-			// 连接数据库
-			db, err := database.NewMySQLDB(&instance)
-			if err != nil {
-				return err
-			}
-			defer db.Close()
-
-			// 开始事务
-			tx := database.GetDB().Begin()
-			if tx.Error != nil {
-				return fmt.Errorf("开始事务失败 [%s]: %v", instance.Name, tx.Error)
+			began := time.Now()
+			err := s.syncInstance(instance, maxConn, instanceMaxConn, timeout)
+			results[i] = model.SyncResult{
+				InstanceID:   instance.ID,
+				InstanceName: instance.Name,
+				Success:      err == nil,
+				DurationMs:   time.Since(began).Milliseconds(),
 			}
-
-			// 同步数据库信息
-			if err := s.syncDatabases(tx, db, instance.ID); err != nil {
-				tx.Rollback()
-				return fmt.Errorf("同步数据库失败 [%s]: %v", instance.Name, err)
-			}
-
-			// 提交事务
-			if err := tx.Commit().Error; err != nil {
-				return fmt.Errorf("提交事务失败 [%s]: %v", instance.Name, err)
+			if err != nil {
+				results[i].Error = err.Error()
 			}
-
+			s.recordSyncResult(&results[i], began)
 			return nil
 		})
 	}
+	g.Wait()
 
-	// 等待所有任务完成
-	if err := g.Wait(); err != nil {
-		return err
+	summary := &model.SyncSummary{
+		Total:      len(results),
+		DurationMs: time.Since(start).Milliseconds(),
+		Results:    results,
 	}
-
-	return nil
+	for _, r := range results {
+		if r.Success {
+			summary.Succeeded++
+		} else {
+			summary.Failed++
+		}
+	}
+	return summary, nil
 }
 
-// syncDatabases 同步单个实例的数据库信息
-func (s *InstanceService) syncDatabases(tx *gorm.DB, db *sql.DB, instanceID uint) error {
-	// 一次性获取所有数据库信息
-	rows, err := db.Query(`
-		SELECT 
-			s.SCHEMA_NAME,
-			s.DEFAULT_CHARACTER_SET_NAME,
-			s.DEFAULT_COLLATION_NAME,
-			COALESCE(SUM(t.data_length + t.index_length), 0) as size,
-			COUNT(t.TABLE_NAME) as table_count
-		FROM information_schema.SCHEMATA s
-		LEFT JOIN information_schema.TABLES t ON s.SCHEMA_NAME = t.TABLE_SCHEMA
-		WHERE s.SCHEMA_NAME NOT IN ('information_schema', 'performance_schema', 'mysql', 'sys')
-		GROUP BY s.SCHEMA_NAME, s.DEFAULT_CHARACTER_SET_NAME, s.DEFAULT_COLLATION_NAME
-	`)
+// syncInstance 同步单个实例，等待实例并发名额、速率限制和读取实例的语句都受 timeout 限制
+func (s *InstanceService) syncInstance(instance *model.Instance, maxConn, instanceMaxConn int, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	syncErr := func(err error) error {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("同步数据库超时（%s）: %v", timeout, err)
+		}
+		return fmt.Errorf("同步数据库失败: %v", err)
+	}
+
+	// 使用实例级共享连接池，连接数遵循实例级上限
+	pool, err := database.AcquirePool(instance, instance.EffectiveMaxConnections(maxConn))
 	if err != nil {
 		return err
 	}
-	defer rows.Close()
+	defer pool.Release()
 
-	// 批量准备数据库记录
-	var databases []model.Database
-	for rows.Next() {
-		var dbName, charset, collation string
-		var size int64
-		var tableCount int
-		if err := rows.Scan(&dbName, &charset, &collation, &size, &tableCount); err != nil {
-			return err
-		}
+	// 与查询任务共享实例并发名额和速率限制，避免同步时压垮实例；实例繁忙时等待名额同样计入超时
+	if err := instanceSlots.Acquire(ctx, instance.ID, instance.EffectiveMaxConcurrency(instanceMaxConn)); err != nil {
+		return syncErr(err)
+	}
+	defer instanceSlots.Release(instance.ID)
+	if err := instanceRates.Wait(ctx, instance.ID, instance.RateLimit); err != nil {
+		return syncErr(err)
+	}
 
-		databases = append(databases, model.Database{
-			InstanceID:   instanceID,
-			Name:         dbName,
-			CharacterSet: charset,
-			Collation:    collation,
-			Size:         size,
-			TableCount:   tableCount,
-		})
+	// 库列表和各库结构需要逐库访问实例，在开始事务前获取
+	databases, err := pool.Driver().ListDatabases(ctx, pool.SQLDB())
+	if err != nil {
+		return syncErr(err)
 	}
+	for i := range databases {
+		databases[i].InstanceID = instance.ID
+	}
+	schemas := describeSchemas(ctx, pool, instance.ID, databases)
 
-	if err := rows.Err(); err != nil {
-		return err
+	// 开始事务
+	tx := database.GetDB().Begin()
+	if tx.Error != nil {
+		return fmt.Errorf("开始事务失败: %v", tx.Error)
+	}
+
+	// 同步数据库信息
+	if err := s.syncDatabases(ctx, tx, pool, instance.ID, databases, schemas); err != nil {
+		tx.Rollback()
+		return syncErr(err)
+	}
+
+	// 提交事务
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("提交事务失败: %v", err)
+	}
+	return nil
+}
+
+// recordSyncResult 将同步结果写回实例，不更新实例的修改时间
+func (s *InstanceService) recordSyncResult(result *model.SyncResult, at time.Time) {
+	status := int8(1)
+	if !result.Success {
+		status = 2
+	}
+	if err := database.GetDB().Model(&model.Instance{}).Where("id = ?", result.InstanceID).UpdateColumns(map[string]interface{}{
+		"last_sync_at":          at,
+		"last_sync_status":      status,
+		"last_sync_error":       result.Error,
+		"last_sync_duration_ms": result.DurationMs,
+	}).Error; err != nil {
+		log.Printf("ERROR: failed to record sync result for instance %s (ID: %d): %v", result.InstanceName, result.InstanceID, err)
 	}
+}
 
+// syncDatabases 按事务前获取的库列表和库结构更新实例下的库、表及结构版本记录
+func (s *InstanceService) syncDatabases(ctx context.Context, tx *gorm.DB, pool *database.Pool, instanceID uint, databases []model.Database, schemas map[string][]model.SchemaTable) error {
 	// 获取当前数据库记录（仅获取名称和 ID 以便对比）
 	var existingDBs []model.Database
 	if err := tx.Where("instance_id = ?", instanceID).Find(&existingDBs).Error; err != nil {
@@ -428,6 +705,21 @@ func (s *InstanceService) syncDatabases(tx *gorm.DB, db *sql.DB, instanceID uint
 		}
 	}
 
+	// 同步各库的表及字段名
+	if err := s.syncTables(ctx, tx, pool, instanceID); err != nil {
+		return fmt.Errorf("同步表结构失败: %v", err)
+	}
+	tables, err := s.syncTableInfos(ctx, tx, pool, instanceID)
+	if err != nil {
+		return fmt.Errorf("同步表元数据失败: %v", err)
+	}
+	if err := recordSizeSnapshots(tx, instanceID, databases, tables); err != nil {
+		return fmt.Errorf("记录大小快照失败: %v", err)
+	}
+	if err := recordSchemaVersions(tx, instanceID, schemas); err != nil {
+		return fmt.Errorf("记录库结构版本失败: %v", err)
+	}
+
 	// 更新实例的最后同步时间
 	if err := tx.Model(&model.Instance{}).Where("id = ?", instanceID).Update("last_sync_at", time.Now()).Error; err != nil {
 		return err
@@ -436,48 +728,127 @@ func (s *InstanceService) syncDatabases(tx *gorm.DB, db *sql.DB, instanceID uint
 	return nil
 }
 
-// TestConnection 测试数据库连接
-func (s *InstanceService) TestConnection(host string, port int, username, password string, params model.InstanceParams) error {
-	instance := &model.Instance{
-		Host:     host,
-		Port:     port,
-		Username: username,
-		Password: password,
-		Params:   params,
-	}
-
-	db, err := database.NewMySQLDB(instance)
+// syncTables 重新获取实例下各库的表及字段名，整体替换已保存的记录
+func (s *InstanceService) syncTables(ctx context.Context, tx *gorm.DB, pool *database.Pool, instanceID uint) error {
+	refs, err := pool.Driver().ListColumns(ctx, pool.SQLDB())
 	if err != nil {
 		return err
 	}
-	defer db.Close()
 
-	// 测试连接
-	if err := db.Ping(); err != nil {
-		return fmt.Errorf("%w: %v", ErrConnectionFailed, err)
+	// 驱动按库、表排列返回，相邻的同表字段合并为一条记录
+	var tables []model.DatabaseTable
+	for _, ref := range refs {
+		if n := len(tables); n > 0 && tables[n-1].DatabaseName == ref.DatabaseName && tables[n-1].Name == ref.TableName {
+			tables[n-1].Columns = append(tables[n-1].Columns, ref.ColumnName)
+			continue
+		}
+		tables = append(tables, model.DatabaseTable{
+			InstanceID:   instanceID,
+			DatabaseName: ref.DatabaseName,
+			Name:         ref.TableName,
+			Columns:      model.ColumnList{ref.ColumnName},
+		})
 	}
 
+	if err := tx.Where("instance_id = ?", instanceID).Delete(&model.DatabaseTable{}).Error; err != nil {
+		return err
+	}
+	if len(tables) > 0 {
+		return tx.CreateInBatches(tables, 500).Error
+	}
 	return nil
 }
 
-// getMySQLVersion 获取MySQL版本
-func (s *InstanceService) getMySQLVersion(host string, port int, username, password string, params model.InstanceParams) (string, error) {
-	instance := &model.Instance{
-		Host:     host,
-		Port:     port,
-		Username: username,
-		Password: password,
-		Params:   params,
+// syncTableInfos 重新获取实例下各表的行数、大小等元数据，整体替换已保存的记录
+func (s *InstanceService) syncTableInfos(ctx context.Context, tx *gorm.DB, pool *database.Pool, instanceID uint) ([]model.TableInfo, error) {
+	tables, err := pool.Driver().ListTables(ctx, pool.SQLDB())
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range tables {
+		tables[i].InstanceID = instanceID
+		tables[i].SyncedAt = now
+	}
+	if err := tx.Where("instance_id = ?", instanceID).Delete(&model.TableInfo{}).Error; err != nil {
+		return nil, err
+	}
+	if len(tables) > 0 {
+		if err := tx.CreateInBatches(tables, 500).Error; err != nil {
+			return nil, err
+		}
+	}
+	return tables, nil
+}
+
+// TestConnection 测试数据库连接，返回数据库版本及协商的 TLS 信息
+// id 大于 0 时表示测试已有实例的修改，未填写的密码、私钥等沿用已保存的值。
+// 测试连接需要管理权限：已有实例需要该实例的管理权限，新实例需要在任一范围内有管理权限。
+func (s *InstanceService) TestConnection(ctx context.Context, id uint, instance *model.Instance, clear []string) (*model.TestConnectionResult, error) {
+	policy, err := accessPolicy(ctx, database.GetDB())
+	if err != nil {
+		return nil, err
+	}
+	if id > 0 {
+		if err := policyAllows(database.GetDB(), policy, rbac.ActionManage, []uint{id}); err != nil {
+			return nil, err
+		}
+	} else if !policy.CanAny(rbac.ActionManage) {
+		return nil, fmt.Errorf("%w：没有管理实例的权限", ErrForbidden)
+	}
+	if id > 0 {
+		stored := &model.Instance{}
+		if err := database.GetDB().First(stored, id).Error; err != nil {
+			return nil, err
+		}
+		fillSecrets(instance, stored, clear)
+	}
+	if !validSSH(instance.SSH) {
+		return nil, ErrInvalidSSH
+	}
+	if !validTLS(instance.TLS) {
+		return nil, ErrInvalidTLS
 	}
 
-	db, err := database.NewMySQLDB(instance)
+	if err := validEngine(instance.Engine, instance.FileGlob); err != nil {
+		return nil, err
+	}
+
+	db, driver, err := database.OpenDB(instance)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	// 使用同一个连接查询会话级的 TLS 状态
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrConnectionFailed, err)
+	}
+	defer conn.Close()
+
+	result := &model.TestConnectionResult{}
+	if result.Version, err = driver.Version(ctx, conn); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrConnectionFailed, err)
+	}
+	if result.TLSVersion, result.TLSCipher, err = driver.TLSStatus(ctx, conn); err != nil {
+		// 部分兼容 MySQL 协议的数据库不支持查询 TLS 状态，不影响连接结果
+		log.Printf("WARN: failed to query TLS status: %v", err)
+		result.TLSVersion, result.TLSCipher = "", ""
+	}
+
+	return result, nil
+}
+
+// getVersion 获取数据库版本
+func (s *InstanceService) getVersion(instance *model.Instance) (string, error) {
+	db, driver, err := database.OpenDB(instance)
 	if err != nil {
 		return "", err
 	}
 	defer db.Close()
 
-	var version string
-	err = db.QueryRow("SELECT VERSION()").Scan(&version)
+	version, err := driver.Version(context.Background(), db)
 	if err != nil {
 		return "", fmt.Errorf("获取版本失败: %v", err)
 	}
@@ -485,12 +856,180 @@ func (s *InstanceService) getMySQLVersion(host string, port int, username, passw
 	return version, nil
 }
 
-// GetOptions 获取实例选项列表
-func (s *InstanceService) GetOptions() ([]model.Option, error) {
+// fillSecrets 使用已保存实例的值补全为空的密码、私钥等敏感字段
+// clear 中列出的字段（列名见 model.InstanceSecretColumns）不补全，用于清除已保存的值。
+func fillSecrets(instance, stored *model.Instance, clear []string) {
+	storedFields := stored.SecretFields()
+	for i, field := range instance.SecretFields() {
+		if *field == "" && !slices.Contains(clear, model.InstanceSecretColumns[i]) {
+			*field = *storedFields[i]
+		}
+	}
+}
+
+// validEngine 校验数据库引擎及引擎相关的配置
+func validEngine(engine, fileGlob string) error {
+	if !model.ValidEngine(engine) {
+		return ErrInvalidEngine
+	}
+	if engine == model.EngineSQLite && database.ValidFileGlob(fileGlob) != nil {
+		return ErrInvalidFileGlob
+	}
+	return nil
+}
+
+// normalizeProtection 校验保护模式，为空时为不限制
+func normalizeProtection(mode string) (string, error) {
+	if !model.ValidProtectionMode(mode) {
+		return "", ErrInvalidProtection
+	}
+	if mode == "" {
+		return model.ProtectionUnrestricted, nil
+	}
+	return mode, nil
+}
+
+// normalizeTags 去除标签名和值两端的空白并校验标签名
+func normalizeTags(tags model.InstanceTags) (model.InstanceTags, error) {
+	normalized := make(model.InstanceTags, len(tags))
+	for key, value := range tags {
+		key = strings.TrimSpace(key)
+		if !tagexpr.ValidKey(key) || len(key) > 100 || len(value) > 200 {
+			return nil, ErrInvalidTags
+		}
+		normalized[key] = strings.TrimSpace(value)
+	}
+	return normalized, nil
+}
+
+// matchInstanceIDs 获取标签满足表达式的实例ID，按ID排列
+func matchInstanceIDs(db *gorm.DB, expr tagexpr.Expr) ([]uint, error) {
+	var instances []model.Instance
+	if err := db.Select("id, tags").Order("id ASC").Find(&instances).Error; err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(instances))
+	for _, instance := range instances {
+		if expr.Match(instance.Tags) {
+			ids = append(ids, instance.ID)
+		}
+	}
+	return ids, nil
+}
+
+// BatchTag 批量新增、覆盖或删除实例标签，需要实例修改前后的标签范围内都有管理权限
+func (s *InstanceService) BatchTag(ctx context.Context, req *model.BatchTagInstancesRequest) error {
+	set, err := normalizeTags(req.Set)
+	if err != nil {
+		return err
+	}
+	policy, err := accessPolicy(ctx, database.GetDB())
+	if err != nil {
+		return err
+	}
+	if err := policyAllows(database.GetDB(), policy, rbac.ActionManage, req.InstanceIDs); err != nil {
+		return err
+	}
+	return database.GetDB().Transaction(func(tx *gorm.DB) error {
+		var instances []model.Instance
+		if err := tx.Select("id, tags").Where("id IN ?", req.InstanceIDs).Find(&instances).Error; err != nil {
+			return err
+		}
+		for _, instance := range instances {
+			tags := instance.Tags
+			if tags == nil {
+				tags = make(model.InstanceTags)
+			}
+			for _, key := range req.Remove {
+				delete(tags, strings.TrimSpace(key))
+			}
+			for key, value := range set {
+				tags[key] = value
+			}
+			if !policy.Can(rbac.ActionManage, rbac.Instance{ID: instance.ID, Tags: tags}) {
+				return fmt.Errorf("%w：修改后的标签超出了可管理的范围", ErrForbidden)
+			}
+			// 仅更新标签列，避免触发敏感字段加密钩子
+			if err := tx.Model(&model.Instance{}).Where("id = ?", instance.ID).UpdateColumn("tags", tags).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// TagOptions 获取当前用户可查看的实例已使用的标签键及取值，用于输入提示
+func (s *InstanceService) TagOptions(ctx context.Context) ([]model.InstanceTagOption, error) {
+	query := database.GetDB().Select("id, tags")
+	visible, all, err := accessibleInstanceIDs(ctx, database.GetDB(), rbac.ActionView)
+	if err != nil {
+		return nil, err
+	}
+	if !all {
+		query = query.Where("id IN ?", visible)
+	}
+	var instances []model.Instance
+	if err := query.Find(&instances).Error; err != nil {
+		return nil, err
+	}
+	valueSets := make(map[string]map[string]bool)
+	for _, instance := range instances {
+		for key, value := range instance.Tags {
+			if valueSets[key] == nil {
+				valueSets[key] = make(map[string]bool)
+			}
+			valueSets[key][value] = true
+		}
+	}
+	options := make([]model.InstanceTagOption, 0, len(valueSets))
+	for key, set := range valueSets {
+		option := model.InstanceTagOption{Key: key, Values: make([]string, 0, len(set))}
+		for value := range set {
+			option.Values = append(option.Values, value)
+		}
+		sort.Strings(option.Values)
+		options = append(options, option)
+	}
+	sort.Slice(options, func(i, j int) bool { return options[i].Key < options[j].Key })
+	return options, nil
+}
+
+// validTLS 校验 TLS 配置，证书内容的有效性在建立连接时校验
+func validTLS(settings model.InstanceTLS) bool {
+	if !model.ValidTLSMode(settings.Mode) {
+		return false
+	}
+	if settings.Mode == model.TLSModePreferred && settings.Cert != "" {
+		return false
+	}
+	return (settings.Cert == "") == (settings.Key == "")
+}
+
+// validSSH 校验 SSH 隧道配置，未启用时不校验
+func validSSH(ssh model.SSHTunnel) bool {
+	if !ssh.Enabled {
+		return true
+	}
+	if ssh.Host == "" || ssh.User == "" || ssh.Port < 0 || ssh.Port > 65535 {
+		return false
+	}
+	return ssh.Password != "" || ssh.PrivateKey != ""
+}
+
+// GetOptions 获取当前用户可查看的实例选项列表
+func (s *InstanceService) GetOptions(ctx context.Context) ([]model.Option, error) {
 	var instances []model.Instance
+	query := database.GetDB()
+	visible, all, err := accessibleInstanceIDs(ctx, query, rbac.ActionView)
+	if err != nil {
+		return nil, err
+	}
+	if !all {
+		query = query.Where("id IN ?", visible)
+	}
 
 	// 获取所有实例，只选择需要的字段
-	if err := database.GetDB().
+	if err := query.
 		Select("id, name").
 		Order("name ASC").
 		Find(&instances).Error; err != nil {
@@ -510,24 +1049,58 @@ func (s *InstanceService) GetOptions() ([]model.Option, error) {
 }
 
 // ExportInstances 导出实例配置
-func (s *InstanceService) ExportInstances(instanceIDs []uint) ([]model.Instance, error) {
+// 提供口令时密码使用口令加密导出，否则导出文件中不包含密码。需要实例的管理权限。
+func (s *InstanceService) ExportInstances(ctx context.Context, instanceIDs []uint, passphrase string) ([]model.Instance, error) {
 	var instances []model.Instance
 	db := database.GetDB()
 
-	// 如果 instanceIDs 不为空，则按 ID 筛选；否则，获取所有实例
+	// 如果 instanceIDs 不为空，则按 ID 筛选；否则，获取所有可管理的实例
 	if len(instanceIDs) > 0 {
+		if err := requireAccess(ctx, db, rbac.ActionManage, instanceIDs); err != nil {
+			return nil, err
+		}
 		db = db.Where("id IN ?", instanceIDs)
+	} else {
+		managed, all, err := accessibleInstanceIDs(ctx, db, rbac.ActionManage)
+		if err != nil {
+			return nil, err
+		}
+		if !all {
+			db = db.Where("id IN ?", managed)
+		}
 	}
 
 	if err := db.Find(&instances).Error; err != nil {
 		return nil, err
 	}
 
+	var passphraseCipher *secret.PassphraseCipher
+	if passphrase != "" {
+		var err error
+		if passphraseCipher, err = secret.NewPassphraseCipher(passphrase); err != nil {
+			return nil, err
+		}
+	}
+	for i := range instances {
+		for _, field := range instances[i].SecretFields() {
+			if passphraseCipher == nil {
+				*field = ""
+				continue
+			}
+			encrypted, err := passphraseCipher.Encrypt(*field)
+			if err != nil {
+				return nil, fmt.Errorf("加密实例 '%s' 密码失败: %w", instances[i].Name, err)
+			}
+			*field = encrypted
+		}
+	}
+
 	return instances, nil
 }
 
 // ImportInstances 导入实例配置
-func (s *InstanceService) ImportInstances(fileContent io.Reader) (*model.ImportSummary, error) {
+// 口令加密的密码需提供导出时使用的口令；不含密码的实例仅保存配置，需导入后补填密码。
+func (s *InstanceService) ImportInstances(ctx context.Context, fileContent io.Reader, passphrase string) (*model.ImportSummary, error) {
 	bytes, err := io.ReadAll(fileContent)
 	if err != nil {
 		return nil, fmt.Errorf("读取文件内容失败: %w", err)
@@ -538,6 +1111,18 @@ func (s *InstanceService) ImportInstances(fileContent io.Reader) (*model.ImportS
 		return nil, fmt.Errorf("解析JSON文件失败: %w", err)
 	}
 
+	var passphraseCipher *secret.PassphraseCipher
+	if passphrase != "" {
+		if passphraseCipher, err = secret.NewPassphraseCipher(passphrase); err != nil {
+			return nil, err
+		}
+	}
+
+	policy, err := accessPolicy(ctx, database.GetDB())
+	if err != nil {
+		return nil, err
+	}
+
 	summary := &model.ImportSummary{}
 	var successfulIDs []uint
 	for _, instance := range instancesToImport {
@@ -554,9 +1139,61 @@ func (s *InstanceService) ImportInstances(fileContent io.Reader) (*model.ImportS
 		instance.UpdatedAt = time.Time{}
 		instance.DeletedAt = gorm.DeletedAt{}
 		instance.LastSyncAt = nil
+		instance.LastSyncStatus, instance.LastSyncError, instance.LastSyncDurationMs = 0, "", 0
+		// 从库ID仅在原环境有效，导入后需重新配置
+		instance.ReplicaIDs = nil
+		// 创建人记为执行导入的用户
+		instance.CreatedBy = authctx.UserID(ctx)
+		instance.CreatedByName = authctx.Username(ctx)
+		if err := validEngine(instance.Engine, instance.FileGlob); err != nil {
+			summary.Failed++
+			summary.Errors = append(summary.Errors, fmt.Sprintf("实例 '%s' %v", instance.Name, err))
+			continue
+		}
+		if instance.Tags, err = normalizeTags(instance.Tags); err != nil {
+			summary.Failed++
+			summary.Errors = append(summary.Errors, fmt.Sprintf("实例 '%s' %v", instance.Name, err))
+			continue
+		}
+		if instance.ProtectionMode, err = normalizeProtection(instance.ProtectionMode); err != nil {
+			summary.Failed++
+			summary.Errors = append(summary.Errors, fmt.Sprintf("实例 '%s' %v", instance.Name, err))
+			continue
+		}
+		if !policy.Can(rbac.ActionManage, rbac.Instance{Tags: instance.Tags}) {
+			summary.Failed++
+			summary.Errors = append(summary.Errors, fmt.Sprintf("实例 '%s' 的标签超出了可管理的范围", instance.Name))
+			continue
+		}
+		instance.Engine = instance.EngineName()
+
+		// 还原密码、私钥等敏感字段
+		if err := restoreSecrets(&instance, passphraseCipher); err != nil {
+			summary.Failed++
+			summary.Errors = append(summary.Errors, fmt.Sprintf("实例 '%s' %v", instance.Name, err))
+			continue
+		}
+		if err := validImportedInstance(&instance); err != nil {
+			summary.Failed++
+			summary.Errors = append(summary.Errors, fmt.Sprintf("实例 '%s' %v", instance.Name, err))
+			continue
+		}
+
+		// 导出文件不含密码时无法连接实例，仅保存配置（SQLite 实例无需密码）
+		if instance.Password == "" && instance.Engine != model.EngineSQLite {
+			instance.Version = ""
+			if err := database.GetDB().Create(&instance).Error; err != nil {
+				summary.Failed++
+				summary.Errors = append(summary.Errors, fmt.Sprintf("创建实例 '%s' 失败: %v", instance.Name, err))
+				continue
+			}
+			summary.Succeeded++
+			summary.Errors = append(summary.Errors, fmt.Sprintf("实例 '%s' 未包含密码，请编辑实例补填密码后再同步", instance.Name))
+			continue
+		}
 
 		// 获取数据库版本
-		version, err := s.getMySQLVersion(instance.Host, instance.Port, instance.Username, instance.Password, instance.Params)
+		version, err := s.getVersion(&instance)
 		if err != nil {
 			summary.Failed++
 			summary.Errors = append(summary.Errors, fmt.Sprintf("获取实例 '%s' 版本失败: %v", instance.Name, err))
@@ -577,7 +1214,11 @@ func (s *InstanceService) ImportInstances(fileContent io.Reader) (*model.ImportS
 	// 异步同步所有成功导入的实例的数据库信息
 	if len(successfulIDs) > 0 {
 		go func() {
-			if err := s.SyncDatabases(successfulIDs); err != nil {
+			summary, err := s.SyncDatabases(authctx.WithSystem(context.Background()), successfulIDs)
+			if err == nil {
+				err = summary.Err()
+			}
+			if err != nil {
 				log.Printf("ERROR: failed to sync databases for imported instances: %v", err)
 			}
 		}()
@@ -586,6 +1227,54 @@ func (s *InstanceService) ImportInstances(fileContent io.Reader) (*model.ImportS
 	return summary, nil
 }
 
+// validImportedInstance 按新建实例的规则校验导入实例的限制、SSH 和 TLS 配置
+// 导出文件不含密码时只保存配置，SSH 密码、私钥和 TLS 私钥留待编辑实例时补填，此时不要求填写。
+func validImportedInstance(instance *model.Instance) error {
+	if instance.MaxConnections < 0 || instance.MaxConcurrency < 0 || instance.RateLimit < 0 {
+		return ErrInvalidLimits
+	}
+	if instance.MaxReplicaLagSec < 0 {
+		return ErrInvalidReplicas
+	}
+	ssh, tls := instance.SSH, instance.TLS
+	if instance.Password == "" && instance.Engine != model.EngineSQLite {
+		// 以占位值代替缺少的敏感信息，仅用于校验其余配置
+		if ssh.Password == "" && ssh.PrivateKey == "" {
+			ssh.Password = "-"
+		}
+		if tls.Cert != "" && tls.Key == "" {
+			tls.Key = "-"
+		}
+	}
+	if !validSSH(ssh) {
+		return ErrInvalidSSH
+	}
+	if !validTLS(tls) {
+		return ErrInvalidTLS
+	}
+	return nil
+}
+
+// restoreSecrets 解密导入文件中使用口令加密的敏感字段
+func restoreSecrets(instance *model.Instance, passphraseCipher *secret.PassphraseCipher) error {
+	for _, field := range instance.SecretFields() {
+		switch {
+		case secret.IsEncrypted(*field):
+			return errors.New("的密码使用其他环境的主密钥加密，无法导入")
+		case secret.IsPassphraseEncrypted(*field):
+			if passphraseCipher == nil {
+				return errors.New("的密码已加密，请提供导出口令")
+			}
+			plaintext, err := passphraseCipher.Decrypt(*field)
+			if err != nil {
+				return fmt.Errorf("解密密码失败: %w", err)
+			}
+			*field = plaintext
+		}
+	}
+	return nil
+}
+
 // areParamsEqual 比较两个 InstanceParams 是否相等
 func (s *InstanceService) areParamsEqual(p1, p2 model.InstanceParams) bool {
 	if len(p1) != len(p2) {
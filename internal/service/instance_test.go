@@ -0,0 +1,48 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"my-bulker/internal/model"
+)
+
+func TestValidImportedInstance(t *testing.T) {
+	ssh := model.SSHTunnel{Enabled: true, Host: "bastion", User: "ops", Password: "secret"}
+	tests := []struct {
+		name     string
+		instance model.Instance
+		wantErr  error
+	}{
+		{name: "plain", instance: model.Instance{Engine: model.EngineMySQL, Password: "pw"}},
+		{name: "negative limits", instance: model.Instance{Engine: model.EngineMySQL, Password: "pw", RateLimit: -1}, wantErr: ErrInvalidLimits},
+		{name: "negative replica lag", instance: model.Instance{Engine: model.EngineMySQL, Password: "pw", MaxReplicaLagSec: -1}, wantErr: ErrInvalidReplicas},
+		{name: "ssh", instance: model.Instance{Engine: model.EngineMySQL, Password: "pw", SSH: ssh}},
+		{name: "ssh without host", instance: model.Instance{Engine: model.EngineMySQL, Password: "pw",
+			SSH: model.SSHTunnel{Enabled: true, User: "ops", Password: "secret"}}, wantErr: ErrInvalidSSH},
+		{name: "ssh port out of range", instance: model.Instance{Engine: model.EngineMySQL, Password: "pw",
+			SSH: model.SSHTunnel{Enabled: true, Host: "bastion", User: "ops", Port: 70000, Password: "secret"}}, wantErr: ErrInvalidSSH},
+		{name: "ssh without credentials", instance: model.Instance{Engine: model.EngineMySQL, Password: "pw",
+			SSH: model.SSHTunnel{Enabled: true, Host: "bastion", User: "ops"}}, wantErr: ErrInvalidSSH},
+		{name: "ssh credentials left for editing", instance: model.Instance{Engine: model.EngineMySQL,
+			SSH: model.SSHTunnel{Enabled: true, Host: "bastion", User: "ops"}}},
+		{name: "unknown tls mode", instance: model.Instance{Engine: model.EngineMySQL, Password: "pw",
+			TLS: model.InstanceTLS{Mode: "sometimes"}}, wantErr: ErrInvalidTLS},
+		{name: "client cert in preferred mode", instance: model.Instance{Engine: model.EngineMySQL, Password: "pw",
+			TLS: model.InstanceTLS{Mode: model.TLSModePreferred, Cert: "cert", Key: "key"}}, wantErr: ErrInvalidTLS},
+		{name: "client cert without key", instance: model.Instance{Engine: model.EngineMySQL, Password: "pw",
+			TLS: model.InstanceTLS{Mode: model.TLSModeRequired, Cert: "cert"}}, wantErr: ErrInvalidTLS},
+		{name: "tls key left for editing", instance: model.Instance{Engine: model.EngineMySQL,
+			TLS: model.InstanceTLS{Mode: model.TLSModeRequired, Cert: "cert"}}},
+		{name: "sqlite has no password", instance: model.Instance{Engine: model.EngineSQLite,
+			TLS: model.InstanceTLS{Mode: model.TLSModeRequired, Cert: "cert"}}, wantErr: ErrInvalidTLS},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validImportedInstance(&tt.instance)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("validImportedInstance() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
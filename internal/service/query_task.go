@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/rbac"
 
 	"gorm.io/gorm"
 )
@@ -49,17 +50,26 @@ func (s *QueryTaskService) Get(ctx context.Context, id uint) (*model.QueryTaskRe
 		CompletedAt:   task.CompletedAt,
 		Description:   task.Description,
 		IsFavorite:    task.IsFavorite,
+		QueuePosition: GetQueryTaskQueue().Position(task.ID),
+		ChunkSize:     task.ChunkSize,
+		ChunkSleepMs:  task.ChunkSleepMs,
+		TargetRule:    task.TargetRule,
+		CreatedBy:     task.CreatedBy,
+		CreatedByName: task.CreatedByName,
 	}
 
 	return response, nil
 }
 
-// List 获取查询任务列表
+// List 获取查询任务列表，只包含当前用户可查看全部目标实例的任务
 func (s *QueryTaskService) List(ctx context.Context, req *model.QueryTaskListRequest) (*model.QueryTaskListResponse, error) {
 	var total int64
 	var tasks []model.QueryTask
 
-	query := s.db.Model(&model.QueryTask{})
+	query, err := visibleTasks(ctx, s.db, s.db.Model(&model.QueryTask{}))
+	if err != nil {
+		return nil, err
+	}
 
 	// 应用过滤条件
 	if req.TaskName != "" {
@@ -107,6 +117,12 @@ func (s *QueryTaskService) List(ctx context.Context, req *model.QueryTaskListReq
 			CompletedAt:   task.CompletedAt,
 			Description:   task.Description,
 			IsFavorite:    task.IsFavorite,
+			QueuePosition: GetQueryTaskQueue().Position(task.ID),
+			ChunkSize:     task.ChunkSize,
+			ChunkSleepMs:  task.ChunkSleepMs,
+			TargetRule:    task.TargetRule,
+			CreatedBy:     task.CreatedBy,
+			CreatedByName: task.CreatedByName,
 		}
 	}
 
@@ -201,6 +217,10 @@ func (s *QueryTaskService) GetSQLsWithExecutions(ctx context.Context, taskID uin
 			"error_message":  e.ErrorMessage,
 			"result_count":   e.ResultCount,
 			"execution_time": e.ExecutionTime,
+			"paused_time":    e.PausedTime,
+			"pause_reason":   e.PauseReason,
+			"affected_rows":  e.AffectedRows,
+			"chunk_count":    e.ChunkCount,
 			"started_at":     e.StartedAt,
 			"completed_at":   e.CompletedAt,
 			"instance_name":  nameMap[e.InstanceID],
@@ -237,13 +257,17 @@ func (s *QueryTaskService) GetExecutionStats(ctx context.Context, taskID uint) (
 		Completed int64
 		Failed    int64
 		Pending   int64
+		Paused    int64
+		PausedDBs int64
 	}
 	var dbStats dbStat
 	s.db.Raw(`
 		SELECT COUNT(*) AS total,
 		SUM(CASE WHEN status = 2 THEN 1 ELSE 0 END) AS completed,
 		SUM(CASE WHEN status = 3 THEN 1 ELSE 0 END) AS failed,
-		SUM(CASE WHEN status IN (0,1) THEN 1 ELSE 0 END) AS pending
+		SUM(CASE WHEN status IN (0,1) THEN 1 ELSE 0 END) AS pending,
+		COALESCE(SUM(paused_time), 0) AS paused,
+		SUM(CASE WHEN paused_time > 0 THEN 1 ELSE 0 END) AS paused_dbs
 		FROM query_task_executions WHERE task_id = ?
 	`, taskID).Scan(&dbStats)
 
@@ -289,6 +313,11 @@ func (s *QueryTaskService) GetExecutionStats(ctx context.Context, taskID uint) (
 			"failed":    failedSQL,
 			"pending":   pendingSQL,
 		},
+		// 因从库复制延迟而暂停下发的累计时长
+		"throttle": map[string]int64{
+			"paused_ms":  dbStats.Paused,
+			"paused_dbs": dbStats.PausedDBs,
+		},
 	}, nil
 }
 
@@ -306,8 +335,15 @@ func (s *QueryTaskService) ToggleFavoriteStatus(ctx context.Context, taskID uint
 	return s.db.WithContext(ctx).Model(&task).Update("is_favorite", !task.IsFavorite).Error
 }
 
-// BatchDeleteTasks 批量删除任务及其所有相关数据
+// BatchDeleteTasks 批量删除任务及其所有相关数据，需要任务全部目标实例的运行权限
 func (s *QueryTaskService) BatchDeleteTasks(ctx context.Context, taskIDs []uint) error {
+	instanceIDs, err := taskInstanceIDs(s.db, taskIDs)
+	if err != nil {
+		return err
+	}
+	if err := requireAccess(ctx, s.db, rbac.ActionRunRead, instanceIDs); err != nil {
+		return err
+	}
 	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// 1. 查找所有要删除任务的SQL记录，以获取结果表名
 		var sqls []model.QueryTaskSQL
@@ -325,12 +361,17 @@ func (s *QueryTaskService) BatchDeleteTasks(ctx context.Context, taskIDs []uint)
 			return fmt.Errorf("删除任务SQL记录失败: %w", err)
 		}
 
-		// 4. 删除任务记录本身
+		// 4. 删除审批记录
+		if err := tx.Where("task_id IN ?", taskIDs).Delete(&model.QueryTaskReview{}).Error; err != nil {
+			return fmt.Errorf("删除任务审批记录失败: %w", err)
+		}
+
+		// 5. 删除任务记录本身
 		if err := tx.Where("id IN ?", taskIDs).Delete(&model.QueryTask{}).Error; err != nil {
 			return fmt.Errorf("删除任务记录失败: %w", err)
 		}
 
-		// 5. 最后，在事务中删除所有结果表
+		// 6. 最后，在事务中删除所有结果表
 		for _, sql := range sqls {
 			if sql.ResultTableName != "" {
 				if err := tx.Exec("DROP TABLE IF EXISTS `" + sql.ResultTableName + "`").Error; err != nil {
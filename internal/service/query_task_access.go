@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/rbac"
+
+	"gorm.io/gorm"
+)
+
+// taskInstanceIDs 获取任务执行明细涉及的实例ID
+func taskInstanceIDs(db *gorm.DB, taskIDs []uint) ([]uint, error) {
+	var ids []uint
+	err := db.Model(&model.QueryTaskExecution{}).
+		Where("task_id IN ?", taskIDs).
+		Distinct().
+		Pluck("instance_id", &ids).Error
+	return ids, err
+}
+
+// Authorize 检查当前用户能否对任务的全部目标实例执行操作，任务不存在时返回 gorm.ErrRecordNotFound
+func (s *QueryTaskService) Authorize(ctx context.Context, taskID uint, action rbac.Action) error {
+	var task model.QueryTask
+	if err := s.db.Select("id").First(&task, taskID).Error; err != nil {
+		return err
+	}
+	ids, err := taskInstanceIDs(s.db, []uint{taskID})
+	if err != nil {
+		return err
+	}
+	return requireAccess(ctx, s.db, action, ids)
+}
+
+// AuthorizeRun 按任务当前的 SQL 检查运行权限：只读任务需要 operator，包含写入语句的任务需要 writer
+func (s *QueryTaskService) AuthorizeRun(ctx context.Context, taskID uint) error {
+	var statements []string
+	if err := s.db.Model(&model.QueryTaskSQL{}).Where("task_id = ?", taskID).Pluck("sql_content", &statements).Error; err != nil {
+		return err
+	}
+	return s.Authorize(ctx, taskID, taskAction(statements))
+}
+
+// AuthorizeSQL 检查当前用户能否查看 SQL 所属任务的结果，返回 SQL 记录
+func (s *QueryTaskService) AuthorizeSQL(ctx context.Context, sqlID uint) (*model.QueryTaskSQL, error) {
+	var sql model.QueryTaskSQL
+	if err := s.db.First(&sql, sqlID).Error; err != nil {
+		return nil, err
+	}
+	if err := s.Authorize(ctx, sql.TaskID, rbac.ActionView); err != nil {
+		return nil, err
+	}
+	return &sql, nil
+}
+
+// visibleTasks 限定为当前用户可查看的任务：任务的全部目标实例都可查看
+func visibleTasks(ctx context.Context, db, query *gorm.DB) (*gorm.DB, error) {
+	visible, all, err := accessibleInstanceIDs(ctx, db, rbac.ActionView)
+	if err != nil || all {
+		return query, err
+	}
+	hidden := db.Model(&model.QueryTaskExecution{}).Select("task_id")
+	if len(visible) > 0 {
+		hidden = hidden.Where("instance_id NOT IN ?", visible)
+	}
+	return query.Where("id NOT IN (?)", hidden), nil
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/database"
+	"my-bulker/internal/pkg/sql_parse"
+
+	"gorm.io/gorm"
+)
+
+// errChunkNoPrimaryKey UPDATE 分批执行需要单列主键
+var errChunkNoPrimaryKey = errors.New("UPDATE 分批执行需要表具有单列主键")
+
+// errChunkEngine 分批改写依赖 MySQL 的 LIMIT 语法
+var errChunkEngine = errors.New("分批执行暂仅支持 MySQL 实例")
+
+// chunkResult 分批执行结果
+type chunkResult struct {
+	affected    int64         // 累计影响行数
+	chunks      int           // 执行批次数
+	paused      time.Duration // 批次之间因复制延迟暂停的时长
+	pauseReason string        // 最近一次暂停原因
+}
+
+// isChunkCandidate 判断语句是否属于分批执行的范围
+func isChunkCandidate(sqlContent string) bool {
+	keyword := sql_parse.StatementKeyword(sqlContent)
+	return keyword == "UPDATE" || keyword == "DELETE"
+}
+
+// executeChunked 在单个库上分批执行 UPDATE/DELETE
+// DELETE 以 LIMIT 循环执行直到影响行数为 0；UPDATE 执行后可能仍满足条件，按主键区间逐段推进。
+// 每批单独借用连接并计算超时，批次之间按任务设置休眠，并重新检查复制延迟和实例速率限制。
+func (s *QueryTaskRunService) executeChunked(
+	ctx context.Context,
+	pool *database.Pool,
+	dbName string,
+	inst *model.Instance,
+	stmt *sql_parse.ChunkStatement,
+	task *model.QueryTask,
+	settings runSettings,
+) (chunkResult, error) {
+	var result chunkResult
+	timeout := time.Duration(settings.queryTimeoutSec) * time.Second
+
+	// between 批次之间的节流
+	between := func() error {
+		if task.ChunkSleepMs > 0 {
+			timer := time.NewTimer(time.Duration(task.ChunkSleepMs) * time.Millisecond)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			}
+		}
+		paused, reason, err := replicaLag.Wait(ctx, inst)
+		result.paused += paused
+		if reason != "" {
+			result.pauseReason = reason
+		}
+		if err != nil {
+			return err
+		}
+		return instanceRates.Wait(ctx, inst.ID, inst.RateLimit)
+	}
+
+	// execChunk 以独立超时执行一批
+	execChunk := func(tx *gorm.DB, query string, args ...interface{}) (int64, error) {
+		chunkCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		res := tx.WithContext(chunkCtx).Exec(query, args...)
+		if res.Error != nil {
+			return 0, res.Error
+		}
+		result.chunks++
+		result.affected += res.RowsAffected
+		return res.RowsAffected, nil
+	}
+
+	// withConn 每批单独借用连接，批次之间的休眠和等待不占用连接
+	withConn := func(fn func(tx *gorm.DB) error) error {
+		return pool.WithDatabase(ctx, dbName, func(tx *gorm.DB) error {
+			database.MarkSessionDirty(tx)
+			return fn(tx)
+		})
+	}
+
+	if stmt.Verb == "DELETE" {
+		query := stmt.LimitSQL(task.ChunkSize)
+		for {
+			var affected int64
+			err := withConn(func(tx *gorm.DB) error {
+				var err error
+				affected, err = execChunk(tx, query)
+				return err
+			})
+			if err != nil || affected == 0 {
+				return result, err
+			}
+			if err := between(); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	var pk string
+	var lower interface{}
+	err := withConn(func(tx *gorm.DB) error {
+		var err error
+		if pk, err = chunkPrimaryKey(ctx, tx, stmt); err != nil {
+			return err
+		}
+		lower, err = chunkScalar(ctx, tx, timeout, fmt.Sprintf("SELECT MIN(%s) FROM %s", database.QuoteIdentifier(pk), stmt.QualifiedTable()))
+		return err
+	})
+	if err != nil || lower == nil {
+		return result, err
+	}
+
+	table := stmt.QualifiedTable()
+	pkCol := database.QuoteIdentifier(pk)
+	query := stmt.RangeSQL(pk)
+	for {
+		err := withConn(func(tx *gorm.DB) error {
+			// 取从下界开始的第 ChunkSize 个主键作为上界，不足一批时取最大值
+			upper, err := chunkScalar(ctx, tx, timeout,
+				fmt.Sprintf("SELECT %s FROM %s WHERE %s >= ? ORDER BY %s LIMIT 1 OFFSET %d", pkCol, table, pkCol, pkCol, task.ChunkSize-1), lower)
+			if err != nil {
+				return err
+			}
+			if upper == nil {
+				if upper, err = chunkScalar(ctx, tx, timeout, fmt.Sprintf("SELECT MAX(%s) FROM %s", pkCol, table)); err != nil || upper == nil {
+					lower = nil
+					return err
+				}
+			}
+
+			if _, err := execChunk(tx, query, lower, upper); err != nil {
+				return err
+			}
+
+			lower, err = chunkScalar(ctx, tx, timeout, fmt.Sprintf("SELECT MIN(%s) FROM %s WHERE %s > ?", pkCol, table, pkCol), upper)
+			return err
+		})
+		if err != nil || lower == nil {
+			return result, err
+		}
+		if err := between(); err != nil {
+			return result, err
+		}
+	}
+}
+
+// chunkPrimaryKey 获取语句目标表的单列主键
+func chunkPrimaryKey(ctx context.Context, tx *gorm.DB, stmt *sql_parse.ChunkStatement) (string, error) {
+	var cols []string
+	err := tx.WithContext(ctx).Raw(`
+		SELECT COLUMN_NAME FROM information_schema.KEY_COLUMN_USAGE
+		WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND TABLE_NAME = ? AND CONSTRAINT_NAME = 'PRIMARY'
+		ORDER BY ORDINAL_POSITION
+	`, stmt.Schema, stmt.Table).Scan(&cols).Error
+	if err != nil {
+		return "", err
+	}
+	if len(cols) != 1 {
+		return "", errChunkNoPrimaryKey
+	}
+	return cols[0], nil
+}
+
+// chunkScalar 查询单个值，无结果或结果为 NULL 时返回 nil
+func chunkScalar(ctx context.Context, tx *gorm.DB, timeout time.Duration, query string, args ...interface{}) (interface{}, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	rows, err := tx.WithContext(queryCtx).Raw(query, args...).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	var value interface{}
+	if err := rows.Scan(&value); err != nil {
+		return nil, err
+	}
+	// 文本协议返回的字节切片转为字符串，按列的排序规则比较
+	if b, ok := value.([]byte); ok {
+		return string(b), nil
+	}
+	return value, nil
+}
+
+// chunkErrorMessage 转换分批执行错误信息
+func chunkErrorMessage(ctx context.Context, err error) string {
+	switch {
+	case ctx.Err() != nil:
+		return "任务已取消"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "SQL执行超时"
+	case errors.Is(err, errChunkNoPrimaryKey):
+		return err.Error()
+	default:
+		return "SQL执行失败: " + err.Error()
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/authctx"
 	"my-bulker/internal/pkg/database"
 	"my-bulker/internal/pkg/sql_parse"
 
@@ -32,9 +33,13 @@ func (s *QueryTaskCreatorService) Create(ctx context.Context, req *model.CreateQ
 	}
 
 	// 确定目标数据库列表
-	targetDBs, err := s.determineTargetDatabases(req.DatabaseMode, req.SelectedDBs, req.InstanceIDs)
+	rule := targetRuleOf(&req.TargetSelection)
+	if err := s.authorizeProbe(ctx, rule); err != nil {
+		return nil, err
+	}
+	targetDBs, err := s.determineTargetDatabases(rule, req.SelectedDBs, req.InstanceIDs)
 	if err != nil {
-		return nil, fmt.Errorf("确定目标数据库失败: %v", err)
+		return nil, fmt.Errorf("确定目标数据库失败: %w", err)
 	}
 
 	// 拆分SQL语句
@@ -43,6 +48,44 @@ func (s *QueryTaskCreatorService) Create(ctx context.Context, req *model.CreateQ
 		return nil, fmt.Errorf("SQL语句拆分失败: %v", err)
 	}
 
+	// 分批模式下，所有 UPDATE/DELETE 都必须能改写为分批语句
+	if req.ChunkSize < 0 || req.ChunkSleepMs < 0 {
+		return nil, fmt.Errorf("分批行数和分批间隔不能为负数")
+	}
+	if req.ChunkSize > 0 {
+		hasChunk := false
+		for i, sqlContent := range sqlStatements {
+			if !isChunkCandidate(sqlContent) {
+				continue
+			}
+			if _, err := sql_parse.ParseChunkStatement(sqlContent); err != nil {
+				return nil, fmt.Errorf("第 %d 条语句无法分批执行: %v", i+1, err)
+			}
+			hasChunk = true
+		}
+		if hasChunk && !s.allMySQL(targetDBs) {
+			return nil, errChunkEngine
+		}
+	}
+
+	// 目标实例都需要相应的角色：只读任务需要 operator，包含写入语句的任务需要 writer
+	if err := requireAccess(ctx, s.db, taskAction(sqlStatements), targetDBs.InstanceIDs()); err != nil {
+		return nil, err
+	}
+
+	// 按实例保护模式检查写入语句：只读实例直接拒绝，要求审批的实例创建为待审批任务
+	check, err := checkProtection(s.db, sqlStatements, targetDBs.InstanceIDs())
+	if err != nil {
+		return nil, err
+	}
+	if err := check.err(); err != nil {
+		return nil, err
+	}
+	status := model.QueryTaskStatusPending
+	if check.needsApproval() {
+		status = model.QueryTaskStatusPendingApproval
+	}
+
 	// 使用事务创建任务和SQL语句
 	var task *model.QueryTask
 	err = s.db.Transaction(func(tx *gorm.DB) error {
@@ -50,13 +93,18 @@ func (s *QueryTaskCreatorService) Create(ctx context.Context, req *model.CreateQ
 		task = &model.QueryTask{
 			TaskName:      req.TaskName,
 			Description:   req.Description,
-			Status:        0, // 待执行
+			Status:        status,
 			TotalDBs:      len(targetDBs),
 			CompletedDBs:  0,
 			FailedDBs:     0,
 			TotalSQLs:     len(sqlStatements),
 			CompletedSQLs: 0,
 			FailedSQLs:    0,
+			ChunkSize:     req.ChunkSize,
+			ChunkSleepMs:  req.ChunkSleepMs,
+			TargetRule:    rule,
+			CreatedBy:     authctx.UserID(ctx),
+			CreatedByName: authctx.Username(ctx),
 		}
 
 		// 将目标数据库列表转换为JSON字符串
@@ -184,11 +232,55 @@ func (s *QueryTaskCreatorService) checkTaskNameExists(taskName string) bool {
 	return count > 0
 }
 
+// allMySQL 判断目标数据库是否均位于 MySQL 实例
+func (s *QueryTaskCreatorService) allMySQL(targetDBs model.TaskDatabases) bool {
+	ids := make([]uint, 0, len(targetDBs))
+	for _, db := range targetDBs {
+		ids = append(ids, db.InstanceID)
+	}
+	var count int64
+	s.db.Model(&model.Instance{}).Where("id IN ? AND engine <> ?", ids, model.EngineMySQL).Count(&count)
+	return count == 0
+}
+
 // determineTargetDatabases 确定目标数据库列表
-func (s *QueryTaskCreatorService) determineTargetDatabases(mode string, selectedDBs model.TaskDatabases, instanceIDs []uint) (model.TaskDatabases, error) {
+func (s *QueryTaskCreatorService) determineTargetDatabases(rule model.TargetRule, selectedDBs model.TaskDatabases, instanceIDs []uint) (model.TaskDatabases, error) {
+	mode := rule.Mode
+	switch mode {
+	case model.TargetModeTags:
+		// 标签模式：标签表达式匹配的实例下的所有数据库
+		ids, err := s.scopeInstanceIDs(rule)
+		if err != nil {
+			return nil, err
+		}
+		return s.findDatabases(ids, nil)
+	case model.TargetModePattern:
+		// 模式匹配：所选实例中名称和属性满足筛选条件的数据库
+		ids, err := s.scopeInstanceIDs(rule)
+		if err != nil {
+			return nil, err
+		}
+		return s.findDatabases(ids, rule.Filter)
+	case model.TargetModeSchema:
+		// 表结构模式：所选实例中包含指定表、字段或探测语句返回真值的数据库
+		ids, err := s.scopeInstanceIDs(rule)
+		if err != nil {
+			return nil, err
+		}
+		candidates, err := s.findDatabases(ids, nil)
+		if err != nil {
+			return nil, err
+		}
+		return s.filterBySchema(candidates, rule.Require)
+	}
+
 	if mode == "include" {
-		// 包含模式：直接使用选中的数据库，但需要填充实例名称
-		return s.fillInstanceNames(selectedDBs), nil
+		// 包含模式：使用选中的数据库中仍存在于最近一次同步结果的库，并填充实例名称
+		existing, err := s.existingDatabases(selectedDBs)
+		if err != nil {
+			return nil, err
+		}
+		return s.fillInstanceNames(existing), nil
 	} else if mode == "exclude" {
 		// 排除模式：获取指定实例的所有数据库，排除选中的数据库
 		var allDBs []model.Database
@@ -221,6 +313,31 @@ func (s *QueryTaskCreatorService) determineTargetDatabases(mode string, selected
 	return nil, fmt.Errorf("无效的数据库选择模式: %s", mode)
 }
 
+// existingDatabases 过滤掉已不在同步结果中的数据库，保持原有顺序
+func (s *QueryTaskCreatorService) existingDatabases(databases model.TaskDatabases) (model.TaskDatabases, error) {
+	if len(databases) == 0 {
+		return databases, nil
+	}
+	instanceIDs := databases.InstanceIDs()
+
+	var synced []model.Database
+	if err := s.db.Select("instance_id", "name").Where("instance_id IN ?", instanceIDs).Find(&synced).Error; err != nil {
+		return nil, fmt.Errorf("获取数据库列表失败: %v", err)
+	}
+	exists := make(map[string]bool, len(synced))
+	for _, db := range synced {
+		exists[targetKey(db.InstanceID, db.Name)] = true
+	}
+
+	result := make(model.TaskDatabases, 0, len(databases))
+	for _, db := range databases {
+		if exists[targetKey(db.InstanceID, db.DatabaseName)] {
+			result = append(result, db)
+		}
+	}
+	return result, nil
+}
+
 // fillInstanceNames 填充实例名称
 func (s *QueryTaskCreatorService) fillInstanceNames(databases model.TaskDatabases) model.TaskDatabases {
 	if len(databases) == 0 {
@@ -271,7 +388,8 @@ func (s *QueryTaskCreatorService) generateResultTableName(taskID uint, sqlOrder
 func (s *QueryTaskCreatorService) inferTableSchemaWithInstance(sqlContent string, instanceID uint, dbName string) []string {
 	headers := sql_parse.DetectResultHeaders(sqlContent)
 	// 能连上真实数据库时优先读取驱动返回列名，保证建表字段与执行结果完全一致。
-	if instanceID > 0 && dbName != "" {
+	// 仅对只读语句探测，避免创建任务时误执行写入。
+	if instanceID > 0 && dbName != "" && sql_parse.ClassifyStatement(sqlContent) == sql_parse.StatementRead {
 		if realHeaders := s.fetchRealResultHeaders(sqlContent, instanceID, dbName); len(realHeaders) > 0 {
 			return realHeaders
 		}
@@ -286,26 +404,28 @@ func (s *QueryTaskCreatorService) fetchRealResultHeaders(sqlContent string, inst
 		return nil
 	}
 
-	dbConn, err := database.NewMySQLGormDB(&instance, dbName, 2)
+	pool, err := database.AcquirePool(&instance, instance.EffectiveMaxConnections(NewConfigService().GetIntConfig("max_conn", model.DefaultConfigValues.MaxConn)))
 	if err != nil {
 		return nil
 	}
+	defer pool.Release()
 
 	sqlToExec := sqlContent
 	if !strings.Contains(strings.ToLower(sqlContent), "limit ") {
 		sqlToExec = sqlContent + " LIMIT 1"
 	}
 
-	rows, err := dbConn.Raw(sqlToExec).Rows()
-	if err != nil {
-		return nil
-	}
-	defer rows.Close()
-
-	cols, err := rows.Columns()
-	if err != nil || len(cols) == 0 {
-		return nil
-	}
+	// 在总是回滚的只读事务中执行，语句分类遗漏或函数有副作用时也不会在创建任务时写入
+	var cols []string
+	_ = pool.WithReadOnlyTransaction(context.Background(), dbName, func(tx *gorm.DB) error {
+		rows, err := tx.Raw(sqlToExec).Rows()
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		cols, err = rows.Columns()
+		return err
+	})
 
 	return cols
 }
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/sql_parse"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrReadOnlyInstance = errors.New("目标包含只读实例，不能执行写入语句")
+	ErrApprovalRequired = errors.New("任务包含写入语句，审批通过后才能执行")
+	ErrTaskRejected     = errors.New("任务已被驳回，不能执行")
+)
+
+// protectionCheck 写入语句与目标实例保护模式的检查结果
+type protectionCheck struct {
+	writes    []int    // 非只读语句的序号（从 1 开始）
+	dangerous []int    // DML/DDL 语句的序号（从 1 开始）
+	allWrites bool     // 是否所有 DML/DDL 都需要审批
+	readOnly  []string // 目标中的只读实例
+	approval  []string // 目标中要求审批的实例
+}
+
+// checkProtection 按 sql_parse 的语句分类检查写入语句是否命中受保护的实例
+// 除只读查询外的语句（DML、DDL、会话设置等）都按写入处理。
+func checkProtection(db *gorm.DB, statements []string, instanceIDs []uint) (*protectionCheck, error) {
+	check := &protectionCheck{
+		allWrites: NewConfigService().GetIntConfig("approve_all_writes", model.DefaultConfigValues.ApproveAllWrites) == 1,
+	}
+	for i, stmt := range statements {
+		if sql_parse.ClassifyStatement(stmt) != sql_parse.StatementRead {
+			check.writes = append(check.writes, i+1)
+		}
+		if sql_parse.IsWrite(stmt) {
+			check.dangerous = append(check.dangerous, i+1)
+		}
+	}
+	if len(check.writes) == 0 || len(instanceIDs) == 0 {
+		return check, nil
+	}
+
+	var instances []model.Instance
+	if err := db.Select("id", "name", "protection_mode").Where("id IN ?", instanceIDs).Find(&instances).Error; err != nil {
+		return nil, fmt.Errorf("获取实例失败: %v", err)
+	}
+	for _, instance := range instances {
+		switch instance.Protection() {
+		case model.ProtectionReadOnly:
+			check.readOnly = append(check.readOnly, instance.Name)
+		case model.ProtectionApproval:
+			check.approval = append(check.approval, instance.Name)
+		}
+	}
+	sort.Strings(check.readOnly)
+	sort.Strings(check.approval)
+	return check, nil
+}
+
+// err 写入语句命中只读实例时返回错误
+func (c *protectionCheck) err() error {
+	if len(c.writes) == 0 || len(c.readOnly) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: 第 %s 条语句不是只读查询，只读实例: %s", ErrReadOnlyInstance, joinInts(c.writes), strings.Join(c.readOnly, "、"))
+}
+
+// needsApproval 是否需要审批：写入语句命中要求审批的实例，或开启了全部 DML/DDL 审批
+func (c *protectionCheck) needsApproval() bool {
+	return (len(c.writes) > 0 && len(c.approval) > 0) || (c.allWrites && len(c.dangerous) > 0)
+}
+
+// approvalErr 需要审批时的错误说明
+func (c *protectionCheck) approvalErr() error {
+	if len(c.writes) > 0 && len(c.approval) > 0 {
+		return fmt.Errorf("%w，要求审批的实例: %s", ErrApprovalRequired, strings.Join(c.approval, "、"))
+	}
+	return fmt.Errorf("%w，第 %s 条语句为 DML/DDL", ErrApprovalRequired, joinInts(c.dangerous))
+}
+
+// CheckRunProtection 运行前按任务当前的 SQL 和目标检查保护模式
+// 写入命中只读实例时返回 ErrReadOnlyInstance；需要审批但没有有效的审批时将任务转为待审批并返回 ErrApprovalRequired。
+func (s *QueryTaskRunService) CheckRunProtection(taskID uint) error {
+	var task model.QueryTask
+	if err := s.db.First(&task, taskID).Error; err != nil {
+		return err
+	}
+	switch task.Status {
+	case model.QueryTaskStatusPendingApproval:
+		return ErrApprovalRequired
+	case model.QueryTaskStatusRejected:
+		return ErrTaskRejected
+	}
+	var sqls []model.QueryTaskSQL
+	if err := s.db.Where("task_id = ?", taskID).Order("sql_order ASC").Find(&sqls).Error; err != nil {
+		return err
+	}
+	targets, err := taskTargets(s.db, taskID)
+	if err != nil {
+		return err
+	}
+	return s.guardProtection(&task, sqls, targets)
+}
+
+// guardProtection 检查保护模式，需要审批时校验最近一次审批是否仍与任务的 SQL 和目标一致，否则将任务转为待审批
+func (s *QueryTaskRunService) guardProtection(task *model.QueryTask, sqls []model.QueryTaskSQL, targets model.TaskDatabases) error {
+	statements := make([]string, len(sqls))
+	for i, sql := range sqls {
+		statements[i] = sql.SQLContent
+	}
+	check, err := checkProtection(s.db, statements, targets.InstanceIDs())
+	if err != nil {
+		return err
+	}
+	if err := check.err(); err != nil {
+		return err
+	}
+	if !check.needsApproval() {
+		return nil
+	}
+
+	review, err := latestReview(s.db, task.ID)
+	if err != nil {
+		return err
+	}
+	sqlHash := sqlDigest(sqls)
+	targetHash, _ := targetDigest(targets)
+	if review != nil && review.Decision == model.ReviewDecisionApproved && review.SQLHash == sqlHash && review.TargetHash == targetHash {
+		return nil
+	}
+
+	if err := s.db.Model(&model.QueryTask{}).Where("id = ?", task.ID).Update("status", model.QueryTaskStatusPendingApproval).Error; err != nil {
+		return err
+	}
+	task.Status = model.QueryTaskStatusPendingApproval
+	if review != nil && review.Decision == model.ReviewDecisionApproved {
+		return fmt.Errorf("%w（审批后任务的 SQL 或目标数据库已变更，需要重新审批）", ErrApprovalRequired)
+	}
+	return check.approvalErr()
+}
+
+// latestReview 获取任务最近一次审批记录，没有时返回 nil
+func latestReview(db *gorm.DB, taskID uint) (*model.QueryTaskReview, error) {
+	var reviews []model.QueryTaskReview
+	if err := db.Where("task_id = ?", taskID).Order("id DESC").Limit(1).Find(&reviews).Error; err != nil {
+		return nil, err
+	}
+	if len(reviews) == 0 {
+		return nil, nil
+	}
+	return &reviews[0], nil
+}
+
+// taskTargets 获取任务当前的目标数据库
+func taskTargets(db *gorm.DB, taskID uint) (model.TaskDatabases, error) {
+	var targets model.TaskDatabases
+	err := db.Model(&model.QueryTaskExecution{}).
+		Select("DISTINCT instance_id, database_name").
+		Where("task_id = ?", taskID).
+		Scan(&targets).Error
+	return targets, err
+}
+
+// executionTargets 由执行记录得到目标数据库，重复项由摘要计算时去除
+func executionTargets(executions []model.QueryTaskExecution) model.TaskDatabases {
+	targets := make(model.TaskDatabases, 0, len(executions))
+	for _, e := range executions {
+		targets = append(targets, model.TaskDatabase{InstanceID: e.InstanceID, DatabaseName: e.DatabaseName})
+	}
+	return targets
+}
+
+// sqlDigest 按执行顺序计算 SQL 语句的摘要，sqls 需已按 sql_order 排序
+func sqlDigest(sqls []model.QueryTaskSQL) string {
+	h := sha256.New()
+	for _, sql := range sqls {
+		h.Write([]byte(sql.SQLContent))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// targetDigest 计算目标数据库列表的摘要，与顺序无关，同时返回去重后的数量
+func targetDigest(targets model.TaskDatabases) (string, int) {
+	seen := make(map[string]bool, len(targets))
+	keys := make([]string, 0, len(targets))
+	for _, db := range targets {
+		key := targetKey(db.InstanceID, db.DatabaseName)
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, key := range keys {
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), len(keys)
+}
+
+// joinInts 以顿号连接整数
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, "、")
+}
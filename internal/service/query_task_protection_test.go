@@ -0,0 +1,235 @@
+package service
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/database"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB 打开迁移好的临时应用库并设为 database.GetDB() 的返回值，测试结束后恢复
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	// 使用临时文件而不是内存库：服务在事务中仍会通过 database.GetDB() 读取配置，需要多个连接访问同一个库
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "app.db")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	saved := database.GetDB()
+	database.SetDB(db)
+	t.Cleanup(func() {
+		database.SetDB(saved)
+		sqlDB.Close()
+	})
+	return db
+}
+
+// createTestTask 创建包含指定语句和目标库的任务，返回任务和按顺序排列的语句
+func createTestTask(t *testing.T, db *gorm.DB, statements []string, targets model.TaskDatabases) (*model.QueryTask, []model.QueryTaskSQL) {
+	t.Helper()
+	task := &model.QueryTask{TaskName: "test", CreatedBy: 2}
+	if err := db.Create(task).Error; err != nil {
+		t.Fatal(err)
+	}
+	sqls := make([]model.QueryTaskSQL, len(statements))
+	for i, stmt := range statements {
+		sqls[i] = model.QueryTaskSQL{TaskID: task.ID, SQLContent: stmt, SQLOrder: i + 1}
+		if err := db.Create(&sqls[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+		for _, target := range targets {
+			exec := model.QueryTaskExecution{TaskID: task.ID, SQLID: sqls[i].ID, InstanceID: target.InstanceID, DatabaseName: target.DatabaseName}
+			if err := db.Create(&exec).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return task, sqls
+}
+
+func TestGuardProtection(t *testing.T) {
+	targets := model.TaskDatabases{{InstanceID: 1, DatabaseName: "app1"}, {InstanceID: 1, DatabaseName: "app2"}}
+	changedTargets := append(model.TaskDatabases{{InstanceID: 1, DatabaseName: "app3"}}, targets...)
+	statements := []string{"SELECT * FROM orders", "UPDATE orders SET status = 1 WHERE id = 2"}
+
+	tests := []struct {
+		name       string
+		protection string
+		statements []string
+		review     *model.QueryTaskReview // 审批记录，摘要按审批时的 SQL 和目标计算
+		sqls       []string               // 审批后修改为的 SQL，为空时不修改
+		targets    model.TaskDatabases    // 审批后修改为的目标，为空时不修改
+		wantErr    error
+		wantStatus int8
+		wantReason string
+	}{
+		{name: "read only statements", protection: model.ProtectionApproval, statements: []string{"SELECT 1"}},
+		{name: "session statement on unrestricted instance", protection: model.ProtectionUnrestricted, statements: []string{"SET NAMES utf8mb4"}},
+		{name: "dml on unrestricted instance with approve_all_writes default", protection: model.ProtectionUnrestricted, statements: statements,
+			wantErr: ErrApprovalRequired, wantStatus: model.QueryTaskStatusPendingApproval},
+		{name: "readonly instance", protection: model.ProtectionReadOnly, statements: statements, wantErr: ErrReadOnlyInstance},
+		{name: "cte write is not read only", protection: model.ProtectionReadOnly,
+			statements: []string{"WITH d AS (DELETE FROM orders RETURNING *) SELECT count(*) FROM d"}, wantErr: ErrReadOnlyInstance},
+		{name: "not reviewed", protection: model.ProtectionApproval, statements: statements,
+			wantErr: ErrApprovalRequired, wantStatus: model.QueryTaskStatusPendingApproval},
+		{name: "approved", protection: model.ProtectionApproval, statements: statements,
+			review: &model.QueryTaskReview{Decision: model.ReviewDecisionApproved}},
+		{name: "sql changed after approval", protection: model.ProtectionApproval, statements: statements,
+			review:  &model.QueryTaskReview{Decision: model.ReviewDecisionApproved},
+			sqls:    []string{"SELECT * FROM orders", "DELETE FROM orders"},
+			wantErr: ErrApprovalRequired, wantStatus: model.QueryTaskStatusPendingApproval, wantReason: "重新审批"},
+		{name: "sql reordered after approval", protection: model.ProtectionApproval, statements: statements,
+			review:  &model.QueryTaskReview{Decision: model.ReviewDecisionApproved},
+			sqls:    []string{statements[1], statements[0]},
+			wantErr: ErrApprovalRequired, wantStatus: model.QueryTaskStatusPendingApproval, wantReason: "重新审批"},
+		{name: "target added after approval", protection: model.ProtectionApproval, statements: statements,
+			review:  &model.QueryTaskReview{Decision: model.ReviewDecisionApproved},
+			targets: changedTargets,
+			wantErr: ErrApprovalRequired, wantStatus: model.QueryTaskStatusPendingApproval, wantReason: "重新审批"},
+		{name: "rejected", protection: model.ProtectionApproval, statements: statements,
+			review:  &model.QueryTaskReview{Decision: model.ReviewDecisionRejected},
+			wantErr: ErrApprovalRequired, wantStatus: model.QueryTaskStatusPendingApproval},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openTestDB(t)
+			if err := db.Create(&model.Instance{ID: 1, Name: "prod", Engine: model.EngineMySQL, ProtectionMode: tt.protection}).Error; err != nil {
+				t.Fatal(err)
+			}
+			task, sqls := createTestTask(t, db, tt.statements, targets)
+			if tt.review != nil {
+				review := *tt.review
+				review.TaskID = task.ID
+				review.SQLHash = sqlDigest(sqls)
+				review.TargetHash, review.TargetCount = targetDigest(targets)
+				if err := db.Create(&review).Error; err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			currentTargets := targets
+			if tt.targets != nil {
+				currentTargets = tt.targets
+			}
+			if tt.sqls != nil {
+				for i := range sqls {
+					sqls[i].SQLContent = tt.sqls[i]
+				}
+			}
+
+			s := NewQueryTaskRunService(db)
+			err := s.guardProtection(task, sqls, currentTargets)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("guardProtection() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantReason != "" && !strings.Contains(err.Error(), tt.wantReason) {
+				t.Errorf("guardProtection() error = %v, want it to mention %q", err, tt.wantReason)
+			}
+			var stored model.QueryTask
+			if err := db.First(&stored, task.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if stored.Status != tt.wantStatus || task.Status != tt.wantStatus {
+				t.Errorf("task status = %d (in memory %d), want %d", stored.Status, task.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestCheckRunProtectionBlockedStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int8
+		wantErr error
+	}{
+		{name: "pending approval", status: model.QueryTaskStatusPendingApproval, wantErr: ErrApprovalRequired},
+		{name: "rejected", status: model.QueryTaskStatusRejected, wantErr: ErrTaskRejected},
+		{name: "completed read only task", status: model.QueryTaskStatusCompleted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openTestDB(t)
+			if err := db.Create(&model.Instance{ID: 1, Name: "prod", Engine: model.EngineMySQL, ProtectionMode: model.ProtectionApproval}).Error; err != nil {
+				t.Fatal(err)
+			}
+			task, _ := createTestTask(t, db, []string{"SELECT 1"}, model.TaskDatabases{{InstanceID: 1, DatabaseName: "app1"}})
+			if err := db.Model(task).Update("status", tt.status).Error; err != nil {
+				t.Fatal(err)
+			}
+			err := NewQueryTaskRunService(db).CheckRunProtection(task.ID)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("CheckRunProtection() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSQLDigest(t *testing.T) {
+	sqls := func(statements ...string) []model.QueryTaskSQL {
+		result := make([]model.QueryTaskSQL, len(statements))
+		for i, stmt := range statements {
+			result[i] = model.QueryTaskSQL{SQLContent: stmt, SQLOrder: i + 1}
+		}
+		return result
+	}
+	base := sqlDigest(sqls("UPDATE t SET a = 1", "DELETE FROM t"))
+	tests := []struct {
+		name string
+		sqls []model.QueryTaskSQL
+		same bool
+	}{
+		{name: "identical", sqls: sqls("UPDATE t SET a = 1", "DELETE FROM t"), same: true},
+		{name: "statement changed", sqls: sqls("UPDATE t SET a = 2", "DELETE FROM t")},
+		{name: "order changed", sqls: sqls("DELETE FROM t", "UPDATE t SET a = 1")},
+		{name: "statement added", sqls: sqls("UPDATE t SET a = 1", "DELETE FROM t", "SELECT 1")},
+		{name: "boundary moved", sqls: sqls("UPDATE t SET a = 1DELETE FROM t")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sqlDigest(tt.sqls) == base; got != tt.same {
+				t.Errorf("sqlDigest() equal = %v, want %v", got, tt.same)
+			}
+		})
+	}
+}
+
+func TestTargetDigest(t *testing.T) {
+	base, count := targetDigest(model.TaskDatabases{{InstanceID: 1, DatabaseName: "a"}, {InstanceID: 2, DatabaseName: "b"}})
+	if count != 2 {
+		t.Fatalf("targetDigest() count = %d, want 2", count)
+	}
+	tests := []struct {
+		name      string
+		targets   model.TaskDatabases
+		same      bool
+		wantCount int
+	}{
+		{name: "reordered", targets: model.TaskDatabases{{InstanceID: 2, DatabaseName: "b"}, {InstanceID: 1, DatabaseName: "a"}}, same: true, wantCount: 2},
+		{name: "duplicates", targets: model.TaskDatabases{{InstanceID: 1, DatabaseName: "a"}, {InstanceID: 2, DatabaseName: "b"}, {InstanceID: 1, DatabaseName: "a"}}, same: true, wantCount: 2},
+		{name: "database added", targets: model.TaskDatabases{{InstanceID: 1, DatabaseName: "a"}, {InstanceID: 2, DatabaseName: "b"}, {InstanceID: 2, DatabaseName: "c"}}, wantCount: 3},
+		{name: "instance changed", targets: model.TaskDatabases{{InstanceID: 1, DatabaseName: "a"}, {InstanceID: 3, DatabaseName: "b"}}, wantCount: 2},
+		{name: "database removed", targets: model.TaskDatabases{{InstanceID: 1, DatabaseName: "a"}}, wantCount: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			digest, count := targetDigest(tt.targets)
+			if (digest == base) != tt.same || count != tt.wantCount {
+				t.Errorf("targetDigest() equal = %v, count = %d, want %v, %d", digest == base, count, tt.same, tt.wantCount)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/authctx"
+	"my-bulker/internal/pkg/database"
+	"my-bulker/internal/pkg/limiter"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTaskAlreadyQueued = errors.New("任务已在排队或执行中")
+	ErrTaskNotQueued     = errors.New("任务未在排队或执行中")
+)
+
+var (
+	// instanceSlots 实例级并发名额，在所有运行中的任务之间共享
+	instanceSlots = limiter.NewKeyedSemaphore()
+	// instanceRates 实例级语句速率限制，在所有运行中的任务之间共享
+	instanceRates = limiter.NewKeyedRateLimiter()
+)
+
+// QueryTaskQueue 全局查询任务执行队列
+// 所有任务运行请求先进入队列，按先进先出顺序在不超过最大运行数的前提下依次启动；
+// 同一实例上的执行名额由 instanceSlots 在任务间按排队顺序分配，避免单个任务独占实例。
+type QueryTaskQueue struct {
+	db      *gorm.DB
+	mu      sync.Mutex
+	queued  []*queueEntry
+	running map[uint]*queueEntry
+}
+
+// queueEntry 队列中的任务
+type queueEntry struct {
+	taskID     uint
+	taskName   string
+	userID     uint // 发起运行的用户，执行时以该用户的权限重新解析目标
+	enqueuedAt time.Time
+	startedAt  *time.Time
+	cancel     context.CancelFunc
+}
+
+var (
+	queryTaskQueueInstance *QueryTaskQueue
+	queryTaskQueueOnce     sync.Once
+)
+
+// GetQueryTaskQueue 获取全局执行队列（单例）
+func GetQueryTaskQueue() *QueryTaskQueue {
+	queryTaskQueueOnce.Do(func() {
+		queryTaskQueueInstance = &QueryTaskQueue{
+			db:      database.GetDB(),
+			running: make(map[uint]*queueEntry),
+		}
+	})
+	return queryTaskQueueInstance
+}
+
+// Enqueue 将任务加入执行队列，返回排队位置（0 表示已立即开始执行）
+// userID 为发起运行的用户，记录在任务上，服务重启恢复队列时沿用。
+func (q *QueryTaskQueue) Enqueue(task *model.QueryTask, userID uint) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.isActiveLocked(task.ID) {
+		return 0, ErrTaskAlreadyQueued
+	}
+
+	if err := q.db.Model(&model.QueryTask{}).Where("id = ?", task.ID).Updates(map[string]interface{}{
+		"status": model.QueryTaskStatusQueued,
+		"run_by": userID,
+	}).Error; err != nil {
+		return 0, err
+	}
+	q.queued = append(q.queued, &queueEntry{
+		taskID:     task.ID,
+		taskName:   task.TaskName,
+		userID:     userID,
+		enqueuedAt: time.Now(),
+	})
+	q.dispatchLocked()
+
+	return q.positionLocked(task.ID), nil
+}
+
+// Cancel 取消排队中或运行中的任务
+func (q *QueryTaskQueue) Cancel(taskID uint) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if entry, ok := q.running[taskID]; ok {
+		// 运行中的任务通过上下文取消，由执行器负责收尾并更新状态
+		entry.cancel()
+		return nil
+	}
+
+	for i, entry := range q.queued {
+		if entry.taskID == taskID {
+			q.queued = append(q.queued[:i], q.queued[i+1:]...)
+			return q.db.Model(&model.QueryTask{}).Where("id = ?", taskID).Update("status", model.QueryTaskStatusPending).Error
+		}
+	}
+
+	return ErrTaskNotQueued
+}
+
+// IsActive 判断任务是否在排队或执行中
+func (q *QueryTaskQueue) IsActive(taskID uint) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.isActiveLocked(taskID)
+}
+
+// Position 获取任务排队位置，从1开始，未排队返回0
+func (q *QueryTaskQueue) Position(taskID uint) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.positionLocked(taskID)
+}
+
+// Status 获取队列状态
+func (q *QueryTaskQueue) Status() *model.QueryTaskQueueResponse {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	resp := &model.QueryTaskQueueResponse{
+		MaxRunning: q.maxRunning(),
+		Running:    make([]model.QueryTaskQueueItem, 0, len(q.running)),
+		Queued:     make([]model.QueryTaskQueueItem, 0, len(q.queued)),
+	}
+	for _, entry := range q.running {
+		resp.Running = append(resp.Running, entry.toItem(0))
+	}
+	for i, entry := range q.queued {
+		resp.Queued = append(resp.Queued, entry.toItem(i+1))
+	}
+	return resp
+}
+
+// Restore 服务启动时恢复队列
+// 上次退出时仍在排队的任务重新入队；仍处于执行中的任务已无法继续，标记为失败以便重新运行。
+func (q *QueryTaskQueue) Restore() error {
+	if err := q.db.Model(&model.QueryTask{}).
+		Where("status = ?", model.QueryTaskStatusRunning).
+		Update("status", model.QueryTaskStatusFailed).Error; err != nil {
+		return err
+	}
+
+	var tasks []model.QueryTask
+	if err := q.db.Where("status = ?", model.QueryTaskStatusQueued).Order("updated_at ASC").Find(&tasks).Error; err != nil {
+		return err
+	}
+	for i := range tasks {
+		if _, err := q.Enqueue(&tasks[i], tasks[i].RunBy); err != nil {
+			log.Printf("ERROR: failed to restore queued task #%d: %v", tasks[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// dispatchLocked 在运行数未达上限时按顺序启动排队任务，调用方需持有锁
+func (q *QueryTaskQueue) dispatchLocked() {
+	maxRunning := q.maxRunning()
+	for len(q.running) < maxRunning && len(q.queued) > 0 {
+		entry := q.queued[0]
+		q.queued = q.queued[1:]
+
+		ctx, cancel := context.WithCancel(context.Background())
+		now := time.Now()
+		entry.startedAt = &now
+		entry.cancel = cancel
+		q.running[entry.taskID] = entry
+
+		if err := q.db.Model(&model.QueryTask{}).Where("id = ?", entry.taskID).Update("status", model.QueryTaskStatusRunning).Error; err != nil {
+			log.Printf("ERROR: failed to mark task #%d as running: %v", entry.taskID, err)
+		}
+
+		go q.run(ctx, entry)
+	}
+}
+
+// run 执行任务并在结束后释放运行名额
+func (q *QueryTaskQueue) run(ctx context.Context, entry *queueEntry) {
+	defer func() {
+		entry.cancel()
+		q.mu.Lock()
+		delete(q.running, entry.taskID)
+		q.dispatchLocked()
+		q.mu.Unlock()
+	}()
+
+	// 以发起运行的用户的权限执行；用户不存在或已禁用时上下文中没有用户，按无权限处理
+	var user model.User
+	if err := q.db.Where("id = ? AND disabled = ?", entry.userID, false).Limit(1).Find(&user).Error; err != nil {
+		log.Printf("ERROR: failed to load user %d for query task #%d: %v", entry.userID, entry.taskID, err)
+	} else if user.ID != 0 {
+		ctx = authctx.WithUser(ctx, &user)
+	}
+
+	runService := NewQueryTaskRunService(q.db)
+	if err := runService.Run(ctx, entry.taskID); err != nil {
+		log.Printf("ERROR: query task #%d failed: %v", entry.taskID, err)
+	}
+}
+
+func (q *QueryTaskQueue) isActiveLocked(taskID uint) bool {
+	if _, ok := q.running[taskID]; ok {
+		return true
+	}
+	return q.positionLocked(taskID) > 0
+}
+
+func (q *QueryTaskQueue) positionLocked(taskID uint) int {
+	for i, entry := range q.queued {
+		if entry.taskID == taskID {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// maxRunning 获取同时运行的最大任务数
+func (q *QueryTaskQueue) maxRunning() int {
+	n := NewConfigService().GetIntConfig("max_running_tasks", model.DefaultConfigValues.MaxRunningTasks)
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+func (e *queueEntry) toItem(position int) model.QueryTaskQueueItem {
+	return model.QueryTaskQueueItem{
+		TaskID:     e.taskID,
+		TaskName:   e.taskName,
+		Position:   position,
+		EnqueuedAt: e.enqueuedAt,
+		StartedAt:  e.startedAt,
+	}
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/authctx"
+	"my-bulker/internal/pkg/rbac"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTaskNotPendingApproval = errors.New("任务不在待审批状态")
+	ErrSelfApproval           = fmt.Errorf("%w：不能审批通过自己创建的任务", ErrForbidden)
+)
+
+// QueryTaskReviewService 查询任务审批服务
+type QueryTaskReviewService struct {
+	db *gorm.DB
+}
+
+// NewQueryTaskReviewService 创建查询任务审批服务
+func NewQueryTaskReviewService(db *gorm.DB) *QueryTaskReviewService {
+	return &QueryTaskReviewService{db: db}
+}
+
+// Approve 审批通过，记录当前 SQL 和目标数据库的摘要并将任务转为待执行
+// 之后 SQL 或目标发生变化时审批失效，运行前会重新转为待审批。
+func (s *QueryTaskReviewService) Approve(ctx context.Context, taskID uint, req *model.ReviewQueryTaskRequest) (*model.QueryTaskReview, error) {
+	return s.review(ctx, taskID, model.ReviewDecisionApproved, model.QueryTaskStatusPending, req)
+}
+
+// Reject 驳回任务，驳回后的任务不能再执行
+func (s *QueryTaskReviewService) Reject(ctx context.Context, taskID uint, req *model.ReviewQueryTaskRequest) (*model.QueryTaskReview, error) {
+	return s.review(ctx, taskID, model.ReviewDecisionRejected, model.QueryTaskStatusRejected, req)
+}
+
+// List 获取任务的审批记录，按时间倒序
+func (s *QueryTaskReviewService) List(taskID uint) ([]model.QueryTaskReview, error) {
+	var reviews []model.QueryTaskReview
+	if err := s.db.Where("task_id = ?", taskID).Order("id DESC").Find(&reviews).Error; err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
+
+// review 记录审批结论并更新任务状态，仅待审批的任务可以审批
+func (s *QueryTaskReviewService) review(ctx context.Context, taskID uint, decision, status int8, req *model.ReviewQueryTaskRequest) (*model.QueryTaskReview, error) {
+	var review *model.QueryTaskReview
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var task model.QueryTask
+		if err := tx.First(&task, taskID).Error; err != nil {
+			return err
+		}
+		if task.Status != model.QueryTaskStatusPendingApproval {
+			return ErrTaskNotPendingApproval
+		}
+		// 写操作需要另一个人确认，创建人不能审批通过自己的任务
+		if decision == model.ReviewDecisionApproved && task.CreatedBy != 0 && task.CreatedBy == authctx.UserID(ctx) {
+			return ErrSelfApproval
+		}
+
+		var sqls []model.QueryTaskSQL
+		if err := tx.Where("task_id = ?", taskID).Order("sql_order ASC").Find(&sqls).Error; err != nil {
+			return err
+		}
+		targets, err := taskTargets(tx, taskID)
+		if err != nil {
+			return err
+		}
+		// 审批人需要有全部目标实例的写入权限
+		if err := requireAccess(ctx, tx, rbac.ActionRunWrite, targets.InstanceIDs()); err != nil {
+			return err
+		}
+		if decision == model.ReviewDecisionApproved {
+			// 审批期间实例可能被改为只读，此时不能通过
+			statements := make([]string, len(sqls))
+			for i, sql := range sqls {
+				statements[i] = sql.SQLContent
+			}
+			check, err := checkProtection(tx, statements, targets.InstanceIDs())
+			if err != nil {
+				return err
+			}
+			if err := check.err(); err != nil {
+				return err
+			}
+		}
+
+		targetHash, targetCount := targetDigest(targets)
+		review = &model.QueryTaskReview{
+			TaskID:      taskID,
+			Decision:    decision,
+			ReviewerID:  authctx.UserID(ctx),
+			Reviewer:    authctx.Username(ctx),
+			Comment:     req.Comment,
+			SQLHash:     sqlDigest(sqls),
+			TargetHash:  targetHash,
+			TargetCount: targetCount,
+		}
+		if err := tx.Create(review).Error; err != nil {
+			return err
+		}
+		// 以状态为条件更新，避免并发审批重复生效
+		result := tx.Model(&model.QueryTask{}).
+			Where("id = ? AND status = ?", taskID, model.QueryTaskStatusPendingApproval).
+			Update("status", status)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTaskNotPendingApproval
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return review, nil
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/authctx"
+)
+
+func TestReviewSelfApproval(t *testing.T) {
+	creator := &model.User{ID: 2, Username: "alice", IsAdmin: true}
+	other := &model.User{ID: 3, Username: "bob", IsAdmin: true}
+	tests := []struct {
+		name       string
+		user       *model.User
+		approve    bool
+		wantErr    error
+		wantStatus int8
+	}{
+		{name: "creator approves", user: creator, approve: true, wantErr: ErrSelfApproval, wantStatus: model.QueryTaskStatusPendingApproval},
+		{name: "creator rejects", user: creator, wantStatus: model.QueryTaskStatusRejected},
+		{name: "other approves", user: other, approve: true, wantStatus: model.QueryTaskStatusPending},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openTestDB(t)
+			if err := db.Create(&model.Instance{ID: 1, Name: "prod", Engine: model.EngineMySQL, ProtectionMode: model.ProtectionApproval}).Error; err != nil {
+				t.Fatal(err)
+			}
+			task, _ := createTestTask(t, db, []string{"DELETE FROM orders WHERE id = 1"}, model.TaskDatabases{{InstanceID: 1, DatabaseName: "app"}})
+			if err := db.Model(task).Update("status", model.QueryTaskStatusPendingApproval).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := authctx.WithUser(context.Background(), tt.user)
+			s := NewQueryTaskReviewService(db)
+			var err error
+			if tt.approve {
+				_, err = s.Approve(ctx, task.ID, &model.ReviewQueryTaskRequest{})
+			} else {
+				_, err = s.Reject(ctx, task.ID, &model.ReviewQueryTaskRequest{Comment: "no"})
+			}
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("review error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil && !errors.Is(err, ErrForbidden) {
+				t.Errorf("review error = %v, want it to wrap ErrForbidden", err)
+			}
+			var stored model.QueryTask
+			if err := db.First(&stored, task.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if stored.Status != tt.wantStatus {
+				t.Errorf("task status = %d, want %d", stored.Status, tt.wantStatus)
+			}
+		})
+	}
+}
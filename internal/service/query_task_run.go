@@ -4,12 +4,12 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"my-bulker/internal/model"
 	"my-bulker/internal/pkg/database"
 	"my-bulker/internal/pkg/sql_parse"
-	"strconv"
 	"sync"
 	"time"
 
@@ -49,6 +49,10 @@ func (s *QueryTaskRunService) ResetQueryTask(ctx context.Context, taskID uint) e
 			"error_message":  "",
 			"result_count":   nil,
 			"execution_time": nil,
+			"paused_time":    0,
+			"pause_reason":   "",
+			"affected_rows":  nil,
+			"chunk_count":    0,
 			"started_at":     nil,
 			"completed_at":   nil,
 		}).Error; err != nil {
@@ -94,6 +98,16 @@ func (s *QueryTaskRunService) ResetQueryTask(ctx context.Context, taskID uint) e
 	})
 }
 
+// markFailed 将未能开始执行的任务标记为失败
+func (s *QueryTaskRunService) markFailed(task *model.QueryTask) {
+	t := time.Now()
+	task.CompletedAt = &t
+	task.Status = model.QueryTaskStatusFailed
+	if err := s.db.Save(task).Error; err != nil {
+		log.Printf("ERROR: failed to mark query task #%d as failed: %v", task.ID, err)
+	}
+}
+
 // statResult 定义了任务执行后的统计结果
 type statResult struct {
 	completedDBs map[string]struct{}
@@ -103,12 +117,40 @@ type statResult struct {
 
 // Run 执行查询任务（允许重复执行）
 func (s *QueryTaskRunService) Run(ctx context.Context, taskID uint) error {
+	// 动态选择模式的任务先重新确定目标，失败时沿用上次的目标
+	_, refreshErr := s.refreshTargets(ctx, taskID, false)
+	if refreshErr != nil && !errors.Is(refreshErr, ErrForbidden) {
+		log.Printf("WARN: failed to refresh targets for query task #%d, using previous targets: %v", taskID, refreshErr)
+	}
+
 	// 1. 准备任务所需的所有数据
 	task, sqls, executions, instMap, err := s.prepareTaskData(taskID)
 	if err != nil {
 		return fmt.Errorf("准备任务数据失败: %w", err)
 	}
 
+	// 以发起运行的用户的权限检查全部目标，权限已收回或上下文中没有用户时不执行
+	if errors.Is(refreshErr, ErrForbidden) {
+		s.markFailed(task)
+		return refreshErr
+	}
+	statements := make([]string, len(sqls))
+	for i, sql := range sqls {
+		statements[i] = sql.SQLContent
+	}
+	if err := requireAccess(ctx, s.db, taskAction(statements), executionTargets(executions).InstanceIDs()); err != nil {
+		s.markFailed(task)
+		return err
+	}
+
+	// 动态目标可能新增了受保护的实例或改变了审批时的目标，执行前再次检查保护模式和审批
+	if err := s.guardProtection(task, sqls, executionTargets(executions)); err != nil {
+		if errors.Is(err, ErrReadOnlyInstance) {
+			s.markFailed(task)
+		}
+		return err
+	}
+
 	// 如果没有任何执行项，直接将任务标记为完成
 	if len(executions) == 0 {
 		log.Printf("任务 #%d 没有执行项，直接标记为完成。", taskID)
@@ -119,13 +161,13 @@ func (s *QueryTaskRunService) Run(ctx context.Context, taskID uint) error {
 	}
 
 	// 2. 获取相关设置
-	maxConn, concurrency, queryTimeoutSec := s.getSetting()
+	settings := s.getSetting()
 
 	// 3. 并发执行所有SQL
-	stats := s.executeSQLsConcurrently(ctx, task, sqls, executions, instMap, maxConn, concurrency, queryTimeoutSec)
+	stats := s.executeSQLsConcurrently(ctx, task, sqls, executions, instMap, settings)
 
 	// 4. 聚合统计结果并更新数据库
-	return s.aggregateAndSaveStats(task, sqls, executions, stats)
+	return s.aggregateAndSaveStats(ctx, task, sqls, executions, stats)
 }
 
 // prepareTaskData 从数据库加载任务执行所需的所有数据
@@ -168,22 +210,18 @@ func (s *QueryTaskRunService) executeSQLsConcurrently(
 	sqls []model.QueryTaskSQL,
 	executions []model.QueryTaskExecution,
 	instMap map[uint]*model.Instance,
-	maxConn int,
-	concurrency int,
-	queryTimeoutSec int,
+	settings runSettings,
 ) *statResult {
-	// 连接池：key=instanceID+dbName，value=*gorm.DB
-	dbConnPool := make(map[string]*gorm.DB)
+	// 实例级连接池：key=instanceID，同一实例下的所有库共享，并与其他任务复用
+	instPools := make(map[uint]*database.Pool)
 	var poolMu sync.Mutex
 
-	// 任务结束时，延迟关闭所有在本次任务中创建的连接
+	// 任务结束时释放本次任务持有的连接池引用，连接池本身由空闲回收机制关闭
 	defer func() {
 		poolMu.Lock()
 		defer poolMu.Unlock()
-		for _, dbConn := range dbConnPool {
-			if sqlDB, err := dbConn.DB(); err == nil {
-				sqlDB.Close()
-			}
+		for _, pool := range instPools {
+			pool.Release()
 		}
 	}()
 
@@ -200,7 +238,7 @@ func (s *QueryTaskRunService) executeSQLsConcurrently(
 		s.db.Model(&model.QueryTask{}).Where("id = ?", task.ID).Update("started_at", startTime)
 	}
 
-	sem := make(chan struct{}, concurrency)
+	sem := make(chan struct{}, settings.concurrency)
 
 	type StatMsg struct {
 		SQLID        uint
@@ -309,6 +347,14 @@ func (s *QueryTaskRunService) executeSQLsConcurrently(
 		if len(sqlExecutions) == 0 {
 			continue
 		}
+		// 写入语句在下发前需检查从库复制延迟
+		isWrite := sql_parse.IsWrite(sql.SQLContent)
+		// 分批模式下的 UPDATE/DELETE 改写为分批语句执行
+		var chunkStmt *sql_parse.ChunkStatement
+		var chunkErr error
+		if task.ChunkSize > 0 && isChunkCandidate(sql.SQLContent) {
+			chunkStmt, chunkErr = sql_parse.ParseChunkStatement(sql.SQLContent)
+		}
 
 		var wg sync.WaitGroup
 		wg.Add(len(sqlExecutions))
@@ -319,103 +365,147 @@ func (s *QueryTaskRunService) executeSQLsConcurrently(
 				defer wg.Done()
 				defer func() { <-sem }()
 
-				inst := instMap[exec.InstanceID]
-				if inst == nil {
-					exec.Status = 3
-					exec.ErrorMessage = "实例不存在"
+				// finish 记录执行结果并上报统计
+				finish := func(status int8, message string) {
+					exec.Status = status
+					exec.ErrorMessage = message
 					t := time.Now()
 					exec.CompletedAt = &t
 					statCh <- StatMsg{SQLID: exec.SQLID, InstanceID: exec.InstanceID, DatabaseName: exec.DatabaseName, Status: exec.Status}
 					updateQueue <- exec
+				}
+
+				// 任务被取消后不再发起新的执行
+				if ctx.Err() != nil {
+					finish(3, "任务已取消")
 					return
 				}
-				poolKey := fmt.Sprintf("%d_%s", exec.InstanceID, exec.DatabaseName)
+
+				inst := instMap[exec.InstanceID]
+				if inst == nil {
+					finish(3, "实例不存在")
+					return
+				}
+				if chunkErr != nil {
+					finish(3, "语句无法分批执行: "+chunkErr.Error())
+					return
+				}
+				if chunkStmt != nil && inst.EngineName() != model.EngineMySQL {
+					finish(3, errChunkEngine.Error())
+					return
+				}
+				// 复制延迟超过阈值时暂停下发，暂停期间不占用实例名额
+				if isWrite {
+					paused, reason, err := replicaLag.Wait(ctx, inst)
+					exec.PausedTime = paused.Milliseconds()
+					exec.PauseReason = reason
+					if err != nil {
+						finish(3, "任务已取消")
+						return
+					}
+				}
+				// 占用实例级共享名额，与其他运行中的任务按排队顺序轮流使用同一实例
+				if err := instanceSlots.Acquire(ctx, exec.InstanceID, inst.EffectiveMaxConcurrency(settings.instanceMaxConn)); err != nil {
+					finish(3, "任务已取消")
+					return
+				}
+				defer instanceSlots.Release(exec.InstanceID)
+				// 按实例速率限制节流
+				if err := instanceRates.Wait(ctx, exec.InstanceID, inst.RateLimit); err != nil {
+					finish(3, "任务已取消")
+					return
+				}
+
 				poolMu.Lock()
-				dbConn, ok := dbConnPool[poolKey]
-				poolMu.Unlock()
+				pool, ok := instPools[exec.InstanceID]
 				var err error
 				if !ok {
-					dbConn, err = database.NewMySQLGormDB(inst, exec.DatabaseName, maxConn)
+					pool, err = database.AcquirePool(inst, inst.EffectiveMaxConnections(settings.maxConn))
+					if err == nil {
+						instPools[exec.InstanceID] = pool
+					}
+				}
+				poolMu.Unlock()
+				if err != nil {
+					finish(3, "连接数据库失败: "+err.Error())
+					return
+				}
+
+				// 分批执行的 UPDATE/DELETE 不产生结果集，单独处理
+				if chunkStmt != nil {
+					result, err := s.executeChunked(ctx, pool, exec.DatabaseName, inst, chunkStmt, task, settings)
+					exec.PausedTime += result.paused.Milliseconds()
+					if result.pauseReason != "" {
+						exec.PauseReason = result.pauseReason
+					}
+					exec.AffectedRows = &result.affected
+					exec.ChunkCount = result.chunks
 					if err != nil {
-						exec.Status = 3
-						exec.ErrorMessage = "连接数据库失败: " + err.Error()
-						t := time.Now()
-						exec.CompletedAt = &t
-						statCh <- StatMsg{SQLID: exec.SQLID, InstanceID: exec.InstanceID, DatabaseName: exec.DatabaseName, Status: exec.Status}
-						updateQueue <- exec
+						finish(3, chunkErrorMessage(ctx, err))
 						return
 					}
-					poolMu.Lock()
-					dbConnPool[poolKey] = dbConn
-					poolMu.Unlock()
+					finish(2, "")
+					return
 				}
-				queryDone := make(chan error, 1)
+
+				// 超时或任务取消时由驱动中断查询并丢弃该连接
+				queryCtx, cancelQuery := context.WithTimeout(ctx, time.Duration(settings.queryTimeoutSec)*time.Second)
+				defer cancelQuery()
 				var rows []map[string]interface{}
-				go func() {
-					queryDone <- dbConn.Raw(currentSQL.SQLContent).Scan(&rows).Error
-				}()
-				select {
-				case err = <-queryDone:
-					if err != nil {
-						exec.Status = 3
-						exec.ErrorMessage = "SQL执行失败: " + err.Error()
-						t := time.Now()
-						exec.CompletedAt = &t
-						statCh <- StatMsg{SQLID: exec.SQLID, InstanceID: exec.InstanceID, DatabaseName: exec.DatabaseName, Status: exec.Status}
-						updateQueue <- exec
-						return
+				err = pool.WithDatabase(queryCtx, exec.DatabaseName, func(tx *gorm.DB) error {
+					if sql_parse.MayChangeSession(currentSQL.SQLContent) {
+						database.MarkSessionDirty(tx)
+					}
+					return tx.Raw(currentSQL.SQLContent).Scan(&rows).Error
+				})
+				if err != nil {
+					switch {
+					case ctx.Err() != nil:
+						finish(3, "任务已取消")
+					case errors.Is(queryCtx.Err(), context.DeadlineExceeded):
+						finish(3, "SQL执行超时")
+					default:
+						finish(3, "SQL执行失败: "+err.Error())
 					}
-					if len(rows) > 0 {
-						var schemaObj model.TableSchema
-						_ = json.Unmarshal([]byte(currentSQL.ResultTableSchema), &schemaObj)
-						b64Map := make(map[string]string)
-						for _, f := range schemaObj.Fields {
-							b64 := base64.RawURLEncoding.EncodeToString([]byte(f.Name))
-							b64Map[f.Name] = b64
-							normalizedName := sql_parse.NormalizeResultHeaderName(f.Name)
-							// 旧任务 schema 里可能保留了表前缀，这里补一层兼容映射。
-							if normalizedName != f.Name {
-								if _, exists := b64Map[normalizedName]; !exists {
-									b64Map[normalizedName] = b64
-								}
+					return
+				}
+				if len(rows) > 0 {
+					var schemaObj model.TableSchema
+					_ = json.Unmarshal([]byte(currentSQL.ResultTableSchema), &schemaObj)
+					b64Map := make(map[string]string)
+					for _, f := range schemaObj.Fields {
+						b64 := base64.RawURLEncoding.EncodeToString([]byte(f.Name))
+						b64Map[f.Name] = b64
+						normalizedName := sql_parse.NormalizeResultHeaderName(f.Name)
+						// 旧任务 schema 里可能保留了表前缀，这里补一层兼容映射。
+						if normalizedName != f.Name {
+							if _, exists := b64Map[normalizedName]; !exists {
+								b64Map[normalizedName] = b64
 							}
 						}
-						buf := buffers[currentSQL.ID]
-						buf.mu.Lock()
-						for _, row := range rows {
-							insert := make(map[string]interface{})
-							for k, v := range row {
-								if b64, ok := b64Map[k]; ok {
-									insert[b64] = v
-								}
-							}
-							insert[base64.RawURLEncoding.EncodeToString([]byte("query_task_execution_instance_id"))] = exec.InstanceID
-							insert[base64.RawURLEncoding.EncodeToString([]byte("query_task_execution_instance_name"))] = inst.Name
-							insert[base64.RawURLEncoding.EncodeToString([]byte("query_task_execution_database_name"))] = exec.DatabaseName
-							insert[base64.RawURLEncoding.EncodeToString([]byte("query_task_execution_error_message"))] = exec.ErrorMessage
-							buf.rows = append(buf.rows, insert)
-							if len(buf.rows) >= batchSize {
-								s.db.Table(currentSQL.ResultTableName).CreateInBatches(buf.rows, batchSize)
-								buf.rows = buf.rows[:0]
+					}
+					buf := buffers[currentSQL.ID]
+					buf.mu.Lock()
+					for _, row := range rows {
+						insert := make(map[string]interface{})
+						for k, v := range row {
+							if b64, ok := b64Map[k]; ok {
+								insert[b64] = v
 							}
 						}
-						buf.mu.Unlock()
+						insert[base64.RawURLEncoding.EncodeToString([]byte("query_task_execution_instance_id"))] = exec.InstanceID
+						insert[base64.RawURLEncoding.EncodeToString([]byte("query_task_execution_instance_name"))] = inst.Name
+						insert[base64.RawURLEncoding.EncodeToString([]byte("query_task_execution_database_name"))] = exec.DatabaseName
+						insert[base64.RawURLEncoding.EncodeToString([]byte("query_task_execution_error_message"))] = exec.ErrorMessage
+						buf.rows = append(buf.rows, insert)
+						if len(buf.rows) >= batchSize {
+							s.db.Table(currentSQL.ResultTableName).CreateInBatches(buf.rows, batchSize)
+							buf.rows = buf.rows[:0]
+						}
 					}
-					exec.Status = 2
-					exec.ErrorMessage = ""
-					t := time.Now()
-					exec.CompletedAt = &t
-					statCh <- StatMsg{SQLID: exec.SQLID, InstanceID: exec.InstanceID, DatabaseName: exec.DatabaseName, Status: exec.Status}
-					updateQueue <- exec
-				case <-time.After(time.Duration(queryTimeoutSec) * time.Second):
-					exec.Status = 3
-					exec.ErrorMessage = "SQL执行超时"
-					t := time.Now()
-					exec.CompletedAt = &t
-					statCh <- StatMsg{SQLID: exec.SQLID, InstanceID: exec.InstanceID, DatabaseName: exec.DatabaseName, Status: exec.Status}
-					updateQueue <- exec
-					return
+					buf.mu.Unlock()
 				}
+				finish(2, "")
 			}(e, sql)
 		}
 		// 等待当前 SQL 的所有 execution 完成
@@ -445,6 +535,7 @@ func (s *QueryTaskRunService) executeSQLsConcurrently(
 
 // aggregateAndSaveStats 聚合最终的统计数据并保存到数据库
 func (s *QueryTaskRunService) aggregateAndSaveStats(
+	ctx context.Context,
 	task *model.QueryTask,
 	sqls []model.QueryTaskSQL,
 	executions []model.QueryTaskExecution,
@@ -502,53 +593,31 @@ func (s *QueryTaskRunService) aggregateAndSaveStats(
 	t := time.Now()
 	s.db.Model(&model.QueryTask{}).Where("id = ?", task.ID).Update("completed_at", t)
 	task.CompletedAt = &t
-	task.Status = 2
+	task.Status = model.QueryTaskStatusCompleted
+	// 被取消的任务标记为失败，便于重新运行
+	if ctx.Err() != nil {
+		task.Status = model.QueryTaskStatusFailed
+	}
 	return s.db.Save(task).Error
 }
 
-// getSetting 获取任务执行相关设置（最大连接数、并发数、查询超时时间）
-func (s *QueryTaskRunService) getSetting() (maxConn int, concurrency int, queryTimeoutSec int) {
+// runSettings 任务执行相关设置
+type runSettings struct {
+	maxConn         int // 单个实例连接池最大连接数
+	concurrency     int // 单个任务的并发数
+	queryTimeoutSec int // 查询超时时间(秒)
+	instanceMaxConn int // 单个实例在所有任务间共享的最大并发执行数
+}
+
+// getSetting 获取任务执行相关设置
+func (s *QueryTaskRunService) getSetting() runSettings {
 	configSvc := NewConfigService()
 	defaults := model.DefaultConfigValues
 
-	// 获取最大连接数
-	maxConnStr, err := configSvc.GetConfig("max_conn")
-	if err == nil {
-		if val, err := strconv.Atoi(maxConnStr); err == nil {
-			maxConn = val
-		} else {
-			log.Printf("WARN: Could not parse max_conn '%s', using default %d. Error: %v", maxConnStr, defaults.MaxConn, err)
-			maxConn = defaults.MaxConn
-		}
-	} else {
-		maxConn = defaults.MaxConn
-	}
-
-	// 获取并发数
-	concurrencyStr, err := configSvc.GetConfig("concurrency")
-	if err == nil {
-		if val, err := strconv.Atoi(concurrencyStr); err == nil {
-			concurrency = val
-		} else {
-			log.Printf("WARN: Could not parse concurrency '%s', using default %d. Error: %v", concurrencyStr, defaults.Concurrency, err)
-			concurrency = defaults.Concurrency
-		}
-	} else {
-		concurrency = defaults.Concurrency
+	return runSettings{
+		maxConn:         configSvc.GetIntConfig("max_conn", defaults.MaxConn),
+		concurrency:     configSvc.GetIntConfig("concurrency", defaults.Concurrency),
+		queryTimeoutSec: configSvc.GetIntConfig("query_timeout_sec", defaults.QueryTimeoutSec),
+		instanceMaxConn: configSvc.GetIntConfig("instance_max_conn", defaults.InstanceMaxConn),
 	}
-
-	// 获取查询超时时间
-	queryTimeoutSecStr, err := configSvc.GetConfig("query_timeout_sec")
-	if err == nil {
-		if val, err := strconv.Atoi(queryTimeoutSecStr); err == nil {
-			queryTimeoutSec = val
-		} else {
-			log.Printf("WARN: Could not parse query_timeout_sec '%s', using default %d. Error: %v", queryTimeoutSecStr, defaults.QueryTimeoutSec, err)
-			queryTimeoutSec = defaults.QueryTimeoutSec
-		}
-	} else {
-		queryTimeoutSec = defaults.QueryTimeoutSec
-	}
-
-	return
 }
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/database"
+	"my-bulker/internal/pkg/sql_parse"
+
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
+)
+
+// probeTimeout 单个库执行探测语句的超时时间
+const probeTimeout = 10 * time.Second
+
+// filterBySchema 按表结构条件筛选候选库：先按已同步的表和字段过滤，再在剩余库上执行探测语句
+func (s *QueryTaskCreatorService) filterBySchema(candidates model.TaskDatabases, require *model.SchemaRequirement) (model.TaskDatabases, error) {
+	if require == nil {
+		return nil, fmt.Errorf("%w: 请填写需要的表、字段或探测语句", ErrInvalidTargetRule)
+	}
+	tables, columns, err := parseSchemaRequirement(require)
+	if err != nil {
+		return nil, err
+	}
+	probeSQL := strings.TrimSpace(require.ProbeSQL)
+	if len(tables) == 0 && len(columns) == 0 && probeSQL == "" {
+		return nil, fmt.Errorf("%w: 请填写需要的表、字段或探测语句", ErrInvalidTargetRule)
+	}
+	if probeSQL != "" {
+		if err := validProbeSQL(probeSQL); err != nil {
+			return nil, err
+		}
+	}
+	if len(candidates) == 0 {
+		return candidates, nil
+	}
+
+	matched := candidates
+	if len(tables) > 0 {
+		if matched, err = s.matchTables(candidates, tables, columns); err != nil {
+			return nil, err
+		}
+	}
+	if probeSQL != "" && len(matched) > 0 {
+		return s.probeDatabases(matched, probeSQL)
+	}
+	return matched, nil
+}
+
+// parseSchemaRequirement 整理需要的表及字段，字段所在的表同样视为需要存在的表
+func parseSchemaRequirement(require *model.SchemaRequirement) ([]string, map[string][]string, error) {
+	var tables []string
+	seen := make(map[string]bool)
+	addTable := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			tables = append(tables, name)
+		}
+	}
+	for _, t := range require.Tables {
+		if t = strings.TrimSpace(t); t != "" {
+			addTable(t)
+		}
+	}
+
+	columns := make(map[string][]string)
+	for _, c := range require.Columns {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		var table, column string
+		if i := strings.LastIndex(c, "."); i >= 0 {
+			table, column = strings.TrimSpace(c[:i]), strings.TrimSpace(c[i+1:])
+		}
+		if table == "" || column == "" {
+			return nil, nil, fmt.Errorf("%w: 字段 %s 需写作 表名.字段名", ErrInvalidTargetRule, c)
+		}
+		addTable(table)
+		columns[table] = append(columns[table], column)
+	}
+	return tables, columns, nil
+}
+
+// validProbeSQL 探测语句只能是单条只读查询
+func validProbeSQL(probeSQL string) error {
+	statements, err := sql_parse.SplitSQLStatements(probeSQL)
+	if err != nil {
+		return fmt.Errorf("%w: 探测语句无效: %v", ErrInvalidTargetRule, err)
+	}
+	if len(statements) != 1 {
+		return fmt.Errorf("%w: 探测语句只能包含一条语句", ErrInvalidTargetRule)
+	}
+	if sql_parse.ClassifyStatement(statements[0]) != sql_parse.StatementRead {
+		return fmt.Errorf("%w: 探测语句必须是只读查询", ErrInvalidTargetRule)
+	}
+	return nil
+}
+
+// matchTables 按最近一次同步的表结构筛选包含全部所需表和字段的库
+func (s *QueryTaskCreatorService) matchTables(candidates model.TaskDatabases, tables []string, columns map[string][]string) (model.TaskDatabases, error) {
+	instanceIDs := candidates.InstanceIDs()
+
+	var rows []model.DatabaseTable
+	if err := s.db.Where("instance_id IN ? AND name IN ?", instanceIDs, tables).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("获取表结构失败: %v", err)
+	}
+	found := make(map[string]map[string]model.ColumnList)
+	for _, row := range rows {
+		key := targetKey(row.InstanceID, row.DatabaseName)
+		if found[key] == nil {
+			found[key] = make(map[string]model.ColumnList)
+		}
+		found[key][row.Name] = row.Columns
+	}
+
+	matched := make(model.TaskDatabases, 0, len(candidates))
+	for _, db := range candidates {
+		if hasSchema(found[targetKey(db.InstanceID, db.DatabaseName)], tables, columns) {
+			matched = append(matched, db)
+		}
+	}
+	return matched, nil
+}
+
+// hasSchema 判断库中是否存在全部所需表和字段
+func hasSchema(dbTables map[string]model.ColumnList, tables []string, columns map[string][]string) bool {
+	for _, table := range tables {
+		cols, ok := dbTables[table]
+		if !ok {
+			return false
+		}
+		for _, column := range columns[table] {
+			if !cols.Has(column) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// probeDatabases 在每个库上执行探测语句，保留首行首列为真值的库
+// 单个库执行失败（如缺少探测的表）视为不匹配；实例无法连接时返回错误。
+func (s *QueryTaskCreatorService) probeDatabases(candidates model.TaskDatabases, probeSQL string) (model.TaskDatabases, error) {
+	byInstance := make(map[uint][]string)
+	var instanceIDs []uint
+	for _, db := range candidates {
+		if _, ok := byInstance[db.InstanceID]; !ok {
+			instanceIDs = append(instanceIDs, db.InstanceID)
+		}
+		byInstance[db.InstanceID] = append(byInstance[db.InstanceID], db.DatabaseName)
+	}
+
+	var instances []model.Instance
+	if err := s.db.Find(&instances, instanceIDs).Error; err != nil {
+		return nil, fmt.Errorf("获取实例失败: %v", err)
+	}
+
+	configSvc := NewConfigService()
+	maxConn := configSvc.GetIntConfig("max_conn", model.DefaultConfigValues.MaxConn)
+	instanceMaxConn := configSvc.GetIntConfig("instance_max_conn", model.DefaultConfigValues.InstanceMaxConn)
+
+	var mu sync.Mutex
+	matched := make(map[string]bool)
+
+	g, _ := errgroup.WithContext(context.Background())
+	g.SetLimit(5)
+	for _, instance := range instances {
+		instance := instance
+		g.Go(func() error {
+			pool, err := database.AcquirePool(&instance, instance.EffectiveMaxConnections(maxConn))
+			if err != nil {
+				return err
+			}
+			defer pool.Release()
+
+			ctx := context.Background()
+			if err := instanceSlots.Acquire(ctx, instance.ID, instance.EffectiveMaxConcurrency(instanceMaxConn)); err != nil {
+				return err
+			}
+			defer instanceSlots.Release(instance.ID)
+
+			for _, dbName := range byInstance[instance.ID] {
+				if err := instanceRates.Wait(ctx, instance.ID, instance.RateLimit); err != nil {
+					return err
+				}
+				ok, err := probeDatabase(pool, dbName, probeSQL)
+				if err != nil {
+					log.Printf("WARN: probe query failed on instance %s database %s: %v", instance.Name, dbName, err)
+					continue
+				}
+				if ok {
+					mu.Lock()
+					matched[targetKey(instance.ID, dbName)] = true
+					mu.Unlock()
+				}
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, fmt.Errorf("执行探测语句失败: %v", err)
+	}
+
+	result := make(model.TaskDatabases, 0, len(matched))
+	for _, db := range candidates {
+		if matched[targetKey(db.InstanceID, db.DatabaseName)] {
+			result = append(result, db)
+		}
+	}
+	return result, nil
+}
+
+// probeDatabase 在指定库的只读事务中执行探测语句，无结果视为假
+func probeDatabase(pool *database.Pool, dbName, probeSQL string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+
+	var ok bool
+	err := pool.WithReadOnlyTransaction(ctx, dbName, func(tx *gorm.DB) error {
+		rows, err := tx.Raw(probeSQL).Rows()
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		if !rows.Next() {
+			return rows.Err()
+		}
+		cols, err := rows.Columns()
+		if err != nil {
+			return err
+		}
+		values := make([]interface{}, len(cols))
+		dest := make([]interface{}, len(cols))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		ok = len(values) > 0 && truthy(values[0])
+		return nil
+	})
+	return ok, err
+}
+
+// truthy 判断探测结果是否为真：NULL、0、空字符串及 false/no 视为假
+func truthy(v interface{}) bool {
+	switch x := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return x
+	case int64:
+		return x != 0
+	case float64:
+		return x != 0
+	case []byte:
+		return truthyString(string(x))
+	case string:
+		return truthyString(x)
+	default:
+		return true
+	}
+}
+
+func truthyString(s string) bool {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "", "false", "f", "no", "n":
+		return false
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f != 0
+	}
+	return true
+}
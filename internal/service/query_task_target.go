@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/namepattern"
+	"my-bulker/internal/pkg/rbac"
+	"my-bulker/internal/pkg/tagexpr"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidTargetRule 目标选择规则无效
+var ErrInvalidTargetRule = errors.New("目标选择规则无效")
+
+// PreviewTargets 按选择参数解析目标数据库，不创建任务，需要命中实例的运行权限
+func (s *QueryTaskCreatorService) PreviewTargets(ctx context.Context, sel *model.TargetSelection) (*model.PreviewTargetsResponse, error) {
+	rule := targetRuleOf(sel)
+	if err := s.authorizeProbe(ctx, rule); err != nil {
+		return nil, err
+	}
+	targetDBs, err := s.determineTargetDatabases(rule, sel.SelectedDBs, sel.InstanceIDs)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireAccess(ctx, s.db, rbac.ActionRunRead, targetDBs.InstanceIDs()); err != nil {
+		return nil, err
+	}
+	if targetDBs == nil {
+		targetDBs = model.TaskDatabases{}
+	}
+	return &model.PreviewTargetsResponse{Total: len(targetDBs), Items: targetDBs}, nil
+}
+
+// authorizeProbe 按探测查询选择目标时会在范围内的每个实例上执行查询，解析前先检查这些实例的运行权限
+func (s *QueryTaskCreatorService) authorizeProbe(ctx context.Context, rule model.TargetRule) error {
+	if rule.Mode != model.TargetModeSchema || rule.Require == nil || rule.Require.ProbeSQL == "" {
+		return nil
+	}
+	ids, err := s.scopeInstanceIDs(rule)
+	if err != nil {
+		return err
+	}
+	return requireAccess(ctx, s.db, rbac.ActionRunRead, ids)
+}
+
+// targetRuleOf 根据选择参数生成保存在任务上的目标选择规则
+func targetRuleOf(sel *model.TargetSelection) model.TargetRule {
+	rule := model.TargetRule{Mode: sel.DatabaseMode, TagExpr: strings.TrimSpace(sel.TagExpr)}
+	switch sel.DatabaseMode {
+	case model.TargetModeInclude, model.TargetModeExclude:
+		rule.InstanceIDs = sel.InstanceIDs
+		rule.SelectedDBs = sel.SelectedDBs
+	case model.TargetModePattern:
+		filter := sel.Filter
+		rule.InstanceIDs = sel.InstanceIDs
+		rule.Filter = &filter
+	case model.TargetModeSchema:
+		require := sel.Require
+		require.ProbeSQL = strings.TrimSpace(require.ProbeSQL)
+		rule.InstanceIDs = sel.InstanceIDs
+		rule.Require = &require
+	}
+	return rule
+}
+
+// scopeInstanceIDs 获取动态模式下的实例范围：优先使用指定的实例，否则按标签表达式匹配
+func (s *QueryTaskCreatorService) scopeInstanceIDs(rule model.TargetRule) ([]uint, error) {
+	if len(rule.InstanceIDs) > 0 {
+		return rule.InstanceIDs, nil
+	}
+	if rule.TagExpr == "" {
+		return nil, fmt.Errorf("%w: 请选择实例或填写标签表达式", ErrInvalidTargetRule)
+	}
+	expr, err := tagexpr.Parse(rule.TagExpr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTagExpr, err)
+	}
+	ids, err := matchInstanceIDs(s.db, expr)
+	if err != nil {
+		return nil, fmt.Errorf("获取实例列表失败: %v", err)
+	}
+	return ids, nil
+}
+
+// findDatabases 获取实例下已同步的数据库，filter 不为空时按名称模式及属性筛选
+func (s *QueryTaskCreatorService) findDatabases(instanceIDs []uint, filter *model.DatabaseFilter) (model.TaskDatabases, error) {
+	query := s.db.Where("instance_id IN ?", instanceIDs)
+	var include, exclude namepattern.Set
+	if filter != nil {
+		var err error
+		if include, err = namepattern.CompileSet(filter.IncludePatterns); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidTargetRule, err)
+		}
+		if exclude, err = namepattern.CompileSet(filter.ExcludePatterns); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidTargetRule, err)
+		}
+		const mb = 1024 * 1024
+		if filter.MinSizeMB != nil {
+			query = query.Where("size >= ?", *filter.MinSizeMB*mb)
+		}
+		if filter.MaxSizeMB != nil {
+			query = query.Where("size <= ?", *filter.MaxSizeMB*mb)
+		}
+		if filter.MinTables != nil {
+			query = query.Where("table_count >= ?", *filter.MinTables)
+		}
+		if filter.MaxTables != nil {
+			query = query.Where("table_count <= ?", *filter.MaxTables)
+		}
+		if charset := strings.TrimSpace(filter.Charset); charset != "" {
+			query = query.Where("LOWER(character_set) = LOWER(?)", charset)
+		}
+	}
+
+	var allDBs []model.Database
+	if err := query.Order("instance_id ASC, name ASC").Find(&allDBs).Error; err != nil {
+		return nil, fmt.Errorf("获取数据库列表失败: %v", err)
+	}
+	targetDBs := make(model.TaskDatabases, 0, len(allDBs))
+	for _, db := range allDBs {
+		if len(include) > 0 && !include.Match(db.Name) {
+			continue
+		}
+		if exclude.Match(db.Name) {
+			continue
+		}
+		targetDBs = append(targetDBs, model.TaskDatabase{
+			InstanceID:   db.InstanceID,
+			DatabaseName: db.Name,
+		})
+	}
+	return s.fillInstanceNames(targetDBs), nil
+}
+
+// ErrTargetRuleMissing 任务未保存目标选择规则
+var ErrTargetRuleMissing = errors.New("任务创建时未保存目标选择规则，无法重新解析目标")
+
+// ReResolveTargets 按任务保存的选择规则和最近一次同步结果重新确定目标，适用于所有选择模式
+// 新的目标需要当前用户有运行权限，否则不做修改。
+func (s *QueryTaskRunService) ReResolveTargets(ctx context.Context, taskID uint) (*model.RefreshTargetsResult, error) {
+	return s.refreshTargets(ctx, taskID, true)
+}
+
+// refreshTargets 按任务保存的选择规则重新确定目标
+// 为新匹配的数据库创建执行明细，移除已不在目标范围内的执行明细；force 为 false 时仅处理动态选择模式的任务。
+func (s *QueryTaskRunService) refreshTargets(ctx context.Context, taskID uint, force bool) (*model.RefreshTargetsResult, error) {
+	var task model.QueryTask
+	if err := s.db.First(&task, taskID).Error; err != nil {
+		return nil, err
+	}
+	if !force && !task.TargetRule.Dynamic() {
+		return nil, nil
+	}
+	if task.TargetRule.Mode == "" {
+		return nil, ErrTargetRuleMissing
+	}
+
+	rule := task.TargetRule
+	creator := NewQueryTaskCreatorService(s.db)
+	if err := creator.authorizeProbe(ctx, rule); err != nil {
+		return nil, err
+	}
+	targetDBs, err := creator.determineTargetDatabases(rule, rule.SelectedDBs, rule.InstanceIDs)
+	if err != nil {
+		return nil, err
+	}
+	var statements []string
+	if err := s.db.Model(&model.QueryTaskSQL{}).Where("task_id = ?", taskID).Pluck("sql_content", &statements).Error; err != nil {
+		return nil, err
+	}
+	if err := requireAccess(ctx, s.db, taskAction(statements), targetDBs.InstanceIDs()); err != nil {
+		return nil, err
+	}
+
+	result := &model.RefreshTargetsResult{Total: len(targetDBs)}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var sqls []model.QueryTaskSQL
+		if err := tx.Where("task_id = ?", taskID).Find(&sqls).Error; err != nil {
+			return err
+		}
+		var executions []model.QueryTaskExecution
+		if err := tx.Where("task_id = ?", taskID).Find(&executions).Error; err != nil {
+			return err
+		}
+
+		targetSet := make(map[string]bool, len(targetDBs))
+		for _, db := range targetDBs {
+			targetSet[targetKey(db.InstanceID, db.DatabaseName)] = true
+		}
+
+		// 删除已不在目标范围内的执行明细，并记录每条 SQL 已有的目标
+		existing := make(map[uint]map[string]bool)
+		previous := make(map[string]bool)
+		var staleIDs []uint
+		for _, e := range executions {
+			key := targetKey(e.InstanceID, e.DatabaseName)
+			if !previous[key] {
+				previous[key] = true
+				if !targetSet[key] {
+					result.Retired++
+				}
+			}
+			if !targetSet[key] {
+				staleIDs = append(staleIDs, e.ID)
+				continue
+			}
+			if existing[e.SQLID] == nil {
+				existing[e.SQLID] = make(map[string]bool)
+			}
+			existing[e.SQLID][key] = true
+		}
+		if len(staleIDs) > 0 {
+			if err := tx.Unscoped().Where("id IN ?", staleIDs).Delete(&model.QueryTaskExecution{}).Error; err != nil {
+				return fmt.Errorf("删除执行明细失败: %v", err)
+			}
+		}
+
+		for _, db := range targetDBs {
+			if !previous[targetKey(db.InstanceID, db.DatabaseName)] {
+				result.Added++
+			}
+		}
+
+		// 为新匹配的数据库创建执行明细
+		var added []model.QueryTaskExecution
+		for _, sql := range sqls {
+			for _, db := range targetDBs {
+				if existing[sql.ID][targetKey(db.InstanceID, db.DatabaseName)] {
+					continue
+				}
+				added = append(added, model.QueryTaskExecution{
+					TaskID:       taskID,
+					SQLID:        sql.ID,
+					InstanceID:   db.InstanceID,
+					DatabaseName: db.DatabaseName,
+					Status:       0, // 待执行
+				})
+			}
+		}
+		if len(added) > 0 {
+			if err := tx.Create(&added).Error; err != nil {
+				return fmt.Errorf("创建执行明细失败: %v", err)
+			}
+		}
+
+		databasesJSON, err := targetDBs.Value()
+		if err != nil {
+			return fmt.Errorf("序列化数据库列表失败: %v", err)
+		}
+		if err := tx.Model(&model.QueryTask{}).Where("id = ?", taskID).Updates(map[string]interface{}{
+			"databases": string(databasesJSON.([]byte)),
+			"total_dbs": len(targetDBs),
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.QueryTaskSQL{}).Where("task_id = ?", taskID).Update("total_dbs", len(targetDBs)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// targetKey 目标数据库的唯一标识
+func targetKey(instanceID uint, databaseName string) string {
+	return fmt.Sprintf("%d|%s", instanceID, databaseName)
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/database"
+
+	"gorm.io/gorm"
+)
+
+// lagCheckInterval 复制延迟检查间隔，同一实例在间隔内复用上次结果
+const lagCheckInterval = 2 * time.Second
+
+// replicaLag 实例级复制延迟闸门，在所有运行中的任务之间共享
+var replicaLag = newReplicaLagGate()
+
+// replicaLagGate 复制延迟闸门
+// 写入语句下发前检查主库对应从库的复制延迟，超过阈值时暂停下发，延迟恢复后继续。
+type replicaLagGate struct {
+	mu     sync.Mutex
+	states map[uint]*lagState
+}
+
+// lagState 单个实例的延迟检查状态
+type lagState struct {
+	mu        sync.Mutex
+	checkedAt time.Time
+	lagSec    float64
+	err       error
+	reason    string
+}
+
+func newReplicaLagGate() *replicaLagGate {
+	return &replicaLagGate{states: make(map[uint]*lagState)}
+}
+
+// Wait 等待实例的复制延迟回落到阈值以内，返回本次暂停的时长及最近一次暂停原因
+// 延迟无法获取（检查失败或从库未返回延迟）时视为复制异常并暂停，
+// 实例开启 LagCheckFailOpen 时改为记录日志后放行。
+func (g *replicaLagGate) Wait(ctx context.Context, inst *model.Instance) (time.Duration, string, error) {
+	if !inst.LagCheckEnabled() {
+		return 0, "", nil
+	}
+
+	state := g.state(inst.ID)
+	threshold := float64(inst.MaxReplicaLagSec)
+	var paused time.Duration
+	var lastReason string
+	for {
+		lag, err := state.current(ctx, inst)
+		reason := ""
+		switch {
+		case err != nil && inst.LagCheckFailOpen:
+		case err != nil:
+			reason = fmt.Sprintf("无法获取复制延迟: %v", err)
+		case lag > threshold:
+			reason = fmt.Sprintf("复制延迟 %.0f 秒超过 %d 秒", lag, inst.MaxReplicaLagSec)
+		}
+		state.setReason(inst, reason)
+		if reason == "" {
+			return paused, lastReason, nil
+		}
+		lastReason = reason
+
+		start := time.Now()
+		timer := time.NewTimer(lagCheckInterval)
+		select {
+		case <-timer.C:
+			paused += time.Since(start)
+		case <-ctx.Done():
+			timer.Stop()
+			paused += time.Since(start)
+			return paused, lastReason, ctx.Err()
+		}
+	}
+}
+
+func (g *replicaLagGate) state(instanceID uint) *lagState {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	st, ok := g.states[instanceID]
+	if !ok {
+		st = &lagState{}
+		g.states[instanceID] = st
+	}
+	return st
+}
+
+// current 获取实例当前的最大复制延迟（秒），检查间隔内复用缓存结果
+// 检查失败的结果同样在间隔内复用，避免频繁重试
+func (st *lagState) current(ctx context.Context, inst *model.Instance) (float64, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if !st.checkedAt.IsZero() && time.Since(st.checkedAt) < lagCheckInterval {
+		return st.lagSec, st.err
+	}
+	lag, err := measureReplicaLag(ctx, inst)
+	if err != nil {
+		log.Printf("WARN: failed to check replica lag for instance %s (ID: %d): %v", inst.Name, inst.ID, err)
+	}
+	st.lagSec, st.err = lag, err
+	st.checkedAt = time.Now()
+	return lag, err
+}
+
+// setReason 记录暂停原因变化，仅在切换时输出日志，空原因表示放行
+func (st *lagState) setReason(inst *model.Instance, reason string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.reason == reason {
+		return
+	}
+	st.reason = reason
+	if reason != "" {
+		log.Printf("INFO: pausing writes on instance %s (ID: %d): %s", inst.Name, inst.ID, reason)
+	} else {
+		log.Printf("INFO: resuming writes on instance %s (ID: %d)", inst.Name, inst.ID)
+	}
+}
+
+// measureReplicaLag 获取实例所有从库及自定义查询中的最大延迟
+func measureReplicaLag(ctx context.Context, inst *model.Instance) (float64, error) {
+	maxConn := NewConfigService().GetIntConfig("max_conn", model.DefaultConfigValues.MaxConn)
+	var maxLag float64
+
+	if inst.LagCheckQuery != "" {
+		lag, ok, err := queryCustomLag(ctx, inst, maxConn)
+		if err != nil {
+			return 0, fmt.Errorf("执行延迟查询失败: %w", err)
+		}
+		if !ok {
+			return 0, fmt.Errorf("延迟查询返回 NULL")
+		}
+		if lag > maxLag {
+			maxLag = lag
+		}
+	}
+
+	if len(inst.ReplicaIDs) > 0 {
+		var replicas []model.Instance
+		if err := database.GetDB().Where("id IN ?", []uint(inst.ReplicaIDs)).Find(&replicas).Error; err != nil {
+			return 0, err
+		}
+		for i := range replicas {
+			lag, ok, err := queryReplicaStatusLag(ctx, &replicas[i], maxConn)
+			if err != nil {
+				return 0, fmt.Errorf("获取从库 [%s] 复制状态失败: %w", replicas[i].Name, err)
+			}
+			if !ok {
+				// 延迟为 NULL 通常表示复制线程未运行，此时无法保证从库跟上主库
+				return 0, fmt.Errorf("从库 [%s] 未返回复制延迟，复制可能已停止", replicas[i].Name)
+			}
+			if lag > maxLag {
+				maxLag = lag
+			}
+		}
+	}
+
+	return maxLag, nil
+}
+
+// queryCustomLag 在实例上执行自定义延迟查询，取第一行第一列作为延迟秒数
+// 查询由用户填写且在主库上反复执行，放在总是回滚的只读事务中，避免误写入。
+func queryCustomLag(ctx context.Context, inst *model.Instance, maxConn int) (float64, bool, error) {
+	pool, err := database.AcquirePool(inst, inst.EffectiveMaxConnections(maxConn))
+	if err != nil {
+		return 0, false, err
+	}
+	defer pool.Release()
+
+	var lag sql.NullFloat64
+	err = pool.WithReadOnlyTransaction(ctx, "", func(tx *gorm.DB) error {
+		return tx.Raw(inst.LagCheckQuery).Row().Scan(&lag)
+	})
+	if err != nil {
+		return 0, false, err
+	}
+	return lag.Float64, lag.Valid, nil
+}
+
+// queryReplicaStatusLag 由引擎驱动获取从库复制延迟
+// MySQL 使用 SHOW REPLICA STATUS，多源复制时取所有通道中的最大值；PostgreSQL 根据备库回放进度计算。
+func queryReplicaStatusLag(ctx context.Context, replica *model.Instance, maxConn int) (float64, bool, error) {
+	pool, err := database.AcquirePool(replica, replica.EffectiveMaxConnections(maxConn))
+	if err != nil {
+		return 0, false, err
+	}
+	defer pool.Release()
+
+	return pool.Driver().ReplicaLag(ctx, pool.SQLDB())
+}
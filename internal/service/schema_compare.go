@@ -0,0 +1,361 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/authctx"
+	"my-bulker/internal/pkg/database"
+	"my-bulker/internal/pkg/rbac"
+	"my-bulker/internal/pkg/schemadiff"
+
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrSchemaCompareRunning 检查任务正在运行
+	ErrSchemaCompareRunning = errors.New("检查任务正在运行")
+	// ErrReferenceNotFound 参照库不在最近一次同步结果中
+	ErrReferenceNotFound = errors.New("参照库不存在，请先同步实例")
+	// ErrNoCompareTargets 比较范围内除参照库外没有其他库
+	ErrNoCompareTargets = errors.New("比较范围内没有参照库以外的数据库")
+)
+
+// SchemaCompareService 库结构一致性检查服务
+type SchemaCompareService struct {
+	db      *gorm.DB
+	creator *QueryTaskCreatorService
+}
+
+// NewSchemaCompareService 创建库结构一致性检查服务
+func NewSchemaCompareService(db *gorm.DB) *SchemaCompareService {
+	return &SchemaCompareService{db: db, creator: NewQueryTaskCreatorService(db)}
+}
+
+// Create 创建检查任务并立即在后台运行，需要参照库和比较范围内实例的运行权限
+func (s *SchemaCompareService) Create(ctx context.Context, req *model.CreateSchemaCompareRequest) (*model.SchemaCompareJob, error) {
+	job := &model.SchemaCompareJob{
+		Name:                strings.TrimSpace(req.Name),
+		ReferenceInstanceID: req.ReferenceInstanceID,
+		ReferenceDatabase:   req.ReferenceDatabase,
+		TargetRule:          targetRuleOf(&req.TargetSelection),
+		GenerateDDL:         req.GenerateDDL,
+		Status:              1,
+		CreatedBy:           authctx.UserID(ctx),
+		CreatedByName:       authctx.Username(ctx),
+	}
+	targets, err := s.resolveTargets(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	job.StartedAt = &now
+	job.Total = len(targets)
+	if err := s.db.Create(job).Error; err != nil {
+		return nil, err
+	}
+	go s.run(*job, targets)
+	return job, nil
+}
+
+// Run 重新解析比较范围并在后台再次运行检查任务
+func (s *SchemaCompareService) Run(ctx context.Context, id uint) (*model.SchemaCompareJob, error) {
+	job, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status == 1 {
+		return nil, ErrSchemaCompareRunning
+	}
+	targets, err := s.resolveTargets(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	// 以状态作为条件更新，避免并发的两次运行
+	result := s.db.Model(&model.SchemaCompareJob{}).Where("id = ? AND status <> ?", id, 1).Updates(map[string]interface{}{
+		"status": 1, "total": len(targets), "consistent": 0, "deviated": 0, "failed": 0,
+		"error": "", "started_at": now, "finished_at": nil,
+	})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrSchemaCompareRunning
+	}
+	if err := s.db.First(job, id).Error; err != nil {
+		return nil, err
+	}
+	go s.run(*job, targets)
+	return job, nil
+}
+
+// resolveTargets 检查权限并解析比较范围，结果中不含参照库
+func (s *SchemaCompareService) resolveTargets(ctx context.Context, job *model.SchemaCompareJob) (model.TaskDatabases, error) {
+	if err := requireAccess(ctx, s.db, rbac.ActionRunRead, []uint{job.ReferenceInstanceID}); err != nil {
+		return nil, err
+	}
+	var count int64
+	if err := s.db.Model(&model.Database{}).
+		Where("instance_id = ? AND name = ?", job.ReferenceInstanceID, job.ReferenceDatabase).
+		Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, ErrReferenceNotFound
+	}
+
+	rule := job.TargetRule
+	if err := s.creator.authorizeProbe(ctx, rule); err != nil {
+		return nil, err
+	}
+	resolved, err := s.creator.determineTargetDatabases(rule, rule.SelectedDBs, rule.InstanceIDs)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireAccess(ctx, s.db, rbac.ActionRunRead, resolved.InstanceIDs()); err != nil {
+		return nil, err
+	}
+	targets := make(model.TaskDatabases, 0, len(resolved))
+	for _, t := range resolved {
+		if t.InstanceID != job.ReferenceInstanceID || t.DatabaseName != job.ReferenceDatabase {
+			targets = append(targets, t)
+		}
+	}
+	if len(targets) == 0 {
+		return nil, ErrNoCompareTargets
+	}
+	return targets, nil
+}
+
+// run 获取参照库结构后并发比较各目标库，每个库的结果完成后立即保存
+func (s *SchemaCompareService) run(job model.SchemaCompareJob, targets model.TaskDatabases) {
+	ctx := context.Background()
+	if err := s.db.Where("job_id = ?", job.ID).Delete(&model.SchemaCompareResult{}).Error; err != nil {
+		s.finish(job.ID, nil, err)
+		return
+	}
+	var reference model.Instance
+	if err := s.db.First(&reference, job.ReferenceInstanceID).Error; err != nil {
+		s.finish(job.ID, nil, fmt.Errorf("获取参照库实例失败: %v", err))
+		return
+	}
+	refTables, err := describeDatabase(ctx, &reference, job.ReferenceDatabase)
+	if err != nil {
+		s.finish(job.ID, nil, fmt.Errorf("获取参照库结构失败: %v", err))
+		return
+	}
+	refTables = schemadiff.Normalize(refTables)
+	if err := s.db.Model(&model.SchemaCompareJob{}).Where("id = ?", job.ID).
+		Update("reference_fingerprint", schemadiff.Fingerprint(refTables)).Error; err != nil {
+		s.finish(job.ID, nil, err)
+		return
+	}
+
+	instanceIDs := targets.InstanceIDs()
+	var instances []model.Instance
+	if err := s.db.Where("id IN ?", instanceIDs).Find(&instances).Error; err != nil {
+		s.finish(job.ID, nil, err)
+		return
+	}
+	byID := make(map[uint]*model.Instance, len(instances))
+	for i := range instances {
+		byID[instances[i].ID] = &instances[i]
+	}
+
+	var mu sync.Mutex
+	counts := make(map[int8]int)
+	g := new(errgroup.Group)
+	g.SetLimit(max(NewConfigService().GetIntConfig("concurrency", model.DefaultConfigValues.Concurrency), 1))
+	for _, target := range targets {
+		target := target
+		g.Go(func() error {
+			result := s.compareOne(ctx, &job, byID[target.InstanceID], target, refTables)
+			mu.Lock()
+			counts[result.Status]++
+			mu.Unlock()
+			return s.db.Create(result).Error
+		})
+	}
+	err = g.Wait()
+	s.finish(job.ID, counts, err)
+}
+
+// compareOne 比较单个库与参照库，结果中的变更为使该库与参照库一致所需的变更
+func (s *SchemaCompareService) compareOne(ctx context.Context, job *model.SchemaCompareJob, instance *model.Instance, target model.TaskDatabase, refTables []model.SchemaTable) *model.SchemaCompareResult {
+	result := &model.SchemaCompareResult{
+		JobID:        job.ID,
+		InstanceID:   target.InstanceID,
+		InstanceName: target.InstanceName,
+		DatabaseName: target.DatabaseName,
+	}
+	if instance == nil {
+		result.Status, result.Error = 2, "实例不存在"
+		return result
+	}
+	tables, err := describeDatabase(ctx, instance, target.DatabaseName)
+	if err != nil {
+		result.Status, result.Error = 2, err.Error()
+		return result
+	}
+	result.Fingerprint = schemadiff.Fingerprint(tables)
+	changes := schemadiff.Diff(tables, refTables)
+	result.DiffCount = len(changes)
+	if len(changes) == 0 {
+		return result
+	}
+	result.Status = 1
+	result.Changes, _ = json.Marshal(changes)
+	if job.GenerateDDL {
+		result.DDL = strings.Join(schemadiff.AlterStatements(instance.EngineName(), tables, refTables), "\n")
+	}
+	return result
+}
+
+// finish 记录运行结果，err 不为空时任务标记为失败
+func (s *SchemaCompareService) finish(id uint, counts map[int8]int, err error) {
+	updates := map[string]interface{}{
+		"status":      2,
+		"consistent":  counts[0],
+		"deviated":    counts[1],
+		"failed":      counts[2],
+		"finished_at": time.Now(),
+	}
+	if err != nil {
+		updates["status"] = 3
+		updates["error"] = err.Error()
+	}
+	if err := s.db.Model(&model.SchemaCompareJob{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		log.Printf("ERROR: Failed to update schema compare job %d: %v", id, err)
+	}
+}
+
+// describeDatabase 通过实例连接池获取指定库的结构
+// 与查询任务、元数据同步共享实例并发名额和速率限制，检查任务同时比较大量库时不会压垮实例。
+func describeDatabase(ctx context.Context, instance *model.Instance, dbName string) ([]model.SchemaTable, error) {
+	configSvc := NewConfigService()
+	pool, err := database.AcquirePool(instance, instance.EffectiveMaxConnections(configSvc.GetIntConfig("max_conn", model.DefaultConfigValues.MaxConn)))
+	if err != nil {
+		return nil, fmt.Errorf("连接数据库失败: %v", err)
+	}
+	defer pool.Release()
+
+	instanceMaxConn := configSvc.GetIntConfig("instance_max_conn", model.DefaultConfigValues.InstanceMaxConn)
+	if err := instanceSlots.Acquire(ctx, instance.ID, instance.EffectiveMaxConcurrency(instanceMaxConn)); err != nil {
+		return nil, err
+	}
+	defer instanceSlots.Release(instance.ID)
+	if err := instanceRates.Wait(ctx, instance.ID, instance.RateLimit); err != nil {
+		return nil, err
+	}
+	var tables []model.SchemaTable
+	err = pool.WithDatabase(ctx, dbName, func(tx *gorm.DB) error {
+		tables, err = pool.Driver().DescribeSchema(ctx, tx.Statement.ConnPool, dbName)
+		return err
+	})
+	return tables, err
+}
+
+// Get 获取检查任务，需要参照库实例的查看权限
+func (s *SchemaCompareService) Get(ctx context.Context, id uint) (*model.SchemaCompareJob, error) {
+	var job model.SchemaCompareJob
+	if err := s.db.First(&job, id).Error; err != nil {
+		return nil, err
+	}
+	if err := requireAccess(ctx, s.db, rbac.ActionView, []uint{job.ReferenceInstanceID}); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// List 获取当前用户可查看参照库实例的检查任务
+func (s *SchemaCompareService) List(ctx context.Context, req *model.SchemaCompareListRequest) (*model.SchemaCompareListResponse, error) {
+	query := s.db.Model(&model.SchemaCompareJob{})
+	ids, all, err := accessibleInstanceIDs(ctx, s.db, rbac.ActionView)
+	if err != nil {
+		return nil, err
+	}
+	if !all {
+		query = query.Where("reference_instance_id IN ?", ids)
+	}
+	if name := strings.TrimSpace(req.Name); name != "" {
+		query = query.Where("name LIKE ?", "%"+name+"%")
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+	var jobs []model.SchemaCompareJob
+	if err := query.Order("id DESC").Offset(req.Pagination.GetOffset()).Limit(req.Pagination.GetLimit()).Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	return &model.SchemaCompareListResponse{Total: total, Items: jobs}, nil
+}
+
+// Results 分页获取检查任务的比较结果，只包含当前用户可查看实例中的库
+func (s *SchemaCompareService) Results(ctx context.Context, id uint, req *model.SchemaCompareResultListRequest) (*model.SchemaCompareResultListResponse, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	query := s.db.Model(&model.SchemaCompareResult{}).Where("job_id = ?", id)
+	ids, all, err := accessibleInstanceIDs(ctx, s.db, rbac.ActionView)
+	if err != nil {
+		return nil, err
+	}
+	if !all {
+		query = query.Where("instance_id IN ?", ids)
+	}
+	if req.Status != nil {
+		query = query.Where("status = ?", *req.Status)
+	}
+	if name := strings.TrimSpace(req.DatabaseName); name != "" {
+		query = query.Where("database_name LIKE ?", "%"+name+"%")
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+	var results []model.SchemaCompareResult
+	if err := query.Order("status DESC").Order("diff_count DESC").Order("id ASC").
+		Offset(req.Pagination.GetOffset()).Limit(req.Pagination.GetLimit()).Find(&results).Error; err != nil {
+		return nil, err
+	}
+	return &model.SchemaCompareResultListResponse{Total: total, Items: results}, nil
+}
+
+// Delete 删除检查任务及其结果，需要参照库实例的运行权限
+func (s *SchemaCompareService) Delete(ctx context.Context, id uint) error {
+	var job model.SchemaCompareJob
+	if err := s.db.First(&job, id).Error; err != nil {
+		return err
+	}
+	if err := requireAccess(ctx, s.db, rbac.ActionRunRead, []uint{job.ReferenceInstanceID}); err != nil {
+		return err
+	}
+	if job.Status == 1 {
+		return ErrSchemaCompareRunning
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("job_id = ?", id).Delete(&model.SchemaCompareResult{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.SchemaCompareJob{}, id).Error
+	})
+}
+
+// FailInterruptedSchemaCompares 将服务重启前未完成的检查任务标记为失败
+func FailInterruptedSchemaCompares() error {
+	return database.GetDB().Model(&model.SchemaCompareJob{}).Where("status = ?", 1).Updates(map[string]interface{}{
+		"status":      3,
+		"error":       "服务重启，任务已中断",
+		"finished_at": time.Now(),
+	}).Error
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/rbac"
+	"my-bulker/internal/pkg/schemadiff"
+
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrEngineMismatch 两个库的数据库类型不同，字段类型无法直接对应
+	ErrEngineMismatch = errors.New("源库与目标库的数据库类型不同，无法生成迁移语句")
+	// ErrNothingToMigrate 没有可执行的迁移语句
+	ErrNothingToMigrate = errors.New("没有可执行的迁移语句，目标库已与源库一致或差异需要手动处理")
+)
+
+// SchemaMigrationResponse 迁移语句生成结果
+type SchemaMigrationResponse struct {
+	Dialect           string              `json:"dialect"`            // 语句方言，即目标库的数据库类型
+	SourceFingerprint string              `json:"source_fingerprint"` // 源库结构指纹
+	TargetFingerprint string              `json:"target_fingerprint"` // 目标库结构指纹
+	Changes           []schemadiff.Change `json:"changes"`            // 目标库需要的变更
+	Statements        []string            `json:"statements"`         // 按建表、变更、删表排序的语句，以 -- 开头的为需要手动处理的提示
+	SQL               string              `json:"sql"`                // 全部语句，可直接作为查询任务的 SQL
+}
+
+// SchemaMigrationService 比较两个库的结构并生成迁移语句
+type SchemaMigrationService struct {
+	db      *gorm.DB
+	creator *QueryTaskCreatorService
+}
+
+// NewSchemaMigrationService 创建迁移语句服务
+func NewSchemaMigrationService(db *gorm.DB) *SchemaMigrationService {
+	return &SchemaMigrationService{db: db, creator: NewQueryTaskCreatorService(db)}
+}
+
+// Generate 实时读取两个库的结构，生成使目标库与源库一致的语句，需要两个实例的运行权限
+func (s *SchemaMigrationService) Generate(ctx context.Context, req *model.SchemaMigrationRequest) (*SchemaMigrationResponse, error) {
+	if err := requireAccess(ctx, s.db, rbac.ActionRunRead, []uint{req.SourceInstanceID, req.TargetInstanceID}); err != nil {
+		return nil, err
+	}
+	var source, target model.Instance
+	if err := s.db.First(&source, req.SourceInstanceID).Error; err != nil {
+		return nil, err
+	}
+	if err := s.db.First(&target, req.TargetInstanceID).Error; err != nil {
+		return nil, err
+	}
+	if source.EngineName() != target.EngineName() {
+		return nil, ErrEngineMismatch
+	}
+
+	sourceTables, err := describeDatabase(ctx, &source, req.SourceDatabase)
+	if err != nil {
+		return nil, fmt.Errorf("获取源库结构失败: %w", err)
+	}
+	targetTables, err := describeDatabase(ctx, &target, req.TargetDatabase)
+	if err != nil {
+		return nil, fmt.Errorf("获取目标库结构失败: %w", err)
+	}
+	stmts := schemadiff.MigrationStatements(target.EngineName(), targetTables, sourceTables)
+	return &SchemaMigrationResponse{
+		Dialect:           target.EngineName(),
+		SourceFingerprint: schemadiff.Fingerprint(sourceTables),
+		TargetFingerprint: schemadiff.Fingerprint(targetTables),
+		Changes:           schemadiff.Diff(targetTables, sourceTables),
+		Statements:        stmts,
+		SQL:               strings.Join(stmts, "\n"),
+	}, nil
+}
+
+// CreateTask 生成迁移语句并创建为查询任务
+// 执行范围在创建时解析为具体的库，范围内的实例必须与目标库同为一种数据库类型，库结构必须与目标库一致
+func (s *SchemaMigrationService) CreateTask(ctx context.Context, req *model.CreateSchemaMigrationTaskRequest) (*model.QueryTask, *SchemaMigrationResponse, error) {
+	migration, err := s.Generate(ctx, &req.SchemaMigrationRequest)
+	if err != nil {
+		return nil, nil, err
+	}
+	executable := false
+	for _, stmt := range migration.Statements {
+		if !strings.HasPrefix(stmt, "--") {
+			executable = true
+			break
+		}
+	}
+	if !executable {
+		return nil, migration, ErrNothingToMigrate
+	}
+
+	targets := model.TaskDatabases{{InstanceID: req.TargetInstanceID, DatabaseName: req.TargetDatabase}}
+	if req.DatabaseMode != "" {
+		rule := targetRuleOf(&req.TargetSelection)
+		if err := s.creator.authorizeProbe(ctx, rule); err != nil {
+			return nil, nil, err
+		}
+		if targets, err = s.creator.determineTargetDatabases(rule, req.SelectedDBs, req.InstanceIDs); err != nil {
+			return nil, nil, fmt.Errorf("确定目标数据库失败: %w", err)
+		}
+		if len(targets) == 0 {
+			return nil, nil, fmt.Errorf("%w: 执行范围内没有数据库", ErrInvalidTargetRule)
+		}
+		var instances []model.Instance
+		if err := s.db.Where("id IN ?", targets.InstanceIDs()).Find(&instances).Error; err != nil {
+			return nil, nil, err
+		}
+		for _, instance := range instances {
+			if instance.EngineName() != migration.Dialect {
+				return nil, nil, fmt.Errorf("%w: 实例 %s 的数据库类型与目标库不同", ErrInvalidTargetRule, instance.Name)
+			}
+		}
+		if err := s.checkSameSchema(ctx, req, instances, targets, migration.TargetFingerprint); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	task, err := s.creator.Create(ctx, &model.CreateQueryTaskRequest{
+		TaskName:    req.TaskName,
+		Description: req.Description,
+		TargetSelection: model.TargetSelection{
+			InstanceIDs:  targets.InstanceIDs(),
+			DatabaseMode: model.TargetModeInclude,
+			SelectedDBs:  targets,
+		},
+		SQLContent: migration.SQL,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return task, migration, nil
+}
+
+// checkSameSchema 迁移语句按目标库的结构生成，执行范围内的其他库结构必须与目标库完全一致，否则语句可能失败或产生不同的结果
+// 每个库的结构经 describeDatabase 读取，与检查任务一样占用实例并发名额并遵守速率限制，实例繁忙时按 ctx 等待。
+func (s *SchemaMigrationService) checkSameSchema(ctx context.Context, req *model.CreateSchemaMigrationTaskRequest, instances []model.Instance, targets model.TaskDatabases, fingerprint string) error {
+	byID := make(map[uint]*model.Instance, len(instances))
+	for i := range instances {
+		byID[instances[i].ID] = &instances[i]
+	}
+
+	var mu sync.Mutex
+	var mismatched []string
+	g := new(errgroup.Group)
+	g.SetLimit(max(NewConfigService().GetIntConfig("concurrency", model.DefaultConfigValues.Concurrency), 1))
+	for _, target := range targets {
+		if target.InstanceID == req.TargetInstanceID && target.DatabaseName == req.TargetDatabase {
+			continue
+		}
+		instance := byID[target.InstanceID]
+		if instance == nil {
+			continue
+		}
+		g.Go(func() error {
+			name := instance.Name + "." + target.DatabaseName
+			tables, err := describeDatabase(ctx, instance, target.DatabaseName)
+			if err != nil {
+				name += "（" + err.Error() + "）"
+			} else if schemadiff.Fingerprint(tables) == fingerprint {
+				return nil
+			}
+			mu.Lock()
+			mismatched = append(mismatched, name)
+			mu.Unlock()
+			return nil
+		})
+	}
+	g.Wait()
+	if len(mismatched) == 0 {
+		return nil
+	}
+	sort.Strings(mismatched)
+	if len(mismatched) > 10 {
+		mismatched = append(mismatched[:10], fmt.Sprintf("等 %d 个库", len(mismatched)))
+	}
+	return fmt.Errorf("%w: 以下库的结构与目标库 %s 不同，不能执行按目标库生成的迁移语句: %s",
+		ErrInvalidTargetRule, req.TargetDatabase, strings.Join(mismatched, ", "))
+}
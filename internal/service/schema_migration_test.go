@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/schemadiff"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// createSQLiteFile 在目录下创建 SQLite 文件并执行建表语句
+func createSQLiteFile(t *testing.T, dir, name, ddl string) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(dir, name)), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	if err := db.Exec(ddl).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckSameSchema(t *testing.T) {
+	db := openTestDB(t)
+	dir := t.TempDir()
+	createSQLiteFile(t, dir, "a.db", "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)")
+	createSQLiteFile(t, dir, "b.db", "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)")
+	createSQLiteFile(t, dir, "c.db", "CREATE TABLE users (id INTEGER PRIMARY KEY)")
+
+	instance := model.Instance{Name: "files", Engine: model.EngineSQLite, FileGlob: filepath.Join(dir, "*.db"), MaxConcurrency: 1}
+	if err := db.Create(&instance).Error; err != nil {
+		t.Fatal(err)
+	}
+	tables, err := describeDatabase(context.Background(), &instance, "a.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fingerprint := schemadiff.Fingerprint(tables)
+
+	req := &model.CreateSchemaMigrationTaskRequest{}
+	req.TargetInstanceID = instance.ID
+	req.TargetDatabase = "a.db"
+	svc := &SchemaMigrationService{db: db}
+	instances := []model.Instance{instance}
+	target := func(name string) model.TaskDatabase {
+		return model.TaskDatabase{InstanceID: instance.ID, DatabaseName: name}
+	}
+
+	tests := []struct {
+		name    string
+		targets model.TaskDatabases
+		busy    bool   // 实例并发名额已被占满
+		want    string // 期望错误中出现的库，为空表示检查通过
+	}{
+		{"same schema", model.TaskDatabases{target("a.db"), target("b.db")}, false, ""},
+		{"different schema", model.TaskDatabases{target("a.db"), target("b.db"), target("c.db")}, false, "files.c.db"},
+		{"waits for instance slot", model.TaskDatabases{target("a.db"), target("b.db")}, true, "files.b.db（context deadline exceeded）"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.busy {
+				if err := instanceSlots.Acquire(context.Background(), instance.ID, instance.MaxConcurrency); err != nil {
+					t.Fatal(err)
+				}
+				defer instanceSlots.Release(instance.ID)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			err := svc.checkSameSchema(ctx, req, instances, tt.targets, fingerprint)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("checkSameSchema() error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidTargetRule) || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("checkSameSchema() error = %v, want mention of %q", err, tt.want)
+			}
+			if strings.Contains(err.Error(), "files.b.db") != tt.busy {
+				t.Fatalf("checkSameSchema() error = %v, b.db reported = %v", err, !tt.busy)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/database"
+	"my-bulker/internal/pkg/rbac"
+	"my-bulker/internal/pkg/schemadiff"
+
+	"gorm.io/gorm"
+)
+
+// describeSchemas 采集实例下各库的结构，需在同步事务开始前调用，避免逐库访问实例期间占用应用库的写事务
+// 单个库采集失败时记录日志并跳过，该库本次不记录版本，不影响其他库和本次同步。
+func describeSchemas(ctx context.Context, pool *database.Pool, instanceID uint, databases []model.Database) map[string][]model.SchemaTable {
+	schemas := make(map[string][]model.SchemaTable, len(databases))
+	for _, db := range databases {
+		var tables []model.SchemaTable
+		err := pool.WithDatabase(ctx, db.Name, func(conn *gorm.DB) error {
+			var err error
+			tables, err = pool.Driver().DescribeSchema(ctx, conn.Statement.ConnPool, db.Name)
+			return err
+		})
+		if err != nil {
+			log.Printf("WARN: failed to describe schema of %s on instance %d, skip schema version: %v", db.Name, instanceID, err)
+			continue
+		}
+		schemas[db.Name] = schemadiff.Normalize(tables)
+	}
+	return schemas
+}
+
+// recordSchemaVersions 保存采集到的各库结构，结构指纹变化时生成新版本并记录与上一版本之间的变更
+func recordSchemaVersions(tx *gorm.DB, instanceID uint, schemas map[string][]model.SchemaTable) error {
+	now := time.Now()
+	for dbName, tables := range schemas {
+		if err := saveSchemaVersion(tx, instanceID, dbName, tables, now); err != nil {
+			return fmt.Errorf("%s: %v", dbName, err)
+		}
+	}
+	return nil
+}
+
+// saveSchemaVersion 保存一个库本次采集到的结构，与最新版本相同时只更新最后采集时间
+func saveSchemaVersion(tx *gorm.DB, instanceID uint, dbName string, tables []model.SchemaTable, now time.Time) error {
+	fingerprint := schemadiff.Fingerprint(tables)
+	var latest model.SchemaVersion
+	err := tx.Select("id", "version", "fingerprint").
+		Where("instance_id = ? AND database_name = ?", instanceID, dbName).
+		Order("version DESC").First(&latest).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	found := err == nil
+	if found && latest.Fingerprint == fingerprint {
+		return tx.Model(&model.SchemaVersion{}).Where("id = ?", latest.ID).Update("last_seen_at", now).Error
+	}
+
+	version := model.SchemaVersion{
+		InstanceID:   instanceID,
+		DatabaseName: dbName,
+		Version:      latest.Version + 1,
+		Fingerprint:  fingerprint,
+		TableCount:   len(tables),
+		Tables:       tables,
+		CapturedAt:   now,
+		LastSeenAt:   now,
+	}
+	if err := tx.Create(&version).Error; err != nil {
+		return err
+	}
+	// 第一个版本作为基线，不产生变更记录
+	if !found {
+		return nil
+	}
+	var previous model.SchemaVersion
+	if err := tx.Select("tables").First(&previous, latest.ID).Error; err != nil {
+		return err
+	}
+	diff := schemadiff.Diff(previous.Tables, tables)
+	changes := make([]model.SchemaChange, len(diff))
+	for i, c := range diff {
+		changes[i] = model.SchemaChange{
+			InstanceID:    instanceID,
+			DatabaseName:  dbName,
+			FromVersionID: latest.ID,
+			ToVersionID:   version.ID,
+			Kind:          c.Kind,
+			Table:         c.Table,
+			Object:        c.Object,
+			Before:        c.Before,
+			After:         c.After,
+			DetectedAt:    now,
+		}
+	}
+	if len(changes) > 0 {
+		return tx.CreateInBatches(changes, 500).Error
+	}
+	return nil
+}
+
+// SchemaDiffResponse 两个结构版本的比较结果，版本信息不含结构内容
+type SchemaDiffResponse struct {
+	From    model.SchemaVersion `json:"from"`
+	To      model.SchemaVersion `json:"to"`
+	Changes []schemadiff.Change `json:"changes"`
+}
+
+// SchemaVersionService 库结构版本服务
+type SchemaVersionService struct {
+	db *gorm.DB
+}
+
+// NewSchemaVersionService 创建库结构版本服务
+func NewSchemaVersionService(db *gorm.DB) *SchemaVersionService {
+	return &SchemaVersionService{db: db}
+}
+
+// ListVersions 分页查询结构版本，不含结构内容
+func (s *SchemaVersionService) ListVersions(ctx context.Context, req *model.SchemaVersionListRequest) (*model.SchemaVersionListResponse, error) {
+	query, err := s.visible(ctx, &model.SchemaVersion{})
+	if err != nil {
+		return nil, err
+	}
+	if req.InstanceID > 0 {
+		query = query.Where("instance_id = ?", req.InstanceID)
+	}
+	if req.DatabaseName != "" {
+		query = query.Where("database_name = ?", req.DatabaseName)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+	var versions []model.SchemaVersion
+	if err := query.Omit("tables").Order("captured_at DESC").Order("id DESC").
+		Offset(req.Pagination.GetOffset()).Limit(req.Pagination.GetLimit()).Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return &model.SchemaVersionListResponse{Total: total, Items: versions}, nil
+}
+
+// GetVersion 获取结构版本及其结构内容
+func (s *SchemaVersionService) GetVersion(ctx context.Context, id uint) (*model.SchemaVersion, error) {
+	var version model.SchemaVersion
+	if err := s.db.First(&version, id).Error; err != nil {
+		return nil, err
+	}
+	if err := requireAccess(ctx, s.db, rbac.ActionView, []uint{version.InstanceID}); err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// ListChanges 分页查询结构变更记录
+func (s *SchemaVersionService) ListChanges(ctx context.Context, req *model.SchemaChangeListRequest) (*model.SchemaChangeListResponse, error) {
+	query, err := s.visible(ctx, &model.SchemaChange{})
+	if err != nil {
+		return nil, err
+	}
+	if req.InstanceID > 0 {
+		query = query.Where("instance_id = ?", req.InstanceID)
+	}
+	if req.DatabaseName != "" {
+		query = query.Where("database_name = ?", req.DatabaseName)
+	}
+	if req.Kind != "" {
+		query = query.Where("kind = ?", req.Kind)
+	}
+	if req.TableName != "" {
+		query = query.Where("table_name = ?", req.TableName)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+	var changes []model.SchemaChange
+	if err := query.Order("detected_at DESC").Order("id ASC").
+		Offset(req.Pagination.GetOffset()).Limit(req.Pagination.GetLimit()).Find(&changes).Error; err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(changes))
+	for _, c := range changes {
+		ids = append(ids, c.InstanceID)
+	}
+	names := make(map[uint]string)
+	if len(ids) > 0 {
+		var instances []model.Instance
+		if err := s.db.Select("id", "name").Where("id IN ?", ids).Find(&instances).Error; err != nil {
+			return nil, err
+		}
+		for _, inst := range instances {
+			names[inst.ID] = inst.Name
+		}
+	}
+	items := make([]model.SchemaChangeResponse, len(changes))
+	for i, c := range changes {
+		items[i] = model.SchemaChangeResponse{
+			SchemaChange: c,
+			Instance:     model.InstanceBasicInfo{ID: c.InstanceID, Name: names[c.InstanceID]},
+		}
+	}
+	return &model.SchemaChangeListResponse{Total: total, Items: items}, nil
+}
+
+// Diff 比较任意两个结构版本，两个版本可以属于不同的库
+func (s *SchemaVersionService) Diff(ctx context.Context, fromID, toID uint) (*SchemaDiffResponse, error) {
+	from, err := s.GetVersion(ctx, fromID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.GetVersion(ctx, toID)
+	if err != nil {
+		return nil, err
+	}
+	changes := schemadiff.Diff(from.Tables, to.Tables)
+	if changes == nil {
+		changes = []schemadiff.Change{}
+	}
+	from.Tables, to.Tables = nil, nil
+	return &SchemaDiffResponse{From: *from, To: *to, Changes: changes}, nil
+}
+
+// visible 限定为当前用户可查看的实例
+func (s *SchemaVersionService) visible(ctx context.Context, table interface{}) (*gorm.DB, error) {
+	query := s.db.Model(table)
+	ids, all, err := accessibleInstanceIDs(ctx, s.db, rbac.ActionView)
+	if err != nil {
+		return nil, err
+	}
+	if !all {
+		query = query.Where("instance_id IN ?", ids)
+	}
+	return query, nil
+}
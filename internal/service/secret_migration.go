@@ -0,0 +1,53 @@
+package service
+
+import (
+	"fmt"
+	"log"
+
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/database"
+	"my-bulker/internal/pkg/secret"
+)
+
+// MigrateInstanceSecrets 加密历史明文密码，并将旧主密钥加密的密码改用当前主密钥加密
+// 服务启动时执行，已是当前主密钥密文的记录不会被改动，可重复执行。
+func MigrateInstanceSecrets() error {
+	type row struct {
+		ID    uint
+		Name  string
+		Value string
+	}
+
+	migrated := 0
+	for _, column := range model.InstanceSecretColumns {
+		var rows []row
+		// 直接读取原始列，绕过模型钩子中的自动解密
+		if err := database.GetDB().Model(&model.Instance{}).Unscoped().
+			Select("id", "name", column+" AS value").Scan(&rows).Error; err != nil {
+			return err
+		}
+
+		for _, r := range rows {
+			if !secret.NeedsRotation(r.Value) {
+				continue
+			}
+			plaintext, err := secret.Decrypt(r.Value)
+			if err != nil {
+				return fmt.Errorf("解密实例 [%s] 的 %s 失败: %w", r.Name, column, err)
+			}
+			encrypted, err := secret.Encrypt(plaintext)
+			if err != nil {
+				return err
+			}
+			if err := database.GetDB().Model(&model.Instance{}).Unscoped().Where("id = ?", r.ID).
+				UpdateColumn(column, encrypted).Error; err != nil {
+				return err
+			}
+			migrated++
+		}
+	}
+	if migrated > 0 {
+		log.Printf("INFO: encrypted %d instance secret(s) with the current master key", migrated)
+	}
+	return nil
+}
@@ -1,9 +1,12 @@
 package service
 
 import (
+	"context"
 	"log"
 	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/authctx"
 	"my-bulker/internal/pkg/database"
+	"sync/atomic"
 	"time"
 
 	"gorm.io/gorm"
@@ -13,6 +16,9 @@ type SimpleSchedulerService struct {
 	db              *gorm.DB
 	instanceService *InstanceService
 	dbDocService    *DbDocService
+	sizeHistory     *SizeHistoryService
+	lastCompactDay  string      // 上次清理大小快照的日期，每天清理一次
+	syncing         atomic.Bool // 定时同步是否仍在进行，进行中时跳过本轮，避免同一实例被重复同步
 	ticker          *time.Ticker
 	quit            chan struct{}
 }
@@ -23,6 +29,7 @@ func NewSimpleSchedulerService() *SimpleSchedulerService {
 		db:              db,
 		instanceService: NewInstanceService(),
 		dbDocService:    NewDbDocService(db),
+		sizeHistory:     NewSizeHistoryService(db),
 		quit:            make(chan struct{}),
 	}
 }
@@ -49,6 +56,24 @@ func (s *SimpleSchedulerService) Stop() {
 func (s *SimpleSchedulerService) runScheduledSyncs() {
 	s.syncInstances()
 	s.runDbDocTasks()
+	s.compactSizeHistory()
+}
+
+// compactSizeHistory 每天对大小快照降采样并删除过期快照
+func (s *SimpleSchedulerService) compactSizeHistory() {
+	today := time.Now().Format(time.DateOnly)
+	if s.lastCompactDay == today {
+		return
+	}
+	removed, err := s.sizeHistory.Compact(authctx.WithSystem(context.Background()))
+	if err != nil {
+		log.Printf("ERROR: Failed to compact size history: %v", err)
+		return
+	}
+	s.lastCompactDay = today
+	if removed > 0 {
+		log.Printf("Compacted size history, removed %d snapshots", removed)
+	}
 }
 
 // IsScheduled 检查任务是否达到运行时间
@@ -95,21 +120,34 @@ func (s *SimpleSchedulerService) syncInstances() {
 		return
 	}
 
+	var ids []uint
 	for _, instance := range instances {
 		if IsScheduled(instance.SyncInterval, instance.LastSyncAt) {
-			go func(inst model.Instance) {
-				err := s.instanceService.SyncDatabases([]uint{inst.ID})
-				if err != nil {
-					log.Printf("ERROR: Scheduled sync failed for instance %s: %v", inst.Name, err)
-				}
-				now := time.Now()
-				updateData := map[string]interface{}{"last_sync_at": &now}
-				if err := s.db.Model(&model.Instance{}).Where("id = ?", inst.ID).Updates(updateData).Error; err != nil {
-					log.Printf("ERROR: Failed to update last_sync_at for instance '%s' (ID: %d): %v", inst.Name, inst.ID, err)
-				}
-			}(instance)
+			ids = append(ids, instance.ID)
 		}
 	}
+	if len(ids) == 0 {
+		return
+	}
+	// 到期的实例一起交给 SyncDatabases，由其按 sync_concurrency 控制并发；上一轮未结束时跳过，到期的实例下一轮再同步
+	if !s.syncing.CompareAndSwap(false, true) {
+		log.Printf("INFO: Previous scheduled sync is still running, skip %d due instances", len(ids))
+		return
+	}
+	go func() {
+		defer s.syncing.Store(false)
+		// 同步结果和时间由 SyncDatabases 写回实例
+		summary, err := s.instanceService.SyncDatabases(authctx.WithSystem(context.Background()), ids)
+		if err != nil {
+			log.Printf("ERROR: Scheduled sync failed: %v", err)
+			return
+		}
+		for _, r := range summary.Results {
+			if !r.Success {
+				log.Printf("ERROR: Scheduled sync failed for instance %s: %s", r.InstanceName, r.Error)
+			}
+		}
+	}()
 }
 
 func (s *SimpleSchedulerService) runDbDocTasks() {
@@ -122,7 +160,7 @@ func (s *SimpleSchedulerService) runDbDocTasks() {
 	for _, task := range tasks {
 		if IsScheduled(task.SyncInterval, task.LastRunAt) {
 			go func(t model.DbDocTask) {
-				if err := s.dbDocService.RunTask(t.ID); err != nil {
+				if err := s.dbDocService.RunTask(authctx.WithSystem(context.Background()), t.ID); err != nil {
 					log.Printf("ERROR: Failed to run db doc task '%s' (ID: %d): %v", t.TaskName, t.ID, err)
 				}
 				now := time.Now()
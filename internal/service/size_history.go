@@ -0,0 +1,335 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/rbac"
+	"my-bulker/internal/pkg/retention"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultGrowthDays     = 30   // 增长趋势默认统计天数
+	defaultTopGrowingDays = 7    // 增长排行默认统计天数
+	maxGrowthDays         = 3660 // 统计天数上限
+	maxTopGrowing         = 100  // 增长排行最多返回的数量
+	pruneBatchSize        = 500  // 清理快照时每批删除的数量
+)
+
+// ErrInvalidGrowthFilter 增长趋势查询条件不完整
+var ErrInvalidGrowthFilter = fmt.Errorf("按表查询时需要同时指定实例和数据库")
+
+// recordSizeSnapshots 记录本次同步得到的数据库和表大小，同一次同步的快照使用相同的采集时间
+func recordSizeSnapshots(tx *gorm.DB, instanceID uint, databases []model.Database, tables []model.TableInfo) error {
+	now := time.Now()
+	dbSnapshots := make([]model.DatabaseSizeSnapshot, len(databases))
+	for i, db := range databases {
+		dbSnapshots[i] = model.DatabaseSizeSnapshot{
+			InstanceID:   instanceID,
+			DatabaseName: db.Name,
+			Size:         db.Size,
+			TableCount:   db.TableCount,
+			CapturedAt:   now,
+		}
+	}
+	if len(dbSnapshots) > 0 {
+		if err := tx.CreateInBatches(dbSnapshots, 500).Error; err != nil {
+			return err
+		}
+	}
+	tableSnapshots := make([]model.TableSizeSnapshot, len(tables))
+	for i, t := range tables {
+		tableSnapshots[i] = model.TableSizeSnapshot{
+			InstanceID:   instanceID,
+			DatabaseName: t.DatabaseName,
+			Table:        t.Name,
+			Rows:         t.Rows,
+			TotalSize:    t.TotalSize,
+			CapturedAt:   now,
+		}
+	}
+	if len(tableSnapshots) > 0 {
+		return tx.CreateInBatches(tableSnapshots, 500).Error
+	}
+	return nil
+}
+
+// SizeHistoryService 数据库与表大小历史服务
+type SizeHistoryService struct {
+	db *gorm.DB
+}
+
+// NewSizeHistoryService 创建大小历史服务
+func NewSizeHistoryService(db *gorm.DB) *SizeHistoryService {
+	return &SizeHistoryService{db: db}
+}
+
+// Growth 获取指定时间段内的大小变化趋势
+func (s *SizeHistoryService) Growth(ctx context.Context, req *model.SizeGrowthRequest) (*model.SizeGrowthResponse, error) {
+	if req.TableName != "" && (req.InstanceID == 0 || req.DatabaseName == "") {
+		return nil, ErrInvalidGrowthFilter
+	}
+	start := time.Now().AddDate(0, 0, -clampDays(req.Days, defaultGrowthDays))
+	ids, all, err := accessibleInstanceIDs(ctx, s.db, rbac.ActionView)
+	if err != nil {
+		return nil, err
+	}
+	if !all && req.InstanceID > 0 && !containsID(ids, req.InstanceID) {
+		return growthResponse(nil), nil
+	}
+
+	var points []model.SizePoint
+	switch {
+	case req.TableName != "":
+		var snapshots []model.TableSizeSnapshot
+		if err := s.db.Where("instance_id = ? AND database_name = ? AND table_name = ? AND captured_at >= ?",
+			req.InstanceID, req.DatabaseName, req.TableName, start).
+			Order("captured_at ASC").Find(&snapshots).Error; err != nil {
+			return nil, err
+		}
+		for _, snap := range snapshots {
+			points = append(points, model.SizePoint{At: snap.CapturedAt, Size: snap.TotalSize, Rows: snap.Rows})
+		}
+	case req.InstanceID > 0 && req.DatabaseName != "":
+		var snapshots []model.DatabaseSizeSnapshot
+		if err := s.db.Where("instance_id = ? AND database_name = ? AND captured_at >= ?", req.InstanceID, req.DatabaseName, start).
+			Order("captured_at ASC").Find(&snapshots).Error; err != nil {
+			return nil, err
+		}
+		for _, snap := range snapshots {
+			points = append(points, model.SizePoint{At: snap.CapturedAt, Size: snap.Size})
+		}
+	default:
+		query := s.db.Select("instance_id", "database_name", "size", "captured_at").Where("captured_at >= ?", start)
+		if req.InstanceID > 0 {
+			query = query.Where("instance_id = ?", req.InstanceID)
+		} else if !all {
+			query = query.Where("instance_id IN ?", ids)
+		}
+		var snapshots []model.DatabaseSizeSnapshot
+		if err := query.Order("captured_at ASC").Order("id ASC").Find(&snapshots).Error; err != nil {
+			return nil, err
+		}
+		points = dailyTotals(snapshots)
+	}
+	return growthResponse(points), nil
+}
+
+// dailyTotals 按天汇总多个数据库的大小，snapshots 需按采集时间升序
+// 每天取各库截至当天的最新大小求和；实例某次同步中不再出现的库视为已删除，不再计入。
+func dailyTotals(snapshots []model.DatabaseSizeSnapshot) []model.SizePoint {
+	type series struct {
+		instanceID uint
+		name       string
+	}
+	type latest struct {
+		size int64
+		at   time.Time
+	}
+	lastSync := make(map[uint]time.Time)
+	values := make(map[series]latest)
+	var points []model.SizePoint
+	flush := func(day time.Time) {
+		var total int64
+		for key, v := range values {
+			if v.at.Equal(lastSync[key.instanceID]) {
+				total += v.size
+			}
+		}
+		points = append(points, model.SizePoint{At: day, Size: total})
+	}
+
+	var day time.Time
+	for i, snap := range snapshots {
+		y, m, d := snap.CapturedAt.Local().Date()
+		snapDay := time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+		if i > 0 && !snapDay.Equal(day) {
+			flush(day)
+		}
+		day = snapDay
+		if snap.CapturedAt.After(lastSync[snap.InstanceID]) {
+			lastSync[snap.InstanceID] = snap.CapturedAt
+		}
+		values[series{snap.InstanceID, snap.DatabaseName}] = latest{size: snap.Size, at: snap.CapturedAt}
+	}
+	if len(snapshots) > 0 {
+		flush(day)
+	}
+	return points
+}
+
+// growthResponse 根据采样点计算区间首尾大小与增长量
+func growthResponse(points []model.SizePoint) *model.SizeGrowthResponse {
+	resp := &model.SizeGrowthResponse{Points: points}
+	if resp.Points == nil {
+		resp.Points = []model.SizePoint{}
+	}
+	if n := len(points); n > 0 {
+		resp.StartSize = points[0].Size
+		resp.EndSize = points[n-1].Size
+		resp.Growth = resp.EndSize - resp.StartSize
+	}
+	return resp
+}
+
+// TopGrowing 获取指定时间段内增长最多的数据库或表，按区间内首个与最后一个快照的差值倒序
+func (s *SizeHistoryService) TopGrowing(ctx context.Context, req *model.TopGrowingRequest) ([]model.SizeGrowthItem, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > maxTopGrowing {
+		limit = maxTopGrowing
+	}
+	start := time.Now().AddDate(0, 0, -clampDays(req.Days, defaultTopGrowingDays))
+
+	// 表名与字段名均为常量，不来自请求参数
+	table, keys, size := "database_size_snapshots", []string{"instance_id", "database_name"}, "size"
+	tableName := "'' AS table_name"
+	if req.Level == "table" {
+		table, keys, size = "table_size_snapshots", []string{"instance_id", "database_name", "table_name"}, "total_size"
+		tableName = "f.table_name"
+	}
+	scope, args := "", []interface{}{start}
+	ids, all, err := accessibleInstanceIDs(ctx, s.db, rbac.ActionView)
+	if err != nil {
+		return nil, err
+	}
+	if !all {
+		if len(ids) == 0 {
+			return []model.SizeGrowthItem{}, nil
+		}
+		scope = " AND instance_id IN ?"
+		args = append(args, ids)
+	}
+	joinOn := func(alias, at string) string {
+		conds := make([]string, 0, len(keys)+1)
+		for _, k := range keys {
+			conds = append(conds, fmt.Sprintf("%s.%s = f.%s", alias, k, k))
+		}
+		return strings.Join(append(conds, fmt.Sprintf("%s.captured_at = f.%s", alias, at)), " AND ")
+	}
+	group := strings.Join(keys, ", ")
+	sql := fmt.Sprintf(`SELECT f.instance_id, f.database_name, %[1]s,
+			a.%[2]s AS start_size, b.%[2]s AS end_size, b.%[2]s - a.%[2]s AS growth,
+			a.captured_at AS start_at, b.captured_at AS end_at
+		FROM (SELECT %[3]s, MIN(captured_at) AS first_at, MAX(captured_at) AS last_at
+			FROM %[4]s WHERE captured_at >= ?%[5]s GROUP BY %[3]s) f
+		JOIN %[4]s a ON %[6]s
+		JOIN %[4]s b ON %[7]s
+		WHERE b.%[2]s - a.%[2]s > 0
+		ORDER BY growth DESC
+		LIMIT %[8]d`,
+		tableName, size, group, table, scope, joinOn("a", "first_at"), joinOn("b", "last_at"), limit)
+
+	var items []model.SizeGrowthItem
+	if err := s.db.WithContext(ctx).Raw(sql, args...).Scan(&items).Error; err != nil {
+		return nil, err
+	}
+	if items == nil {
+		return []model.SizeGrowthItem{}, nil
+	}
+	instanceIDs := make([]uint, 0, len(items))
+	for _, item := range items {
+		instanceIDs = append(instanceIDs, item.InstanceID)
+	}
+	var instances []model.Instance
+	if err := s.db.Select("id", "name").Where("id IN ?", instanceIDs).Find(&instances).Error; err != nil {
+		return nil, err
+	}
+	names := make(map[uint]string, len(instances))
+	for _, inst := range instances {
+		names[inst.ID] = inst.Name
+	}
+	for i := range items {
+		items[i].InstanceName = names[items[i].InstanceID]
+	}
+	return items, nil
+}
+
+// Compact 按配置的保留期对大小快照降采样并删除过期快照，返回删除的快照数
+func (s *SizeHistoryService) Compact(ctx context.Context) (int, error) {
+	config := NewConfigService()
+	rawDays := config.GetIntConfig("size_history_raw_days", model.DefaultConfigValues.SizeHistoryRawDays)
+	dbDays := config.GetIntConfig("size_history_days", model.DefaultConfigValues.SizeHistoryDays)
+	tableDays := config.GetIntConfig("table_size_history_days", model.DefaultConfigValues.TableSizeHistoryDays)
+
+	var instanceIDs []uint
+	if err := s.db.WithContext(ctx).Model(&model.Instance{}).Pluck("id", &instanceIDs).Error; err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	removed := 0
+	for _, id := range instanceIDs {
+		n, err := s.compact(ctx, &model.DatabaseSizeSnapshot{}, []string{"id", "database_name", "captured_at"}, id, now, policyDays(rawDays, dbDays))
+		removed += n
+		if err != nil {
+			return removed, err
+		}
+		n, err = s.compact(ctx, &model.TableSizeSnapshot{}, []string{"id", "database_name", "table_name", "captured_at"}, id, now, policyDays(rawDays, tableDays))
+		removed += n
+		if err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+// compact 清理单个实例在一张快照表中的快照，只需读取早于原始保留期的快照
+func (s *SizeHistoryService) compact(ctx context.Context, table interface{}, columns []string, instanceID uint, now time.Time, policy retention.Policy) (int, error) {
+	var rows []struct {
+		ID           uint
+		DatabaseName string
+		TableName    string
+		CapturedAt   time.Time
+	}
+	if err := s.db.WithContext(ctx).Model(table).Select(columns).
+		Where("instance_id = ? AND captured_at < ?", instanceID, now.Add(-policy.Raw)).
+		Find(&rows).Error; err != nil {
+		return 0, err
+	}
+	points := make([]retention.Point, len(rows))
+	for i, r := range rows {
+		points[i] = retention.Point{ID: r.ID, Series: r.DatabaseName + "\x00" + r.TableName, At: r.CapturedAt}
+	}
+	drop := retention.Prune(points, now, policy, time.Local)
+	for i := 0; i < len(drop); i += pruneBatchSize {
+		end := min(i+pruneBatchSize, len(drop))
+		if err := s.db.WithContext(ctx).Where("id IN ?", drop[i:end]).Delete(table).Error; err != nil {
+			return i, err
+		}
+	}
+	return len(drop), nil
+}
+
+// policyDays 将按天配置的保留期转换为保留策略，保留天数小于等于 0 表示不限
+func policyDays(rawDays, keepDays int) retention.Policy {
+	policy := retention.Policy{Raw: time.Duration(max(rawDays, 0)) * 24 * time.Hour}
+	if keepDays > 0 {
+		policy.Keep = time.Duration(keepDays) * 24 * time.Hour
+	}
+	return policy
+}
+
+// clampDays 统计天数未指定时使用默认值，并限制在上限以内
+func clampDays(days, defaultDays int) int {
+	if days <= 0 {
+		return defaultDays
+	}
+	return min(days, maxGrowthDays)
+}
+
+// containsID 判断 ids 中是否包含 id
+func containsID(ids []uint, id uint) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
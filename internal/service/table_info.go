@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/rbac"
+
+	"gorm.io/gorm"
+)
+
+// tableInfoSortFields 表元数据允许的排序字段
+var tableInfoSortFields = map[string]bool{
+	"total_size":    true,
+	"data_size":     true,
+	"index_size":    true,
+	"rows":          true,
+	"name":          true,
+	"database_name": true,
+	"update_time":   true,
+}
+
+// maxLargestTables 最大表排行最多返回的数量
+const maxLargestTables = 500
+
+// TableInfoService 表元数据服务
+type TableInfoService struct {
+	db *gorm.DB
+}
+
+// NewTableInfoService 创建表元数据服务
+func NewTableInfoService(db *gorm.DB) *TableInfoService {
+	return &TableInfoService{db: db}
+}
+
+// List 按条件分页查询当前用户可查看实例下的表元数据，默认按总大小倒序
+func (s *TableInfoService) List(ctx context.Context, req *model.TableInfoListRequest) (*model.TableInfoListResponse, error) {
+	query, err := s.visible(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.InstanceID > 0 {
+		query = query.Where("instance_id = ?", req.InstanceID)
+	}
+	if req.DatabaseName != "" {
+		query = query.Where("database_name = ?", req.DatabaseName)
+	}
+	if name := strings.TrimSpace(req.Name); name != "" {
+		query = query.Where("name LIKE ?", "%"+name+"%")
+	}
+	if req.Engine != "" {
+		query = query.Where("LOWER(engine) = LOWER(?)", req.Engine)
+	}
+	if req.MinSizeMB > 0 {
+		query = query.Where("total_size >= ?", req.MinSizeMB*1024*1024)
+	}
+	if req.MinRows > 0 {
+		query = query.Where("rows >= ?", req.MinRows)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	// 排序字段来自请求参数，只接受白名单内的字段
+	sortField, sortOrder := "total_size", "desc"
+	if tableInfoSortFields[req.SortField] {
+		sortField = req.SortField
+	}
+	if req.SortOrder == "asc" {
+		sortOrder = "asc"
+	}
+	var tables []model.TableInfo
+	if err := query.Order(sortField + " " + sortOrder).Order("id ASC").
+		Offset(req.Pagination.GetOffset()).Limit(req.Pagination.GetLimit()).Find(&tables).Error; err != nil {
+		return nil, err
+	}
+	items, err := s.withInstances(tables)
+	if err != nil {
+		return nil, err
+	}
+	return &model.TableInfoListResponse{Total: total, Items: items}, nil
+}
+
+// Largest 获取当前用户可查看实例中最大的表，by 为 rows 时按行数排序，否则按总大小排序
+func (s *TableInfoService) Largest(ctx context.Context, limit int, by string) ([]model.TableInfoResponse, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > maxLargestTables {
+		limit = maxLargestTables
+	}
+	field := "total_size"
+	if by == "rows" {
+		field = "rows"
+	}
+	query, err := s.visible(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var tables []model.TableInfo
+	if err := query.Order(field + " DESC").Order("id ASC").Limit(limit).Find(&tables).Error; err != nil {
+		return nil, err
+	}
+	return s.withInstances(tables)
+}
+
+// visible 限定为当前用户可查看的实例
+func (s *TableInfoService) visible(ctx context.Context) (*gorm.DB, error) {
+	query := s.db.Model(&model.TableInfo{})
+	ids, all, err := accessibleInstanceIDs(ctx, s.db, rbac.ActionView)
+	if err != nil {
+		return nil, err
+	}
+	if !all {
+		query = query.Where("instance_id IN ?", ids)
+	}
+	return query, nil
+}
+
+// withInstances 为表元数据补充实例名称
+func (s *TableInfoService) withInstances(tables []model.TableInfo) ([]model.TableInfoResponse, error) {
+	ids := make([]uint, 0, len(tables))
+	seen := make(map[uint]bool)
+	for _, t := range tables {
+		if !seen[t.InstanceID] {
+			seen[t.InstanceID] = true
+			ids = append(ids, t.InstanceID)
+		}
+	}
+	names := make(map[uint]string, len(ids))
+	if len(ids) > 0 {
+		var instances []model.Instance
+		if err := s.db.Select("id", "name").Where("id IN ?", ids).Find(&instances).Error; err != nil {
+			return nil, err
+		}
+		for _, inst := range instances {
+			names[inst.ID] = inst.Name
+		}
+	}
+	items := make([]model.TableInfoResponse, len(tables))
+	for i, t := range tables {
+		items[i] = model.TableInfoResponse{
+			TableInfo: t,
+			Instance:  model.InstanceBasicInfo{ID: t.InstanceID, Name: names[t.InstanceID]},
+		}
+	}
+	return items, nil
+}
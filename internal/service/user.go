@@ -0,0 +1,191 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"log"
+	"os"
+	"strings"
+
+	"my-bulker/internal/model"
+	"my-bulker/internal/pkg/credential"
+	"my-bulker/internal/pkg/database"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// EnvAdminUsername 首次启动时创建的管理员用户名，默认 admin
+	EnvAdminUsername = "MY_BULKER_ADMIN_USERNAME"
+	// EnvAdminPassword 首次启动时创建的管理员密码，未设置时随机生成并打印到日志
+	EnvAdminPassword = "MY_BULKER_ADMIN_PASSWORD"
+
+	defaultAdminUsername = "admin"
+)
+
+var (
+	ErrUsernameTaken = errors.New("用户名已存在")
+	ErrLastAdmin     = errors.New("至少需要保留一个启用的管理员")
+)
+
+// UserService 用户管理服务
+type UserService struct {
+	db *gorm.DB
+}
+
+// NewUserService 创建用户管理服务
+func NewUserService() *UserService {
+	return &UserService{db: database.GetDB()}
+}
+
+// List 获取全部用户
+func (s *UserService) List() ([]model.User, error) {
+	var users []model.User
+	if err := s.db.Order("id ASC").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// Create 创建用户
+func (s *UserService) Create(req *model.CreateUserRequest) (*model.User, error) {
+	username := strings.TrimSpace(req.Username)
+	var count int64
+	if err := s.db.Unscoped().Model(&model.User{}).Where("username = ?", username).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrUsernameTaken
+	}
+	hash, err := credential.HashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+	user := &model.User{
+		Username:     username,
+		PasswordHash: hash,
+		DisplayName:  strings.TrimSpace(req.DisplayName),
+		IsAdmin:      req.IsAdmin,
+	}
+	if err := s.db.Create(user).Error; err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Update 更新用户；禁用用户或重置密码时注销其全部会话
+func (s *UserService) Update(id uint, req *model.UpdateUserRequest) (*model.User, error) {
+	var user model.User
+	if err := s.db.First(&user, id).Error; err != nil {
+		return nil, err
+	}
+	if user.IsAdmin && !user.Disabled && (!req.IsAdmin || req.Disabled) {
+		if err := s.ensureOtherAdmin(user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	updates := map[string]interface{}{
+		"display_name": strings.TrimSpace(req.DisplayName),
+		"is_admin":     req.IsAdmin,
+		"disabled":     req.Disabled,
+	}
+	if req.Password != "" {
+		hash, err := credential.HashPassword(req.Password)
+		if err != nil {
+			return nil, err
+		}
+		updates["password_hash"] = hash
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(updates).Error; err != nil {
+			return err
+		}
+		if req.Disabled || req.Password != "" {
+			return tx.Where("user_id = ?", user.ID).Delete(&model.UserSession{}).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.First(&user, id).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Delete 删除用户及其会话和 API 令牌
+func (s *UserService) Delete(id uint) error {
+	var user model.User
+	if err := s.db.First(&user, id).Error; err != nil {
+		return err
+	}
+	if user.IsAdmin && !user.Disabled {
+		if err := s.ensureOtherAdmin(user.ID); err != nil {
+			return err
+		}
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", id).Delete(&model.UserSession{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&model.APIToken{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&model.RoleBinding{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&user).Error
+	})
+}
+
+// ensureOtherAdmin 确认除指定用户外还有启用的管理员
+func (s *UserService) ensureOtherAdmin(exceptID uint) error {
+	var count int64
+	if err := s.db.Model(&model.User{}).Where("is_admin = ? AND disabled = ? AND id <> ?", true, false, exceptID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+// EnsureAdmin 没有任何用户时创建初始管理员
+// 用户名和密码取自环境变量，未设置密码时随机生成并打印到日志，请在登录后修改。
+func EnsureAdmin() error {
+	db := database.GetDB()
+	var count int64
+	if err := db.Model(&model.User{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	username := strings.TrimSpace(os.Getenv(EnvAdminUsername))
+	if username == "" {
+		username = defaultAdminUsername
+	}
+	password := os.Getenv(EnvAdminPassword)
+	generated := password == ""
+	if generated {
+		buf := make([]byte, 12)
+		if _, err := rand.Read(buf); err != nil {
+			return err
+		}
+		password = base64.RawURLEncoding.EncodeToString(buf)
+	}
+
+	if _, err := NewUserService().Create(&model.CreateUserRequest{Username: username, Password: password, IsAdmin: true}); err != nil {
+		return err
+	}
+	if generated {
+		log.Printf("INFO: created initial admin user %q with password %s, please change it after logging in", username, password)
+	} else {
+		log.Printf("INFO: created initial admin user %q", username)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"my-bulker/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidRoleBinding 角色授权无效
+var ErrInvalidRoleBinding = errors.New("角色授权无效")
+
+// Roles 获取用户的角色授权
+func (s *UserService) Roles(userID uint) ([]model.RoleBinding, error) {
+	if err := s.db.Select("id").First(&model.User{}, userID).Error; err != nil {
+		return nil, err
+	}
+	var bindings []model.RoleBinding
+	if err := s.db.Where("user_id = ?", userID).Order("id ASC").Find(&bindings).Error; err != nil {
+		return nil, err
+	}
+	return bindings, nil
+}
+
+// SaveRoles 整体替换用户的角色授权
+func (s *UserService) SaveRoles(userID uint, req *model.SaveRoleBindingsRequest) ([]model.RoleBinding, error) {
+	if err := s.db.Select("id").First(&model.User{}, userID).Error; err != nil {
+		return nil, err
+	}
+	bindings := make([]model.RoleBinding, 0, len(req.Bindings))
+	for i, item := range req.Bindings {
+		binding, err := toBinding(item.Role, item.InstanceID, item.TagExpr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: 第 %d 条 %v", ErrInvalidRoleBinding, i+1, err)
+		}
+		if item.InstanceID != 0 {
+			var count int64
+			if err := s.db.Model(&model.Instance{}).Where("id = ?", item.InstanceID).Count(&count).Error; err != nil {
+				return nil, err
+			}
+			if count == 0 {
+				return nil, fmt.Errorf("%w: 第 %d 条的实例不存在", ErrInvalidRoleBinding, i+1)
+			}
+		}
+		bindings = append(bindings, model.RoleBinding{
+			UserID:     userID,
+			Role:       string(binding.Role),
+			InstanceID: item.InstanceID,
+			TagExpr:    strings.TrimSpace(item.TagExpr),
+		})
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.RoleBinding{}).Error; err != nil {
+			return err
+		}
+		if len(bindings) == 0 {
+			return nil
+		}
+		return tx.Create(&bindings).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bindings, nil
+}
@@ -13,6 +13,7 @@ export default defineConfig({
         },
     },
     model: {},
+    access: {},
     initialState: {},
     request: {},
     layout: {
@@ -37,6 +38,12 @@ export default defineConfig({
             path: "/",
             redirect: "/home",
         },
+        {
+            name: "登录",
+            path: "/login",
+            component: "./Login",
+            layout: false,
+        },
         {
             name: "主页",
             path: "/home",
@@ -55,6 +62,12 @@ export default defineConfig({
             component: "./Database",
             icon: "DatabaseOutlined",
         },
+        {
+            name: "表",
+            path: "/table",
+            component: "./Table",
+            icon: "TableOutlined",
+        },
         {
             name: "查询",
             path: "/query-task",
@@ -73,6 +86,39 @@ export default defineConfig({
             component: "./DbDoc",
             icon: "FileTextOutlined",
         },
+        {
+            name: "结构一致性",
+            path: "/schema-compare",
+            component: "./SchemaCompare",
+            icon: "DiffOutlined",
+        },
+        {
+            name: "结构迁移",
+            path: "/schema-migration",
+            component: "./SchemaMigration",
+            icon: "SwapOutlined",
+        },
+
+        {
+            name: "用户",
+            path: "/user",
+            component: "./User",
+            icon: "TeamOutlined",
+            access: "isAdmin",
+        },
+        {
+            name: "审计日志",
+            path: "/audit",
+            component: "./Audit",
+            icon: "AuditOutlined",
+            access: "isAdmin",
+        },
+        {
+            name: "个人设置",
+            path: "/account",
+            component: "./Account",
+            hideInMenu: true,
+        },
 
         // 始终保持系统配置在最后
         {
@@ -0,0 +1,8 @@
+import type { InitialState } from './app';
+
+// 权限定义，菜单和路由通过 access 字段引用
+export default function access(initialState: InitialState | undefined) {
+    return {
+        isAdmin: !!initialState?.currentUser?.is_admin,
+    };
+}
@@ -1,15 +1,59 @@
 // 运行时配置
 import { DefaultFooter } from "@ant-design/pro-components";
+import type { RequestConfig } from "@umijs/max";
+import { history } from "@umijs/max";
+import { Dropdown } from "antd";
+import type { ReactNode } from "react";
 import Logo from "./components/Logo";
 import { APP_VERSION } from "./constants";
-import { GithubOutlined } from "@ant-design/icons";
+import { GithubOutlined, LogoutOutlined, UserOutlined } from "@ant-design/icons";
+import { getCurrentUser, logout } from "./services/auth/AuthController";
+import type { CurrentUser } from "./services/auth/typings";
+
+const LOGIN_PATH = "/login";
+
+export interface InitialState {
+    name: string;
+    currentUser?: CurrentUser;
+}
+
+// 跳转到登录页，登录后回到当前页面
+const redirectToLogin = () => {
+    const { pathname, search } = history.location;
+    if (pathname === LOGIN_PATH) return;
+    history.replace(`${LOGIN_PATH}?redirect=${encodeURIComponent(pathname + search)}`);
+};
 
 // 全局初始化数据配置，用于 Layout 用户信息和权限初始化
 // 更多信息见文档：https://umijs.org/docs/api/runtime-config#getinitialstate
-export async function getInitialState(): Promise<{ name: string }> {
+export async function getInitialState(): Promise<InitialState> {
+    if (history.location.pathname === LOGIN_PATH) {
+        return { name: "" };
+    }
+    try {
+        const res = await getCurrentUser();
+        if (res.code === 200 && res.data) {
+            return { name: res.data.display_name || res.data.username, currentUser: res.data };
+        }
+    } catch {
+        // 未登录时跳转到登录页
+    }
+    redirectToLogin();
     return { name: "" };
 }
 
+// 接口返回 401 时跳转到登录页
+export const request: RequestConfig = {
+    responseInterceptors: [
+        (response: any) => {
+            if (response?.data?.code === 401 && !response.config?.skipAuthRedirect) {
+                redirectToLogin();
+            }
+            return response;
+        },
+    ],
+};
+
 // 渲染全局页脚信息
 const renderAppFooter = (collapsed?: boolean, isMobile?: boolean) => {
     const siderOffset = isMobile ? 0 : (collapsed ? 64 : 160) / 2;
@@ -49,9 +93,38 @@ const renderAppFooter = (collapsed?: boolean, isMobile?: boolean) => {
 };
 
 // 配置全局布局
-export const layout = () => {
+export const layout = ({ initialState, setInitialState }: { initialState?: InitialState; setInitialState: (state: InitialState) => void }) => {
+    const handleLogout = async () => {
+        await logout();
+        setInitialState({ name: "" });
+        history.replace(LOGIN_PATH);
+    };
+
     return {
         logo: <Logo />,
+        avatarProps: initialState?.currentUser && {
+            icon: <UserOutlined />,
+            size: "small",
+            title: initialState.name,
+            render: (_: any, dom: ReactNode) => (
+                <Dropdown
+                    menu={{
+                        items: [
+                            { key: "account", icon: <UserOutlined />, label: "个人设置" },
+                            { key: "logout", icon: <LogoutOutlined />, label: "退出登录" },
+                        ],
+                        onClick: ({ key }) => (key === "logout" ? handleLogout() : history.push("/account")),
+                    }}
+                >
+                    {dom}
+                </Dropdown>
+            ),
+        },
+        onPageChange: () => {
+            if (!initialState?.currentUser) {
+                redirectToLogin();
+            }
+        },
         menu: {
             locale: false,
             // loading: true,
@@ -0,0 +1,66 @@
+import React from 'react';
+import { Empty, Tooltip } from 'antd';
+import dayjs from 'dayjs';
+import { SizePoint } from '@/services/dashboard/typings';
+import { formatFileSize } from '@/utils/format';
+
+export interface SizeTrendChartProps {
+    points: SizePoint[];
+    height?: number;
+    dateFormat?: string;
+}
+
+const WIDTH = 600;
+const PADDING = { top: 12, right: 12, bottom: 24, left: 72 };
+
+// 大小趋势折线图，直接以 SVG 绘制，不依赖图表库
+const SizeTrendChart: React.FC<SizeTrendChartProps> = ({ points, height = 220, dateFormat = 'MM-DD' }) => {
+    if (!points || points.length === 0) {
+        return <Empty image={Empty.PRESENTED_IMAGE_SIMPLE} description="暂无历史数据，同步实例后开始记录" />;
+    }
+
+    const sizes = points.map((p) => p.size);
+    let min = Math.min(...sizes);
+    let max = Math.max(...sizes);
+    if (min === max) {
+        min = Math.max(0, min - 1);
+        max = max + 1;
+    }
+    const innerW = WIDTH - PADDING.left - PADDING.right;
+    const innerH = height - PADDING.top - PADDING.bottom;
+    const x = (i: number) => PADDING.left + (points.length === 1 ? innerW / 2 : (innerW * i) / (points.length - 1));
+    const y = (size: number) => PADDING.top + innerH - ((size - min) / (max - min)) * innerH;
+    const path = points.map((p, i) => `${i === 0 ? 'M' : 'L'}${x(i).toFixed(1)},${y(p.size).toFixed(1)}`).join(' ');
+    const area = `${path} L${x(points.length - 1).toFixed(1)},${PADDING.top + innerH} L${x(0).toFixed(1)},${PADDING.top + innerH} Z`;
+    const ticks = [max, (max + min) / 2, min];
+    const labelStep = Math.max(1, Math.ceil(points.length / 6));
+
+    return (
+        <svg viewBox={`0 0 ${WIDTH} ${height}`} style={{ width: '100%', height }} preserveAspectRatio="none">
+            {ticks.map((t) => (
+                <g key={t}>
+                    <line x1={PADDING.left} x2={WIDTH - PADDING.right} y1={y(t)} y2={y(t)} stroke="#f0f0f0" />
+                    <text x={PADDING.left - 6} y={y(t) + 4} textAnchor="end" fontSize={11} fill="#8c8c8c">
+                        {formatFileSize(Math.round(t), 1)}
+                    </text>
+                </g>
+            ))}
+            <path d={area} fill="#1677ff" fillOpacity={0.08} />
+            <path d={path} fill="none" stroke="#1677ff" strokeWidth={2} />
+            {points.map((p, i) => (
+                <g key={p.at}>
+                    <Tooltip title={`${dayjs(p.at).format('YYYY-MM-DD HH:mm')}：${formatFileSize(p.size)}`}>
+                        <circle cx={x(i)} cy={y(p.size)} r={3} fill="#fff" stroke="#1677ff" strokeWidth={1.5} />
+                    </Tooltip>
+                    {(i % labelStep === 0 || i === points.length - 1) && (
+                        <text x={x(i)} y={height - 6} textAnchor="middle" fontSize={11} fill="#8c8c8c">
+                            {dayjs(p.at).format(dateFormat)}
+                        </text>
+                    )}
+                </g>
+            ))}
+        </svg>
+    );
+};
+
+export default SizeTrendChart;
@@ -0,0 +1,169 @@
+import React, { useEffect, useState } from 'react';
+import { PageContainer } from '@ant-design/pro-components';
+import { Alert, Button, Card, Form, Input, InputNumber, Modal, Popconfirm, Space, Table, Typography, message } from 'antd';
+import { PlusOutlined } from '@ant-design/icons';
+import { changePassword, createAPIToken, deleteAPIToken, listAPITokens } from '@/services/auth/AuthController';
+import type { APIToken, ChangePasswordRequest, CreateAPITokenRequest } from '@/services/auth/typings';
+import { formatDateTime } from '@/utils/format';
+
+const AccountPage: React.FC = () => {
+    const [passwordForm] = Form.useForm();
+    const [tokenForm] = Form.useForm();
+    const [changing, setChanging] = useState(false);
+    const [tokens, setTokens] = useState<APIToken[]>([]);
+    const [loading, setLoading] = useState(false);
+    const [tokenModalOpen, setTokenModalOpen] = useState(false);
+    const [creating, setCreating] = useState(false);
+    const [createdToken, setCreatedToken] = useState<string>('');
+
+    const fetchTokens = async () => {
+        setLoading(true);
+        try {
+            const res = await listAPITokens();
+            if (res.code === 200) setTokens(res.data || []);
+        } finally {
+            setLoading(false);
+        }
+    };
+
+    useEffect(() => {
+        fetchTokens();
+    }, []);
+
+    const handleChangePassword = async (values: ChangePasswordRequest & { confirm: string }) => {
+        setChanging(true);
+        try {
+            const res = await changePassword({ old_password: values.old_password, new_password: values.new_password });
+            if (res.code === 200) {
+                message.success(res.message || '密码已修改');
+                passwordForm.resetFields();
+            } else {
+                message.error(res.message || '修改密码失败');
+            }
+        } finally {
+            setChanging(false);
+        }
+    };
+
+    const handleCreateToken = async () => {
+        const values: CreateAPITokenRequest = await tokenForm.validateFields();
+        setCreating(true);
+        try {
+            const res = await createAPIToken({ ...values, expires_in_days: values.expires_in_days || 0 });
+            if (res.code === 200) {
+                setTokenModalOpen(false);
+                setCreatedToken(res.data.token);
+                fetchTokens();
+            } else {
+                message.error(res.message || '创建令牌失败');
+            }
+        } finally {
+            setCreating(false);
+        }
+    };
+
+    const handleDeleteToken = async (id: number) => {
+        const res = await deleteAPIToken(id);
+        if (res.code === 200) {
+            message.success(res.message || '令牌已删除');
+            fetchTokens();
+        } else {
+            message.error(res.message || '删除失败');
+        }
+    };
+
+    const columns = [
+        { title: '名称', dataIndex: 'name' },
+        { title: '令牌', dataIndex: 'prefix', render: (v: string) => <code>{v}…</code> },
+        { title: '创建时间', dataIndex: 'created_at', width: 170, render: (v: string) => formatDateTime(v) },
+        { title: '过期时间', dataIndex: 'expires_at', width: 170, render: (v?: string) => v ? formatDateTime(v) : '永不过期' },
+        { title: '最后使用', dataIndex: 'last_used_at', width: 170, render: (v?: string) => v ? formatDateTime(v) : '-' },
+        {
+            title: '操作',
+            key: 'action',
+            width: 80,
+            render: (_: any, record: APIToken) => (
+                <Popconfirm title="删除后使用该令牌的脚本将无法访问，确定删除吗？" onConfirm={() => handleDeleteToken(record.id)}>
+                    <a style={{ color: '#ff4d4f' }}>删除</a>
+                </Popconfirm>
+            ),
+        },
+    ];
+
+    return (
+        <PageContainer ghost>
+            <Space direction="vertical" size={16} style={{ width: '100%' }}>
+                <Card title="修改密码">
+                    <Form form={passwordForm} layout="vertical" style={{ maxWidth: 400 }} onFinish={handleChangePassword}>
+                        <Form.Item name="old_password" label="原密码" rules={[{ required: true, message: '请输入原密码' }]}>
+                            <Input.Password />
+                        </Form.Item>
+                        <Form.Item
+                            name="new_password"
+                            label="新密码"
+                            rules={[{ required: true, message: '请输入新密码' }, { min: 8, message: '密码长度不能少于 8 位' }]}
+                        >
+                            <Input.Password />
+                        </Form.Item>
+                        <Form.Item
+                            name="confirm"
+                            label="确认新密码"
+                            dependencies={['new_password']}
+                            rules={[
+                                { required: true, message: '请再次输入新密码' },
+                                ({ getFieldValue }) => ({
+                                    validator: (_, value) => !value || getFieldValue('new_password') === value
+                                        ? Promise.resolve()
+                                        : Promise.reject(new Error('两次输入的密码不一致')),
+                                }),
+                            ]}
+                        >
+                            <Input.Password />
+                        </Form.Item>
+                        <Button type="primary" htmlType="submit" loading={changing}>修改密码</Button>
+                    </Form>
+                </Card>
+                <Card
+                    title="API 令牌"
+                    extra={<Button icon={<PlusOutlined />} onClick={() => { tokenForm.resetFields(); setTokenModalOpen(true); }}>创建令牌</Button>}
+                >
+                    <Typography.Paragraph type="secondary">
+                        脚本调用接口时在请求头中携带 <code>Authorization: Bearer &lt;令牌&gt;</code>，令牌拥有与你相同的权限。
+                    </Typography.Paragraph>
+                    <Table rowKey="id" size="small" loading={loading} columns={columns} dataSource={tokens} pagination={false} />
+                </Card>
+            </Space>
+            <Modal
+                title="创建 API 令牌"
+                open={tokenModalOpen}
+                confirmLoading={creating}
+                onOk={handleCreateToken}
+                onCancel={() => setTokenModalOpen(false)}
+                destroyOnClose
+            >
+                <Form form={tokenForm} layout="vertical" preserve={false}>
+                    <Form.Item name="name" label="名称" rules={[{ required: true, message: '请输入令牌名称' }]}>
+                        <Input placeholder="如：巡检脚本" />
+                    </Form.Item>
+                    <Form.Item name="expires_in_days" label="有效天数" extra="留空或 0 表示永不过期">
+                        <InputNumber min={0} max={3650} style={{ width: '100%' }} />
+                    </Form.Item>
+                </Form>
+            </Modal>
+            <Modal
+                title="令牌已创建"
+                open={!!createdToken}
+                onOk={() => setCreatedToken('')}
+                onCancel={() => setCreatedToken('')}
+                cancelButtonProps={{ style: { display: 'none' } }}
+            >
+                <Alert type="warning" showIcon style={{ marginBottom: 12 }} message="令牌只显示这一次，请立即复制保存。" />
+                <Typography.Paragraph copyable={{ text: createdToken }}>
+                    <code style={{ wordBreak: 'break-all' }}>{createdToken}</code>
+                </Typography.Paragraph>
+            </Modal>
+        </PageContainer>
+    );
+};
+
+export default AccountPage;
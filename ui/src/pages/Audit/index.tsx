@@ -0,0 +1,188 @@
+import React, { useEffect, useState } from 'react';
+import { PageContainer } from '@ant-design/pro-components';
+import { Alert, Button, Card, DatePicker, Form, Input, InputNumber, Select, Space, Table, Tag, Typography } from 'antd';
+import { DownloadOutlined, SafetyCertificateOutlined, SearchOutlined } from '@ant-design/icons';
+import type { Dayjs } from 'dayjs';
+import { auditExportURL, listAuditEvents, verifyAuditChain } from '@/services/audit/AuditController';
+import type { AuditEvent, AuditListParams, AuditVerifyResult } from '@/services/audit/typings';
+import { formatDateTime } from '@/utils/format';
+
+const actionOptions = [
+    { value: 'auth.', label: '登录与令牌（全部）' },
+    { value: 'auth.login_failed', label: '登录失败' },
+    { value: 'user.', label: '用户管理（全部）' },
+    { value: 'instance.', label: '实例（全部）' },
+    { value: 'instance.password_reveal', label: '查看实例密码' },
+    { value: 'instance.export', label: '导出实例配置' },
+    { value: 'query_task.', label: '查询任务（全部）' },
+    { value: 'query_task.run', label: '运行查询任务' },
+    { value: 'query_task.result_export', label: '导出查询结果' },
+    { value: 'db_doc.', label: '文档任务（全部）' },
+    { value: 'schema_compare.', label: '结构一致性检查（全部）' },
+    { value: 'config.set', label: '修改配置' },
+    { value: 'audit.export', label: '导出审计日志' },
+];
+
+const targetTypeOptions = [
+    { value: 'instance', label: '实例' },
+    { value: 'query_task', label: '查询任务' },
+    { value: 'query_task_sql', label: '查询SQL' },
+    { value: 'db_doc_task', label: '文档任务' },
+    { value: 'schema_compare', label: '结构一致性检查' },
+    { value: 'user', label: '用户' },
+    { value: 'api_token', label: 'API 令牌' },
+    { value: 'config', label: '配置' },
+];
+
+interface FilterValues {
+    actor?: string;
+    action?: string;
+    target_type?: string;
+    target_id?: number;
+    ip?: string;
+    range?: [Dayjs, Dayjs];
+}
+
+// 审计日志：只读查询，支持按条件导出 JSONL 和校验哈希链
+const AuditPage: React.FC = () => {
+    const [form] = Form.useForm<FilterValues>();
+    const [items, setItems] = useState<AuditEvent[]>([]);
+    const [total, setTotal] = useState(0);
+    const [loading, setLoading] = useState(false);
+    const [params, setParams] = useState<AuditListParams>({ page: 1, pageSize: 20 });
+    const [verifying, setVerifying] = useState(false);
+    const [verifyResult, setVerifyResult] = useState<AuditVerifyResult | null>(null);
+
+    const fetchEvents = async (p: AuditListParams) => {
+        setLoading(true);
+        try {
+            const res = await listAuditEvents(p);
+            if (res.code === 200) {
+                setItems(res.data.items || []);
+                setTotal(res.data.total);
+            }
+        } finally {
+            setLoading(false);
+        }
+    };
+
+    useEffect(() => {
+        fetchEvents(params);
+    }, [params]);
+
+    const handleSearch = (values: FilterValues) => {
+        const { range, ...rest } = values;
+        setParams({
+            ...rest,
+            start: range?.[0]?.format('YYYY-MM-DD HH:mm:ss'),
+            end: range?.[1]?.format('YYYY-MM-DD HH:mm:ss'),
+            page: 1,
+            pageSize: params.pageSize,
+        });
+    };
+
+    const handleVerify = async () => {
+        setVerifying(true);
+        try {
+            const res = await verifyAuditChain();
+            if (res.code === 200) setVerifyResult(res.data);
+        } finally {
+            setVerifying(false);
+        }
+    };
+
+    const columns = [
+        { title: '时间', dataIndex: 'created_at', width: 180, render: (v: string) => formatDateTime(v) },
+        {
+            title: '操作人',
+            dataIndex: 'actor',
+            width: 120,
+            render: (v: string, r: AuditEvent) => (v ? (r.actor_id ? v : <Typography.Text type="secondary">{v}</Typography.Text>) : '系统'),
+        },
+        { title: 'IP', dataIndex: 'ip', width: 130, render: (v: string) => v || '-' },
+        {
+            title: '动作',
+            dataIndex: 'action',
+            width: 200,
+            render: (v: string) => <Tag color={v.endsWith('failed') ? 'red' : v.includes('password') || v.includes('export') ? 'orange' : 'blue'}>{v}</Tag>,
+        },
+        {
+            title: '对象',
+            dataIndex: 'target_ids',
+            render: (v: string, r: AuditEvent) => (r.target_type ? `${r.target_type}${v ? ' #' + v.split(',').join(', #') : ''}` : '-'),
+        },
+        {
+            title: '哈希',
+            dataIndex: 'hash',
+            width: 120,
+            render: (v: string) => <Typography.Text code copyable={{ text: v }}>{v.slice(0, 8)}</Typography.Text>,
+        },
+    ];
+
+    return (
+        <PageContainer>
+            <Card style={{ marginBottom: 16 }}>
+                <Form form={form} layout="inline" onFinish={handleSearch}>
+                    <Form.Item name="actor">
+                        <Input placeholder="操作人" allowClear style={{ width: 120 }} />
+                    </Form.Item>
+                    <Form.Item name="action">
+                        <Select placeholder="动作" allowClear options={actionOptions} style={{ width: 180 }} />
+                    </Form.Item>
+                    <Form.Item name="target_type">
+                        <Select placeholder="对象类型" allowClear options={targetTypeOptions} style={{ width: 120 }} />
+                    </Form.Item>
+                    <Form.Item name="target_id">
+                        <InputNumber placeholder="对象ID" min={1} style={{ width: 100 }} />
+                    </Form.Item>
+                    <Form.Item name="ip">
+                        <Input placeholder="IP" allowClear style={{ width: 130 }} />
+                    </Form.Item>
+                    <Form.Item name="range">
+                        <DatePicker.RangePicker showTime />
+                    </Form.Item>
+                    <Form.Item>
+                        <Space>
+                            <Button type="primary" htmlType="submit" icon={<SearchOutlined />}>查询</Button>
+                            <Button icon={<DownloadOutlined />} onClick={() => window.open(auditExportURL(params))}>导出 JSONL</Button>
+                            <Button icon={<SafetyCertificateOutlined />} loading={verifying} onClick={handleVerify}>校验完整性</Button>
+                        </Space>
+                    </Form.Item>
+                </Form>
+                {verifyResult && (
+                    <Alert
+                        style={{ marginTop: 16 }}
+                        showIcon
+                        closable
+                        type={verifyResult.valid ? 'success' : 'error'}
+                        message={verifyResult.message}
+                        onClose={() => setVerifyResult(null)}
+                    />
+                )}
+            </Card>
+            <Card>
+                <Table
+                    rowKey="id"
+                    size="small"
+                    loading={loading}
+                    columns={columns}
+                    dataSource={items}
+                    expandable={{
+                        rowExpandable: (r) => r.detail != null,
+                        expandedRowRender: (r) => <pre style={{ margin: 0, whiteSpace: 'pre-wrap' }}>{JSON.stringify(r.detail, null, 2)}</pre>,
+                    }}
+                    pagination={{
+                        current: params.page,
+                        pageSize: params.pageSize,
+                        total,
+                        showSizeChanger: true,
+                        showTotal: (t) => `共 ${t} 条`,
+                        onChange: (page, pageSize) => setParams({ ...params, page, pageSize }),
+                    }}
+                />
+            </Card>
+        </PageContainer>
+    );
+};
+
+export default AuditPage;
@@ -7,6 +7,14 @@ const CONFIG_KEYS = [
   { key: "max_conn", label: "数据库最大连接数", min: 1, max: 99999, default: 100 },
   { key: "concurrency", label: "查询并发数量", min: 1, max: 99999, default: 50 },
   { key: "query_timeout_sec", label: "查询超时时间(秒)", min: 1, max: 99999, default: 300 },
+  { key: "max_running_tasks", label: "同时运行任务数", min: 1, max: 999, default: 3 },
+  { key: "instance_max_conn", label: "单实例共享并发数", min: 1, max: 99999, default: 50 },
+  { key: "approve_all_writes", label: "DML/DDL 均需审批(1-是，0-否)", min: 0, max: 1, default: 1 },
+  { key: "size_history_raw_days", label: "大小快照完整保留天数", min: 0, max: 3660, default: 7 },
+  { key: "size_history_days", label: "库大小快照保留天数(0-不限)", min: 0, max: 3660, default: 365 },
+  { key: "table_size_history_days", label: "表大小快照保留天数(0-不限)", min: 0, max: 3660, default: 90 },
+  { key: "sync_concurrency", label: "同步实例并发数", min: 1, max: 100, default: 5 },
+  { key: "sync_timeout_sec", label: "单实例同步超时(秒)", min: 1, max: 86400, default: 300 },
 ];
 
 const ConfigPage: React.FC = () => {
@@ -0,0 +1,158 @@
+import React, { useEffect, useState } from 'react';
+import { Button, Empty, Space, Table, Tabs, Tag, Typography } from 'antd';
+import { DiffOutlined } from '@ant-design/icons';
+import { diffSchemaVersions, listSchemaChanges, listSchemaVersions } from '@/services/schema/SchemaController';
+import type { SchemaChange, SchemaDiff, SchemaVersion } from '@/services/schema/typings';
+import { formatDateTime } from '@/utils/format';
+
+export const changeKindMap: Record<string, { text: string; color: string }> = {
+    table_added: { text: '新增表', color: 'green' },
+    table_dropped: { text: '删除表', color: 'red' },
+    table_changed: { text: '修改表', color: 'blue' },
+    column_added: { text: '新增字段', color: 'green' },
+    column_dropped: { text: '删除字段', color: 'red' },
+    column_changed: { text: '修改字段', color: 'orange' },
+    index_added: { text: '新增索引', color: 'green' },
+    index_dropped: { text: '删除索引', color: 'red' },
+    index_changed: { text: '修改索引', color: 'orange' },
+};
+
+export const changeColumns = [
+    {
+        title: '变更',
+        dataIndex: 'kind',
+        width: 100,
+        render: (v: string) => <Tag color={changeKindMap[v]?.color}>{changeKindMap[v]?.text || v}</Tag>,
+    },
+    {
+        title: '对象',
+        dataIndex: 'table_name',
+        render: (v: string, r: SchemaChange) => (r.object_name ? `${v}.${r.object_name}` : v),
+    },
+    {
+        title: '变更前 → 变更后',
+        dataIndex: 'after',
+        render: (_: string, r: SchemaChange) => (
+            <Space direction="vertical" size={0}>
+                {r.before && <Typography.Text delete type="secondary">{r.before}</Typography.Text>}
+                {r.after && <Typography.Text>{r.after}</Typography.Text>}
+            </Space>
+        ),
+    },
+];
+
+interface SchemaHistoryProps {
+    instanceId: number;
+    databaseName: string;
+}
+
+// 库结构历史：变更记录与版本列表，可选择任意两个版本比较
+const SchemaHistory: React.FC<SchemaHistoryProps> = ({ instanceId, databaseName }) => {
+    const [changes, setChanges] = useState<SchemaChange[]>([]);
+    const [versions, setVersions] = useState<SchemaVersion[]>([]);
+    const [selected, setSelected] = useState<number[]>([]);
+    const [diff, setDiff] = useState<SchemaDiff | null>(null);
+    const [diffing, setDiffing] = useState(false);
+
+    useEffect(() => {
+        const params = { instance_id: instanceId, database_name: databaseName, page: 1, pageSize: 100 };
+        listSchemaChanges(params).then((res) => {
+            if (res.code === 200) setChanges(res.data.items || []);
+        });
+        listSchemaVersions(params).then((res) => {
+            if (res.code === 200) setVersions(res.data.items || []);
+        });
+    }, [instanceId, databaseName]);
+
+    const handleDiff = async () => {
+        // 版本ID越大越新，旧版本在前
+        const [from, to] = [...selected].sort((a, b) => a - b);
+        setDiffing(true);
+        try {
+            const res = await diffSchemaVersions(from, to);
+            if (res.code === 200) setDiff(res.data);
+        } finally {
+            setDiffing(false);
+        }
+    };
+
+    return (
+        <Tabs
+            items={[
+                {
+                    key: 'changes',
+                    label: '变更记录',
+                    children: (
+                        <Table
+                            rowKey="id"
+                            size="small"
+                            dataSource={changes}
+                            pagination={{ pageSize: 20 }}
+                            locale={{ emptyText: <Empty image={Empty.PRESENTED_IMAGE_SIMPLE} description="同步以来结构未发生变化" /> }}
+                            columns={[
+                                { title: '发现时间', dataIndex: 'detected_at', width: 170, render: (v: string) => formatDateTime(v) },
+                                ...changeColumns,
+                            ]}
+                        />
+                    ),
+                },
+                {
+                    key: 'versions',
+                    label: `版本（${versions.length}）`,
+                    children: (
+                        <>
+                            <Space style={{ marginBottom: 12 }}>
+                                <Button
+                                    icon={<DiffOutlined />}
+                                    disabled={selected.length !== 2}
+                                    loading={diffing}
+                                    onClick={handleDiff}
+                                >
+                                    比较选中的两个版本
+                                </Button>
+                            </Space>
+                            <Table
+                                rowKey="id"
+                                size="small"
+                                dataSource={versions}
+                                pagination={false}
+                                rowSelection={{
+                                    selectedRowKeys: selected,
+                                    onChange: (keys) => setSelected((keys as number[]).slice(-2)),
+                                }}
+                                columns={[
+                                    { title: '版本', dataIndex: 'version', width: 70, render: (v: number) => `v${v}` },
+                                    { title: '表数量', dataIndex: 'table_count', width: 80 },
+                                    { title: '首次发现', dataIndex: 'captured_at', render: (v: string) => formatDateTime(v) },
+                                    { title: '最后采集', dataIndex: 'last_seen_at', render: (v: string) => formatDateTime(v) },
+                                    {
+                                        title: '指纹',
+                                        dataIndex: 'fingerprint',
+                                        render: (v: string) => <Typography.Text code copyable={{ text: v }}>{v.slice(0, 8)}</Typography.Text>,
+                                    },
+                                ]}
+                            />
+                            {diff && (
+                                <>
+                                    <Typography.Title level={5} style={{ marginTop: 16 }}>
+                                        v{diff.from.version} → v{diff.to.version}
+                                    </Typography.Title>
+                                    <Table
+                                        rowKey={(r) => `${r.kind}:${r.table_name}:${r.object_name}`}
+                                        size="small"
+                                        dataSource={diff.changes}
+                                        pagination={false}
+                                        columns={changeColumns}
+                                        locale={{ emptyText: '两个版本结构相同' }}
+                                    />
+                                </>
+                            )}
+                        </>
+                    ),
+                },
+            ]}
+        />
+    );
+};
+
+export default SchemaHistory;
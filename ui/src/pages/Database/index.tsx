@@ -6,6 +6,7 @@ import { queryDatabaseList, getDatabaseDetail } from '@/services/database/Databa
 import { getInstanceOptions, InstanceOption } from '@/services/instance/InstanceController';
 import { DatabaseInfo } from '@/services/database/typings';
 import { formatFileSize } from '@/utils/format';
+import SchemaHistory from './SchemaHistory';
 
 const DatabasePage: React.FC = () => {
     const actionRef = useRef<ActionType>();
@@ -138,7 +139,7 @@ const DatabasePage: React.FC = () => {
 
             <Drawer
                 title={currentDatabase ? `数据库：${currentDatabase.name}` : '数据库详情'}
-                width={400}
+                width={760}
                 open={drawerVisible}
                 onClose={() => {
                     setDrawerVisible(false);
@@ -150,7 +151,7 @@ const DatabasePage: React.FC = () => {
                     <Spin />
                 ) : currentDatabase ? (
                     <>
-                        <Descriptions column={1} bordered style={{ marginBottom: 24 }}>
+                        <Descriptions column={2} bordered style={{ marginBottom: 24 }}>
                             <Descriptions.Item label="数据库名称">{currentDatabase.name}</Descriptions.Item>
                             <Descriptions.Item label="实例名称">{currentDatabase.instance?.name}</Descriptions.Item>
                             <Descriptions.Item label="字符集">{currentDatabase.character_set}</Descriptions.Item>
@@ -158,6 +159,7 @@ const DatabasePage: React.FC = () => {
                             <Descriptions.Item label="表数量">{currentDatabase.table_count}</Descriptions.Item>
                             <Descriptions.Item label="数据库大小">{formatFileSize(currentDatabase.size)}</Descriptions.Item>
                         </Descriptions>
+                        <SchemaHistory instanceId={currentDatabase.instance_id} databaseName={currentDatabase.name} />
                     </>
                 ) : null}
             </Drawer>
@@ -23,6 +23,7 @@ interface DbDocTask {
   last_run_at: string;
   last_status: number;
   last_error: string;
+  created_by_name?: string;
   instance?: {
     name: string;
   };
@@ -248,6 +249,12 @@ const DbDoc: React.FC = () => {
         </Space>
       )
     },
+    {
+      title: '创建人',
+      dataIndex: 'created_by_name',
+      hideInSearch: true,
+      render: (_, record) => record.created_by_name || '-',
+    },
     {
       title: '操作',
       key: 'action',
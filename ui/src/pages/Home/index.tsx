@@ -1,15 +1,32 @@
 import React, { useEffect, useState } from 'react';
 import { PageContainer } from '@ant-design/pro-components';
 import { history } from '@umijs/max';
-import { Card, Col, Row, Statistic, Spin, List, Tag, Button, Space, Typography, Skeleton } from 'antd';
-import { PlusOutlined, DatabaseOutlined, SyncOutlined, CloseCircleOutlined } from '@ant-design/icons';
-import { getDashboardStats } from '@/services/dashboard/DashboardController';
-import { DashboardStats } from '@/services/dashboard/typings';
-import { formatDateTime } from '@/utils/format';
+import { Card, Col, Row, Statistic, Spin, List, Tag, Button, Space, Typography, Skeleton, Segmented } from 'antd';
+import { PlusOutlined, DatabaseOutlined, SyncOutlined, CloseCircleOutlined, RiseOutlined } from '@ant-design/icons';
+import { getDashboardStats, getSizeGrowth, getTopGrowing } from '@/services/dashboard/DashboardController';
+import { DashboardStats, SizeGrowth, SizeGrowthItem } from '@/services/dashboard/typings';
+import SizeTrendChart from '@/components/SizeTrendChart';
+import { formatDateTime, formatFileSize } from '@/utils/format';
 
 const HomePage: React.FC = () => {
     const [stats, setStats] = useState<DashboardStats | null>(null);
     const [loading, setLoading] = useState(true);
+    const [growthDays, setGrowthDays] = useState<number>(30);
+    const [growth, setGrowth] = useState<SizeGrowth | null>(null);
+    const [topLevel, setTopLevel] = useState<'database' | 'table'>('database');
+    const [topGrowing, setTopGrowing] = useState<SizeGrowthItem[]>([]);
+
+    useEffect(() => {
+        getSizeGrowth({ days: growthDays }).then((res) => {
+            if (res.code === 200) setGrowth(res.data);
+        });
+    }, [growthDays]);
+
+    useEffect(() => {
+        getTopGrowing({ days: growthDays, limit: 10, level: topLevel }).then((res) => {
+            if (res.code === 200) setTopGrowing(res.data || []);
+        });
+    }, [growthDays, topLevel]);
 
     useEffect(() => {
         const fetchStats = async () => {
@@ -33,6 +50,9 @@ const HomePage: React.FC = () => {
         1: { text: '执行中', color: 'processing' },
         2: { text: '已完成', color: 'success' },
         3: { text: '失败', color: 'error' },
+        4: { text: '排队中', color: 'warning' },
+        5: { text: '待审批', color: 'purple' },
+        6: { text: '已驳回', color: 'magenta' },
     };
 
     const renderStatusTag = (status: number) => {
@@ -97,6 +117,70 @@ const HomePage: React.FC = () => {
                     )}
                 </Skeleton>
 
+                <Row gutter={[16, 16]}>
+                    <Col xs={24} lg={14}>
+                        <Card
+                            title="数据总量趋势"
+                            extra={
+                                <Segmented
+                                    size="small"
+                                    value={growthDays}
+                                    onChange={(v) => setGrowthDays(v as number)}
+                                    options={[
+                                        { label: '7天', value: 7 },
+                                        { label: '30天', value: 30 },
+                                        { label: '90天', value: 90 },
+                                        { label: '1年', value: 365 },
+                                    ]}
+                                />
+                            }
+                        >
+                            {growth && growth.points.length > 0 && (
+                                <Statistic
+                                    value={formatFileSize(growth.end_size)}
+                                    suffix={
+                                        <Typography.Text type={growth.growth > 0 ? 'danger' : 'secondary'} style={{ fontSize: 14 }}>
+                                            {growth.growth >= 0 ? '+' : '-'}{formatFileSize(Math.abs(growth.growth))}
+                                        </Typography.Text>
+                                    }
+                                />
+                            )}
+                            <SizeTrendChart points={growth?.points || []} />
+                        </Card>
+                    </Col>
+                    <Col xs={24} lg={10}>
+                        <Card
+                            title={<Space><RiseOutlined />增长最快</Space>}
+                            extra={
+                                <Segmented
+                                    size="small"
+                                    value={topLevel}
+                                    onChange={(v) => setTopLevel(v as 'database' | 'table')}
+                                    options={[
+                                        { label: '库', value: 'database' },
+                                        { label: '表', value: 'table' },
+                                    ]}
+                                />
+                            }
+                        >
+                            <List
+                                size="small"
+                                dataSource={topGrowing}
+                                locale={{ emptyText: '该时间段内没有增长' }}
+                                renderItem={(item) => (
+                                    <List.Item>
+                                        <List.Item.Meta
+                                            title={item.table_name ? `${item.database_name}.${item.table_name}` : item.database_name}
+                                            description={<Tag>{item.instance_name}</Tag>}
+                                        />
+                                        <Typography.Text type="danger">+{formatFileSize(item.growth)}</Typography.Text>
+                                    </List.Item>
+                                )}
+                            />
+                        </Card>
+                    </Col>
+                </Row>
+
                 <Card title="最近查询任务">
                     <Skeleton loading={loading} active avatar paragraph={{ rows: 5 }}>
                         <List
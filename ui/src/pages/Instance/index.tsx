@@ -1,11 +1,11 @@
 import { PageContainer, ProTable } from '@ant-design/pro-components';
 import type { ActionType, ProColumns } from '@ant-design/pro-components';
-import { Button, Popconfirm, message, Space, Tag, Spin, Upload, Modal, Tooltip } from 'antd';
+import { Button, Input, Popconfirm, message, Space, Tag, Spin, Upload, Modal, Tooltip } from 'antd';
 import { useRef, useState } from 'react';
-import { addInstance, deleteInstance, batchDeleteInstances, getInstancePassword, modifyInstance, queryInstanceList, syncDatabases } from '@/services/instance/InstanceController';
+import { addInstance, deleteInstance, batchDeleteInstances, batchTagInstances, getInstancePassword, modifyInstance, queryInstanceList, syncDatabases } from '@/services/instance/InstanceController';
 import InstanceForm from './components/InstanceForm';
 import { InstanceInfo, APIResponse } from '@/services/instance/typings';
-import { EditOutlined, DeleteOutlined, PlusOutlined, SyncOutlined, LoadingOutlined, UploadOutlined, DownloadOutlined, ClockCircleOutlined, CopyOutlined, KeyOutlined } from '@ant-design/icons';
+import { EditOutlined, DeleteOutlined, PlusOutlined, SyncOutlined, LoadingOutlined, UploadOutlined, DownloadOutlined, ClockCircleOutlined, CopyOutlined, KeyOutlined, TagsOutlined } from '@ant-design/icons';
 import { request } from '@umijs/max';
 import { formatRelativeTime, formatFrequency } from '@/utils/format';
 
@@ -17,7 +17,13 @@ const InstancePage: React.FC = () => {
     const [syncing, setSyncing] = useState(false);
     const [exporting, setExporting] = useState(false);
     const [importing, setImporting] = useState(false);
+    // 导出/导入口令：导出时用于加密密码，为空则导出文件不包含密码
+    const [passphrase, setPassphrase] = useState('');
     const [batchDeleting, setBatchDeleting] = useState(false);
+    const [tagModalVisible, setTagModalVisible] = useState(false);
+    const [tagSetText, setTagSetText] = useState('');
+    const [tagRemoveText, setTagRemoveText] = useState('');
+    const [tagging, setTagging] = useState(false);
     const [copyingUsernameId, setCopyingUsernameId] = useState<number | null>(null);
     const [copyingPasswordId, setCopyingPasswordId] = useState<number | null>(null);
 
@@ -108,7 +114,7 @@ const InstancePage: React.FC = () => {
             dataIndex: 'version',
             ellipsis: true,
             hideInSearch: true,
-            render: (text) => <Tag>{text}</Tag>,
+            render: (text, record) => <Tag>{record.engine === 'sqlite' ? text : `${record.engine === 'postgres' ? 'PostgreSQL' : 'MySQL'} ${text}`}</Tag>,
         },
         {
             title: '定时同步',
@@ -135,6 +141,60 @@ const InstancePage: React.FC = () => {
                 );
             },
         },
+        {
+            title: '同步状态',
+            dataIndex: 'last_sync_status',
+            hideInSearch: true,
+            render: (_, record) => {
+                if (!record.last_sync_status) return <Tag>未同步</Tag>;
+                const time = record.last_sync_at ? formatRelativeTime(record.last_sync_at) : '';
+                if (record.last_sync_status === 1) {
+                    return (
+                        <Tooltip title={`耗时 ${((record.last_sync_duration_ms || 0) / 1000).toFixed(1)} 秒`}>
+                            <Tag color="success">成功 {time}</Tag>
+                        </Tooltip>
+                    );
+                }
+                return (
+                    <Tooltip title={record.last_sync_error}>
+                        <Tag color="error">失败 {time}</Tag>
+                    </Tooltip>
+                );
+            },
+        },
+        {
+            title: '标签',
+            dataIndex: 'tags',
+            tooltip: '搜索时输入标签表达式，如 env=prod AND region!=cn',
+            fieldProps: { placeholder: 'env=prod AND region!=cn' },
+            render: (_, record) => {
+                const entries = Object.entries(record.tags || {});
+                if (entries.length === 0) return '-';
+                return (
+                    <Space size={[0, 4]} wrap>
+                        {entries.map(([key, value]) => (
+                            <Tag key={key} color="blue">{value ? `${key}=${value}` : key}</Tag>
+                        ))}
+                    </Space>
+                );
+            },
+        },
+        {
+            title: '保护模式',
+            dataIndex: 'protection_mode',
+            hideInSearch: true,
+            render: (_, record) => {
+                if (record.protection_mode === 'readonly') return <Tag color="red">只读</Tag>;
+                if (record.protection_mode === 'approval') return <Tag color="orange">写入需审批</Tag>;
+                return '-';
+            },
+        },
+        {
+            title: '创建人',
+            dataIndex: 'created_by_name',
+            hideInSearch: true,
+            render: (_, record) => record.created_by_name || '-',
+        },
         {
             title: '备注',
             dataIndex: 'remark',
@@ -235,7 +295,25 @@ const InstancePage: React.FC = () => {
             const instanceIds = selectedRows.map(row => row.id);
             const res = await syncDatabases({ instance_ids: instanceIds });
             if (res.code === 200) {
-                message.success(res.message || '同步成功');
+                const summary = res.data;
+                if (summary.failed === 0) {
+                    message.success(`已同步 ${summary.total} 个实例，耗时 ${(summary.duration_ms / 1000).toFixed(1)} 秒`);
+                } else {
+                    Modal.warning({
+                        title: `同步完成：成功 ${summary.succeeded} 个，失败 ${summary.failed} 个`,
+                        width: 560,
+                        content: (
+                            <Space direction="vertical" style={{ width: '100%' }}>
+                                {summary.results.filter(r => !r.success).map(r => (
+                                    <div key={r.instance_id}>
+                                        <Tag color="red">{r.instance_name}</Tag>
+                                        <span style={{ wordBreak: 'break-all' }}>{r.error}</span>
+                                    </div>
+                                ))}
+                            </Space>
+                        ),
+                    });
+                }
                 actionRef.current?.reload();
             } else {
                 message.error(res.message || '同步失败');
@@ -247,13 +325,52 @@ const InstancePage: React.FC = () => {
         }
     };
 
+    // 批量打标签：以空格或逗号分隔，新增/覆盖写 key=value，删除只写 key。
+    const handleBatchTag = async () => {
+        const splitTokens = (text: string) => text.split(/[\s,，]+/).filter(Boolean);
+        const set: Record<string, string> = {};
+        for (const token of splitTokens(tagSetText)) {
+            const index = token.indexOf('=');
+            if (index === 0) {
+                message.error(`标签 "${token}" 缺少标签名`);
+                return;
+            }
+            if (index < 0) {
+                set[token] = '';
+            } else {
+                set[token.slice(0, index)] = token.slice(index + 1);
+            }
+        }
+        const remove = splitTokens(tagRemoveText);
+        if (Object.keys(set).length === 0 && remove.length === 0) {
+            message.warning('请填写要新增或删除的标签');
+            return;
+        }
+
+        setTagging(true);
+        try {
+            const res = await batchTagInstances({ instance_ids: selectedRows.map(row => row.id), set, remove });
+            if (res.code === 200) {
+                message.success(res.message || '修改标签成功');
+                setTagModalVisible(false);
+                actionRef.current?.reload();
+            } else {
+                message.error(res.message || '修改标签失败');
+            }
+        } catch (error: any) {
+            message.error(error.message || '修改标签失败');
+        } finally {
+            setTagging(false);
+        }
+    };
+
     const handleExport = async () => {
         setExporting(true);
         try {
             const instanceIds = selectedRows.map(row => row.id);
             const res = await request<APIResponse<InstanceInfo[]>>('/api/instances/export', {
                 method: 'POST',
-                data: { instance_ids: instanceIds },
+                data: { instance_ids: instanceIds, passphrase },
             });
             if (res.code === 200) {
                 const blob = new Blob([JSON.stringify(res.data, null, 2)], { type: 'application/json' });
@@ -265,7 +382,7 @@ const InstancePage: React.FC = () => {
                 link.click();
                 link.parentNode?.removeChild(link);
                 window.URL.revokeObjectURL(url);
-                message.success('导出成功');
+                message.success(passphrase ? '导出成功，密码已使用口令加密' : '导出成功，导出文件不包含密码');
             } else {
                 message.error(res.message || '导出失败');
             }
@@ -315,10 +432,20 @@ const InstancePage: React.FC = () => {
                     },
                 }}
                 toolBarRender={() => [
+                    <Tooltip key="passphrase" title="导出时用于加密密码，导入时用于解密；为空则导出文件不包含密码">
+                        <Input.Password
+                            placeholder="导出/导入口令"
+                            value={passphrase}
+                            onChange={(e) => setPassphrase(e.target.value)}
+                            style={{ width: 180 }}
+                            autoComplete="new-password"
+                        />
+                    </Tooltip>,
                     <Upload
                         key="import"
                         name="file"
                         action="/api/instances/import"
+                        data={{ passphrase }}
                         showUploadList={false}
                         disabled={importing}
                         onChange={(info) => {
@@ -384,6 +511,18 @@ const InstancePage: React.FC = () => {
                     >
                         {selectedRows.length > 0 ? '导出选中配置' : '导出全部配置'}
                     </Button>,
+                    <Button
+                        key="tags"
+                        icon={<TagsOutlined />}
+                        disabled={selectedRows.length === 0}
+                        onClick={() => {
+                            setTagSetText('');
+                            setTagRemoveText('');
+                            setTagModalVisible(true);
+                        }}
+                    >
+                        批量标签
+                    </Button>,
                     <Button
                         key="sync"
                         onClick={handleSyncDatabases}
@@ -425,6 +564,30 @@ const InstancePage: React.FC = () => {
                     showTotal: (total) => `共 ${total} 条记录`
                 }}
             />
+            <Modal
+                title={`修改 ${selectedRows.length} 个实例的标签`}
+                open={tagModalVisible}
+                onOk={handleBatchTag}
+                onCancel={() => setTagModalVisible(false)}
+                confirmLoading={tagging}
+                okText="保存"
+                cancelText="取消"
+            >
+                <Space direction="vertical" style={{ width: '100%' }}>
+                    <Input
+                        addonBefore="新增/覆盖"
+                        placeholder="env=prod region=eu"
+                        value={tagSetText}
+                        onChange={(e) => setTagSetText(e.target.value)}
+                    />
+                    <Input
+                        addonBefore="删除"
+                        placeholder="region"
+                        value={tagRemoveText}
+                        onChange={(e) => setTagRemoveText(e.target.value)}
+                    />
+                </Space>
+            </Modal>
             <InstanceForm
                 visible={drawerVisible}
                 onClose={() => {
@@ -0,0 +1,57 @@
+import React from 'react';
+import { LoginForm, ProFormText } from '@ant-design/pro-components';
+import { LockOutlined, UserOutlined } from '@ant-design/icons';
+import { history, useModel } from '@umijs/max';
+import { message } from 'antd';
+import Logo from '@/components/Logo';
+import { login } from '@/services/auth/AuthController';
+import type { LoginRequest } from '@/services/auth/typings';
+
+const LoginPage: React.FC = () => {
+    const { setInitialState } = useModel('@@initialState');
+
+    const handleSubmit = async (values: LoginRequest) => {
+        try {
+            const res = await login(values);
+            if (res.code !== 200) {
+                message.error(res.message || '登录失败');
+                return;
+            }
+            const user = res.data.user;
+            await setInitialState((s: any) => ({ ...s, name: user.display_name || user.username, currentUser: user }));
+            message.success('登录成功');
+            // 回到登录前访问的页面
+            const redirect = new URLSearchParams(history.location.search).get('redirect');
+            history.replace(redirect && redirect.startsWith('/') && !redirect.startsWith('/login') ? redirect : '/home');
+        } catch {
+            message.error('登录失败，请稍后重试');
+        }
+    };
+
+    return (
+        <div style={{ minHeight: '100vh', display: 'flex', alignItems: 'center', justifyContent: 'center', background: 'linear-gradient(115deg, white, #f5f5f5 30%)' }}>
+            <LoginForm<LoginRequest>
+                logo={<Logo style={{ fontSize: 44 }} />}
+                title="My Bulker"
+                subTitle="批量管理数据库实例与执行 SQL"
+                onFinish={handleSubmit}
+                submitter={{ searchConfig: { submitText: '登录' } }}
+            >
+                <ProFormText
+                    name="username"
+                    fieldProps={{ size: 'large', prefix: <UserOutlined /> }}
+                    placeholder="用户名"
+                    rules={[{ required: true, message: '请输入用户名' }]}
+                />
+                <ProFormText.Password
+                    name="password"
+                    fieldProps={{ size: 'large', prefix: <LockOutlined /> }}
+                    placeholder="密码"
+                    rules={[{ required: true, message: '请输入密码' }]}
+                />
+            </LoginForm>
+        </div>
+    );
+};
+
+export default LoginPage;
@@ -6,11 +6,12 @@ interface ExecutionStatsProps {
     stats: {
         db: { total: number; completed: number; failed: number; pending: number };
         sql: { total: number; completed: number; failed: number; pending: number };
+        throttle?: { paused_ms: number; paused_dbs: number };
     };
 }
 
 const ExecutionStats: React.FC<ExecutionStatsProps> = ({ stats }) => {
-    const { db, sql } = stats;
+    const { db, sql, throttle } = stats;
     
     const renderStat = (title: string, data: any, icon: React.ReactNode) => {
         const percent = data.total > 0 ? Math.round((data.completed / data.total) * 100) : 0;
@@ -46,6 +47,11 @@ const ExecutionStats: React.FC<ExecutionStatsProps> = ({ stats }) => {
         <div style={{ display: 'grid', gridTemplateColumns: 'repeat(auto-fit, minmax(300px, 1fr))', gap: '16px', marginBottom: 16 }}>
             {renderStat('数据库进度', db, <DatabaseOutlined />)}
             {renderStat('SQL语句进度', sql, <CodeOutlined />)}
+            {throttle && throttle.paused_ms > 0 && (
+                <div style={{ fontSize: '12px', color: '#d97706' }}>
+                    因从库复制延迟暂停 {(throttle.paused_ms / 1000).toFixed(1)} 秒（涉及 {throttle.paused_dbs} 个数据库）
+                </div>
+            )}
         </div>
     );
 };
@@ -130,6 +130,14 @@ const QueryProgressPanel: React.FC<QueryProgressPanelProps> = ({
                                                     </div>
                                                     <div style={{ fontWeight: 500, fontSize: '12px', marginBottom: 2, whiteSpace: 'nowrap', overflow: 'hidden', textOverflow: 'ellipsis', width: '100%' }}>{exec.database_name}</div>
                                                     <div style={{ fontSize: 10, color: '#888', marginBottom: 2, whiteSpace: 'nowrap', overflow: 'hidden', textOverflow: 'ellipsis', width: '100%' }}>{exec.instance_name || '-'}</div>
+                                                    {exec.chunk_count > 0 && (
+                                                        <div style={{ fontSize: 10, color: '#888' }}>影响 {exec.affected_rows ?? 0} 行 / {exec.chunk_count} 批</div>
+                                                    )}
+                                                    {exec.pause_reason && (
+                                                        <div title={exec.pause_reason} style={{ fontSize: 10, color: '#faad14', whiteSpace: 'nowrap', overflow: 'hidden', textOverflow: 'ellipsis', width: '100%' }}>
+                                                            暂停：{exec.pause_reason}
+                                                        </div>
+                                                    )}
                                                 </div>
                                             );
                                             if (exec.status === 3 && exec.error_message) {
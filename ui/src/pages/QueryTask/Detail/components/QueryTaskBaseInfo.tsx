@@ -29,6 +29,38 @@ const QueryTaskBaseInfo: React.FC<QueryTaskBaseInfoProps> = ({ task, status }) =
                     {task.description || '-'}
                 </span>
             </div>
+            <div style={{ display: 'flex', flexDirection: 'column', gap: '6px' }}>
+                <span style={{ fontSize: '13px', color: '#6b7280' }}>创建人</span>
+                <span style={{ fontSize: '14px', color: '#374151' }}>{task.created_by_name || '-'}</span>
+            </div>
+            {task.target_rule && task.target_rule.mode !== 'include' && task.target_rule.mode !== 'exclude' && task.target_rule.tag_expr && !task.target_rule.instance_ids?.length && (
+                <div style={{ display: 'flex', flexDirection: 'column', gap: '6px' }}>
+                    <span style={{ fontSize: '13px', color: '#6b7280' }}>实例标签表达式</span>
+                    <span style={{ fontSize: '14px', color: '#374151' }}><code>{task.target_rule.tag_expr}</code></span>
+                </div>
+            )}
+            {task.target_rule?.mode === 'pattern' && (
+                <div style={{ display: 'flex', flexDirection: 'column', gap: '6px' }}>
+                    <span style={{ fontSize: '13px', color: '#6b7280' }}>数据库名称模式</span>
+                    <span style={{ fontSize: '14px', color: '#374151' }}>
+                        <code>{task.target_rule.filter?.include_patterns?.join(', ') || '全部'}</code>
+                        {Boolean(task.target_rule.filter?.exclude_patterns?.length) && (
+                            <> 排除 <code>{task.target_rule.filter?.exclude_patterns?.join(', ')}</code></>
+                        )}
+                    </span>
+                </div>
+            )}
+            {task.target_rule?.mode === 'schema' && task.target_rule.require && (
+                <div style={{ display: 'flex', flexDirection: 'column', gap: '6px' }}>
+                    <span style={{ fontSize: '13px', color: '#6b7280' }}>表结构条件</span>
+                    <span style={{ fontSize: '14px', color: '#374151' }}>
+                        {[...(task.target_rule.require.tables || []), ...(task.target_rule.require.columns || [])].map(name => (
+                            <code key={name} style={{ marginRight: 8 }}>{name}</code>
+                        ))}
+                        {task.target_rule.require.probe_sql && <code>{task.target_rule.require.probe_sql}</code>}
+                    </span>
+                </div>
+            )}
             <div style={{ display: 'flex', flexDirection: 'column', gap: '6px' }}>
                 <span style={{ fontSize: '13px', color: '#6b7280' }}>创建时间</span>
                 <span style={{ fontSize: '14px', color: '#374151' }}>{formatDateTime(task.created_at)}</span>
@@ -0,0 +1,115 @@
+import React, { useState } from 'react';
+import { Card, Table, Tag, Button, Modal, Form, Input, Space, Alert, message } from 'antd';
+import { approveQueryTask, rejectQueryTask } from '@/services/queryTask/QueryTaskController';
+import { QueryTaskReview } from '@/services/queryTask/typings';
+import { formatDateTime } from '@/utils/format';
+
+interface TaskReviewsProps {
+    taskId: number;
+    status: number;
+    reviews: QueryTaskReview[];
+    onReviewed: () => void;
+}
+
+// 任务审批：待审批时可通过或驳回，并展示历史审批记录
+const TaskReviews: React.FC<TaskReviewsProps> = ({ taskId, status, reviews, onReviewed }) => {
+    const [form] = Form.useForm();
+    const [decision, setDecision] = useState<'approve' | 'reject' | null>(null);
+    const [submitting, setSubmitting] = useState(false);
+
+    const pending = status === 5;
+    if (!pending && reviews.length === 0) {
+        return null;
+    }
+
+    const handleSubmit = async () => {
+        const values = await form.validateFields();
+        setSubmitting(true);
+        try {
+            const res = decision === 'approve'
+                ? await approveQueryTask(taskId, values)
+                : await rejectQueryTask(taskId, values);
+            if (res.code === 200) {
+                message.success(res.message);
+                setDecision(null);
+                form.resetFields(['comment']);
+                onReviewed();
+            } else {
+                message.error(res.message || '审批失败');
+            }
+        } catch {
+            message.error('审批失败');
+        } finally {
+            setSubmitting(false);
+        }
+    };
+
+    const columns = [
+        {
+            title: '结论',
+            dataIndex: 'decision',
+            width: 90,
+            render: (v: number) => v === 1 ? <Tag color="success">通过</Tag> : <Tag color="error">驳回</Tag>,
+        },
+        { title: '审批人', dataIndex: 'reviewer', width: 120 },
+        { title: '审批意见', dataIndex: 'comment', render: (v: string) => v || '-' },
+        { title: '目标库数', dataIndex: 'target_count', width: 90 },
+        {
+            title: 'SQL 摘要',
+            dataIndex: 'sql_hash',
+            width: 120,
+            render: (v: string) => <code title={v}>{v.slice(0, 12)}</code>,
+        },
+        {
+            title: '时间',
+            dataIndex: 'created_at',
+            width: 170,
+            render: (v: string) => formatDateTime(v),
+        },
+    ];
+
+    return (
+        <Card
+            size="small"
+            title="审批"
+            extra={pending && (
+                <Space>
+                    <Button type="primary" size="small" onClick={() => setDecision('approve')}>审批通过</Button>
+                    <Button danger size="small" onClick={() => setDecision('reject')}>驳回</Button>
+                </Space>
+            )}
+        >
+            {pending && (
+                <Alert
+                    type="warning"
+                    showIcon
+                    style={{ marginBottom: reviews.length ? 12 : 0 }}
+                    message="任务包含写入语句，审批通过后才能执行。审批绑定当前的 SQL 和目标数据库，之后任一发生变化都需要重新审批。"
+                />
+            )}
+            {reviews.length > 0 && (
+                <Table rowKey="id" size="small" columns={columns} dataSource={reviews} pagination={false} />
+            )}
+            <Modal
+                title={decision === 'approve' ? '审批通过' : '驳回任务'}
+                open={decision !== null}
+                confirmLoading={submitting}
+                onOk={handleSubmit}
+                onCancel={() => setDecision(null)}
+                destroyOnClose
+            >
+                <Form form={form} layout="vertical" preserve={false}>
+                    <Form.Item
+                        name="comment"
+                        label="审批意见"
+                        rules={[{ required: decision === 'reject', message: '驳回时必须填写审批意见' }]}
+                    >
+                        <Input.TextArea rows={3} placeholder={decision === 'reject' ? '请说明驳回原因' : '可选'} />
+                    </Form.Item>
+                </Form>
+            </Modal>
+        </Card>
+    );
+};
+
+export default TaskReviews;
@@ -1,15 +1,16 @@
 import React, { useState, useEffect, useRef } from 'react';
 import { PageContainer } from '@ant-design/pro-components';
-import { Card, Descriptions, Tag, Space, Button, Spin, message, Tabs, Collapse, Tooltip, Row, Col } from 'antd';
+import { Card, Descriptions, Tag, Space, Button, Dropdown, Spin, message, Tabs, Collapse, Tooltip, Row, Col } from 'antd';
 import { ArrowLeftOutlined, ReloadOutlined } from '@ant-design/icons';
 import { useParams, history, useLocation } from '@umijs/max';
-import { getQueryTaskDetail, getQueryTaskSQLExecutions, getQueryTaskSQLs, runQueryTask, getQueryTaskSQLResult } from '@/services/queryTask/QueryTaskController';
-import { QueryTaskInfo } from '@/services/queryTask/typings';
+import { getQueryTaskDetail, getQueryTaskSQLExecutions, getQueryTaskSQLs, runQueryTask, getQueryTaskSQLResult, getQueryTaskReviews } from '@/services/queryTask/QueryTaskController';
+import { QueryTaskInfo, QueryTaskReview } from '@/services/queryTask/typings';
 import { formatDateTime } from '@/utils/format';
 import ExecutionStats from './components/ExecutionStats';
 import TaskSQLs from './components/TaskSQLs';
 import QueryTaskBaseInfo from './components/QueryTaskBaseInfo';
 import QueryResultsPanel from './components/QueryResultsPanel';
+import TaskReviews from './components/TaskReviews';
 
 const QueryTaskDetailPage: React.FC = () => {
     // hooks 顶层声明
@@ -29,6 +30,7 @@ const QueryTaskDetailPage: React.FC = () => {
     const [sqlList, setSqlList] = useState<any[]>([]); // 新增，保存带 schema 的 SQL 列表
     const resultsPanelRef = useRef<any>();
     const [stats, setStats] = useState<any>(null);
+    const [reviews, setReviews] = useState<QueryTaskReview[]>([]);
     const firstLoading = useRef(true);
     const [runBtnLoading, setRunBtnLoading] = useState(false);
 
@@ -71,17 +73,19 @@ const QueryTaskDetailPage: React.FC = () => {
     const loadAllData = async (isFirst = false) => {
         if (isFirst) setLoading(true);
         try {
-            const [detailRes, sqlsRes, execRes, statsRes] = await Promise.all([
+            const [detailRes, sqlsRes, execRes, statsRes, reviewsRes] = await Promise.all([
                 getQueryTaskDetail(parseInt(id!)),
                 getQueryTaskSQLs(parseInt(id!)),
                 getQueryTaskSQLExecutions(parseInt(id!)),
                 fetch(`/api/query-tasks/${id}/execution-stats`).then(r => r.json()),
+                getQueryTaskReviews(parseInt(id!)),
             ]);
             if (detailRes.code === 200) setTask(detailRes.data);
             else message.error(detailRes.message || '获取任务详情失败');
             if (sqlsRes.code === 200) setSqlList(sqlsRes.data?.items || []);
             if (execRes.code === 200) setSqlExecutions(execRes.data || []);
             if (statsRes.code === 200) setStats(statsRes.data);
+            if (reviewsRes.code === 200) setReviews(reviewsRes.data || []);
         } catch {
             message.error('获取任务数据失败');
         } finally {
@@ -95,9 +99,9 @@ const QueryTaskDetailPage: React.FC = () => {
         loadAllData(true).then(() => { firstLoading.current = false; });
     }, [id]);
 
-    // 轮询逻辑：查询中每1秒刷新一次
+    // 轮询逻辑：查询中或排队中每1秒刷新一次
     useEffect(() => {
-        if (!task || task.status !== 1) return;
+        if (!task || (task.status !== 1 && task.status !== 4)) return;
         const timer = setInterval(() => {
             loadAllData(false);
         }, 1000);
@@ -129,6 +133,9 @@ const QueryTaskDetailPage: React.FC = () => {
         1: { text: '执行中', color: 'processing' },
         2: { text: '已完成', color: 'success' },
         3: { text: '失败', color: 'error' },
+        4: { text: '排队中', color: 'warning' },
+        5: { text: '待审批', color: 'purple' },
+        6: { text: '已驳回', color: 'magenta' },
     };
 
     // 返回列表页
@@ -186,6 +193,7 @@ const QueryTaskDetailPage: React.FC = () => {
             children: (
                 <Space direction="vertical" style={{ width: '100%' }} size={16}>
                     <QueryTaskBaseInfo task={task} status={status} />
+                    <TaskReviews taskId={task.id} status={task.status} reviews={reviews} onReviewed={() => loadAllData(false)} />
                     {stats && <ExecutionStats stats={stats} />}
                     <TaskSQLs 
                         sqls={sqlList} 
@@ -205,6 +213,28 @@ const QueryTaskDetailPage: React.FC = () => {
         },
     ];
 
+    // 运行任务，reResolve 为 true 时按创建时的选择规则和最新同步结果重新确定目标库
+    const handleRun = async (reResolve: boolean) => {
+        if (!id) return;
+        setRunBtnLoading(true);
+        try {
+            const res = await runQueryTask(parseInt(id!), reResolve);
+            if (res.code === 200) {
+                message.success(res.message || '任务已开始执行');
+                setActiveTab('detail');
+                await loadAllData(false);
+            } else {
+                message.error(res.message || '任务启动失败');
+                // 审批失效时任务会转为待审批，刷新以展示审批操作
+                await loadAllData(false);
+            }
+        } catch {
+            message.error('任务启动失败');
+        } finally {
+            setRunBtnLoading(false);
+        }
+    };
+
     return (
         <PageContainer
             ghost
@@ -225,32 +255,19 @@ const QueryTaskDetailPage: React.FC = () => {
                     >
                         刷新
                     </Button>,
-                    <Button
+                    <Dropdown.Button
                         key="run"
                         type="primary"
-                        disabled={task.status === 1}
+                        disabled={task.status === 1 || task.status === 5 || task.status === 6}
                         loading={runBtnLoading}
-                        onClick={async () => {
-                            if (!id) return;
-                            setRunBtnLoading(true);
-                            try {
-                                const res = await runQueryTask(parseInt(id!));
-                                if (res.code === 200) {
-                                    message.success(res.message || '任务已开始执行');
-                                    setActiveTab('detail');
-                                    await loadAllData(false);
-                                } else {
-                                    message.error(res.message || '任务启动失败');
-                                }
-                            } catch {
-                                message.error('任务启动失败');
-                            } finally {
-                                setRunBtnLoading(false);
-                            }
+                        onClick={() => handleRun(false)}
+                        menu={{
+                            items: [{ key: 're_resolve', label: '重新解析目标后查询' }],
+                            onClick: () => handleRun(true),
                         }}
                     >
-                        {task.status === 2 ? '再次查询' : task.status === 0 ? '开始查询' : task.status === 3 ? '重新查询' : '查询中...'}
-                    </Button>,
+                        {task.status === 2 ? '再次查询' : task.status === 0 ? '开始查询' : task.status === 3 ? '重新查询' : task.status === 5 ? '待审批' : task.status === 6 ? '已驳回' : '查询中...'}
+                    </Dropdown.Button>,
                 ],
             }}
         >
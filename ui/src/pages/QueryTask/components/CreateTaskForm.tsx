@@ -1,15 +1,18 @@
 import React, { useState, useEffect, useCallback } from 'react';
-import { Form, Input, Select, Button, Space, Radio, Alert, Modal, message, Row, Col, Card } from 'antd';
-import { CreateQueryTaskRequest } from '@/services/queryTask/typings';
-import { getInstanceOptions } from '@/services/instance/InstanceController';
+import { Form, Input, InputNumber, Select, Button, Space, Radio, Alert, Modal, message, Row, Col, Card, Tag, Table } from 'antd';
+import { CreateQueryTaskRequest, PreviewTargetsResult } from '@/services/queryTask/typings';
+import { getInstanceOptions, getInstanceTagOptions } from '@/services/instance/InstanceController';
+import { InstanceTagOption } from '@/services/instance/typings';
 import DatabaseSelector from './DatabaseSelector';
 import SQLEditor from './SQLEditor';
-import { validateSQL } from '@/services/queryTask/QueryTaskController';
+import { validateSQL, previewTargets } from '@/services/queryTask/QueryTaskController';
 import { QueryTaskTemplate, getQueryTaskTemplates, saveQueryTaskTemplate, deleteQueryTaskTemplate } from '@/utils/queryTaskTemplate';
 import { DeleteOutlined } from '@ant-design/icons';
 
 const { Option } = Select;
 
+type DatabaseMode = CreateQueryTaskRequest['database_mode'];
+
 interface CreateTaskFormProps {
     onSubmit: (values: CreateQueryTaskRequest) => Promise<void>;
     loading?: boolean;
@@ -37,11 +40,14 @@ const CreateTaskForm: React.FC<CreateTaskFormProps> = ({
     const [form] = Form.useForm();
     const [instances, setInstances] = useState<{ label: string; value: number }[]>([]);
     const [selectedInstanceIds, setSelectedInstanceIds] = useState<number[]>([]);
-    const [databaseMode, setDatabaseMode] = useState<'include' | 'exclude'>('include');
+    const [databaseMode, setDatabaseMode] = useState<DatabaseMode>('include');
+    const [tagOptions, setTagOptions] = useState<InstanceTagOption[]>([]);
     const [templates, setTemplates] = useState<QueryTaskTemplate[]>([]);
     const [selectedTemplate, setSelectedTemplate] = useState<string | undefined>(undefined);
     const [isSaveModalVisible, setIsSaveModalVisible] = useState(false);
     const [newTemplateName, setNewTemplateName] = useState('');
+    const [previewLoading, setPreviewLoading] = useState(false);
+    const [previewResult, setPreviewResult] = useState<PreviewTargetsResult | null>(null);
 
     /**
      * 重置表单，保证快速开启下一次查询配置。
@@ -53,7 +59,12 @@ const CreateTaskForm: React.FC<CreateTaskFormProps> = ({
             instance_ids: [],
             database_mode: 'include',
             selected_dbs: [],
+            tag_expr: '',
+            filter: {},
+            require: {},
             sql_content: '',
+            chunk_size: 0,
+            chunk_sleep_ms: 0,
         });
         setSelectedInstanceIds([]);
         setDatabaseMode('include');
@@ -82,6 +93,11 @@ const CreateTaskForm: React.FC<CreateTaskFormProps> = ({
         };
 
         loadInstances();
+        getInstanceTagOptions().then(res => {
+            if (res.code === 200 && res.data) {
+                setTagOptions(res.data);
+            }
+        }).catch(() => {});
     }, []);
 
     // 处理实例选择变化，实例变化后数据库列表需要重新选择。
@@ -92,7 +108,7 @@ const CreateTaskForm: React.FC<CreateTaskFormProps> = ({
     };
 
     // 处理数据库模式变化，模式切换后数据库选择语义会变化。
-    const handleDatabaseModeChange = (mode: 'include' | 'exclude') => {
+    const handleDatabaseModeChange = (mode: DatabaseMode) => {
         setDatabaseMode(mode);
         // 模式变化时清空数据库，避免包含/排除语义混淆。
         form.setFieldsValue({ selected_dbs: [] });
@@ -106,6 +122,24 @@ const CreateTaskForm: React.FC<CreateTaskFormProps> = ({
                 type: 'info' as const,
                 message: '系统将只在您选中的数据库上执行SQL语句。',
             };
+        } else if (databaseMode === 'pattern') {
+            // 名称模式每次运行前重新匹配，适合按命名规则批量选择租户库。
+            return {
+                type: 'warning' as const,
+                message: '系统将在所选实例（未选择时按标签匹配实例）中名称匹配且满足筛选条件的数据库上执行SQL语句，每次运行前按最新的同步结果重新匹配。',
+            };
+        } else if (databaseMode === 'schema') {
+            // 表结构模式适合 schema-per-tenant，按表和字段是否存在选择数据库。
+            return {
+                type: 'warning' as const,
+                message: '系统将在所选实例（未选择时按标签匹配实例）中包含所需表和字段、且探测语句返回真值的数据库上执行SQL语句，表结构以最近一次同步为准，每次运行前重新匹配。',
+            };
+        } else if (databaseMode === 'tags') {
+            // 标签模式每次运行前重新匹配，适合按环境、地域批量执行。
+            return {
+                type: 'warning' as const,
+                message: '系统将在标签匹配的所有实例的全部数据库中执行SQL语句，每次运行前按最新的标签和同步结果重新匹配。',
+            };
         } else {
             // 排除模式会在实例全库执行，适合大范围排查。
             return {
@@ -138,6 +172,24 @@ const CreateTaskForm: React.FC<CreateTaskFormProps> = ({
         }
     };
 
+    // 预览当前选择条件命中的数据库，创建任务前确认执行范围。
+    const handlePreview = async () => {
+        const values = form.getFieldsValue(['instance_ids', 'database_mode', 'selected_dbs', 'tag_expr', 'filter', 'require']);
+        setPreviewLoading(true);
+        try {
+            const res = await previewTargets(values);
+            if (res.code === 200 && res.data) {
+                setPreviewResult(res.data);
+            } else {
+                message.error(res.message || '预览目标数据库失败');
+            }
+        } catch (error: any) {
+            message.error(error?.message || '预览目标数据库失败');
+        } finally {
+            setPreviewLoading(false);
+        }
+    };
+
     // 模板选择后直接回填，减少重复配置。
     const handleTemplateSelect = (templateName?: string) => {
         setSelectedTemplate(templateName);
@@ -162,9 +214,9 @@ const CreateTaskForm: React.FC<CreateTaskFormProps> = ({
 
     // 保存模板前先检查核心字段，避免保存空模板。
     const handleShowSaveModal = () => {
-        const values = form.getFieldsValue(['instance_ids', 'database_mode', 'selected_dbs', 'sql_content']);
+        const values = form.getFieldsValue(['instance_ids', 'database_mode', 'selected_dbs', 'tag_expr', 'filter', 'require', 'sql_content']);
         const hasSQL = Boolean(String(values.sql_content || '').trim());
-        const hasInstances = Array.isArray(values.instance_ids) && values.instance_ids.length > 0;
+        const hasInstances = (Array.isArray(values.instance_ids) && values.instance_ids.length > 0) || Boolean(values.tag_expr);
         if (!hasSQL && !hasInstances) {
             message.warning('请至少填写 SQL 内容或选择实例后再保存模板');
             return;
@@ -180,7 +232,7 @@ const CreateTaskForm: React.FC<CreateTaskFormProps> = ({
             message.error('模板名称不能为空');
             return;
         }
-        const valuesToSave = form.getFieldsValue(['instance_ids', 'database_mode', 'selected_dbs', 'sql_content']);
+        const valuesToSave = form.getFieldsValue(['instance_ids', 'database_mode', 'selected_dbs', 'tag_expr', 'filter', 'require', 'sql_content']);
         const newTemplate: QueryTaskTemplate = {
             name: templateName,
             createdAt: new Date().toISOString(),
@@ -291,8 +343,9 @@ const CreateTaskForm: React.FC<CreateTaskFormProps> = ({
                             >
                                 <Form.Item
                                     name="instance_ids"
-                                    label="选择实例"
-                                    rules={[{ required: true, message: '请选择实例' }]}
+                                    label={databaseMode === 'pattern' || databaseMode === 'schema' ? '选择实例（可选，未选择时按标签表达式匹配实例）' : '选择实例'}
+                                    rules={[{ required: databaseMode === 'include' || databaseMode === 'exclude', message: '请选择实例' }]}
+                                    hidden={databaseMode === 'tags'}
                                 >
                                     <Select
                                         mode="multiple"
@@ -325,6 +378,9 @@ const CreateTaskForm: React.FC<CreateTaskFormProps> = ({
                                     >
                                         <Radio value="include">包含</Radio>
                                         <Radio value="exclude">排除</Radio>
+                                        <Radio value="tags">按标签</Radio>
+                                        <Radio value="pattern">按名称模式</Radio>
+                                        <Radio value="schema">按表结构</Radio>
                                     </Radio.Group>
                                 </Form.Item>
 
@@ -335,17 +391,107 @@ const CreateTaskForm: React.FC<CreateTaskFormProps> = ({
                                     style={{ marginBottom: 16 }}
                                 />
 
-                                <Form.Item
-                                    name="selected_dbs"
-                                    label={databaseMode === 'include' ? '包含的数据库' : '排除的数据库'}
-                                    rules={[{ required: true, message: '请选择数据库' }]}
-                                    style={{ marginBottom: 0 }}
-                                >
-                                    <DatabaseSelector
-                                        instanceIds={selectedInstanceIds}
-                                        disabled={selectedInstanceIds.length === 0}
-                                    />
-                                </Form.Item>
+                                {databaseMode === 'tags' || databaseMode === 'pattern' || databaseMode === 'schema' ? (
+                                    <>
+                                        <Form.Item
+                                            name="tag_expr"
+                                            label={databaseMode === 'tags' ? '实例标签表达式' : '实例标签表达式（未选择实例时使用）'}
+                                            tooltip="条件为 key=value、key!=value 或 key（存在该标签），可用 AND、OR、NOT 和括号组合"
+                                            rules={[{ required: databaseMode === 'tags', whitespace: true, message: '请输入标签表达式' }]}
+                                            extra={tagOptions.length > 0 && (
+                                                <Space size={[0, 4]} wrap style={{ marginTop: 8 }}>
+                                                    {tagOptions.flatMap(option => option.values.map(value => (
+                                                        <Tag key={`${option.key}=${value}`}>{value ? `${option.key}=${value}` : option.key}</Tag>
+                                                    )))}
+                                                </Space>
+                                            )}
+                                            style={{ marginBottom: databaseMode === 'tags' ? 0 : 24 }}
+                                        >
+                                            <Input placeholder="env=prod AND region!=cn" allowClear />
+                                        </Form.Item>
+                                        {databaseMode === 'pattern' && (
+                                            <>
+                                                <Form.Item
+                                                    name={['filter', 'include_patterns']}
+                                                    label="包含的名称模式"
+                                                    tooltip="tenant_% 这类通配符（% 或 * 匹配任意字符，? 匹配单个字符，_ 按字面匹配），或 /正则/；留空表示全部数据库"
+                                                >
+                                                    <Select mode="tags" placeholder="tenant_%" tokenSeparators={[',', ' ']} open={false} />
+                                                </Form.Item>
+                                                <Form.Item
+                                                    name={['filter', 'exclude_patterns']}
+                                                    label="排除的名称模式"
+                                                >
+                                                    <Select mode="tags" placeholder="tenant_test_%" tokenSeparators={[',', ' ']} open={false} />
+                                                </Form.Item>
+                                                <Form.Item label="大小(MB)">
+                                                    <Space.Compact style={{ width: '100%' }}>
+                                                        <Form.Item name={['filter', 'min_size_mb']} noStyle>
+                                                            <InputNumber min={0} placeholder="最小" style={{ width: '50%' }} />
+                                                        </Form.Item>
+                                                        <Form.Item name={['filter', 'max_size_mb']} noStyle>
+                                                            <InputNumber min={0} placeholder="最大" style={{ width: '50%' }} />
+                                                        </Form.Item>
+                                                    </Space.Compact>
+                                                </Form.Item>
+                                                <Form.Item label="表数量">
+                                                    <Space.Compact style={{ width: '100%' }}>
+                                                        <Form.Item name={['filter', 'min_tables']} noStyle>
+                                                            <InputNumber min={0} placeholder="最少" style={{ width: '50%' }} />
+                                                        </Form.Item>
+                                                        <Form.Item name={['filter', 'max_tables']} noStyle>
+                                                            <InputNumber min={0} placeholder="最多" style={{ width: '50%' }} />
+                                                        </Form.Item>
+                                                    </Space.Compact>
+                                                </Form.Item>
+                                                <Form.Item name={['filter', 'charset']} label="字符集" style={{ marginBottom: 0 }}>
+                                                    <Input placeholder="utf8mb4" allowClear />
+                                                </Form.Item>
+                                            </>
+                                        )}
+                                        {databaseMode === 'schema' && (
+                                            <>
+                                                <Form.Item
+                                                    name={['require', 'tables']}
+                                                    label="需要的表"
+                                                    tooltip="按最近一次同步的表结构判断，需同时存在"
+                                                >
+                                                    <Select mode="tags" placeholder="orders" tokenSeparators={[',', ' ']} open={false} />
+                                                </Form.Item>
+                                                <Form.Item
+                                                    name={['require', 'columns']}
+                                                    label="需要的字段"
+                                                    tooltip="写作 表名.字段名，需同时存在"
+                                                >
+                                                    <Select mode="tags" placeholder="orders.refund_status" tokenSeparators={[',', ' ']} open={false} />
+                                                </Form.Item>
+                                                <Form.Item
+                                                    name={['require', 'probe_sql']}
+                                                    label="探测语句"
+                                                    tooltip="单条只读查询，在满足上述条件的库上逐个执行，首行首列为真值（非 0、非空）时选中；执行出错视为不匹配"
+                                                    style={{ marginBottom: 0 }}
+                                                >
+                                                    <Input.TextArea rows={2} placeholder="SELECT COUNT(*) > 0 FROM orders WHERE refund_status IS NOT NULL" allowClear />
+                                                </Form.Item>
+                                            </>
+                                        )}
+                                    </>
+                                ) : (
+                                    <Form.Item
+                                        name="selected_dbs"
+                                        label={databaseMode === 'include' ? '包含的数据库' : '排除的数据库'}
+                                        rules={[{ required: true, message: '请选择数据库' }]}
+                                        style={{ marginBottom: 0 }}
+                                    >
+                                        <DatabaseSelector
+                                            instanceIds={selectedInstanceIds}
+                                            disabled={selectedInstanceIds.length === 0}
+                                        />
+                                    </Form.Item>
+                                )}
+                                <Button style={{ marginTop: 16 }} onClick={handlePreview} loading={previewLoading}>
+                                    预览目标数据库
+                                </Button>
                             </Card>
                         </Col>
 
@@ -367,6 +513,20 @@ const CreateTaskForm: React.FC<CreateTaskFormProps> = ({
                                     >
                                         <SQLEditor height={320} />
                                     </Form.Item>
+                                    <Form.Item
+                                        label="分批执行"
+                                        tooltip="对大表 UPDATE/DELETE 分批执行：DELETE 按 LIMIT 循环直到无影响行，UPDATE 按主键区间推进；0 表示不分批"
+                                        style={{ marginTop: 16, marginBottom: 0 }}
+                                    >
+                                        <Space.Compact style={{ width: '100%' }}>
+                                            <Form.Item name="chunk_size" noStyle>
+                                                <InputNumber min={0} addonBefore="每批行数" style={{ width: '50%' }} />
+                                            </Form.Item>
+                                            <Form.Item name="chunk_sleep_ms" noStyle>
+                                                <InputNumber min={0} addonBefore="批间隔(毫秒)" style={{ width: '50%' }} />
+                                            </Form.Item>
+                                        </Space.Compact>
+                                    </Form.Item>
                                 </Card>
 
                                 <Card bordered={false}>
@@ -389,6 +549,25 @@ const CreateTaskForm: React.FC<CreateTaskFormProps> = ({
                 </Space>
             </Form>
 
+            <Modal
+                title={`目标数据库预览（共 ${previewResult?.total ?? 0} 个）`}
+                open={previewResult !== null}
+                onCancel={() => setPreviewResult(null)}
+                footer={null}
+                width={640}
+            >
+                <Table
+                    size="small"
+                    rowKey={(item) => `${item.instance_id}|${item.database_name}`}
+                    dataSource={previewResult?.items || []}
+                    pagination={{ pageSize: 10, showSizeChanger: false }}
+                    columns={[
+                        { title: '实例', dataIndex: 'instance_name', render: (name, item) => name || `#${item.instance_id}` },
+                        { title: '数据库', dataIndex: 'database_name' },
+                    ]}
+                />
+            </Modal>
+
             <Modal
                 title="保存为模板"
                 open={isSaveModalVisible}
@@ -153,6 +153,9 @@ const QueryTaskPage: React.FC = () => {
                 1: { text: '执行中', status: 'Processing' },
                 2: { text: '已完成', status: 'Success' },
                 3: { text: '失败', status: 'Error' },
+                4: { text: '排队中', status: 'Warning' },
+                5: { text: '待审批', status: 'Processing' },
+                6: { text: '已驳回', status: 'Error' },
             },
         },
         {
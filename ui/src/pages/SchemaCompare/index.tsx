@@ -0,0 +1,435 @@
+import React, { useEffect, useRef, useState } from 'react';
+import { PageContainer, ProTable } from '@ant-design/pro-components';
+import type { ActionType, ProColumns } from '@ant-design/pro-components';
+import { Alert, Button, Drawer, Form, Input, Modal, Popconfirm, Radio, Select, Space, Switch, Table, Tag, Typography, message } from 'antd';
+import { DeleteOutlined, PlayCircleOutlined, PlusOutlined, ProfileOutlined } from '@ant-design/icons';
+import { request } from '@umijs/max';
+import DatabaseSelector from '@/pages/QueryTask/components/DatabaseSelector';
+import { changeColumns } from '@/pages/Database/SchemaHistory';
+import {
+  createSchemaCompare,
+  deleteSchemaCompare,
+  listSchemaCompareResults,
+  listSchemaCompares,
+  runSchemaCompare,
+} from '@/services/schemaCompare/SchemaCompareController';
+import type { SchemaCompareJob, SchemaCompareResult } from '@/services/schemaCompare/typings';
+import { formatDateTime } from '@/utils/format';
+
+const { Text, Paragraph } = Typography;
+
+const jobStatusMap: Record<number, { text: string; color: string }> = {
+  0: { text: '待运行', color: 'default' },
+  1: { text: '运行中', color: 'processing' },
+  2: { text: '已完成', color: 'success' },
+  3: { text: '失败', color: 'error' },
+};
+
+const resultStatusMap: Record<number, { text: string; color: string }> = {
+  0: { text: '一致', color: 'green' },
+  1: { text: '存在差异', color: 'orange' },
+  2: { text: '失败', color: 'red' },
+};
+
+// 比较结果：按库列出差异，展开查看差异明细和变更语句
+const ResultDrawer: React.FC<{ job: SchemaCompareJob | null; onClose: () => void }> = ({ job, onClose }) => {
+  const [results, setResults] = useState<SchemaCompareResult[]>([]);
+  const [total, setTotal] = useState(0);
+  const [loading, setLoading] = useState(false);
+  const [status, setStatus] = useState<number | undefined>();
+  const [page, setPage] = useState(1);
+
+  useEffect(() => {
+    if (!job) return;
+    setLoading(true);
+    listSchemaCompareResults(job.id, { status, page, pageSize: 20 })
+      .then((res) => {
+        if (res.code === 200) {
+          setResults(res.data.items || []);
+          setTotal(res.data.total);
+        }
+      })
+      .finally(() => setLoading(false));
+  }, [job, status, page]);
+
+  return (
+    <Drawer
+      title={job ? `比较结果：${job.name}` : ''}
+      width={960}
+      open={!!job}
+      onClose={onClose}
+      destroyOnClose
+    >
+      {job && (
+        <Space direction="vertical" style={{ width: '100%' }}>
+          <Space wrap>
+            <Text type="secondary">参照库：</Text>
+            <Text strong>{job.reference_database}</Text>
+            <Tag>共 {job.total}</Tag>
+            <Tag color="green">一致 {job.consistent}</Tag>
+            <Tag color="orange">差异 {job.deviated}</Tag>
+            <Tag color="red">失败 {job.failed}</Tag>
+            <Radio.Group
+              size="small"
+              value={status ?? -1}
+              onChange={(e) => {
+                setStatus(e.target.value === -1 ? undefined : e.target.value);
+                setPage(1);
+              }}
+            >
+              <Radio.Button value={-1}>全部</Radio.Button>
+              <Radio.Button value={1}>存在差异</Radio.Button>
+              <Radio.Button value={2}>失败</Radio.Button>
+              <Radio.Button value={0}>一致</Radio.Button>
+            </Radio.Group>
+          </Space>
+          {job.error && <Alert type="error" showIcon message={job.error} />}
+          <Table<SchemaCompareResult>
+            rowKey="id"
+            size="small"
+            loading={loading}
+            dataSource={results}
+            pagination={{ current: page, pageSize: 20, total, onChange: setPage, showSizeChanger: false }}
+            columns={[
+              { title: '实例', dataIndex: 'instance_name', render: (v: string) => <Tag color="blue">{v}</Tag> },
+              { title: '数据库', dataIndex: 'database_name' },
+              {
+                title: '结果',
+                dataIndex: 'status',
+                render: (v: number) => <Tag color={resultStatusMap[v]?.color}>{resultStatusMap[v]?.text}</Tag>,
+              },
+              { title: '差异数', dataIndex: 'diff_count' },
+              {
+                title: '失败原因',
+                dataIndex: 'error',
+                render: (v: string) => (v ? <Text type="danger" ellipsis={{ tooltip: v }} style={{ maxWidth: 280 }}>{v}</Text> : '-'),
+              },
+            ]}
+            expandable={{
+              rowExpandable: (r) => r.diff_count > 0,
+              expandedRowRender: (r) => (
+                <Space direction="vertical" style={{ width: '100%' }}>
+                  <Table
+                    rowKey={(c) => `${c.kind}|${c.table_name}|${c.object_name}`}
+                    size="small"
+                    pagination={false}
+                    dataSource={r.changes || []}
+                    columns={changeColumns}
+                  />
+                  {r.ddl && (
+                    <Paragraph copyable={{ text: r.ddl }} style={{ marginBottom: 0 }}>
+                      <pre style={{ margin: 0, whiteSpace: 'pre-wrap' }}>{r.ddl}</pre>
+                    </Paragraph>
+                  )}
+                </Space>
+              ),
+            }}
+          />
+        </Space>
+      )}
+    </Drawer>
+  );
+};
+
+const SchemaCompare: React.FC = () => {
+  const actionRef = useRef<ActionType>();
+  const [form] = Form.useForm();
+  const [modalVisible, setModalVisible] = useState(false);
+  const [submitting, setSubmitting] = useState(false);
+  const [instances, setInstances] = useState<any[]>([]);
+  const [databases, setDatabases] = useState<any[]>([]);
+  const [viewing, setViewing] = useState<SchemaCompareJob | null>(null);
+  const [hasRunning, setHasRunning] = useState(false);
+
+  const databaseMode = Form.useWatch('database_mode', form);
+  const targetInstanceIds: number[] = Form.useWatch('instance_ids', form) || [];
+
+  useEffect(() => {
+    request('/api/instances/options').then((res) => setInstances(res.data || []));
+  }, []);
+
+  // 有运行中的任务时定时刷新
+  useEffect(() => {
+    if (!hasRunning) return;
+    const timer = setInterval(() => actionRef.current?.reload(), 3000);
+    return () => clearInterval(timer);
+  }, [hasRunning]);
+
+  const fetchDatabases = async (instanceId: number) => {
+    const res = await request('/api/databases/batch-list', {
+      method: 'POST',
+      data: { instance_ids: [instanceId] },
+    });
+    setDatabases((res.data || []).map((item: any) => ({ label: item.database_name, value: item.database_name })));
+  };
+
+  const handleCreate = async (values: any) => {
+    setSubmitting(true);
+    try {
+      const res = await createSchemaCompare({
+        ...values,
+        filter: values.database_mode === 'pattern' ? values.filter : undefined,
+      });
+      if (res.code !== 200) {
+        message.error(res.message || '创建失败');
+        return;
+      }
+      message.success('已开始检查');
+      setModalVisible(false);
+      form.resetFields();
+      actionRef.current?.reload();
+    } finally {
+      setSubmitting(false);
+    }
+  };
+
+  const handleRun = async (id: number) => {
+    const res = await runSchemaCompare(id);
+    if (res.code !== 200) {
+      message.error(res.message || '运行失败');
+      return;
+    }
+    message.success('已开始检查');
+    actionRef.current?.reload();
+  };
+
+  const handleDelete = async (id: number) => {
+    const res = await deleteSchemaCompare(id);
+    if (res.code !== 200) {
+      message.error(res.message || '删除失败');
+      return;
+    }
+    message.success('删除成功');
+    actionRef.current?.reload();
+  };
+
+  const columns: ProColumns<SchemaCompareJob>[] = [
+    { title: '任务名称', dataIndex: 'name' },
+    {
+      title: '参照库',
+      dataIndex: 'reference_database',
+      hideInSearch: true,
+      render: (_, record) => {
+        const instance = instances.find((item) => item.value === record.reference_instance_id);
+        return (
+          <Space size={4}>
+            <Tag color="blue">{instance?.label || record.reference_instance_id}</Tag>
+            {record.reference_database}
+          </Space>
+        );
+      },
+    },
+    {
+      title: '状态',
+      dataIndex: 'status',
+      hideInSearch: true,
+      render: (_, record) => (
+        <Tag color={jobStatusMap[record.status]?.color}>{jobStatusMap[record.status]?.text}</Tag>
+      ),
+    },
+    {
+      title: '结果',
+      key: 'summary',
+      hideInSearch: true,
+      render: (_, record) => (
+        <Space size={4}>
+          <Tag>共 {record.total}</Tag>
+          <Tag color="green">一致 {record.consistent}</Tag>
+          <Tag color="orange">差异 {record.deviated}</Tag>
+          {record.failed > 0 && <Tag color="red">失败 {record.failed}</Tag>}
+        </Space>
+      ),
+    },
+    {
+      title: '变更语句',
+      dataIndex: 'generate_ddl',
+      hideInSearch: true,
+      render: (_, record) => (record.generate_ddl ? '生成' : '-'),
+    },
+    {
+      title: '最近运行',
+      dataIndex: 'finished_at',
+      hideInSearch: true,
+      render: (_, record) => (
+        <Text type="secondary" style={{ fontSize: '12px' }}>
+          {record.finished_at ? formatDateTime(record.finished_at) : record.started_at ? formatDateTime(record.started_at) : '-'}
+        </Text>
+      ),
+    },
+    {
+      title: '创建人',
+      dataIndex: 'created_by_name',
+      hideInSearch: true,
+      render: (_, record) => record.created_by_name || '-',
+    },
+    {
+      title: '操作',
+      key: 'action',
+      valueType: 'option',
+      width: 220,
+      fixed: 'right',
+      render: (_, record) => [
+        <Button key="view" type="link" size="small" icon={<ProfileOutlined />} onClick={() => setViewing(record)}>
+          结果
+        </Button>,
+        <Button
+          key="run"
+          type="link"
+          size="small"
+          icon={<PlayCircleOutlined />}
+          disabled={record.status === 1}
+          onClick={() => handleRun(record.id)}
+        >
+          运行
+        </Button>,
+        <Popconfirm key="delete" title="确定删除吗？" onConfirm={() => handleDelete(record.id)}>
+          <Button type="link" size="small" danger icon={<DeleteOutlined />} disabled={record.status === 1}>
+            删除
+          </Button>
+        </Popconfirm>,
+      ],
+    },
+  ];
+
+  return (
+    <PageContainer ghost>
+      <ProTable<SchemaCompareJob>
+        actionRef={actionRef}
+        rowKey="id"
+        scroll={{ x: 'max-content' }}
+        search={{ labelWidth: 'auto' }}
+        toolBarRender={() => [
+          <Button
+            key="create"
+            type="primary"
+            icon={<PlusOutlined />}
+            onClick={() => {
+              form.resetFields();
+              setModalVisible(true);
+            }}
+          >
+            创建检查
+          </Button>,
+        ]}
+        request={async (params) => {
+          const res = await listSchemaCompares({
+            page: params.current,
+            pageSize: params.pageSize,
+            name: params.name,
+          });
+          const items = res.data?.items || [];
+          setHasRunning(items.some((item) => item.status === 1));
+          return {
+            data: items,
+            success: res.code === 200,
+            total: res.data?.total || 0,
+          };
+        }}
+        columns={columns}
+        pagination={{ pageSize: 10, showSizeChanger: true }}
+      />
+
+      <Modal
+        title="创建库结构一致性检查"
+        open={modalVisible}
+        onOk={() => form.submit()}
+        confirmLoading={submitting}
+        onCancel={() => {
+          setModalVisible(false);
+          form.resetFields();
+        }}
+        width={640}
+      >
+        <Form
+          form={form}
+          layout="vertical"
+          onFinish={handleCreate}
+          initialValues={{ database_mode: 'tags', generate_ddl: true }}
+        >
+          <Form.Item name="name" label="任务名称" rules={[{ required: true, message: '请输入任务名称' }]}>
+            <Input placeholder="租户库结构巡检" />
+          </Form.Item>
+          <Space.Compact style={{ width: '100%' }}>
+            <Form.Item
+              name="reference_instance_id"
+              label="参照实例"
+              rules={[{ required: true, message: '请选择参照实例' }]}
+              style={{ width: '50%' }}
+            >
+              <Select
+                showSearch
+                optionFilterProp="label"
+                options={instances}
+                placeholder="请选择实例"
+                onChange={(val) => {
+                  form.setFieldValue('reference_database', undefined);
+                  fetchDatabases(val);
+                }}
+              />
+            </Form.Item>
+            <Form.Item
+              name="reference_database"
+              label="参照库"
+              rules={[{ required: true, message: '请选择参照库' }]}
+              style={{ width: '50%' }}
+            >
+              <Select showSearch optionFilterProp="label" options={databases} placeholder="请选择数据库" />
+            </Form.Item>
+          </Space.Compact>
+          <Form.Item name="database_mode" label="比较范围">
+            <Radio.Group optionType="button" buttonStyle="solid">
+              <Radio value="tags">按标签</Radio>
+              <Radio value="pattern">按名称模式</Radio>
+              <Radio value="include">指定数据库</Radio>
+            </Radio.Group>
+          </Form.Item>
+          {databaseMode !== 'tags' && (
+            <Form.Item
+              name="instance_ids"
+              label={databaseMode === 'pattern' ? '选择实例（可选，未选择时按标签表达式匹配实例）' : '选择实例'}
+              rules={[{ required: databaseMode === 'include', message: '请选择实例' }]}
+            >
+              <Select mode="multiple" showSearch optionFilterProp="label" options={instances} placeholder="请选择实例" allowClear />
+            </Form.Item>
+          )}
+          {databaseMode === 'include' ? (
+            <Form.Item name="selected_dbs" label="比较的数据库" rules={[{ required: true, message: '请选择数据库' }]}>
+              <DatabaseSelector instanceIds={targetInstanceIds} disabled={targetInstanceIds.length === 0} />
+            </Form.Item>
+          ) : (
+            <Form.Item
+              name="tag_expr"
+              label="实例标签表达式"
+              tooltip="条件为 key=value、key!=value 或 key（存在该标签），可用 AND、OR、NOT 和括号组合"
+              rules={[{ required: databaseMode === 'tags', whitespace: true, message: '请输入标签表达式' }]}
+            >
+              <Input placeholder="env=prod AND region!=cn" allowClear />
+            </Form.Item>
+          )}
+          {databaseMode === 'pattern' && (
+            <Form.Item
+              name={['filter', 'include_patterns']}
+              label="包含的名称模式"
+              tooltip="tenant_% 这类通配符（% 或 * 匹配任意字符，? 匹配单个字符，_ 按字面匹配），或 /正则/；留空表示全部数据库"
+            >
+              <Select mode="tags" placeholder="tenant_%" tokenSeparators={[',', ' ']} open={false} />
+            </Form.Item>
+          )}
+          <Form.Item
+            name="generate_ddl"
+            label="生成变更语句"
+            tooltip="为存在差异的库生成使其与参照库一致的 ALTER 语句，缺少或多出的表只给出提示"
+            valuePropName="checked"
+            style={{ marginBottom: 0 }}
+          >
+            <Switch />
+          </Form.Item>
+        </Form>
+      </Modal>
+
+      <ResultDrawer job={viewing} onClose={() => setViewing(null)} />
+    </PageContainer>
+  );
+};
+
+export default SchemaCompare;
@@ -0,0 +1,218 @@
+import React, { useEffect, useState } from 'react';
+import { PageContainer } from '@ant-design/pro-components';
+import { Alert, Button, Card, Col, Empty, Form, Input, Modal, Radio, Row, Select, Space, Table, Typography, message } from 'antd';
+import { SwapOutlined, ThunderboltOutlined } from '@ant-design/icons';
+import { history, request } from '@umijs/max';
+import { changeColumns } from '@/pages/Database/SchemaHistory';
+import { createSchemaMigrationTask, generateSchemaMigration } from '@/services/schema/SchemaController';
+import type { SchemaMigration as SchemaMigrationResult, SchemaMigrationParams } from '@/services/schema/typings';
+
+const { Paragraph, Text } = Typography;
+
+// 实例和库选择，选择实例后加载该实例下的库
+const DatabasePicker: React.FC<{ prefix: 'source' | 'target'; label: string; instances: any[] }> = ({ prefix, label, instances }) => {
+  const form = Form.useFormInstance();
+  const [databases, setDatabases] = useState<any[]>([]);
+
+  const fetchDatabases = async (instanceId: number) => {
+    const res = await request('/api/databases/batch-list', {
+      method: 'POST',
+      data: { instance_ids: [instanceId] },
+    });
+    setDatabases((res.data || []).map((item: any) => ({ label: item.database_name, value: item.database_name })));
+  };
+
+  return (
+    <Space.Compact style={{ width: '100%' }}>
+      <Form.Item
+        name={`${prefix}_instance_id`}
+        label={`${label}实例`}
+        rules={[{ required: true, message: `请选择${label}实例` }]}
+        style={{ width: '50%' }}
+      >
+        <Select
+          showSearch
+          optionFilterProp="label"
+          options={instances}
+          placeholder="请选择实例"
+          onChange={(val) => {
+            form.setFieldValue(`${prefix}_database`, undefined);
+            fetchDatabases(val);
+          }}
+        />
+      </Form.Item>
+      <Form.Item
+        name={`${prefix}_database`}
+        label={label}
+        rules={[{ required: true, message: `请选择${label}` }]}
+        style={{ width: '50%' }}
+      >
+        <Select showSearch optionFilterProp="label" options={databases} placeholder="请选择数据库" />
+      </Form.Item>
+    </Space.Compact>
+  );
+};
+
+const SchemaMigration: React.FC = () => {
+  const [form] = Form.useForm();
+  const [taskForm] = Form.useForm();
+  const [instances, setInstances] = useState<any[]>([]);
+  const [params, setParams] = useState<SchemaMigrationParams | null>(null);
+  const [result, setResult] = useState<SchemaMigrationResult | null>(null);
+  const [generating, setGenerating] = useState(false);
+  const [taskVisible, setTaskVisible] = useState(false);
+  const [creating, setCreating] = useState(false);
+  const scope = Form.useWatch('scope', taskForm);
+
+  useEffect(() => {
+    request('/api/instances/options').then((res) => setInstances(res.data || []));
+  }, []);
+
+  const handleGenerate = async (values: SchemaMigrationParams) => {
+    setGenerating(true);
+    try {
+      const res = await generateSchemaMigration(values);
+      if (res.code !== 200) {
+        message.error(res.message || '生成迁移语句失败');
+        return;
+      }
+      setParams(values);
+      setResult(res.data);
+    } finally {
+      setGenerating(false);
+    }
+  };
+
+  const handleCreateTask = async (values: any) => {
+    if (!params) return;
+    setCreating(true);
+    try {
+      const res = await createSchemaMigrationTask({
+        ...params,
+        task_name: values.task_name,
+        description: values.description,
+        ...(values.scope === 'tags' ? { database_mode: 'tags', tag_expr: values.tag_expr } : {}),
+      });
+      if (res.code !== 200) {
+        message.error(res.message || '创建查询任务失败');
+        return;
+      }
+      message.success('查询任务已创建');
+      setTaskVisible(false);
+      history.push(`/query-task/detail/${res.data.id}`);
+    } finally {
+      setCreating(false);
+    }
+  };
+
+  const executable = !!result?.statements.some((stmt) => !stmt.startsWith('--'));
+
+  return (
+    <PageContainer ghost>
+      <Card bordered={false} style={{ marginBottom: 24 }}>
+        <Form form={form} layout="vertical" onFinish={handleGenerate}>
+          <Row gutter={24} align="middle">
+            <Col xs={24} xl={10}>
+              <DatabasePicker prefix="source" label="源库" instances={instances} />
+            </Col>
+            <Col xs={24} xl={1} style={{ textAlign: 'center' }}>
+              <SwapOutlined />
+            </Col>
+            <Col xs={24} xl={10}>
+              <DatabasePicker prefix="target" label="目标库" instances={instances} />
+            </Col>
+            <Col xs={24} xl={3}>
+              <Button type="primary" htmlType="submit" loading={generating} block>
+                生成迁移语句
+              </Button>
+            </Col>
+          </Row>
+          <Text type="secondary">实时读取两个库的表、字段和索引，生成使目标库与源库结构一致的 CREATE / ALTER / DROP 语句；两个库需为同一种数据库类型。</Text>
+        </Form>
+      </Card>
+
+      {result && (
+        <Row gutter={24}>
+          <Col xs={24} xl={12}>
+            <Card title={`差异（${result.changes.length}）`} bordered={false}>
+              <Table
+                rowKey={(c) => `${c.kind}|${c.table_name}|${c.object_name}`}
+                size="small"
+                pagination={false}
+                dataSource={result.changes}
+                columns={changeColumns}
+                locale={{ emptyText: <Empty description="两个库结构一致" /> }}
+              />
+            </Card>
+          </Col>
+          <Col xs={24} xl={12}>
+            <Card
+              title="迁移语句"
+              bordered={false}
+              extra={
+                <Button
+                  type="primary"
+                  icon={<ThunderboltOutlined />}
+                  disabled={!executable}
+                  onClick={() => {
+                    taskForm.resetFields();
+                    setTaskVisible(true);
+                  }}
+                >
+                  创建查询任务
+                </Button>
+              }
+            >
+              {result.statements.length > 0 ? (
+                <Paragraph copyable={{ text: result.sql }} style={{ marginBottom: 0 }}>
+                  <pre style={{ margin: 0, whiteSpace: 'pre-wrap' }}>{result.sql}</pre>
+                </Paragraph>
+              ) : (
+                <Empty description="无需迁移" />
+              )}
+            </Card>
+          </Col>
+        </Row>
+      )}
+
+      <Modal
+        title="创建迁移查询任务"
+        open={taskVisible}
+        onOk={() => taskForm.submit()}
+        confirmLoading={creating}
+        onCancel={() => setTaskVisible(false)}
+        width={560}
+      >
+        <Form form={taskForm} layout="vertical" onFinish={handleCreateTask} initialValues={{ scope: 'target' }}>
+          <Form.Item name="task_name" label="任务名称" rules={[{ required: true, message: '请输入任务名称' }]}>
+            <Input placeholder="同步 orders 表结构" />
+          </Form.Item>
+          <Form.Item name="description" label="任务描述">
+            <Input.TextArea rows={2} />
+          </Form.Item>
+          <Form.Item name="scope" label="执行范围">
+            <Radio.Group>
+              <Radio value="target">仅目标库</Radio>
+              <Radio value="tags">按标签选择同结构的库</Radio>
+            </Radio.Group>
+          </Form.Item>
+          {scope === 'tags' && (
+            <>
+              <Form.Item
+                name="tag_expr"
+                label="实例标签表达式"
+                tooltip="条件为 key=value、key!=value 或 key（存在该标签），可用 AND、OR、NOT 和括号组合"
+                rules={[{ required: true, whitespace: true, message: '请输入标签表达式' }]}
+              >
+                <Input placeholder="env=prod AND region!=cn" allowClear />
+              </Form.Item>
+              <Alert type="warning" showIcon message="语句按目标库的结构生成，范围内的库应与目标库结构相同，且实例需为同一种数据库类型" />
+            </>
+          )}
+        </Form>
+      </Modal>
+    </PageContainer>
+  );
+};
+
+export default SchemaMigration;
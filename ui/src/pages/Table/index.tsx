@@ -0,0 +1,153 @@
+import { PageContainer, ProTable } from '@ant-design/pro-components';
+import type { ProColumns } from '@ant-design/pro-components';
+import { Tag, Tooltip } from 'antd';
+import { useEffect, useState } from 'react';
+import { queryTableList } from '@/services/database/DatabaseController';
+import { getInstanceOptions } from '@/services/instance/InstanceController';
+import { TableInfo } from '@/services/database/typings';
+import { formatDateTime, formatFileSize } from '@/utils/format';
+
+// 表元数据：同步时采集的各表行数、大小等信息，默认按总大小倒序，用于查找全部实例中的大表
+const TablePage: React.FC = () => {
+    const [instanceOptions, setInstanceOptions] = useState<{ label: string; value: number }[]>([]);
+
+    useEffect(() => {
+        getInstanceOptions().then((res) => {
+            if (res.code === 200 && res.data) setInstanceOptions(res.data);
+        });
+    }, []);
+
+    const columns: ProColumns<TableInfo>[] = [
+        {
+            title: '表名',
+            dataIndex: 'name',
+            copyable: true,
+            ellipsis: true,
+            sorter: true,
+            render: (text) => <strong>{text}</strong>,
+        },
+        {
+            title: '数据库',
+            dataIndex: 'database_name',
+            ellipsis: true,
+            sorter: true,
+        },
+        {
+            title: '实例名称',
+            dataIndex: 'instance_id',
+            valueType: 'select',
+            fieldProps: {
+                options: instanceOptions,
+                showSearch: true,
+                filterOption: (input: string, option: { label: string; value: number } | undefined) =>
+                    (option?.label ?? '').toLowerCase().includes(input.toLowerCase()),
+            },
+            render: (_, record) => <Tag>{record.instance?.name}</Tag>,
+        },
+        {
+            title: '引擎',
+            dataIndex: 'engine',
+            width: 100,
+            render: (_, record) => record.engine || '-',
+        },
+        {
+            title: '行数(估算)',
+            dataIndex: 'rows',
+            hideInSearch: true,
+            sorter: true,
+            render: (_, record) => record.rows.toLocaleString(),
+        },
+        {
+            title: '最小行数',
+            dataIndex: 'min_rows',
+            valueType: 'digit',
+            hideInTable: true,
+        },
+        {
+            title: '数据大小',
+            dataIndex: 'data_size',
+            hideInSearch: true,
+            sorter: true,
+            render: (_, record) => formatFileSize(record.data_size),
+        },
+        {
+            title: '索引大小',
+            dataIndex: 'index_size',
+            hideInSearch: true,
+            sorter: true,
+            render: (_, record) => formatFileSize(record.index_size),
+        },
+        {
+            title: '总大小',
+            dataIndex: 'total_size',
+            hideInSearch: true,
+            sorter: true,
+            defaultSortOrder: 'descend',
+            render: (_, record) => formatFileSize(record.total_size),
+        },
+        {
+            title: '最小大小(MB)',
+            dataIndex: 'min_size_mb',
+            valueType: 'digit',
+            hideInTable: true,
+        },
+        {
+            title: '自增值',
+            dataIndex: 'auto_increment',
+            hideInSearch: true,
+            render: (_, record) => record.auto_increment ?? '-',
+        },
+        {
+            title: '更新时间',
+            dataIndex: 'update_time',
+            hideInSearch: true,
+            sorter: true,
+            render: (_, record) => (
+                <Tooltip title={record.create_time ? `建表时间：${formatDateTime(record.create_time)}` : undefined}>
+                    {record.update_time ? formatDateTime(record.update_time) : '-'}
+                </Tooltip>
+            ),
+        },
+    ];
+
+    return (
+        <PageContainer ghost>
+            <ProTable<TableInfo>
+                cardBordered
+                rowKey="id"
+                search={{ labelWidth: 120 }}
+                request={async (params, sort) => {
+                    const { current, pageSize, ...rest } = params;
+                    let sort_field = undefined;
+                    let sort_order = undefined;
+                    if (sort && Object.keys(sort).length > 0) {
+                        const fieldName = Object.keys(sort)[0];
+                        sort_field = fieldName;
+                        sort_order = sort[fieldName] === 'ascend' ? 'asc' : 'desc';
+                    }
+                    const res = await queryTableList({
+                        page: current,
+                        pageSize,
+                        sort_field,
+                        sort_order,
+                        ...rest,
+                    });
+                    return {
+                        data: res.data?.items || [],
+                        success: res.code === 200,
+                        total: res.data?.total || 0,
+                    };
+                }}
+                columns={columns}
+                pagination={{
+                    showSizeChanger: true,
+                    pageSizeOptions: ['10', '20', '50', '100'],
+                    defaultPageSize: 20,
+                    showTotal: (total) => `共 ${total} 张表`,
+                }}
+            />
+        </PageContainer>
+    );
+};
+
+export default TablePage;
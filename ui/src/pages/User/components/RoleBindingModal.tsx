@@ -0,0 +1,146 @@
+import React, { useEffect, useState } from 'react';
+import { Alert, Button, Form, Input, Modal, Select, Space, Spin, message } from 'antd';
+import { MinusCircleOutlined, PlusOutlined } from '@ant-design/icons';
+import { getUserRoles, saveUserRoles } from '@/services/auth/AuthController';
+import type { CurrentUser, Role, RoleBindingItem } from '@/services/auth/typings';
+import { getInstanceOptions } from '@/services/instance/InstanceController';
+
+type Scope = 'all' | 'instance' | 'tag';
+
+interface BindingRow {
+    role: Role;
+    scope: Scope;
+    instance_id?: number;
+    tag_expr?: string;
+}
+
+interface RoleBindingModalProps {
+    user: CurrentUser | null;
+    onClose: () => void;
+}
+
+const roleOptions = [
+    { value: 'viewer', label: 'viewer - 查看实例和任务结果' },
+    { value: 'operator', label: 'operator - 运行只读任务' },
+    { value: 'writer', label: 'writer - 运行写入任务、审批' },
+    { value: 'admin', label: 'admin - 管理实例、查看密码' },
+];
+
+const scopeOptions = [
+    { value: 'all', label: '全部实例' },
+    { value: 'instance', label: '指定实例' },
+    { value: 'tag', label: '按标签' },
+];
+
+// 用户角色授权：每条授权为角色 + 作用范围，同一实例命中多条时取最高的角色
+const RoleBindingModal: React.FC<RoleBindingModalProps> = ({ user, onClose }) => {
+    const [form] = Form.useForm();
+    const [loading, setLoading] = useState(false);
+    const [saving, setSaving] = useState(false);
+    const [instanceOptions, setInstanceOptions] = useState<{ value: number; label: string }[]>([]);
+
+    useEffect(() => {
+        if (!user) return;
+        setLoading(true);
+        Promise.all([getUserRoles(user.id), getInstanceOptions()])
+            .then(([rolesRes, instancesRes]) => {
+                if (instancesRes.code === 200) setInstanceOptions(instancesRes.data || []);
+                if (rolesRes.code !== 200) {
+                    message.error(rolesRes.message || '获取角色授权失败');
+                    return;
+                }
+                const rows: BindingRow[] = (rolesRes.data || []).map((b) => ({
+                    role: b.role,
+                    scope: b.instance_id ? 'instance' : b.tag_expr ? 'tag' : 'all',
+                    instance_id: b.instance_id || undefined,
+                    tag_expr: b.tag_expr || undefined,
+                }));
+                form.setFieldsValue({ bindings: rows });
+            })
+            .finally(() => setLoading(false));
+    }, [user]);
+
+    const handleSave = async () => {
+        if (!user) return;
+        const values: { bindings?: BindingRow[] } = await form.validateFields();
+        const bindings: RoleBindingItem[] = (values.bindings || []).map((row) => ({
+            role: row.role,
+            instance_id: row.scope === 'instance' ? row.instance_id : 0,
+            tag_expr: row.scope === 'tag' ? row.tag_expr : '',
+        }));
+        setSaving(true);
+        try {
+            const res = await saveUserRoles(user.id, { bindings });
+            if (res.code === 200) {
+                message.success(res.message || '角色授权已保存');
+                onClose();
+            } else {
+                message.error(res.message || '保存失败');
+            }
+        } finally {
+            setSaving(false);
+        }
+    };
+
+    return (
+        <Modal
+            title={user ? `角色授权 ${user.username}` : '角色授权'}
+            open={!!user}
+            width={760}
+            confirmLoading={saving}
+            onOk={handleSave}
+            onCancel={onClose}
+            destroyOnClose
+        >
+            {user?.is_admin && (
+                <Alert type="info" showIcon style={{ marginBottom: 12 }} message="管理员拥有全部实例的全部权限，授权对其不生效。" />
+            )}
+            <Spin spinning={loading}>
+                <Form form={form} preserve={false}>
+                    <Form.List name="bindings">
+                        {(fields, { add, remove }) => (
+                            <>
+                                {fields.map((field) => (
+                                    <Space key={field.key} align="baseline" style={{ display: 'flex' }}>
+                                        <Form.Item name={[field.name, 'role']} rules={[{ required: true, message: '请选择角色' }]}>
+                                            <Select style={{ width: 230 }} options={roleOptions} placeholder="角色" />
+                                        </Form.Item>
+                                        <Form.Item name={[field.name, 'scope']} initialValue="all">
+                                            <Select style={{ width: 110 }} options={scopeOptions} />
+                                        </Form.Item>
+                                        <Form.Item noStyle shouldUpdate>
+                                            {() => {
+                                                const scope = form.getFieldValue(['bindings', field.name, 'scope']);
+                                                if (scope === 'instance') {
+                                                    return (
+                                                        <Form.Item name={[field.name, 'instance_id']} rules={[{ required: true, message: '请选择实例' }]}>
+                                                            <Select style={{ width: 260 }} showSearch optionFilterProp="label" options={instanceOptions} placeholder="实例" />
+                                                        </Form.Item>
+                                                    );
+                                                }
+                                                if (scope === 'tag') {
+                                                    return (
+                                                        <Form.Item name={[field.name, 'tag_expr']} rules={[{ required: true, message: '请输入标签表达式' }]}>
+                                                            <Input style={{ width: 260 }} placeholder="如 env=staging AND team=pay" />
+                                                        </Form.Item>
+                                                    );
+                                                }
+                                                return null;
+                                            }}
+                                        </Form.Item>
+                                        <MinusCircleOutlined onClick={() => remove(field.name)} />
+                                    </Space>
+                                ))}
+                                <Button type="dashed" block icon={<PlusOutlined />} onClick={() => add({ role: 'viewer', scope: 'all' })}>
+                                    添加授权
+                                </Button>
+                            </>
+                        )}
+                    </Form.List>
+                </Form>
+            </Spin>
+        </Modal>
+    );
+};
+
+export default RoleBindingModal;
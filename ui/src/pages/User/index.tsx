@@ -0,0 +1,163 @@
+import React, { useEffect, useState } from 'react';
+import { PageContainer } from '@ant-design/pro-components';
+import { Button, Card, Checkbox, Form, Input, Modal, Popconfirm, Space, Table, Tag, message } from 'antd';
+import { PlusOutlined } from '@ant-design/icons';
+import { useModel } from '@umijs/max';
+import { createUser, deleteUser, listUsers, updateUser } from '@/services/auth/AuthController';
+import type { CurrentUser, SaveUserRequest } from '@/services/auth/typings';
+import { formatDateTime } from '@/utils/format';
+import RoleBindingModal from './components/RoleBindingModal';
+
+const UserPage: React.FC = () => {
+    const { initialState } = useModel('@@initialState');
+    const [form] = Form.useForm();
+    const [users, setUsers] = useState<CurrentUser[]>([]);
+    const [loading, setLoading] = useState(false);
+    const [editing, setEditing] = useState<CurrentUser | null>(null);
+    const [modalOpen, setModalOpen] = useState(false);
+    const [saving, setSaving] = useState(false);
+    const [roleUser, setRoleUser] = useState<CurrentUser | null>(null);
+
+    const fetchUsers = async () => {
+        setLoading(true);
+        try {
+            const res = await listUsers();
+            if (res.code === 200) setUsers(res.data || []);
+            else message.error(res.message || '获取用户列表失败');
+        } finally {
+            setLoading(false);
+        }
+    };
+
+    useEffect(() => {
+        fetchUsers();
+    }, []);
+
+    const openModal = (user: CurrentUser | null) => {
+        setEditing(user);
+        form.resetFields();
+        form.setFieldsValue(user ? { display_name: user.display_name, is_admin: user.is_admin, disabled: user.disabled } : { is_admin: false });
+        setModalOpen(true);
+    };
+
+    const handleSave = async () => {
+        const values: SaveUserRequest = await form.validateFields();
+        setSaving(true);
+        try {
+            const res = editing ? await updateUser(editing.id, values) : await createUser(values);
+            if (res.code === 200) {
+                message.success(editing ? '用户已更新' : '用户已创建');
+                setModalOpen(false);
+                fetchUsers();
+            } else {
+                message.error(res.message || '保存失败');
+            }
+        } finally {
+            setSaving(false);
+        }
+    };
+
+    const handleDelete = async (id: number) => {
+        const res = await deleteUser(id);
+        if (res.code === 200) {
+            message.success(res.message || '用户已删除');
+            fetchUsers();
+        } else {
+            message.error(res.message || '删除失败');
+        }
+    };
+
+    const isSelf = (user: CurrentUser) => user.id === initialState?.currentUser?.id;
+
+    const columns = [
+        { title: '用户名', dataIndex: 'username' },
+        { title: '显示名称', dataIndex: 'display_name', render: (v: string) => v || '-' },
+        {
+            title: '角色',
+            dataIndex: 'is_admin',
+            width: 100,
+            render: (v: boolean) => v ? <Tag color="gold">管理员</Tag> : <Tag>按授权</Tag>,
+        },
+        {
+            title: '状态',
+            dataIndex: 'disabled',
+            width: 90,
+            render: (v: boolean) => v ? <Tag color="error">已禁用</Tag> : <Tag color="success">正常</Tag>,
+        },
+        {
+            title: '最后登录',
+            dataIndex: 'last_login_at',
+            width: 170,
+            render: (v?: string) => v ? formatDateTime(v) : '-',
+        },
+        {
+            title: '操作',
+            key: 'action',
+            width: 180,
+            render: (_: any, record: CurrentUser) => (
+                <Space>
+                    <a onClick={() => openModal(record)}>编辑</a>
+                    {!record.is_admin && <a onClick={() => setRoleUser(record)}>授权</a>}
+                    {!isSelf(record) && (
+                        <Popconfirm title="确定删除该用户吗？其会话和 API 令牌将一并失效" onConfirm={() => handleDelete(record.id)}>
+                            <a style={{ color: '#ff4d4f' }}>删除</a>
+                        </Popconfirm>
+                    )}
+                </Space>
+            ),
+        },
+    ];
+
+    return (
+        <PageContainer ghost>
+            <Card
+                title="用户管理"
+                extra={<Button type="primary" icon={<PlusOutlined />} onClick={() => openModal(null)}>新建用户</Button>}
+            >
+                <Table rowKey="id" loading={loading} columns={columns} dataSource={users} pagination={false} />
+            </Card>
+            <Modal
+                title={editing ? `编辑用户 ${editing.username}` : '新建用户'}
+                open={modalOpen}
+                confirmLoading={saving}
+                onOk={handleSave}
+                onCancel={() => setModalOpen(false)}
+                destroyOnClose
+            >
+                <Form form={form} layout="vertical" preserve={false}>
+                    {!editing && (
+                        <Form.Item name="username" label="用户名" rules={[{ required: true, message: '请输入用户名' }]}>
+                            <Input placeholder="登录使用的用户名" />
+                        </Form.Item>
+                    )}
+                    <Form.Item name="display_name" label="显示名称">
+                        <Input placeholder="可选" />
+                    </Form.Item>
+                    <Form.Item
+                        name="password"
+                        label={editing ? '重置密码' : '密码'}
+                        rules={[
+                            { required: !editing, message: '请输入密码' },
+                            { min: 8, message: '密码长度不能少于 8 位' },
+                        ]}
+                    >
+                        <Input.Password placeholder={editing ? '留空表示不修改，重置后该用户需重新登录' : '至少 8 位'} />
+                    </Form.Item>
+                    <Space size={24}>
+                        <Form.Item name="is_admin" valuePropName="checked" noStyle>
+                            <Checkbox disabled={!!editing && isSelf(editing)}>管理员</Checkbox>
+                        </Form.Item>
+                        {editing && (
+                            <Form.Item name="disabled" valuePropName="checked" noStyle>
+                                <Checkbox disabled={isSelf(editing)}>禁用</Checkbox>
+                            </Form.Item>
+                        )}
+                    </Space>
+                </Form>
+            </Modal>
+            <RoleBindingModal user={roleUser} onClose={() => setRoleUser(null)} />
+        </PageContainer>
+    );
+};
+
+export default UserPage;
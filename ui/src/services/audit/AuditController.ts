@@ -0,0 +1,29 @@
+import { request } from '@umijs/max';
+import type { APIResponse } from '@/services/auth/typings';
+import type { AuditListParams, AuditListResult, AuditVerifyResult } from './typings.d';
+
+/** 查询审计事件 GET /api/audit */
+export async function listAuditEvents(params: AuditListParams) {
+    return request<APIResponse<AuditListResult>>('/api/audit', {
+        method: 'GET',
+        params,
+    });
+}
+
+/** 校验审计哈希链 GET /api/audit/verify */
+export async function verifyAuditChain() {
+    return request<APIResponse<AuditVerifyResult>>('/api/audit/verify', {
+        method: 'GET',
+    });
+}
+
+/** 审计事件导出地址 GET /api/audit/export，按相同条件导出 JSONL */
+export function auditExportURL(params: AuditListParams) {
+    const query = new URLSearchParams();
+    Object.entries(params).forEach(([key, value]) => {
+        if (value !== undefined && value !== null && value !== '' && key !== 'page' && key !== 'pageSize') {
+            query.set(key, String(value));
+        }
+    });
+    return `/api/audit/export?${query.toString()}`;
+}
@@ -0,0 +1,37 @@
+export interface AuditEvent {
+    id: number;
+    created_at: string;
+    actor_id: number;
+    actor: string;
+    ip: string;
+    action: string;
+    target_type: string;
+    target_ids: string;
+    detail: any;
+    prev_hash: string;
+    hash: string;
+}
+
+export interface AuditListParams {
+    page?: number;
+    pageSize?: number;
+    actor?: string;
+    action?: string;
+    target_type?: string;
+    target_id?: number;
+    ip?: string;
+    start?: string;
+    end?: string;
+}
+
+export interface AuditListResult {
+    total: number;
+    items: AuditEvent[];
+}
+
+export interface AuditVerifyResult {
+    total: number;
+    valid: boolean;
+    broken_id: number;
+    message: string;
+}
@@ -0,0 +1,100 @@
+import { request } from '@umijs/max';
+import type { APIResponse, APIToken, ChangePasswordRequest, CreateAPITokenRequest, CreateAPITokenResult, CurrentUser, LoginRequest, LoginResult, RoleBinding, SaveRoleBindingsRequest, SaveUserRequest } from './typings.d';
+
+/** 登录 POST /api/auth/login */
+export async function login(data: LoginRequest) {
+    return request<APIResponse<LoginResult>>('/api/auth/login', {
+        method: 'POST',
+        data,
+    });
+}
+
+/** 退出登录 POST /api/auth/logout */
+export async function logout() {
+    return request<APIResponse>('/api/auth/logout', {
+        method: 'POST',
+    });
+}
+
+/** 获取当前用户 GET /api/auth/me，skipAuthRedirect 避免未登录时重复跳转 */
+export async function getCurrentUser() {
+    return request<APIResponse<CurrentUser>>('/api/auth/me', {
+        method: 'GET',
+        skipAuthRedirect: true,
+    });
+}
+
+/** 修改密码 POST /api/auth/password */
+export async function changePassword(data: ChangePasswordRequest) {
+    return request<APIResponse>('/api/auth/password', {
+        method: 'POST',
+        data,
+    });
+}
+
+/** 获取 API 令牌 GET /api/auth/tokens */
+export async function listAPITokens() {
+    return request<APIResponse<APIToken[]>>('/api/auth/tokens', {
+        method: 'GET',
+    });
+}
+
+/** 创建 API 令牌 POST /api/auth/tokens */
+export async function createAPIToken(data: CreateAPITokenRequest) {
+    return request<APIResponse<CreateAPITokenResult>>('/api/auth/tokens', {
+        method: 'POST',
+        data,
+    });
+}
+
+/** 删除 API 令牌 DELETE /api/auth/tokens/${id} */
+export async function deleteAPIToken(id: number) {
+    return request<APIResponse>(`/api/auth/tokens/${id}`, {
+        method: 'DELETE',
+    });
+}
+
+/** 获取用户列表 GET /api/users */
+export async function listUsers() {
+    return request<APIResponse<CurrentUser[]>>('/api/users', {
+        method: 'GET',
+    });
+}
+
+/** 创建用户 POST /api/users */
+export async function createUser(data: SaveUserRequest) {
+    return request<APIResponse<CurrentUser>>('/api/users', {
+        method: 'POST',
+        data,
+    });
+}
+
+/** 更新用户 PUT /api/users/${id} */
+export async function updateUser(id: number, data: SaveUserRequest) {
+    return request<APIResponse<CurrentUser>>(`/api/users/${id}`, {
+        method: 'PUT',
+        data,
+    });
+}
+
+/** 删除用户 DELETE /api/users/${id} */
+export async function deleteUser(id: number) {
+    return request<APIResponse>(`/api/users/${id}`, {
+        method: 'DELETE',
+    });
+}
+
+/** 获取用户的角色授权 GET /api/users/${id}/roles */
+export async function getUserRoles(id: number) {
+    return request<APIResponse<RoleBinding[]>>(`/api/users/${id}/roles`, {
+        method: 'GET',
+    });
+}
+
+/** 保存用户的角色授权 PUT /api/users/${id}/roles */
+export async function saveUserRoles(id: number, data: SaveRoleBindingsRequest) {
+    return request<APIResponse<RoleBinding[]>>(`/api/users/${id}/roles`, {
+        method: 'PUT',
+        data,
+    });
+}
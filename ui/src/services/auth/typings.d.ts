@@ -0,0 +1,77 @@
+export interface APIResponse<T = any> {
+    code: number;
+    message: string;
+    data: T;
+}
+
+export interface CurrentUser {
+    id: number;
+    created_at: string;
+    updated_at: string;
+    username: string;
+    display_name: string;
+    is_admin: boolean;
+    disabled: boolean;
+    last_login_at?: string;
+}
+
+export interface LoginRequest {
+    username: string;
+    password: string;
+}
+
+export interface LoginResult {
+    user: CurrentUser;
+    expires_at: string;
+}
+
+export interface ChangePasswordRequest {
+    old_password: string;
+    new_password: string;
+}
+
+export interface APIToken {
+    id: number;
+    created_at: string;
+    user_id: number;
+    name: string;
+    prefix: string;
+    expires_at?: string;
+    last_used_at?: string;
+}
+
+export interface CreateAPITokenRequest {
+    name: string;
+    expires_in_days?: number;
+}
+
+export interface CreateAPITokenResult {
+    token: string;
+    item: APIToken;
+}
+
+export interface SaveUserRequest {
+    username?: string;
+    password?: string;
+    display_name?: string;
+    is_admin?: boolean;
+    disabled?: boolean;
+}
+
+export type Role = 'viewer' | 'operator' | 'writer' | 'admin';
+
+export interface RoleBindingItem {
+    role: Role;
+    instance_id?: number;
+    tag_expr?: string;
+}
+
+export interface RoleBinding extends RoleBindingItem {
+    id: number;
+    created_at: string;
+    user_id: number;
+}
+
+export interface SaveRoleBindingsRequest {
+    bindings: RoleBindingItem[];
+}
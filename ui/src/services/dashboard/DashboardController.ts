@@ -1,9 +1,27 @@
 import { request } from '@umijs/max';
-import { Result_DashboardStats_ } from './typings';
+import { Result_DashboardStats_, Result_SizeGrowth_, Result_SizeGrowthItems_, SizeGrowthParams, TopGrowingParams } from './typings';
 
 export async function getDashboardStats(options?: { [key: string]: any }) {
     return request<Result_DashboardStats_>('/api/dashboard/stats', {
         method: 'GET',
         ...(options || {}),
     });
-} 
\ No newline at end of file
+}
+
+/** 获取数据库或表的大小趋势，不指定库时按天汇总 */
+export async function getSizeGrowth(params: SizeGrowthParams, options?: { [key: string]: any }) {
+    return request<Result_SizeGrowth_>('/api/size-history/growth', {
+        method: 'GET',
+        params,
+        ...(options || {}),
+    });
+}
+
+/** 获取增长最多的数据库或表 */
+export async function getTopGrowing(params: TopGrowingParams, options?: { [key: string]: any }) {
+    return request<Result_SizeGrowthItems_>('/api/size-history/top-growing', {
+        method: 'GET',
+        params,
+        ...(options || {}),
+    });
+}
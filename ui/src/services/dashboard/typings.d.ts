@@ -11,6 +11,7 @@ export interface TaskSummary {
     running: number;
     completed: number;
     failed: number;
+    queued: number;
 }
 
 export interface DashboardStats {
@@ -24,4 +25,53 @@ export interface Result_DashboardStats_ {
     code: number;
     message: string;
     data: DashboardStats;
-} 
\ No newline at end of file
+} 
+export interface SizePoint {
+    at: string;
+    size: number;
+    rows: number;
+}
+
+export interface SizeGrowth {
+    points: SizePoint[];
+    start_size: number;
+    end_size: number;
+    growth: number;
+}
+
+export interface SizeGrowthParams {
+    instance_id?: number;
+    database_name?: string;
+    table_name?: string;
+    days?: number;
+}
+
+export interface SizeGrowthItem {
+    instance_id: number;
+    instance_name: string;
+    database_name: string;
+    table_name: string;
+    start_size: number;
+    end_size: number;
+    growth: number;
+    start_at: string;
+    end_at: string;
+}
+
+export interface TopGrowingParams {
+    days?: number;
+    limit?: number;
+    level?: 'database' | 'table';
+}
+
+export interface Result_SizeGrowth_ {
+    code: number;
+    message: string;
+    data: SizeGrowth;
+}
+
+export interface Result_SizeGrowthItems_ {
+    code: number;
+    message: string;
+    data: SizeGrowthItem[];
+}
@@ -1,5 +1,5 @@
 import { request } from '@umijs/max';
-import { DatabaseInfo, Result_PageInfo_DatabaseInfo__, Result_DatabaseInfo_ } from './typings';
+import { DatabaseInfo, Result_PageInfo_DatabaseInfo__, Result_DatabaseInfo_, Result_PageInfo_TableInfo__, TableInfo } from './typings';
 
 /** 获取数据库列表 GET /api/databases */
 export async function queryDatabaseList(
@@ -42,4 +42,31 @@ export async function batchQueryDatabaseList(instance_ids: number[]) {
         method: 'POST',
         data: { instance_ids },
     });
-} 
\ No newline at end of file
+}
+
+/** 查询表元数据 GET /api/tables，默认按总大小倒序 */
+export async function queryTableList(params: {
+    instance_id?: number;
+    database_name?: string;
+    name?: string;
+    engine?: string;
+    min_size_mb?: number;
+    min_rows?: number;
+    page?: number;
+    pageSize?: number;
+    sort_field?: string;
+    sort_order?: string;
+}) {
+    return request<Result_PageInfo_TableInfo__>('/api/tables', {
+        method: 'GET',
+        params,
+    });
+}
+
+/** 获取全部实例中最大的表 GET /api/tables/largest */
+export async function queryLargestTables(params: { limit?: number; by?: 'total_size' | 'rows' }) {
+    return request<{ code: number; message: string; data: TableInfo[] }>('/api/tables/largest', {
+        method: 'GET',
+        params,
+    });
+}
@@ -29,4 +29,33 @@ export interface DatabaseInfo {
         id: number;
         name: string;
     };
-} 
\ No newline at end of file
+} 
+export interface TableInfo {
+    id: number;
+    instance_id: number;
+    database_name: string;
+    name: string;
+    engine: string;
+    collation: string;
+    rows: number;
+    data_size: number;
+    index_size: number;
+    total_size: number;
+    auto_increment: number | null;
+    create_time: string | null;
+    update_time: string | null;
+    synced_at: string;
+    instance: {
+        id: number;
+        name: string;
+    };
+}
+
+export interface Result_PageInfo_TableInfo__ {
+    code: number;
+    message: string;
+    data: {
+        total: number;
+        items: Array<TableInfo>;
+    };
+}
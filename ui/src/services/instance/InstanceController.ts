@@ -1,5 +1,5 @@
 import { request } from '@umijs/max';
-import { InstanceInfo, InstanceInfoVO, Result_InstanceInfo_, Result_InstancePasswordResponse_, Result_PageInfo_InstanceInfo__, Result_string_, APIResponse } from './typings';
+import { InstanceInfo, InstanceInfoVO, Result_InstanceInfo_, Result_InstancePasswordResponse_, Result_PageInfo_InstanceInfo__, Result_string_, Result_SyncSummary_, APIResponse, SSHTunnel, InstanceTLS, TestConnectionResult, InstanceEngine, InstanceTags, InstanceTagOption } from './typings';
 
 /** 获取实例列表 GET /api/instances */
 export async function queryInstanceList(
@@ -11,6 +11,8 @@ export async function queryInstanceList(
     page?: number;
     /** pageSize */
     pageSize?: number;
+    /** 标签表达式，如 env=prod AND region!=cn */
+    tags?: string;
   },
   options?: { [key: string]: any },
 ) {
@@ -131,15 +133,22 @@ export async function batchDeleteInstances(
 /** 测试数据库连接 POST /api/instances/test-connection */
 export async function testConnection(
   body?: {
+    id?: number;
+    engine?: InstanceEngine;
+    connect_database?: string;
+    file_glob?: string;
     host: string;
     port: number;
     username: string;
     password?: string;
     params?: Array<Record<string, string>>;
+    ssh?: SSHTunnel;
+    tls?: InstanceTLS;
+    clear_secrets?: string[];
   },
   options?: { [key: string]: any },
 ) {
-  return request<Result_string_>('/api/instances/test-connection', {
+  return request<APIResponse<TestConnectionResult>>('/api/instances/test-connection', {
     method: 'POST',
     headers: {
       'Content-Type': 'application/json',
@@ -156,7 +165,7 @@ export async function syncDatabases(
   },
   options?: { [key: string]: any },
 ) {
-  return request<Result_string_>('/api/instances/sync-databases', {
+  return request<Result_SyncSummary_>('/api/instances/sync-databases', {
     method: 'POST',
     headers: {
       'Content-Type': 'application/json',
@@ -175,4 +184,30 @@ export async function getInstanceOptions() {
     return request<APIResponse<InstanceOption[]>>('/api/instances/options', {
         method: 'GET',
     });
-} 
+}
+
+/** 获取已使用的标签 GET /api/instances/tags */
+export async function getInstanceTagOptions() {
+    return request<APIResponse<InstanceTagOption[]>>('/api/instances/tags', {
+        method: 'GET',
+    });
+}
+
+/** 批量修改实例标签 POST /api/instances/batch-tags */
+export async function batchTagInstances(
+  body: {
+    instance_ids: number[];
+    set?: InstanceTags;
+    remove?: string[];
+  },
+  options?: { [key: string]: any },
+) {
+  return request<Result_string_>('/api/instances/batch-tags', {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+    },
+    data: body,
+    ...(options || {}),
+  });
+}
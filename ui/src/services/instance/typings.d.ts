@@ -21,36 +21,133 @@ export interface Result_InstancePasswordResponse_ {
   data: InstancePasswordResponse;
 }
 
+export interface SyncResult {
+  instance_id: number;
+  instance_name: string;
+  success: boolean;
+  duration_ms: number;
+  error?: string;
+}
+
+export interface SyncSummary {
+  total: number;
+  succeeded: number;
+  failed: number;
+  duration_ms: number;
+  results: SyncResult[];
+}
+
+export interface Result_SyncSummary_ {
+  code: number;
+  message: string;
+  data: SyncSummary;
+}
+
 export interface Result_string_ {
   code: number;
   message: string;
   data: string;
 }
 
+export type InstanceEngine = 'mysql' | 'postgres' | 'sqlite';
+
 export interface InstanceInfo {
   id: number;
   name: string;
+  engine: InstanceEngine;
+  connect_database?: string;
+  file_glob?: string;
   host: string;
   port: number;
   username: string;
   version: string;
   params: Array<Record<string, string>>;
   remark: string;
+  tags?: InstanceTags | null;
+  protection_mode?: InstanceProtectionMode;
   created_at: string;
   updated_at: string;
   sync_interval: number;
   last_sync_at?: string | null;
+  last_sync_status?: number;
+  last_sync_error?: string;
+  last_sync_duration_ms?: number;
+  max_connections: number;
+  max_concurrency: number;
+  rate_limit: number;
+  replica_ids: number[] | null;
+  lag_check_query: string;
+  max_replica_lag_sec: number;
+  lag_check_fail_open: boolean;
+  ssh?: SSHTunnel;
+  tls?: InstanceTLS;
+  created_by?: number;
+  created_by_name?: string;
 }
 
 export interface InstanceInfoVO {
   name: string;
+  engine?: InstanceEngine;
+  connect_database?: string;
+  file_glob?: string;
   host: string;
   port: number;
   username: string;
   password?: string;
   params: Array<Record<string, string>>;
   remark: string;
+  tags?: InstanceTags;
+  protection_mode?: InstanceProtectionMode;
   sync_interval: number;
+  max_connections?: number;
+  max_concurrency?: number;
+  rate_limit?: number;
+  replica_ids?: number[];
+  lag_check_query?: string;
+  max_replica_lag_sec?: number;
+  lag_check_fail_open?: boolean;
+  ssh?: SSHTunnel;
+  tls?: InstanceTLS;
+  clear_secrets?: string[]; // 编辑时需清除的已保存敏感字段
+}
+
+// 实例保护模式：不限制、写入需审批、只读
+export type InstanceProtectionMode = 'unrestricted' | 'approval' | 'readonly';
+
+// 实例标签，如 { env: 'prod', region: 'eu' }
+export type InstanceTags = Record<string, string>;
+
+// 已使用的标签键及其取值
+export interface InstanceTagOption {
+  key: string;
+  values: string[];
+}
+
+// SSH 隧道配置，接口返回时不含密码、私钥
+export interface SSHTunnel {
+  enabled: boolean;
+  host?: string;
+  port?: number;
+  user?: string;
+  password?: string;
+  private_key?: string;
+  key_passphrase?: string;
+  known_hosts?: string;
+  skip_host_key_check?: boolean;
+}
+
+// TLS 配置，接口返回时不含客户端私钥
+export interface InstanceTLS {
+  mode: '' | 'disabled' | 'preferred' | 'required' | 'verify-ca' | 'verify-identity';
+  ca?: string;
+  cert?: string;
+  key?: string;
+}
+
+export interface TestConnectionResult {
+  version: string;
+  tls_version: string;
+  tls_cipher: string;
 }
 
 export interface InstancePasswordResponse {
@@ -1,5 +1,5 @@
 import { request } from '@umijs/max';
-import type { QueryTaskInfo, Result_PageInfo_QueryTaskInfo__, CreateQueryTaskRequest, Result_QueryTaskInfo_, Result_PageInfo_QueryTaskSQLInfo__ } from './typings.d';
+import type { QueryTaskInfo, Result_PageInfo_QueryTaskInfo__, CreateQueryTaskRequest, PreviewTargetsRequest, PreviewTargetsResult, Result_QueryTaskInfo_, Result_PageInfo_QueryTaskSQLInfo__, ReviewQueryTaskRequest, QueryTaskReview } from './typings.d';
 
 /** 获取查询任务列表 GET /api/query-tasks */
 export async function queryQueryTaskList(
@@ -43,6 +43,14 @@ export async function createQueryTask(
     });
 }
 
+/** 预览目标数据库 POST /api/query-tasks/preview-targets */
+export async function previewTargets(data: PreviewTargetsRequest) {
+    return request<{ code: number; message: string; data: PreviewTargetsResult }>('/api/query-tasks/preview-targets', {
+        method: 'POST',
+        data,
+    });
+}
+
 /** 获取查询任务详情 GET /api/query-tasks/${id} */
 export async function getQueryTaskDetail(id: number) {
     return request<Result_QueryTaskInfo_>(`/api/query-tasks/${id}`, {
@@ -64,10 +72,34 @@ export async function getQueryTaskSQLExecutions(taskId: number) {
     });
 }
 
-/** 开始查询任务 POST /api/query-tasks/${id}/run */
-export async function runQueryTask(id: number) {
+/** 开始查询任务 POST /api/query-tasks/${id}/run，reResolve 为 true 时先按选择规则重新确定目标 */
+export async function runQueryTask(id: number, reResolve?: boolean) {
     return request<any>(`/api/query-tasks/${id}/run`, {
         method: 'POST',
+        params: reResolve ? { re_resolve: true } : undefined,
+    });
+}
+
+/** 审批通过任务 POST /api/query-tasks/${id}/approve */
+export async function approveQueryTask(id: number, data: ReviewQueryTaskRequest) {
+    return request<any>(`/api/query-tasks/${id}/approve`, {
+        method: 'POST',
+        data,
+    });
+}
+
+/** 驳回任务 POST /api/query-tasks/${id}/reject */
+export async function rejectQueryTask(id: number, data: ReviewQueryTaskRequest) {
+    return request<any>(`/api/query-tasks/${id}/reject`, {
+        method: 'POST',
+        data,
+    });
+}
+
+/** 获取任务审批记录 GET /api/query-tasks/${id}/reviews */
+export async function getQueryTaskReviews(id: number) {
+    return request<{ code: number; message: string; data: QueryTaskReview[] }>(`/api/query-tasks/${id}/reviews`, {
+        method: 'GET',
     });
 }
 
@@ -32,6 +32,32 @@ export interface QueryTaskInfo {
     completed_at?: string;
     description: string;
     is_favorite: boolean;
+    queue_position: number;
+    target_rule?: TargetRule;
+    created_by: number;
+    created_by_name: string;
+}
+
+// 任务目标选择规则，tags、pattern、schema 模式每次运行前重新解析目标
+export interface TargetRule {
+    mode: DatabaseMode;
+    tag_expr?: string;
+    instance_ids?: number[];
+    filter?: DatabaseFilter;
+    require?: SchemaRequirement;
+}
+
+export type DatabaseMode = 'include' | 'exclude' | 'tags' | 'pattern' | 'schema';
+
+// 按名称模式选择数据库的筛选条件，名称模式支持 tenant_% 通配符或 /正则/
+export interface DatabaseFilter {
+    include_patterns?: string[];
+    exclude_patterns?: string[];
+    min_size_mb?: number;
+    max_size_mb?: number;
+    min_tables?: number;
+    max_tables?: number;
+    charset?: string;
 }
 
 // 创建查询任务相关类型
@@ -54,9 +80,28 @@ export interface CreateQueryTaskRequest {
     task_name: string;
     description?: string;
     instance_ids: number[];
-    database_mode: 'include' | 'exclude';
+    database_mode: DatabaseMode;
     selected_dbs: TaskDatabase[];
+    tag_expr?: string;
+    filter?: DatabaseFilter;
+    require?: SchemaRequirement;
     sql_content: string;
+    chunk_size?: number;
+    chunk_sleep_ms?: number;
+}
+
+// 按表结构选择数据库的条件，字段写作 表名.字段名，探测语句首行首列为真值时选中
+export interface SchemaRequirement {
+    tables?: string[];
+    columns?: string[];
+    probe_sql?: string;
+}
+
+export type PreviewTargetsRequest = Pick<CreateQueryTaskRequest, 'instance_ids' | 'database_mode' | 'selected_dbs' | 'tag_expr' | 'filter' | 'require'>;
+
+export interface PreviewTargetsResult {
+    total: number;
+    items: (TaskDatabase & { instance_name?: string })[];
 }
 
 // SQL语句相关类型
@@ -85,4 +130,21 @@ export interface Result_PageInfo_QueryTaskSQLInfo__ {
     code: number;
     message: string;
     data: PageInfo_QueryTaskSQLInfo_;
-} 
\ No newline at end of file
+} 
+// 审批相关类型
+export interface QueryTaskReview {
+    id: number;
+    created_at: string;
+    task_id: number;
+    decision: 1 | 2; // 1-通过，2-驳回
+    reviewer_id: number;
+    reviewer: string;
+    comment: string;
+    sql_hash: string;
+    target_hash: string;
+    target_count: number;
+}
+
+export interface ReviewQueryTaskRequest {
+    comment?: string;
+}
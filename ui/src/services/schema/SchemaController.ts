@@ -0,0 +1,51 @@
+import { request } from '@umijs/max';
+import type { Result_QueryTaskInfo_ } from '@/services/queryTask/typings';
+import {
+    CreateSchemaMigrationTaskParams,
+    Result_SchemaChangeList_,
+    Result_SchemaDiff_,
+    Result_SchemaMigration_,
+    Result_SchemaVersionList_,
+    SchemaListParams,
+    SchemaMigrationParams,
+} from './typings';
+
+/** 查询库的结构版本 GET /api/schema/versions */
+export async function listSchemaVersions(params: SchemaListParams) {
+    return request<Result_SchemaVersionList_>('/api/schema/versions', {
+        method: 'GET',
+        params,
+    });
+}
+
+/** 查询结构变更记录 GET /api/schema/changes */
+export async function listSchemaChanges(params: SchemaListParams) {
+    return request<Result_SchemaChangeList_>('/api/schema/changes', {
+        method: 'GET',
+        params,
+    });
+}
+
+/** 比较两个结构版本 GET /api/schema/diff */
+export async function diffSchemaVersions(from: number, to: number) {
+    return request<Result_SchemaDiff_>('/api/schema/diff', {
+        method: 'GET',
+        params: { from, to },
+    });
+}
+
+/** 比较两个库并生成迁移语句 POST /api/schema/migration */
+export async function generateSchemaMigration(data: SchemaMigrationParams) {
+    return request<Result_SchemaMigration_>('/api/schema/migration', {
+        method: 'POST',
+        data,
+    });
+}
+
+/** 将迁移语句创建为查询任务 POST /api/schema/migration/query-task */
+export async function createSchemaMigrationTask(data: CreateSchemaMigrationTaskParams) {
+    return request<Result_QueryTaskInfo_>('/api/schema/migration/query-task', {
+        method: 'POST',
+        data,
+    });
+}
@@ -0,0 +1,115 @@
+export interface SchemaColumn {
+    name: string;
+    type: string;
+    nullable: boolean;
+    default: string | null;
+    comment: string;
+    collation?: string;
+    extra?: string;
+    generated?: string;
+}
+
+export interface SchemaIndex {
+    name: string;
+    columns: string[];
+    unique: boolean;
+    primary: boolean;
+}
+
+export interface SchemaTable {
+    name: string;
+    comment: string;
+    columns: SchemaColumn[];
+    indexes: SchemaIndex[];
+}
+
+export interface SchemaVersion {
+    id: number;
+    instance_id: number;
+    database_name: string;
+    version: number;
+    fingerprint: string;
+    table_count: number;
+    tables?: SchemaTable[];
+    captured_at: string;
+    last_seen_at: string;
+}
+
+export interface SchemaChange {
+    id?: number;
+    instance_id?: number;
+    database_name?: string;
+    from_version_id?: number;
+    to_version_id?: number;
+    kind: string;
+    table_name: string;
+    object_name: string;
+    before: string;
+    after: string;
+    detected_at?: string;
+    instance?: { id: number; name: string };
+}
+
+export interface SchemaDiff {
+    from: SchemaVersion;
+    to: SchemaVersion;
+    changes: SchemaChange[];
+}
+
+export interface SchemaListParams {
+    instance_id?: number;
+    database_name?: string;
+    kind?: string;
+    table_name?: string;
+    page?: number;
+    pageSize?: number;
+}
+
+export interface Result_SchemaVersionList_ {
+    code: number;
+    message: string;
+    data: { total: number; items: SchemaVersion[] };
+}
+
+export interface Result_SchemaChangeList_ {
+    code: number;
+    message: string;
+    data: { total: number; items: SchemaChange[] };
+}
+
+export interface Result_SchemaDiff_ {
+    code: number;
+    message: string;
+    data: SchemaDiff;
+}
+
+export interface SchemaMigrationParams {
+    source_instance_id: number;
+    source_database: string;
+    target_instance_id: number;
+    target_database: string;
+}
+
+export interface SchemaMigration {
+    dialect: string;
+    source_fingerprint: string;
+    target_fingerprint: string;
+    changes: SchemaChange[];
+    statements: string[];
+    sql: string;
+}
+
+export interface CreateSchemaMigrationTaskParams extends SchemaMigrationParams {
+    task_name: string;
+    description?: string;
+    database_mode?: 'include' | 'exclude' | 'tags' | 'pattern' | 'schema';
+    instance_ids?: number[];
+    selected_dbs?: { instance_id: number; database_name: string }[];
+    tag_expr?: string;
+}
+
+export interface Result_SchemaMigration_ {
+    code: number;
+    message: string;
+    data: SchemaMigration;
+}
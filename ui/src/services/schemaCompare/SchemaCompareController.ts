@@ -0,0 +1,54 @@
+import { request } from '@umijs/max';
+import type { APIResponse } from '@/services/auth/typings';
+import type {
+    CreateSchemaCompareParams,
+    SchemaCompareJob,
+    SchemaCompareListParams,
+    SchemaCompareResult,
+    SchemaCompareResultListParams,
+} from './typings';
+
+/** 创建并运行库结构一致性检查 POST /api/schema-compares */
+export async function createSchemaCompare(data: CreateSchemaCompareParams) {
+    return request<APIResponse<SchemaCompareJob>>('/api/schema-compares', {
+        method: 'POST',
+        data,
+    });
+}
+
+/** 查询检查任务列表 GET /api/schema-compares */
+export async function listSchemaCompares(params: SchemaCompareListParams) {
+    return request<APIResponse<{ total: number; items: SchemaCompareJob[] }>>('/api/schema-compares', {
+        method: 'GET',
+        params,
+    });
+}
+
+/** 查询检查任务 GET /api/schema-compares/:id */
+export async function getSchemaCompare(id: number) {
+    return request<APIResponse<SchemaCompareJob>>(`/api/schema-compares/${id}`, {
+        method: 'GET',
+    });
+}
+
+/** 查询比较结果 GET /api/schema-compares/:id/results */
+export async function listSchemaCompareResults(id: number, params: SchemaCompareResultListParams) {
+    return request<APIResponse<{ total: number; items: SchemaCompareResult[] }>>(`/api/schema-compares/${id}/results`, {
+        method: 'GET',
+        params,
+    });
+}
+
+/** 重新运行检查任务 POST /api/schema-compares/:id/run */
+export async function runSchemaCompare(id: number) {
+    return request<APIResponse<SchemaCompareJob>>(`/api/schema-compares/${id}/run`, {
+        method: 'POST',
+    });
+}
+
+/** 删除检查任务 DELETE /api/schema-compares/:id */
+export async function deleteSchemaCompare(id: number) {
+    return request<APIResponse<null>>(`/api/schema-compares/${id}`, {
+        method: 'DELETE',
+    });
+}
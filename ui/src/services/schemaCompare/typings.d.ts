@@ -0,0 +1,61 @@
+import type { DatabaseFilter, SchemaRequirement, TaskDatabase } from '@/services/queryTask/typings';
+import type { SchemaChange } from '@/services/schema/typings';
+
+export interface SchemaCompareJob {
+    id: number;
+    name: string;
+    reference_instance_id: number;
+    reference_database: string;
+    reference_fingerprint: string;
+    generate_ddl: boolean;
+    status: number;
+    total: number;
+    consistent: number;
+    deviated: number;
+    failed: number;
+    error: string;
+    started_at?: string;
+    finished_at?: string;
+    created_by_name?: string;
+    created_at: string;
+}
+
+export interface SchemaCompareResult {
+    id: number;
+    job_id: number;
+    instance_id: number;
+    instance_name: string;
+    database_name: string;
+    status: number;
+    fingerprint: string;
+    diff_count: number;
+    changes: SchemaChange[] | null;
+    ddl: string;
+    error: string;
+}
+
+export interface CreateSchemaCompareParams {
+    name: string;
+    reference_instance_id: number;
+    reference_database: string;
+    database_mode: 'include' | 'exclude' | 'tags' | 'pattern' | 'schema';
+    instance_ids?: number[];
+    selected_dbs?: TaskDatabase[];
+    tag_expr?: string;
+    filter?: DatabaseFilter;
+    require?: SchemaRequirement;
+    generate_ddl: boolean;
+}
+
+export interface SchemaCompareListParams {
+    name?: string;
+    page?: number;
+    pageSize?: number;
+}
+
+export interface SchemaCompareResultListParams {
+    status?: number;
+    database_name?: string;
+    page?: number;
+    pageSize?: number;
+}